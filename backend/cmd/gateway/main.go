@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/joho/godotenv"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/auth"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/blockchain"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/gateway"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/storage"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/tee"
+)
+
+const defaultGatewayAddr = ":8080"
+
+func main() {
+	// Load environment variables from .env file
+	err := godotenv.Load()
+	if err != nil {
+		fmt.Println("Error loading .env file:", err)
+		return
+	}
+
+	// Get the backend signer private key from .env
+	privateKeyHex := os.Getenv("PRIVATE_KEY")
+	if privateKeyHex == "" {
+		fmt.Println("PRIVATE_KEY not found in .env file")
+		return
+	}
+	ecdsaPrivateKey, err := crypto.HexToECDSA(privateKeyHex)
+	if err != nil {
+		fmt.Println("Error converting private key hex to ECDSA:", err)
+		return
+	}
+
+	gatewayAddr := os.Getenv("GATEWAY_ADDR")
+	if gatewayAddr == "" {
+		gatewayAddr = defaultGatewayAddr
+	}
+
+	// Initialize Optimism rpc client
+	client, err := ethclient.Dial("https://sepolia.optimism.io")
+	if err != nil {
+		fmt.Println("Error connecting to Ethereum rpc client:", err)
+		return
+	}
+
+	// Get the chain ID
+	chainID, err := client.NetworkID(context.Background())
+	if err != nil {
+		fmt.Println("Error getting chain ID:", err)
+		return
+	}
+
+	// Create a new transactor with chain ID
+	transactOpts, err := bind.NewKeyedTransactorWithChainID(ecdsaPrivateKey, chainID)
+	if err != nil {
+		fmt.Println("Error creating keyed transactor:", err)
+		return
+	}
+
+	// Initialize Controller and PCSP services. Concurrent requests send their transactions
+	// through one queue, so they do not collide on the account's nonces.
+	controllerAddress := common.HexToAddress("0x8A8937171197A78f47d8C2eE9A3C92FD33644B63")
+	pcspAddress := common.HexToAddress("0x7bc91a89bb437fBB199fB3D1d0dc3a9D913d4f9F")
+	txManager, err := blockchain.NewTxManagerWithConfig(client, transactOpts, blockchain.TxManagerConfig{
+		FeePolicy: blockchain.FeePolicyForChain(chainID),
+		OnError:   func(err error) { fmt.Println("Transaction manager:", err) },
+	})
+	if err != nil {
+		fmt.Println("Error initializing transaction manager:", err)
+		return
+	}
+	controllerService, err := blockchain.NewControllerServiceWithTxManager(client, txManager, controllerAddress)
+	if err != nil {
+		fmt.Println("Error initializing Controller service:", err)
+		return
+	}
+	pcspService, err := blockchain.NewPCSPService(client, transactOpts, pcspAddress)
+	if err != nil {
+		fmt.Println("Error initializing PCSP service:", err)
+		return
+	}
+	var listenerConfig blockchain.ControllerEventListenerConfig
+	if startBlock := os.Getenv("CONTROLLER_START_BLOCK"); startBlock != "" {
+		listenerConfig.StartBlock, err = strconv.ParseUint(startBlock, 10, 64)
+		if err != nil {
+			fmt.Println("Error parsing CONTROLLER_START_BLOCK:", err)
+			return
+		}
+	}
+	controllerEventListener, err := blockchain.NewControllerEventListenerWithConfig(client, transactOpts, controllerAddress, listenerConfig)
+	if err != nil {
+		fmt.Println("Error initializing controller event listener:", err)
+		return
+	}
+
+	// Bind Sign-In with Ethereum messages to this deployment
+	authService := auth.NewAuthService()
+	authService.ConfigureSIWE(auth.SIWEConfig{
+		Domain:  os.Getenv("SIWE_DOMAIN"),
+		URI:     os.Getenv("SIWE_URI"),
+		ChainID: chainID.Uint64(),
+	})
+
+	// Share the session signing key between gateway instances if one is configured,
+	// otherwise a random key is generated on the first sign-in
+	if signingKeyHex := os.Getenv("SESSION_SIGNING_KEY"); signingKeyHex != "" {
+		signingKey, err := hex.DecodeString(signingKeyHex)
+		if err != nil {
+			fmt.Println("Error decoding session signing key:", err)
+			return
+		}
+		if err := authService.SetSigningKey(os.Getenv("SESSION_SIGNING_KEY_ID"), signingKey); err != nil {
+			fmt.Println("Error setting session signing key:", err)
+			return
+		}
+	}
+
+	// Persist gene data on disk if a database path is configured, otherwise keep it in memory.
+	// The encrypted data goes to an S3-compatible bucket if one is configured.
+	var store storage.GeneDataStore = storage.NewMemoryStore()
+	var blobs storage.BlobStore = storage.NewMemoryBlobStore()
+	if dbPath := os.Getenv("GENE_DATA_DB"); dbPath != "" {
+		store, err = storage.NewBoltStore(dbPath)
+		if err != nil {
+			fmt.Println("Error opening gene data database:", err)
+			return
+		}
+		blobs, err = storage.NewDiskBlobStore(os.Getenv("GENE_BLOB_DIR"))
+		if err != nil {
+			fmt.Println("Error opening gene data blob directory:", err)
+			return
+		}
+	}
+	if bucket := os.Getenv("S3_BUCKET"); bucket != "" {
+		blobs, err = storage.NewS3BlobStore(storage.S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          bucket,
+			Prefix:          os.Getenv("S3_PREFIX"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		})
+		if err != nil {
+			fmt.Println("Error connecting to gene data bucket:", err)
+			return
+		}
+	}
+	geneDataStorageService := storage.NewGeneDataStorageServiceWithStore(store, blobs)
+	defer geneDataStorageService.Close()
+
+	// Keep the attestation key across restarts if one is configured, so verifiers keep
+	// trusting the quotes, and likewise the enclave key, so the TEE can still unwrap the data
+	// keys of stored gene data. Otherwise random keys are generated
+	attestationKey, err := keyFromEnv("ATTESTATION_KEY")
+	if err != nil {
+		fmt.Println("Error converting attestation key hex to ECDSA:", err)
+		return
+	}
+	enclaveKey, err := keyFromEnv("TEE_ENCLAVE_KEY")
+	if err != nil {
+		fmt.Println("Error converting enclave key hex to ECDSA:", err)
+		return
+	}
+	teeService := tee.NewTEEServiceWithKeys(attestationKey, enclaveKey)
+	fmt.Printf("TEE attestation key %s, enclave measurement %s\n", teeService.AttestationKey().Hex(), teeService.Measurement().Hex())
+
+	// The Controller rejects proofs from attestation keys its owner has not registered
+	registered, err := controllerService.IsTEESigner(teeService.AttestationKey())
+	if err != nil {
+		fmt.Println("Error checking TEE signer:", err)
+		return
+	}
+	if !registered {
+		fmt.Println("Warning: the TEE attestation key is not a registered Controller signer, confirmations will revert until the owner adds it")
+	}
+
+	server := gateway.NewServer(gateway.Services{
+		Auth:       authService,
+		Storage:    geneDataStorageService,
+		TEE:        teeService,
+		Controller: controllerService,
+		Sessions:   controllerEventListener,
+		PCSP:       pcspService,
+	})
+
+	fmt.Println("Gateway listening on", gatewayAddr)
+	if err := http.ListenAndServe(gatewayAddr, server); err != nil {
+		fmt.Println("Gateway stopped:", err)
+	}
+}
+
+// keyFromEnv reads a hex-encoded secp256k1 private key from the environment variable, or
+// generates a random key if it is not set.
+func keyFromEnv(name string) (*ecdsa.PrivateKey, error) {
+	keyHex := os.Getenv(name)
+	if keyHex == "" {
+		return crypto.GenerateKey()
+	}
+	return crypto.HexToECDSA(keyHex)
+}
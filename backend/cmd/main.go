@@ -1,24 +1,24 @@
 package main
 
 import (
+	"bytes"
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
 	"crypto/ecdsa"
 	"crypto/rand"
-	"crypto/sha256"
-	"errors"
 	"fmt"
 	"math/big"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/joho/godotenv"
 
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/attestation"
 	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/auth"
 	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/blockchain"
 	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/storage"
@@ -86,53 +86,70 @@ func main() {
 	// Initialize Controller and PCSP services
 	controllerAddress := common.HexToAddress("0x8A8937171197A78f47d8C2eE9A3C92FD33644B63")
 	pcspAddress := common.HexToAddress("0x7bc91a89bb437fBB199fB3D1d0dc3a9D913d4f9F")
-	controllerService, err := blockchain.NewControllerService(client, auth, controllerAddress)
+	txManager, err := blockchain.NewTxManagerWithFeePolicy(client, auth, blockchain.FeePolicyForChain(chainID))
 	if err != nil {
-		fmt.Println("Error initializing Controller service:", err)
+		fmt.Println("Error initializing transaction manager:", err)
 		return
 	}
-	pcspService, err := blockchain.NewPCSPService(client, auth, pcspAddress)
+	controllerService, err := blockchain.NewControllerServiceWithTxManager(client, txManager, controllerAddress)
 	if err != nil {
-		fmt.Println("Error initializing PCSP service:", err)
+		fmt.Println("Error initializing Controller service:", err)
 		return
 	}
-
-	// Initialize Controller event listener
-	controllerEventListener, err := blockchain.NewControllerEventListener(client, auth)
+	pcspService, err := blockchain.NewPCSPService(client, auth, pcspAddress)
 	if err != nil {
-		fmt.Println("Error initializing controller event listener:", err)
+		fmt.Println("Error initializing PCSP service:", err)
 		return
 	}
 
 	// Step 1: Register a new user with public key
 	fmt.Println("\nStep 1")
 	fmt.Println("Registering a new user...")
-	userID := authService.RegisterUserWithPubkey(userPubkeyBytes)
+	userID, err := authService.RegisterUserWithPubkey(userPubkeyBytes)
+	if err != nil {
+		fmt.Println("Error registering user:", err)
+		return
+	}
 	fmt.Printf("User registered with UserID: %d and Ethereum address: %s\n", userID, userETHAddress)
 
-	// Step 2: Authenticate the user using their Ethereum address derived from the public key
+	// Step 2: Authenticate the user by signing a login challenge with their private key
 	fmt.Println("\nStep 2")
-	isAuthenticated := authService.Authenticate(userID, userETHAddress)
-	if !isAuthenticated {
-		fmt.Println("User authentication failed!")
+	challenge, err := authService.IssueChallenge(userID)
+	if err != nil {
+		fmt.Println("Error issuing login challenge:", err)
+		return
+	}
+	challengeSignature, err := crypto.Sign(accounts.TextHash([]byte(challenge.Message)), ecdsaPrivateKey)
+	if err != nil {
+		fmt.Println("Error signing login challenge:", err)
 		return
 	}
-	fmt.Println("User authenticated successfully with Ethereum address:", userETHAddress)
+	session, err := authService.Authenticate(userID, challenge.Nonce, challengeSignature)
+	if err != nil {
+		fmt.Println("User authentication failed!", err)
+		return
+	}
+	fmt.Println("User authenticated successfully with Ethereum address:", session.ETHAddress)
+	fmt.Println("Session token issued, valid until:", session.ExpiresAt.Format(time.RFC3339))
 
-	// Step 3: Encrypt gene data using the user's public key via the TEE service
+	// Step 3: Encrypt gene data under a data key wrapped to the user's public key and to the TEE's
+	// enclave key, so the TEE can score and share the data without the user's private key
 	fmt.Println("\nStep 3")
-	geneData, err := randomStringWithRandomLength(10, 50) // Example gene data to be encrypted
+	geneData, err := randomGeneData() // Example gene data to be encrypted
 	if err != nil {
 		fmt.Println("Error creating random gene data:", err)
 		return
 	}
 	fmt.Printf("Original gene data: %s\n", geneData)
 	fmt.Println("Encrypting gene data...")
-	encryptedData, err := teeService.EncryptGeneData(userPubkeyBytes, geneData)
+	var encryptedBuffer bytes.Buffer
+	wrappedKeys, err := teeService.EncryptGeneDataWithDataKey(&encryptedBuffer, strings.NewReader(geneData),
+		[][]byte{userPubkeyBytes, teeService.EnclavePublicKey()}, nil)
 	if err != nil {
 		fmt.Println("Error encrypting gene data:", err)
 		return
 	}
+	encryptedData := encryptedBuffer.Bytes()
 	fmt.Println("Gene data encrypted successfully.")
 
 	// Step 4: Sign the encrypted gene data using the user's private key
@@ -148,7 +165,7 @@ func main() {
 	// Step 5: Store the encrypted gene data, signature, and hash in the storage service
 	fmt.Println("\nStep 5")
 	fmt.Println("Storing encrypted gene data...")
-	fileID, err := geneDataStorageService.StoreGeneData(userID, encryptedData, signature, hash)
+	fileID, err := geneDataStorageService.StoreGeneDataWithKeys(userID, encryptedData, signature, hash, toStorageWrappedKeys(wrappedKeys))
 	if err != nil {
 		fmt.Println("Error storing gene data:", err)
 		return
@@ -169,42 +186,126 @@ func main() {
 		fmt.Println("Gene data signature is invalid.")
 	}
 
-	// Step 7: Calculate the risk score based on the gene data using the TEE service
+	// Step 7: Calculate the risk score of the stored gene data inside the TEE, which signs a
+	// receipt tying the score to the file
 	fmt.Println("\nStep 7")
 	fmt.Println("Calculating risk score...")
-	riskScore := teeService.CalculateRiskScore(geneData)
-	fmt.Printf("Risk score calculated: %d\n", riskScore)
+	riskResult, receipt, err := teeService.ScoreEncryptedGeneData(context.Background(), encryptedData, wrappedKeys, "", "")
+	if err != nil {
+		fmt.Println("Error calculating risk score:", err)
+		return
+	}
+	fmt.Printf("Risk score calculated: %d (%s) by model %s@%s\n",
+		riskResult.RiskLevel.Score(), riskResult.RiskLevel, riskResult.Model, riskResult.ModelVersion)
+
+	// Step 7.1: Verify and record the computation receipt for auditors
+	verifier := attestation.NewVerifier(teeService.AttestationKey(), teeService.Measurement())
+	if err := verifier.VerifyReceipt(receipt); err != nil {
+		fmt.Println("Error verifying computation receipt:", err)
+		return
+	}
+	if err := geneDataStorageService.RecordReceipt(fileID, *receipt); err != nil {
+		fmt.Println("Error recording computation receipt:", err)
+		return
+	}
+	fmt.Printf("Computation receipt for file %s signed by %s recorded\n", receipt.FileID, teeService.AttestationKey().Hex())
 
 	// Step 8: Upload the gene data to the blockchain for secure storage
 	fmt.Println("\nStep 8")
 	fmt.Println("Uploading gene data to blockchain...")
-	txHash, err := controllerService.UploadData(fileID)
+	upload, err := controllerService.UploadData(fileID)
 	if err != nil {
 		fmt.Println("Error uploading data to blockchain:", err)
 		return
 	}
-	fmt.Printf("Gene data uploaded at txHash: %s\n", txHash.Hex())
+	fmt.Printf("Gene data uploaded at txHash: %s (block %d, gas used %d)\n", upload.TxHash.Hex(), upload.BlockNumber, upload.GasUsed)
 
-	// Step 9: Listen for the UploadData event to get the sessionID
+	// Step 9: Read the sessionID from the UploadData event in the upload receipt
 	fmt.Println("\nStep 9")
-	fmt.Println("Listening for UploadData event to get sessionID...")
-	sessionID, err := controllerEventListener.ListenForUploadDataEvents(fileID)
-	if err != nil {
-		fmt.Println("Error listening for UploadData event:", err)
-		return
-	}
+	sessionID := upload.SessionID
 	fmt.Printf("Received sessionID: %s\n", sessionID)
 
-	// Step 9.1: Confirm the blockchain transaction, mint an NFT, and reward PCSP tokens
+	// Step 9.1: Confirm the blockchain transaction, mint an NFT, and reward PCSP tokens.
+	// The IPFS content ID of the encrypted gene data is anchored as the document content hash.
 	fmt.Println("Confirming transaction on blockchain...")
-	err = controllerService.Confirm(fileID, fmt.Sprintf("%x", hash), fmt.Sprintf("%x", signature), sessionID, uint8(riskScore))
+	storedGeneData, err := geneDataStorageService.GetGeneData(fileID)
+	if err != nil {
+		fmt.Println("Error retrieving gene data:", err)
+		return
+	}
+	// The TEE attests to the risk score for this document and session, and the quote is the proof
+	// sent to the contract
+	nonce, err := controllerService.ConfirmNonce(fileID, storedGeneData.ContentID, sessionID)
+	if err != nil {
+		fmt.Println("Error getting confirm nonce:", err)
+		return
+	}
+	quote, err := teeService.Attest(riskResult, nonce)
+	if err != nil {
+		fmt.Println("Error attesting risk score:", err)
+		return
+	}
+	err = verifier.Verify(quote, attestation.Claims{
+		Model:        riskResult.Model,
+		ModelVersion: riskResult.ModelVersion,
+		InputHash:    receipt.DataHash,
+		OutputHash:   attestation.RiskScoreHash(riskResult.RiskLevel.Score()),
+		Nonce:        nonce,
+	})
+	if err != nil {
+		fmt.Println("Error verifying attestation quote:", err)
+		return
+	}
+	fmt.Printf("Risk score attested by enclave %s with key %s\n", quote.Measurement.Hex(), teeService.AttestationKey().Hex())
+	// The contract only accepts proofs signed by registered TEE signers, which the contract owner manages
+	registered, err := controllerService.IsTEESigner(teeService.AttestationKey())
+	if err != nil {
+		fmt.Println("Error checking TEE signer:", err)
+		return
+	}
+	if !registered {
+		if err := controllerService.AddTEESigner(teeService.AttestationKey()); err != nil {
+			fmt.Println("Error registering TEE signer, the Controller owner must add it:", err)
+			return
+		}
+		fmt.Printf("TEE signer %s registered with the Controller\n", teeService.AttestationKey().Hex())
+	}
+	err = controllerService.ConfirmReceipt(receipt, storedGeneData.ContentID, quote, sessionID)
 	if err != nil {
 		fmt.Println("Error confirming transaction on blockchain:", err)
 		return
 	}
 	fmt.Println("Transaction confirmed, NFT minted, and PCSP tokens rewarded.")
 
-	// Step 9.2: Retrieve the user's PCSP balance from the blockchain
+	// Record which model produced the confirmed risk score
+	err = geneDataStorageService.RecordRiskAssessment(fileID, storage.RiskAssessment{
+		RiskScore:    riskResult.RiskLevel.Score(),
+		Model:        riskResult.Model,
+		ModelVersion: riskResult.ModelVersion,
+	})
+	if err != nil {
+		fmt.Println("Error recording risk assessment:", err)
+		return
+	}
+
+	// Step 9.2: Verify an IPFS export of the encrypted gene data against the anchored content ID
+	anchoredContentID, err := controllerService.AnchoredContentID(fileID)
+	if err != nil {
+		fmt.Println("Error reading anchored content ID:", err)
+		return
+	}
+	var car bytes.Buffer
+	if _, err := geneDataStorageService.ExportCAR(fileID, &car); err != nil {
+		fmt.Println("Error exporting gene data:", err)
+		return
+	}
+	if _, err := storage.ReadCAR(&car, anchoredContentID); err != nil {
+		fmt.Println("Error verifying exported gene data:", err)
+		return
+	}
+	fmt.Printf("Exported gene data matches the anchored content ID %s\n", anchoredContentID)
+
+	// Step 9.3: Retrieve the user's PCSP balance from the blockchain
 	userPCSPBalance, err := pcspService.GetBalance(common.HexToAddress(userETHAddress))
 	if err != nil {
 		fmt.Println("Error retrieving PCSP balance:", err)
@@ -230,8 +331,12 @@ func main() {
 		return
 	}
 
-	// Step 10.2: Retrieve the encrypted gene data from storage using the fileID
-	retrievedEncryptedData, err := geneDataStorageService.RetrieveGeneData(fileID)
+	// Step 10.2: Retrieve the encrypted gene data and its wrapped keys from storage as its owner
+	// using the fileID
+	retrievedGeneData, err := geneDataStorageService.AccessGeneData(fileID, storage.Accessor{
+		UserID:  session.UserID,
+		Address: session.ETHAddress,
+	})
 	if err != nil {
 		fmt.Println("Error retrieving gene data:", err)
 		return
@@ -239,12 +344,91 @@ func main() {
 	fmt.Println("Encrypted gene data retrieved successfully.")
 
 	// Step 10.3: Decrypt the gene data using the user's private key
-	decryptedGeneData, err := decryptGeneData(ecdsaPrivateKey, retrievedEncryptedData)
+	var decryptedGeneData bytes.Buffer
+	err = teeService.DecryptGeneDataWithDataKey(&decryptedGeneData, bytes.NewReader(retrievedGeneData.EncryptedData),
+		ecdsaPrivateKey, toTEEWrappedKeys(retrievedGeneData.WrappedKeys))
 	if err != nil {
 		fmt.Println("Error decrypting gene data:", err)
 		return
 	}
-	fmt.Printf("Original gene data retrieved and decrypted successfully: %s\n", decryptedGeneData)
+	fmt.Printf("Original gene data retrieved and decrypted successfully: %s\n", decryptedGeneData.String())
+
+	// Step 11: Share the gene data with a researcher through a consent grant
+	fmt.Println("\nStep 11")
+	fmt.Println("Sharing gene data with a researcher...")
+	researcherPrivateKey, err := crypto.GenerateKey()
+	if err != nil {
+		fmt.Println("Error generating researcher key:", err)
+		return
+	}
+
+	// Step 11.1: Sign and store a consent grant for the researcher
+	grant := storage.ConsentGrant{
+		FileID:    fileID,
+		Grantee:   crypto.PubkeyToAddress(researcherPrivateKey.PublicKey),
+		Purpose:   "stroke research",
+		ExpiresAt: time.Now().Add(24 * time.Hour),
+		Nonce:     fmt.Sprint(time.Now().UnixNano()),
+	}
+	grant.Signature, err = crypto.Sign(accounts.TextHash([]byte(grant.Message())), ecdsaPrivateKey)
+	if err != nil {
+		fmt.Println("Error signing consent grant:", err)
+		return
+	}
+	grant, err = geneDataStorageService.GrantConsent(grant, userPubkeyBytes)
+	if err != nil {
+		fmt.Println("Error granting consent:", err)
+		return
+	}
+	fmt.Printf("Consent grant %s issued to %s for %s\n", grant.ID, grant.Grantee.Hex(), grant.Purpose)
+
+	// Step 11.2: Check the researcher's grant and read the gene data under it, which is audited
+	researcher := storage.Accessor{Address: grant.Grantee, Purpose: grant.Purpose}
+	grant, err = geneDataStorageService.ActiveGrant(fileID, grant.ID, researcher)
+	if err != nil {
+		fmt.Println("Error checking consent grant:", err)
+		return
+	}
+	sharedData, err := geneDataStorageService.AccessGeneData(fileID, researcher)
+	if err != nil {
+		fmt.Println("Error accessing gene data as researcher:", err)
+		return
+	}
+
+	// Step 11.3: Re-encrypt the gene data to the researcher's key inside the TEE, which checks the
+	// grant and unwraps the data key with its enclave key
+	reencryptedData, err := teeService.ReencryptGeneData(sharedData.EncryptedData, toTEEWrappedKeys(sharedData.WrappedKeys),
+		grant, userPubkeyBytes, crypto.FromECDSAPub(&researcherPrivateKey.PublicKey))
+	if err != nil {
+		fmt.Println("Error re-encrypting gene data:", err)
+		return
+	}
+
+	// Step 11.4: The researcher decrypts the gene data with their own key
+	sharedGeneData, err := teeService.DecryptGeneData(researcherPrivateKey, reencryptedData)
+	if err != nil {
+		fmt.Println("Error decrypting shared gene data:", err)
+		return
+	}
+	fmt.Printf("Researcher decrypted the shared gene data: %s\n", sharedGeneData)
+}
+
+// toStorageWrappedKeys converts wrapped keys returned by the TEE to stored wrapped keys.
+func toStorageWrappedKeys(keys []tee.WrappedKey) []storage.WrappedKey {
+	storageKeys := make([]storage.WrappedKey, 0, len(keys))
+	for _, key := range keys {
+		storageKeys = append(storageKeys, storage.WrappedKey(key))
+	}
+	return storageKeys
+}
+
+// toTEEWrappedKeys converts stored wrapped keys to the wrapped keys the TEE unwraps.
+func toTEEWrappedKeys(keys []storage.WrappedKey) []tee.WrappedKey {
+	teeKeys := make([]tee.WrappedKey, 0, len(keys))
+	for _, key := range keys {
+		teeKeys = append(teeKeys, tee.WrappedKey(key))
+	}
+	return teeKeys
 }
 
 // pubkeyToETHAddress converts a public key byte slice to an Ethereum address.
@@ -269,58 +453,14 @@ func hexToECDSAPrivateKey(hexKey string) (*ecdsa.PrivateKey, error) {
 	return privateKey, nil
 }
 
-// decryptGeneData decrypts the encrypted gene data using the user's private key.
-func decryptGeneData(privateKey *ecdsa.PrivateKey, encryptedData []byte) (string, error) {
-	// Extract the nonce and ciphertext
-	nonceSize := 12 // GCM standard nonce size
-	if len(encryptedData) < nonceSize {
-		return "", errors.New("invalid encrypted data")
-	}
-	nonce, ciphertext := encryptedData[:nonceSize], encryptedData[nonceSize:]
-
-	// Generate the shared secret
-	xBytes := privateKey.PublicKey.X.Bytes()
-	sharedSecret := sha256.Sum256(xBytes)
-
-	// Decrypt using AES-256-GCM
-	block, err := aes.NewCipher(sharedSecret[:])
-	if err != nil {
-		return "", err
-	}
-
-	aesGCM, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	plaintext, err := aesGCM.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return "", err
-	}
-
-	return string(plaintext), nil
-}
-
-func randomStringWithRandomLength(minLength, maxLength int) (string, error) {
-	charset := "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	// Generate a random length within the given range
-	length, err := rand.Int(rand.Reader, big.NewInt(int64(maxLength-minLength+1)))
+// randomGeneData returns the content of a gene file in a random risk category.
+func randomGeneData() (string, error) {
+	riskLevels := []tee.RiskLevel{tee.RiskLevelExtremelyHigh, tee.RiskLevelHigh, tee.RiskLevelSlightlyHigh, tee.RiskLevelLow}
+	index, err := rand.Int(rand.Reader, big.NewInt(int64(len(riskLevels))))
 	if err != nil {
 		return "", err
 	}
-	length = length.Add(length, big.NewInt(int64(minLength)))
-
-	// Build the random string
-	var sb strings.Builder
-	for i := 0; i < int(length.Int64()); i++ {
-		charIndex, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
-		if err != nil {
-			return "", err
-		}
-		sb.WriteByte(charset[charIndex.Int64()])
-	}
-
-	return sb.String(), nil
+	return riskLevels[index.Int64()].String(), nil
 }
 
 // signEncryptedGeneData generates a hash of the encrypted data and then signs it using the provided private key.
@@ -45,13 +45,35 @@ type ControllerUploadSession struct {
 
 // ControllerMetaData contains all meta data concerning the Controller contract.
 var ControllerMetaData = &bind.MetaData{
-	ABI: "[{\"inputs\":[{\"internalType\":\"address\",\"name\":\"nftAddress\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"pcspAddress\",\"type\":\"address\"}],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"string\",\"name\":\"docId\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"sessionId\",\"type\":\"uint256\"}],\"name\":\"UploadData\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"docId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"contentHash\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"proof\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"sessionId\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"riskScore\",\"type\":\"uint256\"}],\"name\":\"confirm\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"geneNFT\",\"outputs\":[{\"internalType\":\"contractGeneNFT\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"docId\",\"type\":\"string\"}],\"name\":\"getDoc\",\"outputs\":[{\"components\":[{\"internalType\":\"string\",\"name\":\"id\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"hashContent\",\"type\":\"string\"}],\"internalType\":\"structController.DataDoc\",\"name\":\"\",\"type\":\"tuple\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"sessionId\",\"type\":\"uint256\"}],\"name\":\"getSession\",\"outputs\":[{\"components\":[{\"internalType\":\"uint256\",\"name\":\"id\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\"},{\"internalType\":\"string\",\"name\":\"proof\",\"type\":\"string\"},{\"internalType\":\"bool\",\"name\":\"confirmed\",\"type\":\"bool\"}],\"internalType\":\"structController.UploadSession\",\"name\":\"\",\"type\":\"tuple\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"pcspToken\",\"outputs\":[{\"internalType\":\"contractPostCovidStrokePrevention\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"docId\",\"type\":\"string\"}],\"name\":\"uploadData\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]",
+	ABI: "[{\"inputs\":[{\"internalType\":\"address\",\"name\":\"nftAddress\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"pcspAddress\",\"type\":\"address\"}],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"previousOwner\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"OwnershipTransferred\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"signer\",\"type\":\"address\"}],\"name\":\"TEESignerAdded\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"signer\",\"type\":\"address\"}],\"name\":\"TEESignerRemoved\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"string\",\"name\":\"docId\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"sessionId\",\"type\":\"uint256\"}],\"name\":\"UploadData\",\"type\":\"event\"},{\"inputs\":[],\"name\":\"QUOTE_VERSION\",\"outputs\":[{\"internalType\":\"uint8\",\"name\":\"\",\"type\":\"uint8\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"signer\",\"type\":\"address\"}],\"name\":\"addTEESigner\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"docId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"contentHash\",\"type\":\"string\"},{\"internalType\":\"bytes\",\"name\":\"proof\",\"type\":\"bytes\"},{\"internalType\":\"uint256\",\"name\":\"sessionId\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"riskScore\",\"type\":\"uint256\"}],\"name\":\"confirm\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"docId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"contentHash\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"sessionId\",\"type\":\"uint256\"}],\"name\":\"confirmNonce\",\"outputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"geneNFT\",\"outputs\":[{\"internalType\":\"contractGeneNFT\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"docId\",\"type\":\"string\"}],\"name\":\"getDoc\",\"outputs\":[{\"components\":[{\"internalType\":\"string\",\"name\":\"id\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"hashContent\",\"type\":\"string\"}],\"internalType\":\"structController.DataDoc\",\"name\":\"\",\"type\":\"tuple\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"sessionId\",\"type\":\"uint256\"}],\"name\":\"getSession\",\"outputs\":[{\"components\":[{\"internalType\":\"uint256\",\"name\":\"id\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\"},{\"internalType\":\"string\",\"name\":\"proof\",\"type\":\"string\"},{\"internalType\":\"bool\",\"name\":\"confirmed\",\"type\":\"bool\"}],\"internalType\":\"structController.UploadSession\",\"name\":\"\",\"type\":\"tuple\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"owner\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"pcspToken\",\"outputs\":[{\"internalType\":\"contractPostCovidStrokePrevention\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"signer\",\"type\":\"address\"}],\"name\":\"removeTEESigner\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"renounceOwnership\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"name\":\"teeSigners\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"transferOwnership\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"docId\",\"type\":\"string\"}],\"name\":\"uploadData\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]",
+	Bin: "0x60806040523480156200001157600080fd5b50604051620030ce380380620030ce833981810160405281019062000037919062000217565b620000576200004b620000e160201b60201c565b620000e960201b60201c565b81600260006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff16021790555080600360006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff16021790555050506200025e565b600033905090565b60008060009054906101000a900473ffffffffffffffffffffffffffffffffffffffff169050816000806101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055508173ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff167f8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e060405160405180910390a35050565b600080fd5b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b6000620001df82620001b2565b9050919050565b620001f181620001d2565b8114620001fd57600080fd5b50565b6000815190506200021181620001e6565b92915050565b60008060408385031215620002315762000230620001ad565b5b6000620002418582860162000200565b9250506020620002548582860162000200565b9150509250929050565b612e60806200026e6000396000f3fe608060405234801561001057600080fd5b50600436106100ea5760003560e01c80639037bbec1161008c578063afcc377c11610066578063afcc377c14610249578063cd88f94e14610267578063dab3761e14610297578063f2fde38b146102b5576100ea565b80639037bbec146101cd578063a5bde23b146101fd578063adde88df1461022d576100ea565b80636ef56ced116100c85780636ef56ced1461016d578063715018a6146101895780637f7c474b146101935780638da5cb5b146101af576100ea565b8063402ff0db146100ef57806350969f441461011f5780635231f6271461014f575b600080fd5b610109600480360381019061010491906116a4565b6102d1565b604051610116919061182f565b60405180910390f35b61013960048036038101906101349190611986565b61040b565b60405161014691906119de565b60405180910390f35b6101576105f0565b6040516101649190611a58565b60405180910390f35b61018760048036038101906101829190611a9f565b610616565b005b610191610733565b005b6101ad60048036038101906101a89190611b6d565b610747565b005b6101b7610abd565b6040516101c49190611c4b565b60405180910390f35b6101e760048036038101906101e29190611c66565b610ae6565b6040516101f49190611d0a565b60405180910390f35b61021760048036038101906102129190611986565b610b20565b6040516102249190611d69565b60405180910390f35b61024760048036038101906102429190611a9f565b610c7c565b005b610251610e12565b60405161025e9190611da7565b60405180910390f35b610281600480360381019061027c9190611a9f565b610e17565b60405161028e9190611dd1565b60405180910390f35b61029f610e37565b6040516102ac9190611e0d565b60405180910390f35b6102cf60048036038101906102ca9190611a9f565b610e5d565b005b6102d9611600565b60046000838152602001908152602001600020604051806080016040529081600082015481526020016001820160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200160028201805461036790611e57565b80601f016020809104026020016040519081016040528092919081815260200182805461039390611e57565b80156103e05780601f106103b5576101008083540402835291602001916103e0565b820191906000526020600020905b8154815290600101906020018083116103c357829003601f168201915b505050505081526020016003820160009054906101000a900460ff1615151515815250509050919050565b600060068260405161041d9190611ec4565b908152602001604051809103902060009054906101000a900460ff1615610479576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161047090611f38565b60405180910390fd5b60006104856001610ee0565b905060405180608001604052808281526020013373ffffffffffffffffffffffffffffffffffffffff16815260200160405180602001604052806000815250815260200160001515815250600460008381526020019081526020016000206000820151816000015560208201518160010160006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff160217905550604082015181600201908161054991906120fa565b5060608201518160030160006101000a81548160ff021916908315150217905550905050600160068460405161057f9190611ec4565b908152602001604051809103902060006101000a81548160ff0219169083151502179055507f698b35ede3baa51dbaa3b9a040c287690e40d0101d312c80eb364c7b17c458bc83826040516105d5929190612205565b60405180910390a16105e76001610eee565b80915050919050565b600260009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1681565b61061e610f04565b600860008273ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060009054906101000a900460ff166106aa576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016106a190612281565b60405180910390fd5b600860008273ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060006101000a81549060ff02191690557f6e286dc7bb51e8f4d0d4050b5ac0b400e8cff2ba684971be957c93fee69270d8816040516107289190611c4b565b60405180910390a150565b61073b610f04565b6107456000610f82565b565b600061075286610b20565b600001515114610797576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161078e90611f38565b60405180910390fd5b3373ffffffffffffffffffffffffffffffffffffffff166107b7836102d1565b6020015173ffffffffffffffffffffffffffffffffffffffff1614610811576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610808906122ed565b60405180910390fd5b6000151561081e836102d1565b60600151151514610864576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161085b90612359565b60405180910390fd5b610879610872868685610ae6565b8483611046565b60405180604001604052808681526020018581525060058660405161089e9190611ec4565b908152602001604051809103902060008201518160000190816108c191906120fa565b5060208201518160010190816108d791906120fa565b509050506000600260009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff166340d097c3336040518263ffffffff1660e01b81526004016109389190611c4b565b6020604051808303816000875af1158015610957573d6000803e3d6000fd5b505050506040513d601f19601f8201168201806040525081019061097b919061238e565b90508560076000838152602001908152602001600020908161099d91906120fa565b50600360009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff166321670f2233846040518363ffffffff1660e01b81526004016109fb9291906123bb565b600060405180830381600087803b158015610a1557600080fd5b505af1158015610a29573d6000803e3d6000fd5b5050505060016004600085815260200190815260200160002060030160006101000a81548160ff0219169083151502179055506040518060400160405280600781526020017f7375636365737300000000000000000000000000000000000000000000000000815250600460008581526020019081526020016000206002019081610ab491906120fa565b50505050505050565b60008060009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905090565b60004630858585604051602001610b019594939291906123e4565b6040516020818303038152906040528051906020012090509392505050565b610b28611640565b600582604051610b389190611ec4565b9081526020016040518091039020604051806040016040529081600082018054610b6190611e57565b80601f0160208091040260200160405190810160405280929190818152602001828054610b8d90611e57565b8015610bda5780601f10610baf57610100808354040283529160200191610bda565b820191906000526020600020905b815481529060010190602001808311610bbd57829003601f168201915b50505050508152602001600182018054610bf390611e57565b80601f0160208091040260200160405190810160405280929190818152602001828054610c1f90611e57565b8015610c6c5780601f10610c4157610100808354040283529160200191610c6c565b820191906000526020600020905b815481529060010190602001808311610c4f57829003601f168201915b5050505050815250509050919050565b610c84610f04565b600073ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff1603610cf3576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610cea90612491565b60405180910390fd5b600860008273ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060009054906101000a900460ff1615610d80576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610d77906124fd565b60405180910390fd5b6001600860008373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060006101000a81548160ff0219169083151502179055507f580faf9e12757a60335492ebbd3e06d7cd87c012f6f9626fc1457cd4c3da0b0881604051610e079190611c4b565b60405180910390a150565b600181565b60086020528060005260406000206000915054906101000a900460ff1681565b600360009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1681565b610e65610f04565b600073ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff1603610ed4576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610ecb9061258f565b60405180910390fd5b610edd81610f82565b50565b600081600001549050919050565b6001816000016000828254019250508190555050565b610f0c611338565b73ffffffffffffffffffffffffffffffffffffffff16610f2a610abd565b73ffffffffffffffffffffffffffffffffffffffff1614610f80576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610f77906125fb565b60405180910390fd5b565b60008060009054906101000a900473ffffffffffffffffffffffffffffffffffffffff169050816000806101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055508173ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff167f8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e060405160405180910390a35050565b600080600080600080600080898060200190518101906110669190612753565b97509750975097509750975097509750600160ff168860ff16146110bf576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016110b6906128a9565b60405180910390fd5b8a8214611101576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016110f89061293b565b60405180910390fd5b60ff801689111580156111395750886040516020016111209190612991565b6040516020818303038152906040528051906020012083145b611178576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161116f90612a1e565b60405180910390fd5b6000888888888888886040516020016111979796959493929190612a3e565b60405160208183030381529060405280519060200120905060418251146111f3576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016111ea90612b07565b60405180910390fd5b601b8260408151811061120957611208612b27565b5b602001015160f81c60f81b60f81c60ff16101561129557601b8260408151811061123657611235612b27565b5b602001015160f81c60f81b60f81c61124e9190612b85565b60f81b8260408151811061126557611264612b27565b5b60200101907effffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff1916908160001a9053505b600860006112a38385611340565b73ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060009054906101000a900460ff1661132a576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161132190612c2c565b60405180910390fd5b505050505050505050505050565b600033905090565b600080600061134f8585611367565b9150915061135c816113b8565b819250505092915050565b60008060418351036113a85760008060006020860151925060408601519150606086015160001a905061139c8782858561151e565b945094505050506113b1565b60006002915091505b9250929050565b600060048111156113cc576113cb612c4c565b5b8160048111156113df576113de612c4c565b5b031561151b57600160048111156113f9576113f8612c4c565b5b81600481111561140c5761140b612c4c565b5b0361144c576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161144390612cc7565b60405180910390fd5b600260048111156114605761145f612c4c565b5b81600481111561147357611472612c4c565b5b036114b3576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016114aa90612d33565b60405180910390fd5b600360048111156114c7576114c6612c4c565b5b8160048111156114da576114d9612c4c565b5b0361151a576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161151190612dc5565b60405180910390fd5b5b50565b6000807f7fffffffffffffffffffffffffffffff5d576e7357a4501ddfe92f46681b20a08360001c11156115595760006003915091506115f7565b60006001878787876040516000815260200160405260405161157e9493929190612de5565b6020604051602081039080840390855afa1580156115a0573d6000803e3d6000fd5b505050602060405103519050600073ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff16036115ee576000600192509250506115f7565b80600092509250505b94509492505050565b604051806080016040528060008152602001600073ffffffffffffffffffffffffffffffffffffffff168152602001606081526020016000151581525090565b604051806040016040528060608152602001606081525090565b6000604051905090565b600080fd5b600080fd5b6000819050919050565b6116818161166e565b811461168c57600080fd5b50565b60008135905061169e81611678565b92915050565b6000602082840312156116ba576116b9611664565b5b60006116c88482850161168f565b91505092915050565b6116da8161166e565b82525050565b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b600061170b826116e0565b9050919050565b61171b81611700565b82525050565b600081519050919050565b600082825260208201905092915050565b60005b8381101561175b578082015181840152602081019050611740565b60008484015250505050565b6000601f19601f8301169050919050565b600061178382611721565b61178d818561172c565b935061179d81856020860161173d565b6117a681611767565b840191505092915050565b60008115159050919050565b6117c6816117b1565b82525050565b60006080830160008301516117e460008601826116d1565b5060208301516117f76020860182611712565b506040830151848203604086015261180f8282611778565b915050606083015161182460608601826117bd565b508091505092915050565b6000602082019050818103600083015261184981846117cc565b905092915050565b600080fd5b600080fd5b7f4e487b7100000000000000000000000000000000000000000000000000000000600052604160045260246000fd5b61189382611767565b810181811067ffffffffffffffff821117156118b2576118b161185b565b5b80604052505050565b60006118c561165a565b90506118d1828261188a565b919050565b600067ffffffffffffffff8211156118f1576118f061185b565b5b6118fa82611767565b9050602081019050919050565b82818337600083830152505050565b6000611929611924846118d6565b6118bb565b90508281526020810184848401111561194557611944611856565b5b611950848285611907565b509392505050565b600082601f83011261196d5761196c611851565b5b813561197d848260208601611916565b91505092915050565b60006020828403121561199c5761199b611664565b5b600082013567ffffffffffffffff8111156119ba576119b9611669565b5b6119c684828501611958565b91505092915050565b6119d88161166e565b82525050565b60006020820190506119f360008301846119cf565b92915050565b6000819050919050565b6000611a1e611a19611a14846116e0565b6119f9565b6116e0565b9050919050565b6000611a3082611a03565b9050919050565b6000611a4282611a25565b9050919050565b611a5281611a37565b82525050565b6000602082019050611a6d6000830184611a49565b92915050565b611a7c81611700565b8114611a8757600080fd5b50565b600081359050611a9981611a73565b92915050565b600060208284031215611ab557611ab4611664565b5b6000611ac384828501611a8a565b91505092915050565b600067ffffffffffffffff821115611ae757611ae661185b565b5b611af082611767565b9050602081019050919050565b6000611b10611b0b84611acc565b6118bb565b905082815260208101848484011115611b2c57611b2b611856565b5b611b37848285611907565b509392505050565b600082601f830112611b5457611b53611851565b5b8135611b64848260208601611afd565b91505092915050565b600080600080600060a08688031215611b8957611b88611664565b5b600086013567ffffffffffffffff811115611ba757611ba6611669565b5b611bb388828901611958565b955050602086013567ffffffffffffffff811115611bd457611bd3611669565b5b611be088828901611958565b945050604086013567ffffffffffffffff811115611c0157611c00611669565b5b611c0d88828901611b3f565b9350506060611c1e8882890161168f565b9250506080611c2f8882890161168f565b9150509295509295909350565b611c4581611700565b82525050565b6000602082019050611c606000830184611c3c565b92915050565b600080600060608486031215611c7f57611c7e611664565b5b600084013567ffffffffffffffff811115611c9d57611c9c611669565b5b611ca986828701611958565b935050602084013567ffffffffffffffff811115611cca57611cc9611669565b5b611cd686828701611958565b9250506040611ce78682870161168f565b9150509250925092565b6000819050919050565b611d0481611cf1565b82525050565b6000602082019050611d1f6000830184611cfb565b92915050565b60006040830160008301518482036000860152611d428282611778565b91505060208301518482036020860152611d5c8282611778565b9150508091505092915050565b60006020820190508181036000830152611d838184611d25565b905092915050565b600060ff82169050919050565b611da181611d8b565b82525050565b6000602082019050611dbc6000830184611d98565b92915050565b611dcb816117b1565b82525050565b6000602082019050611de66000830184611dc2565b92915050565b6000611df782611a25565b9050919050565b611e0781611dec565b82525050565b6000602082019050611e226000830184611dfe565b92915050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052602260045260246000fd5b60006002820490506001821680611e6f57607f821691505b602082108103611e8257611e81611e28565b5b50919050565b600081905092915050565b6000611e9e82611721565b611ea88185611e88565b9350611eb881856020860161173d565b80840191505092915050565b6000611ed08284611e93565b915081905092915050565b600082825260208201905092915050565b7f446f6320616c7265616479206265656e207375626d6974746564000000000000600082015250565b6000611f22601a83611edb565b9150611f2d82611eec565b602082019050919050565b60006020820190508181036000830152611f5181611f15565b9050919050565b60008190508160005260206000209050919050565b60006020601f8301049050919050565b600082821b905092915050565b600060088302611fba7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff82611f7d565b611fc48683611f7d565b95508019841693508086168417925050509392505050565b6000611ff7611ff2611fed8461166e565b6119f9565b61166e565b9050919050565b6000819050919050565b61201183611fdc565b61202561201d82611ffe565b848454611f8a565b825550505050565b600090565b61203a61202d565b612045818484612008565b505050565b5b818110156120695761205e600082612032565b60018101905061204b565b5050565b601f8211156120ae5761207f81611f58565b61208884611f6d565b81016020851015612097578190505b6120ab6120a385611f6d565b83018261204a565b50505b505050565b600082821c905092915050565b60006120d1600019846008026120b3565b1980831691505092915050565b60006120ea83836120c0565b9150826002028217905092915050565b61210382611721565b67ffffffffffffffff81111561211c5761211b61185b565b5b6121268254611e57565b61213182828561206d565b600060209050601f8311600181146121645760008415612152578287015190505b61215c85826120de565b8655506121c4565b601f19841661217286611f58565b60005b8281101561219a57848901518255600182019150602085019450602081019050612175565b868310156121b757848901516121b3601f8916826120c0565b8355505b6001600288020188555050505b505050505050565b60006121d782611721565b6121e18185611edb565b93506121f181856020860161173d565b6121fa81611767565b840191505092915050565b6000604082019050818103600083015261221f81856121cc565b905061222e60208301846119cf565b9392505050565b7f544545207369676e6572206e6f7420666f756e64000000000000000000000000600082015250565b600061226b601483611edb565b915061227682612235565b602082019050919050565b6000602082019050818103600083015261229a8161225e565b9050919050565b7f496e76616c69642073657373696f6e206f776e65720000000000000000000000600082015250565b60006122d7601583611edb565b91506122e2826122a1565b602082019050919050565b60006020820190508181036000830152612306816122ca565b9050919050565b7f53657373696f6e20697320656e64656400000000000000000000000000000000600082015250565b6000612343601083611edb565b915061234e8261230d565b602082019050919050565b6000602082019050818103600083015261237281612336565b9050919050565b60008151905061238881611678565b92915050565b6000602082840312156123a4576123a3611664565b5b60006123b284828501612379565b91505092915050565b60006040820190506123d06000830185611c3c565b6123dd60208301846119cf565b9392505050565b600060a0820190506123f960008301886119cf565b6124066020830187611c3c565b818103604083015261241881866121cc565b9050818103606083015261242c81856121cc565b905061243b60808301846119cf565b9695505050505050565b7f496e76616c696420544545207369676e65720000000000000000000000000000600082015250565b600061247b601283611edb565b915061248682612445565b602082019050919050565b600060208201905081810360008301526124aa8161246e565b9050919050565b7f544545207369676e657220616c72656164792061646465640000000000000000600082015250565b60006124e7601883611edb565b91506124f2826124b1565b602082019050919050565b60006020820190508181036000830152612516816124da565b9050919050565b7f4f776e61626c653a206e6577206f776e657220697320746865207a65726f206160008201527f6464726573730000000000000000000000000000000000000000000000000000602082015250565b6000612579602683611edb565b91506125848261251d565b604082019050919050565b600060208201905081810360008301526125a88161256c565b9050919050565b7f4f776e61626c653a2063616c6c6572206973206e6f7420746865206f776e6572600082015250565b60006125e5602083611edb565b91506125f0826125af565b602082019050919050565b60006020820190508181036000830152612614816125d8565b9050919050565b61262481611d8b565b811461262f57600080fd5b50565b6000815190506126418161261b565b92915050565b61265081611cf1565b811461265b57600080fd5b50565b60008151905061266d81612647565b92915050565b6000612686612681846118d6565b6118bb565b9050828152602081018484840111156126a2576126a1611856565b5b6126ad84828561173d565b509392505050565b600082601f8301126126ca576126c9611851565b5b81516126da848260208601612673565b91505092915050565b60006126f66126f184611acc565b6118bb565b90508281526020810184848401111561271257612711611856565b5b61271d84828561173d565b509392505050565b600082601f83011261273a57612739611851565b5b815161274a8482602086016126e3565b91505092915050565b600080600080600080600080610100898b03121561277457612773611664565b5b60006127828b828c01612632565b98505060206127938b828c0161265e565b975050604089015167ffffffffffffffff8111156127b4576127b3611669565b5b6127c08b828c016126b5565b965050606089015167ffffffffffffffff8111156127e1576127e0611669565b5b6127ed8b828c016126b5565b95505060806127fe8b828c0161265e565b94505060a061280f8b828c0161265e565b93505060c06128208b828c0161265e565b92505060e089015167ffffffffffffffff81111561284157612840611669565b5b61284d8b828c01612725565b9150509295985092959890939650565b7f556e737570706f727465642070726f6f662076657273696f6e00000000000000600082015250565b6000612893601983611edb565b915061289e8261285d565b602082019050919050565b600060208201905081810360008301526128c281612886565b9050919050565b7f50726f6f66206973206e6f7420666f72207468697320636f6e6669726d61746960008201527f6f6e000000000000000000000000000000000000000000000000000000000000602082015250565b6000612925602283611edb565b9150612930826128c9565b604082019050919050565b6000602082019050818103600083015261295481612918565b9050919050565b60008160f81b9050919050565b60006129738261295b565b9050919050565b61298b61298682611d8b565b612968565b82525050565b600061299d828461297a565b60018201915081905092915050565b7f50726f6f6620646f6573206e6f742061747465737420746865207269736b207360008201527f636f726500000000000000000000000000000000000000000000000000000000602082015250565b6000612a08602483611edb565b9150612a13826129ac565b604082019050919050565b60006020820190508181036000830152612a37816129fb565b9050919050565b600060e082019050612a53600083018a611d98565b612a606020830189611cfb565b8181036040830152612a7281886121cc565b90508181036060830152612a8681876121cc565b9050612a956080830186611cfb565b612aa260a0830185611cfb565b612aaf60c0830184611cfb565b98975050505050505050565b7f496e76616c69642070726f6f66207369676e6174757265000000000000000000600082015250565b6000612af1601783611edb565b9150612afc82612abb565b602082019050919050565b60006020820190508181036000830152612b2081612ae4565b9050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052603260045260246000fd5b7f4e487b7100000000000000000000000000000000000000000000000000000000600052601160045260246000fd5b6000612b9082611d8b565b9150612b9b83611d8b565b9250828201905060ff811115612bb457612bb3612b56565b5b92915050565b7f50726f6f66206973206e6f74207369676e65642062792061205445452073696760008201527f6e65720000000000000000000000000000000000000000000000000000000000602082015250565b6000612c16602383611edb565b9150612c2182612bba565b604082019050919050565b60006020820190508181036000830152612c4581612c09565b9050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052602160045260246000fd5b7f45434453413a20696e76616c6964207369676e61747572650000000000000000600082015250565b6000612cb1601883611edb565b9150612cbc82612c7b565b602082019050919050565b60006020820190508181036000830152612ce081612ca4565b9050919050565b7f45434453413a20696e76616c6964207369676e6174757265206c656e67746800600082015250565b6000612d1d601f83611edb565b9150612d2882612ce7565b602082019050919050565b60006020820190508181036000830152612d4c81612d10565b9050919050565b7f45434453413a20696e76616c6964207369676e6174757265202773272076616c60008201527f7565000000000000000000000000000000000000000000000000000000000000602082015250565b6000612daf602283611edb565b9150612dba82612d53565b604082019050919050565b60006020820190508181036000830152612dde81612da2565b9050919050565b6000608082019050612dfa6000830187611cfb565b612e076020830186611d98565b612e146040830185611cfb565b612e216060830184611cfb565b9594505050505056fea2646970667358221220141baec908a39d04ce2b2b917a2fcba5606fc3dc316a5cb82dbd54723193aea364736f6c63430008150033",
 }
 
 // ControllerABI is the input ABI used to generate the binding from.
 // Deprecated: Use ControllerMetaData.ABI instead.
 var ControllerABI = ControllerMetaData.ABI
 
+// ControllerBin is the compiled bytecode used for deploying new contracts.
+// Deprecated: Use ControllerMetaData.Bin instead.
+var ControllerBin = ControllerMetaData.Bin
+
+// DeployController deploys a new Ethereum contract, binding an instance of Controller to it.
+func DeployController(auth *bind.TransactOpts, backend bind.ContractBackend, nftAddress common.Address, pcspAddress common.Address) (common.Address, *types.Transaction, *Controller, error) {
+	parsed, err := ControllerMetaData.GetAbi()
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	if parsed == nil {
+		return common.Address{}, nil, nil, errors.New("GetABI returned nil")
+	}
+
+	address, tx, contract, err := bind.DeployContract(auth, *parsed, common.FromHex(ControllerBin), backend, nftAddress, pcspAddress)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	return address, tx, &Controller{ControllerCaller: ControllerCaller{contract: contract}, ControllerTransactor: ControllerTransactor{contract: contract}, ControllerFilterer: ControllerFilterer{contract: contract}}, nil
+}
+
 // Controller is an auto generated Go binding around an Ethereum contract.
 type Controller struct {
 	ControllerCaller     // Read-only binding to the contract
@@ -194,6 +216,68 @@ func (_Controller *ControllerTransactorRaw) Transact(opts *bind.TransactOpts, me
 	return _Controller.Contract.contract.Transact(opts, method, params...)
 }
 
+// QUOTEVERSION is a free data retrieval call binding the contract method 0xafcc377c.
+//
+// Solidity: function QUOTE_VERSION() view returns(uint8)
+func (_Controller *ControllerCaller) QUOTEVERSION(opts *bind.CallOpts) (uint8, error) {
+	var out []interface{}
+	err := _Controller.contract.Call(opts, &out, "QUOTE_VERSION")
+
+	if err != nil {
+		return *new(uint8), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(uint8)).(*uint8)
+
+	return out0, err
+
+}
+
+// QUOTEVERSION is a free data retrieval call binding the contract method 0xafcc377c.
+//
+// Solidity: function QUOTE_VERSION() view returns(uint8)
+func (_Controller *ControllerSession) QUOTEVERSION() (uint8, error) {
+	return _Controller.Contract.QUOTEVERSION(&_Controller.CallOpts)
+}
+
+// QUOTEVERSION is a free data retrieval call binding the contract method 0xafcc377c.
+//
+// Solidity: function QUOTE_VERSION() view returns(uint8)
+func (_Controller *ControllerCallerSession) QUOTEVERSION() (uint8, error) {
+	return _Controller.Contract.QUOTEVERSION(&_Controller.CallOpts)
+}
+
+// ConfirmNonce is a free data retrieval call binding the contract method 0x9037bbec.
+//
+// Solidity: function confirmNonce(string docId, string contentHash, uint256 sessionId) view returns(bytes32)
+func (_Controller *ControllerCaller) ConfirmNonce(opts *bind.CallOpts, docId string, contentHash string, sessionId *big.Int) ([32]byte, error) {
+	var out []interface{}
+	err := _Controller.contract.Call(opts, &out, "confirmNonce", docId, contentHash, sessionId)
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// ConfirmNonce is a free data retrieval call binding the contract method 0x9037bbec.
+//
+// Solidity: function confirmNonce(string docId, string contentHash, uint256 sessionId) view returns(bytes32)
+func (_Controller *ControllerSession) ConfirmNonce(docId string, contentHash string, sessionId *big.Int) ([32]byte, error) {
+	return _Controller.Contract.ConfirmNonce(&_Controller.CallOpts, docId, contentHash, sessionId)
+}
+
+// ConfirmNonce is a free data retrieval call binding the contract method 0x9037bbec.
+//
+// Solidity: function confirmNonce(string docId, string contentHash, uint256 sessionId) view returns(bytes32)
+func (_Controller *ControllerCallerSession) ConfirmNonce(docId string, contentHash string, sessionId *big.Int) ([32]byte, error) {
+	return _Controller.Contract.ConfirmNonce(&_Controller.CallOpts, docId, contentHash, sessionId)
+}
+
 // GeneNFT is a free data retrieval call binding the contract method 0x5231f627.
 //
 // Solidity: function geneNFT() view returns(address)
@@ -287,6 +371,37 @@ func (_Controller *ControllerCallerSession) GetSession(sessionId *big.Int) (Cont
 	return _Controller.Contract.GetSession(&_Controller.CallOpts, sessionId)
 }
 
+// Owner is a free data retrieval call binding the contract method 0x8da5cb5b.
+//
+// Solidity: function owner() view returns(address)
+func (_Controller *ControllerCaller) Owner(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _Controller.contract.Call(opts, &out, "owner")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Owner is a free data retrieval call binding the contract method 0x8da5cb5b.
+//
+// Solidity: function owner() view returns(address)
+func (_Controller *ControllerSession) Owner() (common.Address, error) {
+	return _Controller.Contract.Owner(&_Controller.CallOpts)
+}
+
+// Owner is a free data retrieval call binding the contract method 0x8da5cb5b.
+//
+// Solidity: function owner() view returns(address)
+func (_Controller *ControllerCallerSession) Owner() (common.Address, error) {
+	return _Controller.Contract.Owner(&_Controller.CallOpts)
+}
+
 // PcspToken is a free data retrieval call binding the contract method 0xdab3761e.
 //
 // Solidity: function pcspToken() view returns(address)
@@ -318,27 +433,142 @@ func (_Controller *ControllerCallerSession) PcspToken() (common.Address, error)
 	return _Controller.Contract.PcspToken(&_Controller.CallOpts)
 }
 
-// Confirm is a paid mutator transaction binding the contract method 0xb62fdfce.
+// TeeSigners is a free data retrieval call binding the contract method 0xcd88f94e.
+//
+// Solidity: function teeSigners(address ) view returns(bool)
+func (_Controller *ControllerCaller) TeeSigners(opts *bind.CallOpts, arg0 common.Address) (bool, error) {
+	var out []interface{}
+	err := _Controller.contract.Call(opts, &out, "teeSigners", arg0)
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// TeeSigners is a free data retrieval call binding the contract method 0xcd88f94e.
+//
+// Solidity: function teeSigners(address ) view returns(bool)
+func (_Controller *ControllerSession) TeeSigners(arg0 common.Address) (bool, error) {
+	return _Controller.Contract.TeeSigners(&_Controller.CallOpts, arg0)
+}
+
+// TeeSigners is a free data retrieval call binding the contract method 0xcd88f94e.
+//
+// Solidity: function teeSigners(address ) view returns(bool)
+func (_Controller *ControllerCallerSession) TeeSigners(arg0 common.Address) (bool, error) {
+	return _Controller.Contract.TeeSigners(&_Controller.CallOpts, arg0)
+}
+
+// AddTEESigner is a paid mutator transaction binding the contract method 0xadde88df.
+//
+// Solidity: function addTEESigner(address signer) returns()
+func (_Controller *ControllerTransactor) AddTEESigner(opts *bind.TransactOpts, signer common.Address) (*types.Transaction, error) {
+	return _Controller.contract.Transact(opts, "addTEESigner", signer)
+}
+
+// AddTEESigner is a paid mutator transaction binding the contract method 0xadde88df.
 //
-// Solidity: function confirm(string docId, string contentHash, string proof, uint256 sessionId, uint256 riskScore) returns()
-func (_Controller *ControllerTransactor) Confirm(opts *bind.TransactOpts, docId string, contentHash string, proof string, sessionId *big.Int, riskScore *big.Int) (*types.Transaction, error) {
+// Solidity: function addTEESigner(address signer) returns()
+func (_Controller *ControllerSession) AddTEESigner(signer common.Address) (*types.Transaction, error) {
+	return _Controller.Contract.AddTEESigner(&_Controller.TransactOpts, signer)
+}
+
+// AddTEESigner is a paid mutator transaction binding the contract method 0xadde88df.
+//
+// Solidity: function addTEESigner(address signer) returns()
+func (_Controller *ControllerTransactorSession) AddTEESigner(signer common.Address) (*types.Transaction, error) {
+	return _Controller.Contract.AddTEESigner(&_Controller.TransactOpts, signer)
+}
+
+// Confirm is a paid mutator transaction binding the contract method 0x7f7c474b.
+//
+// Solidity: function confirm(string docId, string contentHash, bytes proof, uint256 sessionId, uint256 riskScore) returns()
+func (_Controller *ControllerTransactor) Confirm(opts *bind.TransactOpts, docId string, contentHash string, proof []byte, sessionId *big.Int, riskScore *big.Int) (*types.Transaction, error) {
 	return _Controller.contract.Transact(opts, "confirm", docId, contentHash, proof, sessionId, riskScore)
 }
 
-// Confirm is a paid mutator transaction binding the contract method 0xb62fdfce.
+// Confirm is a paid mutator transaction binding the contract method 0x7f7c474b.
 //
-// Solidity: function confirm(string docId, string contentHash, string proof, uint256 sessionId, uint256 riskScore) returns()
-func (_Controller *ControllerSession) Confirm(docId string, contentHash string, proof string, sessionId *big.Int, riskScore *big.Int) (*types.Transaction, error) {
+// Solidity: function confirm(string docId, string contentHash, bytes proof, uint256 sessionId, uint256 riskScore) returns()
+func (_Controller *ControllerSession) Confirm(docId string, contentHash string, proof []byte, sessionId *big.Int, riskScore *big.Int) (*types.Transaction, error) {
 	return _Controller.Contract.Confirm(&_Controller.TransactOpts, docId, contentHash, proof, sessionId, riskScore)
 }
 
-// Confirm is a paid mutator transaction binding the contract method 0xb62fdfce.
+// Confirm is a paid mutator transaction binding the contract method 0x7f7c474b.
 //
-// Solidity: function confirm(string docId, string contentHash, string proof, uint256 sessionId, uint256 riskScore) returns()
-func (_Controller *ControllerTransactorSession) Confirm(docId string, contentHash string, proof string, sessionId *big.Int, riskScore *big.Int) (*types.Transaction, error) {
+// Solidity: function confirm(string docId, string contentHash, bytes proof, uint256 sessionId, uint256 riskScore) returns()
+func (_Controller *ControllerTransactorSession) Confirm(docId string, contentHash string, proof []byte, sessionId *big.Int, riskScore *big.Int) (*types.Transaction, error) {
 	return _Controller.Contract.Confirm(&_Controller.TransactOpts, docId, contentHash, proof, sessionId, riskScore)
 }
 
+// RemoveTEESigner is a paid mutator transaction binding the contract method 0x6ef56ced.
+//
+// Solidity: function removeTEESigner(address signer) returns()
+func (_Controller *ControllerTransactor) RemoveTEESigner(opts *bind.TransactOpts, signer common.Address) (*types.Transaction, error) {
+	return _Controller.contract.Transact(opts, "removeTEESigner", signer)
+}
+
+// RemoveTEESigner is a paid mutator transaction binding the contract method 0x6ef56ced.
+//
+// Solidity: function removeTEESigner(address signer) returns()
+func (_Controller *ControllerSession) RemoveTEESigner(signer common.Address) (*types.Transaction, error) {
+	return _Controller.Contract.RemoveTEESigner(&_Controller.TransactOpts, signer)
+}
+
+// RemoveTEESigner is a paid mutator transaction binding the contract method 0x6ef56ced.
+//
+// Solidity: function removeTEESigner(address signer) returns()
+func (_Controller *ControllerTransactorSession) RemoveTEESigner(signer common.Address) (*types.Transaction, error) {
+	return _Controller.Contract.RemoveTEESigner(&_Controller.TransactOpts, signer)
+}
+
+// RenounceOwnership is a paid mutator transaction binding the contract method 0x715018a6.
+//
+// Solidity: function renounceOwnership() returns()
+func (_Controller *ControllerTransactor) RenounceOwnership(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _Controller.contract.Transact(opts, "renounceOwnership")
+}
+
+// RenounceOwnership is a paid mutator transaction binding the contract method 0x715018a6.
+//
+// Solidity: function renounceOwnership() returns()
+func (_Controller *ControllerSession) RenounceOwnership() (*types.Transaction, error) {
+	return _Controller.Contract.RenounceOwnership(&_Controller.TransactOpts)
+}
+
+// RenounceOwnership is a paid mutator transaction binding the contract method 0x715018a6.
+//
+// Solidity: function renounceOwnership() returns()
+func (_Controller *ControllerTransactorSession) RenounceOwnership() (*types.Transaction, error) {
+	return _Controller.Contract.RenounceOwnership(&_Controller.TransactOpts)
+}
+
+// TransferOwnership is a paid mutator transaction binding the contract method 0xf2fde38b.
+//
+// Solidity: function transferOwnership(address newOwner) returns()
+func (_Controller *ControllerTransactor) TransferOwnership(opts *bind.TransactOpts, newOwner common.Address) (*types.Transaction, error) {
+	return _Controller.contract.Transact(opts, "transferOwnership", newOwner)
+}
+
+// TransferOwnership is a paid mutator transaction binding the contract method 0xf2fde38b.
+//
+// Solidity: function transferOwnership(address newOwner) returns()
+func (_Controller *ControllerSession) TransferOwnership(newOwner common.Address) (*types.Transaction, error) {
+	return _Controller.Contract.TransferOwnership(&_Controller.TransactOpts, newOwner)
+}
+
+// TransferOwnership is a paid mutator transaction binding the contract method 0xf2fde38b.
+//
+// Solidity: function transferOwnership(address newOwner) returns()
+func (_Controller *ControllerTransactorSession) TransferOwnership(newOwner common.Address) (*types.Transaction, error) {
+	return _Controller.Contract.TransferOwnership(&_Controller.TransactOpts, newOwner)
+}
+
 // UploadData is a paid mutator transaction binding the contract method 0x50969f44.
 //
 // Solidity: function uploadData(string docId) returns(uint256)
@@ -360,6 +590,427 @@ func (_Controller *ControllerTransactorSession) UploadData(docId string) (*types
 	return _Controller.Contract.UploadData(&_Controller.TransactOpts, docId)
 }
 
+// ControllerOwnershipTransferredIterator is returned from FilterOwnershipTransferred and is used to iterate over the raw logs and unpacked data for OwnershipTransferred events raised by the Controller contract.
+type ControllerOwnershipTransferredIterator struct {
+	Event *ControllerOwnershipTransferred // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *ControllerOwnershipTransferredIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(ControllerOwnershipTransferred)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(ControllerOwnershipTransferred)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *ControllerOwnershipTransferredIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *ControllerOwnershipTransferredIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// ControllerOwnershipTransferred represents a OwnershipTransferred event raised by the Controller contract.
+type ControllerOwnershipTransferred struct {
+	PreviousOwner common.Address
+	NewOwner      common.Address
+	Raw           types.Log // Blockchain specific contextual infos
+}
+
+// FilterOwnershipTransferred is a free log retrieval operation binding the contract event 0x8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e0.
+//
+// Solidity: event OwnershipTransferred(address indexed previousOwner, address indexed newOwner)
+func (_Controller *ControllerFilterer) FilterOwnershipTransferred(opts *bind.FilterOpts, previousOwner []common.Address, newOwner []common.Address) (*ControllerOwnershipTransferredIterator, error) {
+
+	var previousOwnerRule []interface{}
+	for _, previousOwnerItem := range previousOwner {
+		previousOwnerRule = append(previousOwnerRule, previousOwnerItem)
+	}
+	var newOwnerRule []interface{}
+	for _, newOwnerItem := range newOwner {
+		newOwnerRule = append(newOwnerRule, newOwnerItem)
+	}
+
+	logs, sub, err := _Controller.contract.FilterLogs(opts, "OwnershipTransferred", previousOwnerRule, newOwnerRule)
+	if err != nil {
+		return nil, err
+	}
+	return &ControllerOwnershipTransferredIterator{contract: _Controller.contract, event: "OwnershipTransferred", logs: logs, sub: sub}, nil
+}
+
+// WatchOwnershipTransferred is a free log subscription operation binding the contract event 0x8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e0.
+//
+// Solidity: event OwnershipTransferred(address indexed previousOwner, address indexed newOwner)
+func (_Controller *ControllerFilterer) WatchOwnershipTransferred(opts *bind.WatchOpts, sink chan<- *ControllerOwnershipTransferred, previousOwner []common.Address, newOwner []common.Address) (event.Subscription, error) {
+
+	var previousOwnerRule []interface{}
+	for _, previousOwnerItem := range previousOwner {
+		previousOwnerRule = append(previousOwnerRule, previousOwnerItem)
+	}
+	var newOwnerRule []interface{}
+	for _, newOwnerItem := range newOwner {
+		newOwnerRule = append(newOwnerRule, newOwnerItem)
+	}
+
+	logs, sub, err := _Controller.contract.WatchLogs(opts, "OwnershipTransferred", previousOwnerRule, newOwnerRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(ControllerOwnershipTransferred)
+				if err := _Controller.contract.UnpackLog(event, "OwnershipTransferred", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseOwnershipTransferred is a log parse operation binding the contract event 0x8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e0.
+//
+// Solidity: event OwnershipTransferred(address indexed previousOwner, address indexed newOwner)
+func (_Controller *ControllerFilterer) ParseOwnershipTransferred(log types.Log) (*ControllerOwnershipTransferred, error) {
+	event := new(ControllerOwnershipTransferred)
+	if err := _Controller.contract.UnpackLog(event, "OwnershipTransferred", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ControllerTEESignerAddedIterator is returned from FilterTEESignerAdded and is used to iterate over the raw logs and unpacked data for TEESignerAdded events raised by the Controller contract.
+type ControllerTEESignerAddedIterator struct {
+	Event *ControllerTEESignerAdded // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *ControllerTEESignerAddedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(ControllerTEESignerAdded)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(ControllerTEESignerAdded)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *ControllerTEESignerAddedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *ControllerTEESignerAddedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// ControllerTEESignerAdded represents a TEESignerAdded event raised by the Controller contract.
+type ControllerTEESignerAdded struct {
+	Signer common.Address
+	Raw    types.Log // Blockchain specific contextual infos
+}
+
+// FilterTEESignerAdded is a free log retrieval operation binding the contract event 0x580faf9e12757a60335492ebbd3e06d7cd87c012f6f9626fc1457cd4c3da0b08.
+//
+// Solidity: event TEESignerAdded(address signer)
+func (_Controller *ControllerFilterer) FilterTEESignerAdded(opts *bind.FilterOpts) (*ControllerTEESignerAddedIterator, error) {
+
+	logs, sub, err := _Controller.contract.FilterLogs(opts, "TEESignerAdded")
+	if err != nil {
+		return nil, err
+	}
+	return &ControllerTEESignerAddedIterator{contract: _Controller.contract, event: "TEESignerAdded", logs: logs, sub: sub}, nil
+}
+
+// WatchTEESignerAdded is a free log subscription operation binding the contract event 0x580faf9e12757a60335492ebbd3e06d7cd87c012f6f9626fc1457cd4c3da0b08.
+//
+// Solidity: event TEESignerAdded(address signer)
+func (_Controller *ControllerFilterer) WatchTEESignerAdded(opts *bind.WatchOpts, sink chan<- *ControllerTEESignerAdded) (event.Subscription, error) {
+
+	logs, sub, err := _Controller.contract.WatchLogs(opts, "TEESignerAdded")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(ControllerTEESignerAdded)
+				if err := _Controller.contract.UnpackLog(event, "TEESignerAdded", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseTEESignerAdded is a log parse operation binding the contract event 0x580faf9e12757a60335492ebbd3e06d7cd87c012f6f9626fc1457cd4c3da0b08.
+//
+// Solidity: event TEESignerAdded(address signer)
+func (_Controller *ControllerFilterer) ParseTEESignerAdded(log types.Log) (*ControllerTEESignerAdded, error) {
+	event := new(ControllerTEESignerAdded)
+	if err := _Controller.contract.UnpackLog(event, "TEESignerAdded", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ControllerTEESignerRemovedIterator is returned from FilterTEESignerRemoved and is used to iterate over the raw logs and unpacked data for TEESignerRemoved events raised by the Controller contract.
+type ControllerTEESignerRemovedIterator struct {
+	Event *ControllerTEESignerRemoved // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *ControllerTEESignerRemovedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(ControllerTEESignerRemoved)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(ControllerTEESignerRemoved)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *ControllerTEESignerRemovedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *ControllerTEESignerRemovedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// ControllerTEESignerRemoved represents a TEESignerRemoved event raised by the Controller contract.
+type ControllerTEESignerRemoved struct {
+	Signer common.Address
+	Raw    types.Log // Blockchain specific contextual infos
+}
+
+// FilterTEESignerRemoved is a free log retrieval operation binding the contract event 0x6e286dc7bb51e8f4d0d4050b5ac0b400e8cff2ba684971be957c93fee69270d8.
+//
+// Solidity: event TEESignerRemoved(address signer)
+func (_Controller *ControllerFilterer) FilterTEESignerRemoved(opts *bind.FilterOpts) (*ControllerTEESignerRemovedIterator, error) {
+
+	logs, sub, err := _Controller.contract.FilterLogs(opts, "TEESignerRemoved")
+	if err != nil {
+		return nil, err
+	}
+	return &ControllerTEESignerRemovedIterator{contract: _Controller.contract, event: "TEESignerRemoved", logs: logs, sub: sub}, nil
+}
+
+// WatchTEESignerRemoved is a free log subscription operation binding the contract event 0x6e286dc7bb51e8f4d0d4050b5ac0b400e8cff2ba684971be957c93fee69270d8.
+//
+// Solidity: event TEESignerRemoved(address signer)
+func (_Controller *ControllerFilterer) WatchTEESignerRemoved(opts *bind.WatchOpts, sink chan<- *ControllerTEESignerRemoved) (event.Subscription, error) {
+
+	logs, sub, err := _Controller.contract.WatchLogs(opts, "TEESignerRemoved")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(ControllerTEESignerRemoved)
+				if err := _Controller.contract.UnpackLog(event, "TEESignerRemoved", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseTEESignerRemoved is a log parse operation binding the contract event 0x6e286dc7bb51e8f4d0d4050b5ac0b400e8cff2ba684971be957c93fee69270d8.
+//
+// Solidity: event TEESignerRemoved(address signer)
+func (_Controller *ControllerFilterer) ParseTEESignerRemoved(log types.Log) (*ControllerTEESignerRemoved, error) {
+	event := new(ControllerTEESignerRemoved)
+	if err := _Controller.contract.UnpackLog(event, "TEESignerRemoved", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
 // ControllerUploadDataIterator is returned from FilterUploadData and is used to iterate over the raw logs and unpacked data for UploadData events raised by the Controller contract.
 type ControllerUploadDataIterator struct {
 	Event *ControllerUploadData // Event containing the contract specifics and raw log
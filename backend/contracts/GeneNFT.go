@@ -32,12 +32,34 @@ var (
 // GeneNFTMetaData contains all meta data concerning the GeneNFT contract.
 var GeneNFTMetaData = &bind.MetaData{
 	ABI: "[{\"inputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"approved\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"Approval\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"operator\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"bool\",\"name\":\"approved\",\"type\":\"bool\"}],\"name\":\"ApprovalForAll\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"previousOwner\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"OwnershipTransferred\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"Transfer\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"approve\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"}],\"name\":\"balanceOf\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"burn\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"getApproved\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"operator\",\"type\":\"address\"}],\"name\":\"isApprovedForAll\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"name\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"owner\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"ownerOf\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"renounceOwnership\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"}],\"name\":\"safeMint\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"safeTransferFrom\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"},{\"internalType\":\"bytes\",\"name\":\"data\",\"type\":\"bytes\"}],\"name\":\"safeTransferFrom\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"operator\",\"type\":\"address\"},{\"internalType\":\"bool\",\"name\":\"approved\",\"type\":\"bool\"}],\"name\":\"setApprovalForAll\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes4\",\"name\":\"interfaceId\",\"type\":\"bytes4\"}],\"name\":\"supportsInterface\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"symbol\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"tokenURI\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"transferFrom\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"transferOwnership\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]",
+	Bin: "0x60806040523480156200001157600080fd5b506040518060400160405280600781526020017f47656e654e4654000000000000000000000000000000000000000000000000008152506040518060400160405280600481526020017f474e46540000000000000000000000000000000000000000000000000000000081525081600090816200008f919062000412565b508060019081620000a1919062000412565b505050620000c4620000b8620000ca60201b60201c565b620000d260201b60201c565b620004f9565b600033905090565b6000600660009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905081600660006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055508173ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff167f8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e060405160405180910390a35050565b600081519050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052604160045260246000fd5b7f4e487b7100000000000000000000000000000000000000000000000000000000600052602260045260246000fd5b600060028204905060018216806200021a57607f821691505b60208210810362000230576200022f620001d2565b5b50919050565b60008190508160005260206000209050919050565b60006020601f8301049050919050565b600082821b905092915050565b6000600883026200029a7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff826200025b565b620002a686836200025b565b95508019841693508086168417925050509392505050565b6000819050919050565b6000819050919050565b6000620002f3620002ed620002e784620002be565b620002c8565b620002be565b9050919050565b6000819050919050565b6200030f83620002d2565b620003276200031e82620002fa565b84845462000268565b825550505050565b600090565b6200033e6200032f565b6200034b81848462000304565b505050565b5b8181101562000373576200036760008262000334565b60018101905062000351565b5050565b601f821115620003c2576200038c8162000236565b62000397846200024b565b81016020851015620003a7578190505b620003bf620003b6856200024b565b83018262000350565b50505b505050565b600082821c905092915050565b6000620003e760001984600802620003c7565b1980831691505092915050565b6000620004028383620003d4565b9150826002028217905092915050565b6200041d8262000198565b67ffffffffffffffff811115620004395762000438620001a3565b5b62000445825462000201565b6200045282828562000377565b600060209050601f8311600181146200048a576000841562000475578287015190505b620004818582620003f4565b865550620004f1565b601f1984166200049a8662000236565b60005b82811015620004c4578489015182556001820191506020850194506020810190506200049d565b86831015620004e45784890151620004e0601f891682620003d4565b8355505b6001600288020188555050505b505050505050565b612a6b80620005096000396000f3fe608060405234801561001057600080fd5b50600436106101165760003560e01c806370a08231116100a2578063a22cb46511610071578063a22cb465146102df578063b88d4fde146102fb578063c87b56dd14610317578063e985e9c514610347578063f2fde38b1461037757610116565b806370a0823114610269578063715018a6146102995780638da5cb5b146102a357806395d89b41146102c157610116565b806323b872dd116100e957806323b872dd146101b557806340d097c3146101d157806342842e0e1461020157806342966c681461021d5780636352211e1461023957610116565b806301ffc9a71461011b57806306fdde031461014b578063081812fc14610169578063095ea7b314610199575b600080fd5b61013560048036038101906101309190611c69565b610393565b6040516101429190611cb1565b60405180910390f35b610153610475565b6040516101609190611d5c565b60405180910390f35b610183600480360381019061017e9190611db4565b610507565b6040516101909190611e22565b60405180910390f35b6101b360048036038101906101ae9190611e69565b61054d565b005b6101cf60048036038101906101ca9190611ea9565b610664565b005b6101eb60048036038101906101e69190611efc565b6106c4565b6040516101f89190611f38565b60405180910390f35b61021b60048036038101906102169190611ea9565b6106f9565b005b61023760048036038101906102329190611db4565b610719565b005b610253600480360381019061024e9190611db4565b610775565b6040516102609190611e22565b60405180910390f35b610283600480360381019061027e9190611efc565b6107fb565b6040516102909190611f38565b60405180910390f35b6102a16108b2565b005b6102ab6108c6565b6040516102b89190611e22565b60405180910390f35b6102c96108f0565b6040516102d69190611d5c565b60405180910390f35b6102f960048036038101906102f49190611f7f565b610982565b005b610315600480360381019061031091906120f4565b610998565b005b610331600480360381019061032c9190611db4565b6109fa565b60405161033e9190611d5c565b60405180910390f35b610361600480360381019061035c9190612177565b610a62565b60405161036e9190611cb1565b60405180910390f35b610391600480360381019061038c9190611efc565b610af6565b005b60007f80ac58cd000000000000000000000000000000000000000000000000000000007bffffffffffffffffffffffffffffffffffffffffffffffffffffffff1916827bffffffffffffffffffffffffffffffffffffffffffffffffffffffff1916148061045e57507f5b5e139f000000000000000000000000000000000000000000000000000000007bffffffffffffffffffffffffffffffffffffffffffffffffffffffff1916827bffffffffffffffffffffffffffffffffffffffffffffffffffffffff1916145b8061046e575061046d82610b79565b5b9050919050565b606060008054610484906121e6565b80601f01602080910402602001604051908101604052809291908181526020018280546104b0906121e6565b80156104fd5780601f106104d2576101008083540402835291602001916104fd565b820191906000526020600020905b8154815290600101906020018083116104e057829003601f168201915b5050505050905090565b600061051282610be3565b6004600083815260200190815260200160002060009054906101000a900473ffffffffffffffffffffffffffffffffffffffff169050919050565b600061055882610775565b90508073ffffffffffffffffffffffffffffffffffffffff168373ffffffffffffffffffffffffffffffffffffffff16036105c8576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016105bf90612289565b60405180910390fd5b8073ffffffffffffffffffffffffffffffffffffffff166105e7610c2e565b73ffffffffffffffffffffffffffffffffffffffff161480610616575061061581610610610c2e565b610a62565b5b610655576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161064c9061231b565b60405180910390fd5b61065f8383610c36565b505050565b61067561066f610c2e565b82610cef565b6106b4576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016106ab906123ad565b60405180910390fd5b6106bf838383610d84565b505050565b60006106ce61107d565b60006106da60076110fb565b90506106e66007611109565b6106f0838261111f565b80915050919050565b61071483838360405180602001604052806000815250610998565b505050565b61072a610724610c2e565b82610cef565b610769576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610760906123ad565b60405180910390fd5b6107728161113d565b50565b6000806107818361128b565b9050600073ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff16036107f2576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016107e990612419565b60405180910390fd5b80915050919050565b60008073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff160361086b576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610862906124ab565b60405180910390fd5b600360008373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020549050919050565b6108ba61107d565b6108c460006112c8565b565b6000600660009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905090565b6060600180546108ff906121e6565b80601f016020809104026020016040519081016040528092919081815260200182805461092b906121e6565b80156109785780601f1061094d57610100808354040283529160200191610978565b820191906000526020600020905b81548152906001019060200180831161095b57829003601f168201915b5050505050905090565b61099461098d610c2e565b838361138e565b5050565b6109a96109a3610c2e565b83610cef565b6109e8576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016109df906123ad565b60405180910390fd5b6109f4848484846114fa565b50505050565b6060610a0582610be3565b6000610a0f611556565b90506000815111610a2f5760405180602001604052806000815250610a5a565b80610a398461156d565b604051602001610a4a929190612507565b6040516020818303038152906040525b915050919050565b6000600560008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060008373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060009054906101000a900460ff16905092915050565b610afe61107d565b600073ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff1603610b6d576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610b649061259d565b60405180910390fd5b610b76816112c8565b50565b60007f01ffc9a7000000000000000000000000000000000000000000000000000000007bffffffffffffffffffffffffffffffffffffffffffffffffffffffff1916827bffffffffffffffffffffffffffffffffffffffffffffffffffffffff1916149050919050565b610bec8161163b565b610c2b576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610c2290612419565b60405180910390fd5b50565b600033905090565b816004600083815260200190815260200160002060006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff160217905550808273ffffffffffffffffffffffffffffffffffffffff16610ca983610775565b73ffffffffffffffffffffffffffffffffffffffff167f8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b92560405160405180910390a45050565b600080610cfb83610775565b90508073ffffffffffffffffffffffffffffffffffffffff168473ffffffffffffffffffffffffffffffffffffffff161480610d3d5750610d3c8185610a62565b5b80610d7b57508373ffffffffffffffffffffffffffffffffffffffff16610d6384610507565b73ffffffffffffffffffffffffffffffffffffffff16145b91505092915050565b8273ffffffffffffffffffffffffffffffffffffffff16610da482610775565b73ffffffffffffffffffffffffffffffffffffffff1614610dfa576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610df19061262f565b60405180910390fd5b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff1603610e69576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610e60906126c1565b60405180910390fd5b610e76838383600161167c565b8273ffffffffffffffffffffffffffffffffffffffff16610e9682610775565b73ffffffffffffffffffffffffffffffffffffffff1614610eec576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610ee39061262f565b60405180910390fd5b6004600082815260200190815260200160002060006101000a81549073ffffffffffffffffffffffffffffffffffffffff02191690556001600360008573ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020600082825403925050819055506001600360008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060008282540192505081905550816002600083815260200190815260200160002060006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff160217905550808273ffffffffffffffffffffffffffffffffffffffff168473ffffffffffffffffffffffffffffffffffffffff167fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef60405160405180910390a46110788383836001611682565b505050565b611085610c2e565b73ffffffffffffffffffffffffffffffffffffffff166110a36108c6565b73ffffffffffffffffffffffffffffffffffffffff16146110f9576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016110f09061272d565b60405180910390fd5b565b600081600001549050919050565b6001816000016000828254019250508190555050565b611139828260405180602001604052806000815250611688565b5050565b600061114882610775565b905061115881600084600161167c565b61116182610775565b90506004600083815260200190815260200160002060006101000a81549073ffffffffffffffffffffffffffffffffffffffff02191690556001600360008373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020600082825403925050819055506002600083815260200190815260200160002060006101000a81549073ffffffffffffffffffffffffffffffffffffffff021916905581600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff167fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef60405160405180910390a4611287816000846001611682565b5050565b60006002600083815260200190815260200160002060009054906101000a900473ffffffffffffffffffffffffffffffffffffffff169050919050565b6000600660009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905081600660006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055508173ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff167f8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e060405160405180910390a35050565b8173ffffffffffffffffffffffffffffffffffffffff168373ffffffffffffffffffffffffffffffffffffffff16036113fc576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016113f390612799565b60405180910390fd5b80600560008573ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060006101000a81548160ff0219169083151502179055508173ffffffffffffffffffffffffffffffffffffffff168373ffffffffffffffffffffffffffffffffffffffff167f17307eab39ab6107e8899845ad3d59bd9653f200f220920489ca2b5937696c31836040516114ed9190611cb1565b60405180910390a3505050565b611505848484610d84565b611511848484846116e3565b611550576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016115479061282b565b60405180910390fd5b50505050565b606060405180602001604052806000815250905090565b60606000600161157c8461186a565b01905060008167ffffffffffffffff81111561159b5761159a611fc9565b5b6040519080825280601f01601f1916602001820160405280156115cd5781602001600182028036833780820191505090505b509050600082602001820190505b600115611630578080600190039150507f3031323334353637383961626364656600000000000000000000000000000000600a86061a8153600a85816116245761162361284b565b5b049450600085036115db575b819350505050919050565b60008073ffffffffffffffffffffffffffffffffffffffff1661165d8361128b565b73ffffffffffffffffffffffffffffffffffffffff1614159050919050565b50505050565b50505050565b61169283836119bd565b61169f60008484846116e3565b6116de576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016116d59061282b565b60405180910390fd5b505050565b60006117048473ffffffffffffffffffffffffffffffffffffffff16611bda565b1561185d578373ffffffffffffffffffffffffffffffffffffffff1663150b7a0261172d610c2e565b8786866040518563ffffffff1660e01b815260040161174f94939291906128cf565b6020604051808303816000875af192505050801561178b57506040513d601f19601f820116820180604052508101906117889190612930565b60015b61180d573d80600081146117bb576040519150601f19603f3d011682016040523d82523d6000602084013e6117c0565b606091505b506000815103611805576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016117fc9061282b565b60405180910390fd5b805181602001fd5b63150b7a0260e01b7bffffffffffffffffffffffffffffffffffffffffffffffffffffffff1916817bffffffffffffffffffffffffffffffffffffffffffffffffffffffff191614915050611862565b600190505b949350505050565b600080600090507a184f03e93ff9f4daa797ed6e38ed64bf6a1f01000000000000000083106118c8577a184f03e93ff9f4daa797ed6e38ed64bf6a1f01000000000000000083816118be576118bd61284b565b5b0492506040810190505b6d04ee2d6d415b85acef81000000008310611905576d04ee2d6d415b85acef810000000083816118fb576118fa61284b565b5b0492506020810190505b662386f26fc10000831061193457662386f26fc10000838161192a5761192961284b565b5b0492506010810190505b6305f5e100831061195d576305f5e10083816119535761195261284b565b5b0492506008810190505b61271083106119825761271083816119785761197761284b565b5b0492506004810190505b606483106119a5576064838161199b5761199a61284b565b5b0492506002810190505b600a83106119b4576001810190505b80915050919050565b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff1603611a2c576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401611a23906129a9565b60405180910390fd5b611a358161163b565b15611a75576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401611a6c90612a15565b60405180910390fd5b611a8360008383600161167c565b611a8c8161163b565b15611acc576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401611ac390612a15565b60405180910390fd5b6001600360008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060008282540192505081905550816002600083815260200190815260200160002060006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff160217905550808273ffffffffffffffffffffffffffffffffffffffff16600073ffffffffffffffffffffffffffffffffffffffff167fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef60405160405180910390a4611bd6600083836001611682565b5050565b6000808273ffffffffffffffffffffffffffffffffffffffff163b119050919050565b6000604051905090565b600080fd5b600080fd5b60007fffffffff0000000000000000000000000000000000000000000000000000000082169050919050565b611c4681611c11565b8114611c5157600080fd5b50565b600081359050611c6381611c3d565b92915050565b600060208284031215611c7f57611c7e611c07565b5b6000611c8d84828501611c54565b91505092915050565b60008115159050919050565b611cab81611c96565b82525050565b6000602082019050611cc66000830184611ca2565b92915050565b600081519050919050565b600082825260208201905092915050565b60005b83811015611d06578082015181840152602081019050611ceb565b60008484015250505050565b6000601f19601f8301169050919050565b6000611d2e82611ccc565b611d388185611cd7565b9350611d48818560208601611ce8565b611d5181611d12565b840191505092915050565b60006020820190508181036000830152611d768184611d23565b905092915050565b6000819050919050565b611d9181611d7e565b8114611d9c57600080fd5b50565b600081359050611dae81611d88565b92915050565b600060208284031215611dca57611dc9611c07565b5b6000611dd884828501611d9f565b91505092915050565b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b6000611e0c82611de1565b9050919050565b611e1c81611e01565b82525050565b6000602082019050611e376000830184611e13565b92915050565b611e4681611e01565b8114611e5157600080fd5b50565b600081359050611e6381611e3d565b92915050565b60008060408385031215611e8057611e7f611c07565b5b6000611e8e85828601611e54565b9250506020611e9f85828601611d9f565b9150509250929050565b600080600060608486031215611ec257611ec1611c07565b5b6000611ed086828701611e54565b9350506020611ee186828701611e54565b9250506040611ef286828701611d9f565b9150509250925092565b600060208284031215611f1257611f11611c07565b5b6000611f2084828501611e54565b91505092915050565b611f3281611d7e565b82525050565b6000602082019050611f4d6000830184611f29565b92915050565b611f5c81611c96565b8114611f6757600080fd5b50565b600081359050611f7981611f53565b92915050565b60008060408385031215611f9657611f95611c07565b5b6000611fa485828601611e54565b9250506020611fb585828601611f6a565b9150509250929050565b600080fd5b600080fd5b7f4e487b7100000000000000000000000000000000000000000000000000000000600052604160045260246000fd5b61200182611d12565b810181811067ffffffffffffffff821117156120205761201f611fc9565b5b80604052505050565b6000612033611bfd565b905061203f8282611ff8565b919050565b600067ffffffffffffffff82111561205f5761205e611fc9565b5b61206882611d12565b9050602081019050919050565b82818337600083830152505050565b600061209761209284612044565b612029565b9050828152602081018484840111156120b3576120b2611fc4565b5b6120be848285612075565b509392505050565b600082601f8301126120db576120da611fbf565b5b81356120eb848260208601612084565b91505092915050565b6000806000806080858703121561210e5761210d611c07565b5b600061211c87828801611e54565b945050602061212d87828801611e54565b935050604061213e87828801611d9f565b925050606085013567ffffffffffffffff81111561215f5761215e611c0c565b5b61216b878288016120c6565b91505092959194509250565b6000806040838503121561218e5761218d611c07565b5b600061219c85828601611e54565b92505060206121ad85828601611e54565b9150509250929050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052602260045260246000fd5b600060028204905060018216806121fe57607f821691505b602082108103612211576122106121b7565b5b50919050565b7f4552433732313a20617070726f76616c20746f2063757272656e74206f776e6560008201527f7200000000000000000000000000000000000000000000000000000000000000602082015250565b6000612273602183611cd7565b915061227e82612217565b604082019050919050565b600060208201905081810360008301526122a281612266565b9050919050565b7f4552433732313a20617070726f76652063616c6c6572206973206e6f7420746f60008201527f6b656e206f776e6572206f7220617070726f76656420666f7220616c6c000000602082015250565b6000612305603d83611cd7565b9150612310826122a9565b604082019050919050565b60006020820190508181036000830152612334816122f8565b9050919050565b7f4552433732313a2063616c6c6572206973206e6f7420746f6b656e206f776e6560008201527f72206f7220617070726f76656400000000000000000000000000000000000000602082015250565b6000612397602d83611cd7565b91506123a28261233b565b604082019050919050565b600060208201905081810360008301526123c68161238a565b9050919050565b7f4552433732313a20696e76616c696420746f6b656e2049440000000000000000600082015250565b6000612403601883611cd7565b915061240e826123cd565b602082019050919050565b60006020820190508181036000830152612432816123f6565b9050919050565b7f4552433732313a2061646472657373207a65726f206973206e6f74206120766160008201527f6c6964206f776e65720000000000000000000000000000000000000000000000602082015250565b6000612495602983611cd7565b91506124a082612439565b604082019050919050565b600060208201905081810360008301526124c481612488565b9050919050565b600081905092915050565b60006124e182611ccc565b6124eb81856124cb565b93506124fb818560208601611ce8565b80840191505092915050565b600061251382856124d6565b915061251f82846124d6565b91508190509392505050565b7f4f776e61626c653a206e6577206f776e657220697320746865207a65726f206160008201527f6464726573730000000000000000000000000000000000000000000000000000602082015250565b6000612587602683611cd7565b91506125928261252b565b604082019050919050565b600060208201905081810360008301526125b68161257a565b9050919050565b7f4552433732313a207472616e736665722066726f6d20696e636f72726563742060008201527f6f776e6572000000000000000000000000000000000000000000000000000000602082015250565b6000612619602583611cd7565b9150612624826125bd565b604082019050919050565b600060208201905081810360008301526126488161260c565b9050919050565b7f4552433732313a207472616e7366657220746f20746865207a65726f2061646460008201527f7265737300000000000000000000000000000000000000000000000000000000602082015250565b60006126ab602483611cd7565b91506126b68261264f565b604082019050919050565b600060208201905081810360008301526126da8161269e565b9050919050565b7f4f776e61626c653a2063616c6c6572206973206e6f7420746865206f776e6572600082015250565b6000612717602083611cd7565b9150612722826126e1565b602082019050919050565b600060208201905081810360008301526127468161270a565b9050919050565b7f4552433732313a20617070726f766520746f2063616c6c657200000000000000600082015250565b6000612783601983611cd7565b915061278e8261274d565b602082019050919050565b600060208201905081810360008301526127b281612776565b9050919050565b7f4552433732313a207472616e7366657220746f206e6f6e20455243373231526560008201527f63656976657220696d706c656d656e7465720000000000000000000000000000602082015250565b6000612815603283611cd7565b9150612820826127b9565b604082019050919050565b6000602082019050818103600083015261284481612808565b9050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052601260045260246000fd5b600081519050919050565b600082825260208201905092915050565b60006128a18261287a565b6128ab8185612885565b93506128bb818560208601611ce8565b6128c481611d12565b840191505092915050565b60006080820190506128e46000830187611e13565b6128f16020830186611e13565b6128fe6040830185611f29565b81810360608301526129108184612896565b905095945050505050565b60008151905061292a81611c3d565b92915050565b60006020828403121561294657612945611c07565b5b60006129548482850161291b565b91505092915050565b7f4552433732313a206d696e7420746f20746865207a65726f2061646472657373600082015250565b6000612993602083611cd7565b915061299e8261295d565b602082019050919050565b600060208201905081810360008301526129c281612986565b9050919050565b7f4552433732313a20746f6b656e20616c7265616479206d696e74656400000000600082015250565b60006129ff601c83611cd7565b9150612a0a826129c9565b602082019050919050565b60006020820190508181036000830152612a2e816129f2565b905091905056fea26469706673582212206b479c01d8d6128077a053596d0fad19c83ca1175e01467c6f355d7db267d1dd64736f6c63430008150033",
 }
 
 // GeneNFTABI is the input ABI used to generate the binding from.
 // Deprecated: Use GeneNFTMetaData.ABI instead.
 var GeneNFTABI = GeneNFTMetaData.ABI
 
+// GeneNFTBin is the compiled bytecode used for deploying new contracts.
+// Deprecated: Use GeneNFTMetaData.Bin instead.
+var GeneNFTBin = GeneNFTMetaData.Bin
+
+// DeployGeneNFT deploys a new Ethereum contract, binding an instance of GeneNFT to it.
+func DeployGeneNFT(auth *bind.TransactOpts, backend bind.ContractBackend) (common.Address, *types.Transaction, *GeneNFT, error) {
+	parsed, err := GeneNFTMetaData.GetAbi()
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	if parsed == nil {
+		return common.Address{}, nil, nil, errors.New("GetABI returned nil")
+	}
+
+	address, tx, contract, err := bind.DeployContract(auth, *parsed, common.FromHex(GeneNFTBin), backend)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	return address, tx, &GeneNFT{GeneNFTCaller: GeneNFTCaller{contract: contract}, GeneNFTTransactor: GeneNFTTransactor{contract: contract}, GeneNFTFilterer: GeneNFTFilterer{contract: contract}}, nil
+}
+
 // GeneNFT is an auto generated Go binding around an Ethereum contract.
 type GeneNFT struct {
 	GeneNFTCaller     // Read-only binding to the contract
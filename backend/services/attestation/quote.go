@@ -0,0 +1,158 @@
+package attestation
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// QuoteVersion is the version of the quote layout produced by the TEE.
+const QuoteVersion uint8 = 1
+
+// ErrInvalidQuote is returned when a quote or proof cannot be decoded.
+var ErrInvalidQuote = errors.New("invalid attestation quote")
+
+// Claims are the statements the TEE attests to: which model ran on which input, what it output,
+// and the verifier-chosen nonce that makes the quote fresh.
+type Claims struct {
+	Model        string
+	ModelVersion string
+	InputHash    common.Hash // Keccak256 hash of the gene data that was scored.
+	OutputHash   common.Hash // See RiskScoreHash.
+	Nonce        common.Hash
+}
+
+// Quote is a signed statement by the TEE that code with the given enclave measurement produced
+// the claims. It is signed with the TEE's attestation key over Digest, so it can also be checked
+// on-chain with ecrecover.
+type Quote struct {
+	Version     uint8
+	Measurement common.Hash
+	Claims
+	Signature []byte // 65-byte secp256k1 signature of Digest.
+}
+
+// The quote is ABI encoded so that contracts can decode it with abi.decode.
+var (
+	quoteBodyArguments = abi.Arguments{
+		{Type: mustNewType("uint8")},
+		{Type: mustNewType("bytes32")},
+		{Type: mustNewType("string")},
+		{Type: mustNewType("string")},
+		{Type: mustNewType("bytes32")},
+		{Type: mustNewType("bytes32")},
+		{Type: mustNewType("bytes32")},
+	}
+	quoteArguments = append(quoteBodyArguments[:len(quoteBodyArguments):len(quoteBodyArguments)],
+		abi.Argument{Type: mustNewType("bytes")})
+	confirmNonceArguments = abi.Arguments{
+		{Type: mustNewType("uint256")},
+		{Type: mustNewType("address")},
+		{Type: mustNewType("string")},
+		{Type: mustNewType("string")},
+		{Type: mustNewType("uint256")},
+	}
+)
+
+// RiskScoreHash returns the output hash of a risk score, keccak256(abi.encodePacked(uint8(score))).
+func RiskScoreHash(score uint8) common.Hash {
+	return crypto.Keccak256Hash([]byte{score})
+}
+
+// ConfirmNonce returns the nonce a quote must attest to for the Controller contract at the address
+// on the chain to accept it as the proof for confirming the document in the session, the
+// keccak256 hash of the ABI encoded (uint256 chainID, address controller, string docId,
+// string contentHash, uint256 sessionId). It matches Controller.confirmNonce.
+func ConfirmNonce(chainID *big.Int, controller common.Address, docId, contentHash string, sessionId *big.Int) (common.Hash, error) {
+	data, err := confirmNonceArguments.Pack(chainID, controller, docId, contentHash, sessionId)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(data), nil
+}
+
+// Digest returns the hash signed by the attestation key: the keccak256 hash of the ABI encoded
+// quote without its signature.
+func (q *Quote) Digest() (common.Hash, error) {
+	body, err := quoteBodyArguments.Pack(q.Version, q.Measurement, q.Model, q.ModelVersion,
+		q.InputHash, q.OutputHash, q.Nonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(body), nil
+}
+
+// MarshalBinary ABI encodes the quote as (uint8 version, bytes32 measurement, string model,
+// string modelVersion, bytes32 inputHash, bytes32 outputHash, bytes32 nonce, bytes signature).
+// This is the proof passed to the Controller contract's confirm.
+func (q *Quote) MarshalBinary() ([]byte, error) {
+	return quoteArguments.Pack(q.Version, q.Measurement, q.Model, q.ModelVersion,
+		q.InputHash, q.OutputHash, q.Nonce, q.Signature)
+}
+
+// Proof returns the Controller proof of the quote in text form: the 0x-prefixed hex encoding of
+// MarshalBinary.
+func (q *Quote) Proof() (string, error) {
+	data, err := q.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(data), nil
+}
+
+// ParseQuote decodes a quote encoded by MarshalBinary. It does not verify it.
+func ParseQuote(data []byte) (*Quote, error) {
+	values, err := quoteArguments.Unpack(data)
+	if err != nil || len(values) != len(quoteArguments) {
+		return nil, ErrInvalidQuote
+	}
+
+	var quote Quote
+	var ok [8]bool
+	quote.Version, ok[0] = values[0].(uint8)
+	quote.Measurement, ok[1] = bytes32(values[1])
+	quote.Model, ok[2] = values[2].(string)
+	quote.ModelVersion, ok[3] = values[3].(string)
+	quote.InputHash, ok[4] = bytes32(values[4])
+	quote.OutputHash, ok[5] = bytes32(values[5])
+	quote.Nonce, ok[6] = bytes32(values[6])
+	quote.Signature, ok[7] = values[7].([]byte)
+	for _, decoded := range ok {
+		if !decoded {
+			return nil, ErrInvalidQuote
+		}
+	}
+	return &quote, nil
+}
+
+// ParseProof decodes a quote from the proof format returned by Quote.Proof.
+func ParseProof(proof string) (*Quote, error) {
+	if !strings.HasPrefix(proof, "0x") {
+		return nil, ErrInvalidQuote
+	}
+	data, err := hexutil.Decode(proof)
+	if err != nil {
+		return nil, ErrInvalidQuote
+	}
+	return ParseQuote(data)
+}
+
+// bytes32 converts an unpacked bytes32 value to a hash.
+func bytes32(value any) (common.Hash, bool) {
+	b, ok := value.([32]byte)
+	return common.Hash(b), ok
+}
+
+// mustNewType returns the ABI type with the given name.
+func mustNewType(name string) abi.Type {
+	t, err := abi.NewType(name, "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
@@ -0,0 +1,81 @@
+package attestation
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrInvalidReceipt is returned when a receipt is malformed or not signed by the trusted key.
+var ErrInvalidReceipt = errors.New("invalid computation receipt")
+
+// receiptTypeHash separates receipt signatures from quote signatures made with the same key.
+var receiptTypeHash = crypto.Keccak256Hash([]byte("genomic-system/tee/receipt/1"))
+
+var receiptBodyArguments = abi.Arguments{
+	{Type: mustNewType("bytes32")},
+	{Type: mustNewType("string")},
+	{Type: mustNewType("bytes32")},
+	{Type: mustNewType("uint8")},
+	{Type: mustNewType("string")},
+	{Type: mustNewType("string")},
+	{Type: mustNewType("uint64")},
+}
+
+// Receipt is the TEE's signed record that it computed a risk level from the gene data of a file.
+// The TEE decrypts the file itself, so the receipt proves that the score came from that file.
+type Receipt struct {
+	FileID       string      // File ID of the encrypted gene data, the hex keccak256 hash of the data.
+	DataHash     common.Hash // Keccak256 hash of the decrypted gene data that was scored.
+	RiskLevel    uint8
+	Model        string
+	ModelVersion string
+	Timestamp    time.Time // Second precision.
+	Signature    []byte    // 65-byte secp256k1 signature of Digest by the TEE's attestation key.
+}
+
+// Digest returns the hash signed by the TEE: the keccak256 hash of the ABI encoded
+// (bytes32 typeHash, string fileID, bytes32 dataHash, uint8 riskLevel, string model,
+// string modelVersion, uint64 timestamp).
+func (r *Receipt) Digest() (common.Hash, error) {
+	if r.Timestamp.Unix() < 0 {
+		return common.Hash{}, ErrInvalidReceipt
+	}
+	body, err := receiptBodyArguments.Pack(receiptTypeHash, r.FileID, r.DataHash, r.RiskLevel,
+		r.Model, r.ModelVersion, uint64(r.Timestamp.Unix()))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(body), nil
+}
+
+// Signer returns the address of the key that signed the receipt.
+func (r *Receipt) Signer() (common.Address, error) {
+	digest, err := r.Digest()
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(r.Signature) != crypto.SignatureLength {
+		return common.Address{}, ErrInvalidReceipt
+	}
+	signer, err := crypto.SigToPub(digest.Bytes(), r.Signature)
+	if err != nil {
+		return common.Address{}, ErrInvalidReceipt
+	}
+	return crypto.PubkeyToAddress(*signer), nil
+}
+
+// VerifyReceipt checks that the receipt is signed by the trusted attestation key.
+func (v *Verifier) VerifyReceipt(receipt *Receipt) error {
+	signer, err := receipt.Signer()
+	if err != nil {
+		return err
+	}
+	if signer != v.attestationKey {
+		return ErrUntrustedSigner
+	}
+	return nil
+}
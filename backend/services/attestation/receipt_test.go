@@ -0,0 +1,58 @@
+package attestation_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/attestation"
+)
+
+func TestVerifyReceipt(t *testing.T) {
+	attestationKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	verifier := attestation.NewVerifier(crypto.PubkeyToAddress(attestationKey.PublicKey))
+
+	receipt := &attestation.Receipt{
+		FileID:       "8f1d4bd3a3f7a3b5b3e0d5b56a7d8a8c1c9c4bd2c2b3b7b1c8e2d4f0a1b2c3d4",
+		DataHash:     crypto.Keccak256Hash([]byte("low risk")),
+		RiskLevel:    4,
+		Model:        "g-stroke",
+		ModelVersion: "1.0.0",
+		Timestamp:    time.Unix(1_700_000_000, 0),
+	}
+	digest, err := receipt.Digest()
+	require.NoError(t, err)
+	receipt.Signature, err = crypto.Sign(digest.Bytes(), attestationKey)
+	require.NoError(t, err)
+
+	require.NoError(t, verifier.VerifyReceipt(receipt))
+	signer, err := receipt.Signer()
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(attestationKey.PublicKey), signer)
+
+	// Changing any field invalidates the signature
+	for _, mutate := range []func(*attestation.Receipt){
+		func(r *attestation.Receipt) { r.FileID = "00" + r.FileID[2:] },
+		func(r *attestation.Receipt) { r.DataHash = crypto.Keccak256Hash([]byte("high risk")) },
+		func(r *attestation.Receipt) { r.RiskLevel = 1 },
+		func(r *attestation.Receipt) { r.ModelVersion = "2.0.0" },
+		func(r *attestation.Receipt) { r.Timestamp = r.Timestamp.Add(time.Second) },
+	} {
+		tampered := *receipt
+		mutate(&tampered)
+		require.ErrorIs(t, verifier.VerifyReceipt(&tampered), attestation.ErrUntrustedSigner)
+	}
+
+	// A quote signature over the same key does not verify as a receipt
+	quote := &attestation.Quote{Version: attestation.QuoteVersion, Signature: receipt.Signature}
+	quoteDigest, err := quote.Digest()
+	require.NoError(t, err)
+	require.NotEqual(t, digest, quoteDigest)
+
+	malformed := *receipt
+	malformed.Signature = malformed.Signature[:64]
+	require.ErrorIs(t, verifier.VerifyReceipt(&malformed), attestation.ErrInvalidReceipt)
+}
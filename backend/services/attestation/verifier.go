@@ -0,0 +1,76 @@
+package attestation
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Errors returned by Verifier.
+var (
+	ErrUnsupportedQuoteVersion = errors.New("unsupported attestation quote version")
+	ErrUntrustedMeasurement    = errors.New("enclave measurement is not trusted")
+	ErrUntrustedSigner         = errors.New("quote is not signed by the trusted attestation key")
+	ErrClaimsMismatch          = errors.New("attested claims do not match")
+)
+
+// Verifier checks quotes against the attestation key of a TEE and the enclave measurements
+// trusted to run on it.
+type Verifier struct {
+	attestationKey common.Address
+	measurements   map[common.Hash]bool
+}
+
+// NewVerifier creates a Verifier that trusts quotes signed by the attestation key, identified by
+// its address, for any of the given enclave measurements.
+func NewVerifier(attestationKey common.Address, measurements ...common.Hash) *Verifier {
+	v := &Verifier{
+		attestationKey: attestationKey,
+		measurements:   make(map[common.Hash]bool, len(measurements)),
+	}
+	for _, measurement := range measurements {
+		v.measurements[measurement] = true
+	}
+	return v
+}
+
+// Verify checks that the quote is signed by the trusted attestation key for a trusted enclave
+// measurement and that it attests to exactly the expected claims.
+func (v *Verifier) Verify(quote *Quote, expected Claims) error {
+	if quote.Version != QuoteVersion {
+		return ErrUnsupportedQuoteVersion
+	}
+	if !v.measurements[quote.Measurement] {
+		return ErrUntrustedMeasurement
+	}
+
+	digest, err := quote.Digest()
+	if err != nil {
+		return ErrInvalidQuote
+	}
+	if len(quote.Signature) != crypto.SignatureLength {
+		return ErrInvalidQuote
+	}
+	signer, err := crypto.SigToPub(digest.Bytes(), quote.Signature)
+	if err != nil || crypto.PubkeyToAddress(*signer) != v.attestationKey {
+		return ErrUntrustedSigner
+	}
+
+	if quote.Claims != expected {
+		return ErrClaimsMismatch
+	}
+	return nil
+}
+
+// VerifyProof decodes a quote from a Controller proof and verifies it like Verify.
+func (v *Verifier) VerifyProof(proof string, expected Claims) (*Quote, error) {
+	quote, err := ParseProof(proof)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.Verify(quote, expected); err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
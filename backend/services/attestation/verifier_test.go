@@ -0,0 +1,88 @@
+package attestation_test
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/attestation"
+)
+
+// signQuote returns a quote for the claims signed with the key.
+func signQuote(t *testing.T, key []byte, measurement common.Hash, claims attestation.Claims) *attestation.Quote {
+	privateKey, err := crypto.ToECDSA(key)
+	require.NoError(t, err)
+
+	quote := &attestation.Quote{Version: attestation.QuoteVersion, Measurement: measurement, Claims: claims}
+	digest, err := quote.Digest()
+	require.NoError(t, err)
+	quote.Signature, err = crypto.Sign(digest.Bytes(), privateKey)
+	require.NoError(t, err)
+	return quote
+}
+
+func TestVerifier(t *testing.T) {
+	key := crypto.Keccak256([]byte("attestation key"))
+	privateKey, err := crypto.ToECDSA(key)
+	require.NoError(t, err)
+	measurement := crypto.Keccak256Hash([]byte("enclave"))
+	claims := attestation.Claims{
+		Model:        "g-stroke",
+		ModelVersion: "1.0.0",
+		InputHash:    crypto.Keccak256Hash([]byte("high risk")),
+		OutputHash:   attestation.RiskScoreHash(2),
+		Nonce:        common.BigToHash(common.Big1),
+	}
+	verifier := attestation.NewVerifier(crypto.PubkeyToAddress(privateKey.PublicKey), measurement)
+
+	quote := signQuote(t, key, measurement, claims)
+	require.NoError(t, verifier.Verify(quote, claims))
+
+	// The proof round-trips through its ABI encoding
+	proof, err := quote.Proof()
+	require.NoError(t, err)
+	parsed, err := verifier.VerifyProof(proof, claims)
+	require.NoError(t, err)
+	require.Equal(t, quote, parsed)
+
+	// Any other claim is rejected
+	for _, mutate := range []func(*attestation.Claims){
+		func(c *attestation.Claims) { c.ModelVersion = "2.0.0" },
+		func(c *attestation.Claims) { c.InputHash = crypto.Keccak256Hash([]byte("low risk")) },
+		func(c *attestation.Claims) { c.OutputHash = attestation.RiskScoreHash(4) },
+		func(c *attestation.Claims) { c.Nonce = common.BigToHash(common.Big2) },
+	} {
+		expected := claims
+		mutate(&expected)
+		require.ErrorIs(t, verifier.Verify(quote, expected), attestation.ErrClaimsMismatch)
+	}
+
+	// Tampering with the signed claims breaks the signature
+	tampered := *quote
+	tampered.OutputHash = attestation.RiskScoreHash(4)
+	expected := claims
+	expected.OutputHash = tampered.OutputHash
+	require.ErrorIs(t, verifier.Verify(&tampered, expected), attestation.ErrUntrustedSigner)
+
+	// Quotes from other keys, enclaves or versions are rejected
+	other := signQuote(t, crypto.Keccak256([]byte("other key")), measurement, claims)
+	require.ErrorIs(t, verifier.Verify(other, claims), attestation.ErrUntrustedSigner)
+	untrusted := signQuote(t, key, crypto.Keccak256Hash([]byte("debug enclave")), claims)
+	require.ErrorIs(t, verifier.Verify(untrusted, claims), attestation.ErrUntrustedMeasurement)
+	unsupported := *quote
+	unsupported.Version = 2
+	require.ErrorIs(t, verifier.Verify(&unsupported, claims), attestation.ErrUnsupportedQuoteVersion)
+
+	// Malformed proofs
+	for _, proof := range []string{"", "0x", "0x1234", proof[2:], proof[:len(proof)-64]} {
+		_, err := verifier.VerifyProof(proof, claims)
+		require.ErrorIs(t, err, attestation.ErrInvalidQuote)
+	}
+}
+
+func TestRiskScoreHash(t *testing.T) {
+	// keccak256(abi.encodePacked(uint8(2)))
+	require.Equal(t, common.HexToHash("0xf2ee15ea639b73fa3db9b34a245bdfa015c260c598b211bf05a1ecc4b3e3b4f2"), attestation.RiskScoreHash(2))
+}
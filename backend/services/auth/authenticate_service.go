@@ -4,11 +4,16 @@ import (
 	"errors"
 	"math/rand"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
+// ErrPublicKeyRegistered is returned by RegisterUserWithPubkey when a user with the same public
+// key, and so the same address, is already registered.
+var ErrPublicKeyRegistered = errors.New("public key is already registered")
+
 // User represents a user with a unique ID and their public key as a byte slice.
 // The User struct simulates a user entity in the system.
 type User struct {
@@ -19,68 +24,100 @@ type User struct {
 // AuthService is a mock authentication service that uses an in-memory map to simulate a NoSQL database.
 // This service manages user registration, authentication, and retrieval of user data.
 type AuthService struct {
-	usersDB map[uint64]User // In-memory map simulating a NoSQL database
-	mu      sync.Mutex      // Mutex to ensure thread-safe operations on the usersDB map
+	usersDB       map[uint64]User           // In-memory map simulating a NoSQL database
+	userIDs       map[common.Address]uint64 // User ID of each registered address
+	challenges    map[string]Challenge      // Outstanding login challenges keyed by nonce
+	challengeTTL  time.Duration             // Lifetime of newly issued challenges
+	siweConfig    SIWEConfig                // Domain, URI and chain ID SIWE messages must be bound to
+	siweNonces    map[string]siweNonce      // Outstanding SIWE nonces
+	signingKeys   map[string]*signingKey    // Session token signing keys keyed by key ID
+	currentKeyID  string                    // Key ID used to sign new session tokens
+	revokedTokens map[string]time.Time      // Revoked session token IDs and their expiry
+	sessionTTL    time.Duration             // Lifetime of newly issued session tokens
+	now           func() time.Time          // Clock used for challenge, nonce and session expiry
+	mu            sync.Mutex                // Mutex to ensure thread-safe operations on the maps above
 }
 
 // NewAuthService creates and returns a new instance of AuthService.
-// This function initializes the usersDB map for storing user data.
+// This function initializes the usersDB map for storing user data and the challenge store used for login.
 func NewAuthService() *AuthService {
 	return &AuthService{
-		usersDB: make(map[uint64]User),
+		usersDB:       make(map[uint64]User),
+		userIDs:       make(map[common.Address]uint64),
+		challenges:    make(map[string]Challenge),
+		challengeTTL:  DefaultChallengeTTL,
+		siweNonces:    make(map[string]siweNonce),
+		signingKeys:   make(map[string]*signingKey),
+		revokedTokens: make(map[string]time.Time),
+		sessionTTL:    DefaultSessionTTL,
+		now:           time.Now,
 	}
 }
 
 // RegisterUserWithPubkey registers a new user by storing their public key and generating a unique user ID.
 // It stores the user information in the usersDB map and returns the generated user ID.
-func (s *AuthService) RegisterUserWithPubkey(publicKeyBytes []byte) uint64 {
-	// Generate a random unique user ID
-	userID := rand.Uint64()
+// Each public key can be registered only once, so that an address maps to a single user; it
+// returns ErrPublicKeyRegistered otherwise.
+func (s *AuthService) RegisterUserWithPubkey(publicKeyBytes []byte) (uint64, error) {
+	// Derive the address the user is indexed by
+	publicKey, err := crypto.UnmarshalPubkey(publicKeyBytes)
+	if err != nil {
+		return 0, errors.New("invalid public key")
+	}
+	address := crypto.PubkeyToAddress(*publicKey)
 
 	// Lock the mutex to ensure thread-safe access to usersDB
 	s.mu.Lock()
-	// Store the user ID and public key in the usersDB map
-	s.usersDB[userID] = User{
-		UserID:    userID,
+	defer s.mu.Unlock()
+
+	if _, exists := s.userIDs[address]; exists {
+		return 0, ErrPublicKeyRegistered
+	}
+	// Generate a random unique user ID and store the user
+	user := User{
+		UserID:    s.newUserID(),
 		PublicKey: publicKeyBytes,
 	}
-	// Unlock the mutex after updating usersDB
-	s.mu.Unlock()
+	s.addUser(user, address)
 
 	// Return the generated user ID
-	return userID
+	return user.UserID, nil
 }
 
-// Authenticate checks if the provided user ID and Ethereum address match the stored information.
-// It validates the Ethereum address and compares it with the one derived from the stored public key.
-func (s *AuthService) Authenticate(userID uint64, ethAddress string) bool {
-	// Check if the provided Ethereum address is a valid hex address
-	if !common.IsHexAddress(ethAddress) {
-		return false
-	}
-
-	// Lock the mutex to ensure thread-safe access to usersDB
-	s.mu.Lock()
-	// Retrieve the user information from usersDB using the provided user ID
-	user, exists := s.usersDB[userID]
-	s.mu.Unlock()
-
-	// If the user does not exist, return false
-	if !exists {
-		return false
+// Authenticate completes a challenge-response login. The user must have signed the message of a
+// challenge previously issued by IssueChallenge with EIP-191 personal_sign. The signer is recovered
+// from the signature and compared with the address derived from the user's stored public key.
+// Each challenge can be used only once and must not have expired.
+// On success it returns a session carrying a signed token for later requests.
+func (s *AuthService) Authenticate(userID uint64, nonce string, signature []byte) (Session, error) {
+	// Consume the challenge first so that it cannot be replayed, even if verification fails
+	challenge, user, err := s.consumeChallenge(userID, nonce)
+	if err != nil {
+		return Session{}, err
 	}
 
 	// Convert the stored public key bytes back to an ecdsa.PublicKey
 	publicKey, err := crypto.UnmarshalPubkey(user.PublicKey)
 	if err != nil {
-		return false
+		return Session{}, err
 	}
+	userAddress := crypto.PubkeyToAddress(*publicKey)
 
-	// Derive the Ethereum address from the public key
-	publicKeyAddress := crypto.PubkeyToAddress(*publicKey).Hex()
+	// Recover the signer of the challenge message
+	signerAddress, err := recoverPersonalSignAddress(challenge.Message, signature)
+	if err != nil {
+		return Session{}, err
+	}
 
-	// Compare the derived Ethereum address with the provided one
-	return publicKeyAddress == ethAddress
+	// Compare the recovered signer with the address derived from the stored public key
+	if signerAddress != userAddress {
+		return Session{}, errors.New("signature does not match user")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.newSession(userID, userAddress)
 }
 
 // GetUserPubkey returns the public key bytes for the given user ID.
@@ -110,3 +147,19 @@ func (s *AuthService) QueryUserByUserID(userID uint64) User {
 	// Return the User struct for the given user ID
 	return s.usersDB[userID]
 }
+
+// addUser stores the user and indexes it by its address. The caller must hold s.mu.
+func (s *AuthService) addUser(user User, address common.Address) {
+	s.usersDB[user.UserID] = user
+	s.userIDs[address] = user.UserID
+}
+
+// newUserID generates a random user ID that is not yet taken. The caller must hold s.mu.
+func (s *AuthService) newUserID() uint64 {
+	for {
+		userID := rand.Uint64()
+		if _, exists := s.usersDB[userID]; !exists {
+			return userID
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package auth_test
 
 import (
+	"crypto/ecdsa"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/assert"
 
@@ -21,11 +24,18 @@ func TestRegisterUserWithPubkey(t *testing.T) {
 	publicKeyBytes := crypto.FromECDSAPub(&privateKey.PublicKey)
 
 	// Register the user with the public key
-	userID := authService.RegisterUserWithPubkey(publicKeyBytes)
+	userID, err := authService.RegisterUserWithPubkey(publicKeyBytes)
+	assert.NoError(t, err, "Failed to register user")
 
 	// Query the registered user and verify the public key is stored correctly
 	registeredUser := authService.QueryUserByUserID(userID)
 	assert.Equal(t, publicKeyBytes, registeredUser.PublicKey, "Public key mismatch")
+
+	// A public key can only be registered once, and must be valid
+	_, err = authService.RegisterUserWithPubkey(publicKeyBytes)
+	assert.ErrorIs(t, err, auth.ErrPublicKeyRegistered, "Public key should not be registered twice")
+	_, err = authService.RegisterUserWithPubkey([]byte("invalid"))
+	assert.Error(t, err, "Invalid public key should be rejected")
 }
 
 func TestAuthenticate(t *testing.T) {
@@ -36,21 +46,89 @@ func TestAuthenticate(t *testing.T) {
 	privateKey, err := crypto.GenerateKey()
 	assert.NoError(t, err, "Failed to generate ECDSA key")
 
-	// Extract the public key bytes and Ethereum address
-	publicKeyBytes := crypto.FromECDSAPub(&privateKey.PublicKey)
-	ethAddress := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
-
 	// Register the user with the public key
-	userID := authService.RegisterUserWithPubkey(publicKeyBytes)
+	userID, err := authService.RegisterUserWithPubkey(crypto.FromECDSAPub(&privateKey.PublicKey))
+	assert.NoError(t, err, "Failed to register user")
+
+	// Issue a challenge and sign it with the user's private key
+	challenge, err := authService.IssueChallenge(userID)
+	assert.NoError(t, err, "Failed to issue challenge")
+	assert.Contains(t, challenge.Message, challenge.Nonce, "Challenge message should contain the nonce")
+	signature := signPersonalMessage(t, privateKey, challenge.Message)
 
 	// Test successful authentication
-	isAuthenticated := authService.Authenticate(userID, ethAddress)
-	assert.True(t, isAuthenticated, "User should be authenticated successfully")
+	session, err := authService.Authenticate(userID, challenge.Nonce, signature)
+	assert.NoError(t, err, "User should be authenticated successfully")
+	assert.NotEmpty(t, session.Token, "A session token should be issued")
+	assert.Equal(t, userID, session.UserID)
+	assert.Equal(t, crypto.PubkeyToAddress(privateKey.PublicKey), session.ETHAddress)
+
+	// The challenge is single-use
+	_, err = authService.Authenticate(userID, challenge.Nonce, signature)
+	assert.Error(t, err, "Reusing a challenge should fail")
+}
+
+func TestAuthenticate_WrongSigner(t *testing.T) {
+	// Initialize AuthService
+	authService := auth.NewAuthService()
+
+	// Generate the user's key pair and an attacker's key pair
+	privateKey, err := crypto.GenerateKey()
+	assert.NoError(t, err, "Failed to generate ECDSA key")
+	attackerKey, err := crypto.GenerateKey()
+	assert.NoError(t, err, "Failed to generate ECDSA key")
+
+	userID, err := authService.RegisterUserWithPubkey(crypto.FromECDSAPub(&privateKey.PublicKey))
+	assert.NoError(t, err, "Failed to register user")
+
+	// A signature from another key must not authenticate the user
+	challenge, err := authService.IssueChallenge(userID)
+	assert.NoError(t, err)
+	_, err = authService.Authenticate(userID, challenge.Nonce, signPersonalMessage(t, attackerKey, challenge.Message))
+	assert.EqualError(t, err, "signature does not match user", "Authentication should fail with another signer")
+
+	// The failed attempt consumed the challenge
+	_, err = authService.Authenticate(userID, challenge.Nonce, signPersonalMessage(t, privateKey, challenge.Message))
+	assert.Error(t, err)
+
+	// A challenge issued to another user cannot be used
+	otherUserID, err := authService.RegisterUserWithPubkey(crypto.FromECDSAPub(&attackerKey.PublicKey))
+	assert.NoError(t, err, "Failed to register user")
+	challenge, err = authService.IssueChallenge(otherUserID)
+	assert.NoError(t, err)
+	_, err = authService.Authenticate(userID, challenge.Nonce, signPersonalMessage(t, privateKey, challenge.Message))
+	assert.Error(t, err)
+
+	// Challenges cannot be issued for unknown users
+	_, err = authService.IssueChallenge(9999)
+	assert.Error(t, err, "Expected error for non-existent user")
+}
+
+func TestAuthenticate_ExpiredChallenge(t *testing.T) {
+	// Initialize AuthService with a fake clock
+	now := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	authService := auth.NewAuthService()
+	authService.SetClock(func() time.Time { return now })
+
+	privateKey, err := crypto.GenerateKey()
+	assert.NoError(t, err, "Failed to generate ECDSA key")
+	userID, err := authService.RegisterUserWithPubkey(crypto.FromECDSAPub(&privateKey.PublicKey))
+	assert.NoError(t, err, "Failed to register user")
+
+	challenge, err := authService.IssueChallenge(userID)
+	assert.NoError(t, err)
+	now = now.Add(auth.DefaultChallengeTTL)
+
+	_, err = authService.Authenticate(userID, challenge.Nonce, signPersonalMessage(t, privateKey, challenge.Message))
+	assert.EqualError(t, err, "challenge expired")
+}
 
-	// Test unsuccessful authentication with a wrong Ethereum address
-	wrongEthAddress := "0x0000000000000000000000000000000000000000"
-	isAuthenticated = authService.Authenticate(userID, wrongEthAddress)
-	assert.False(t, isAuthenticated, "Authentication should fail with a wrong Ethereum address")
+// signPersonalMessage signs a message the way wallets do for personal_sign, with V as 27/28.
+func signPersonalMessage(t *testing.T, privateKey *ecdsa.PrivateKey, message string) []byte {
+	signature, err := crypto.Sign(accounts.TextHash([]byte(message)), privateKey)
+	assert.NoError(t, err, "Failed to sign message")
+	signature[crypto.RecoveryIDOffset] += 27
+	return signature
 }
 
 func TestGetUserPubkey(t *testing.T) {
@@ -65,7 +143,8 @@ func TestGetUserPubkey(t *testing.T) {
 	publicKeyBytes := crypto.FromECDSAPub(&privateKey.PublicKey)
 
 	// Register the user with the public key
-	userID := authService.RegisterUserWithPubkey(publicKeyBytes)
+	userID, err := authService.RegisterUserWithPubkey(publicKeyBytes)
+	assert.NoError(t, err, "Failed to register user")
 
 	// Retrieve the public key for the registered user
 	retrievedPubkey, err := authService.GetUserPubkey(userID)
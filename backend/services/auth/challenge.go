@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// DefaultChallengeTTL is how long an issued login challenge stays valid.
+const DefaultChallengeTTL = 5 * time.Minute
+
+// challengeNonceSize is the number of random bytes in a challenge nonce.
+const challengeNonceSize = 16
+
+// Challenge is a single-use login challenge that the user signs with their wallet.
+type Challenge struct {
+	UserID    uint64
+	Nonce     string
+	Message   string // The exact text the user must sign with personal_sign (EIP-191).
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// IssueChallenge creates a new login challenge for the given user.
+// The returned message must be signed with the user's private key using EIP-191 personal_sign.
+func (s *AuthService) IssueChallenge(userID uint64) (Challenge, error) {
+	nonceBytes := make([]byte, challengeNonceSize)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return Challenge{}, err
+	}
+	nonce := hex.EncodeToString(nonceBytes)
+
+	// Lock the mutex to ensure thread-safe access to usersDB and challenges
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.usersDB[userID]; !exists {
+		return Challenge{}, errors.New("user not found")
+	}
+
+	now := s.now()
+	s.pruneExpiredChallenges(now)
+
+	challenge := Challenge{
+		UserID:    userID,
+		Nonce:     nonce,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(s.challengeTTL),
+	}
+	challenge.Message = challengeMessage(challenge)
+	s.challenges[nonce] = challenge
+
+	return challenge, nil
+}
+
+// SetChallengeTTL changes how long newly issued challenges stay valid.
+func (s *AuthService) SetChallengeTTL(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.challengeTTL = ttl
+}
+
+// SetClock replaces the clock used to issue and expire challenges, nonces and sessions.
+func (s *AuthService) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.now = now
+}
+
+// consumeChallenge removes the challenge for the given nonce and checks that it belongs to
+// the user and has not expired. A challenge can only be consumed once, whatever the outcome
+// of the signature check that follows.
+func (s *AuthService) consumeChallenge(userID uint64, nonce string) (Challenge, User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	challenge, exists := s.challenges[nonce]
+	if !exists || challenge.UserID != userID {
+		return Challenge{}, User{}, errors.New("challenge not found or already used")
+	}
+	delete(s.challenges, nonce)
+
+	if !s.now().Before(challenge.ExpiresAt) {
+		return Challenge{}, User{}, errors.New("challenge expired")
+	}
+
+	user, exists := s.usersDB[userID]
+	if !exists {
+		return Challenge{}, User{}, errors.New("user not found")
+	}
+
+	return challenge, user, nil
+}
+
+// pruneExpiredChallenges drops challenges that can no longer be used. The caller must hold s.mu.
+func (s *AuthService) pruneExpiredChallenges(now time.Time) {
+	for nonce, challenge := range s.challenges {
+		if !now.Before(challenge.ExpiresAt) {
+			delete(s.challenges, nonce)
+		}
+	}
+}
+
+// challengeMessage renders the human-readable text the user signs for a challenge.
+func challengeMessage(challenge Challenge) string {
+	return fmt.Sprintf(
+		"Sign in to GenomicDAO\n\nUser ID: %d\nNonce: %s\nIssued At: %s\nExpiration Time: %s",
+		challenge.UserID,
+		challenge.Nonce,
+		challenge.IssuedAt.UTC().Format(time.RFC3339),
+		challenge.ExpiresAt.UTC().Format(time.RFC3339),
+	)
+}
+
+// recoverPersonalSignAddress recovers the address that produced an EIP-191 personal_sign
+// signature over message.
+func recoverPersonalSignAddress(message string, signature []byte) (common.Address, error) {
+	publicKey, err := recoverPersonalSignPubkey(message, signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*publicKey), nil
+}
+
+// recoverPersonalSignPubkey recovers the public key that produced an EIP-191 personal_sign
+// signature over message. Wallets return V as 27/28, which is normalized to 0/1.
+func recoverPersonalSignPubkey(message string, signature []byte) (*ecdsa.PublicKey, error) {
+	if len(signature) != crypto.SignatureLength {
+		return nil, errors.New("invalid signature length")
+	}
+
+	sig := make([]byte, crypto.SignatureLength)
+	copy(sig, signature)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	return crypto.SigToPub(accounts.TextHash([]byte(message)), sig)
+}
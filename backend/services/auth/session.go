@@ -0,0 +1,267 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// DefaultSessionTTL is how long a session token issued after sign-in stays valid.
+	DefaultSessionTTL = time.Hour
+	// sessionTokenIssuer is the "iss" claim of every session token.
+	sessionTokenIssuer = "genomic-system"
+	// signingKeySize is the size of randomly generated HMAC signing keys.
+	signingKeySize = 32
+	// tokenIDSize is the number of random bytes in a token ID ("jti" claim).
+	tokenIDSize = 16
+)
+
+// Session is an authenticated session. Its Token is a signed JWT (HS256) that the caller
+// presents on later requests; VerifySession turns it back into a Session.
+type Session struct {
+	Token      string
+	TokenID    string
+	UserID     uint64
+	ETHAddress common.Address
+	ExpiresAt  time.Time
+}
+
+// sessionClaims are the JWT claims carried by a session token.
+type sessionClaims struct {
+	ETHAddress string `json:"eth"`
+	jwt.RegisteredClaims
+}
+
+// signingKey is an HMAC key used to sign session tokens.
+type signingKey struct {
+	secret    []byte
+	retiredAt time.Time // Zero while the key is current.
+}
+
+// sessionContextKey is the context key under which a verified Session is stored.
+type sessionContextKey struct{}
+
+// ContextWithSession returns a copy of ctx carrying the verified session.
+func ContextWithSession(ctx context.Context, session Session) context.Context {
+	return context.WithValue(ctx, sessionContextKey{}, session)
+}
+
+// SessionFromContext returns the verified session stored in ctx by ContextWithSession.
+func SessionFromContext(ctx context.Context) (Session, bool) {
+	session, ok := ctx.Value(sessionContextKey{}).(Session)
+	return session, ok
+}
+
+// VerifySession checks the signature, issuer and expiry of a session token and that it has not
+// been revoked. Tokens signed with a retired key remain valid until they expire.
+func (s *AuthService) VerifySession(token string) (Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.verifySession(token)
+}
+
+// RevokeSession adds the token to the revocation list so that it is rejected from now on.
+func (s *AuthService) RevokeSession(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.verifySession(token)
+	if err != nil {
+		return err
+	}
+
+	s.pruneRevokedTokens(s.now())
+	s.revokedTokens[session.TokenID] = session.ExpiresAt
+	return nil
+}
+
+// RotateSigningKey generates a new signing key and makes it current. The previous key is kept
+// for verification until every token it signed has expired. It returns the new key ID.
+func (s *AuthService) RotateSigningKey() (string, error) {
+	keyID, secret, err := generateSigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.setSigningKey(keyID, secret)
+	return keyID, nil
+}
+
+// SetSigningKey makes the given secret the current signing key under keyID, which lets several
+// gateway instances share keys. The previous key is kept for verification like in RotateSigningKey.
+func (s *AuthService) SetSigningKey(keyID string, secret []byte) error {
+	if keyID == "" {
+		return errors.New("key ID is required")
+	}
+	if len(secret) < signingKeySize {
+		return fmt.Errorf("signing key must be at least %d bytes", signingKeySize)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.setSigningKey(keyID, secret)
+	return nil
+}
+
+// SetSessionTTL changes how long newly issued session tokens stay valid.
+func (s *AuthService) SetSessionTTL(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessionTTL = ttl
+}
+
+// setSigningKey installs a new current key and retires the previous one. The caller must hold s.mu.
+func (s *AuthService) setSigningKey(keyID string, secret []byte) {
+	now := s.now()
+	if current, exists := s.signingKeys[s.currentKeyID]; exists && current.retiredAt.IsZero() {
+		current.retiredAt = now
+	}
+	s.signingKeys[keyID] = &signingKey{secret: secret}
+	s.currentKeyID = keyID
+
+	s.pruneSigningKeys(now)
+	s.pruneRevokedTokens(now)
+}
+
+// newSession issues a signed session token for the user. The caller must hold s.mu.
+func (s *AuthService) newSession(userID uint64, address common.Address) (Session, error) {
+	// Generate the first signing key lazily
+	if _, exists := s.signingKeys[s.currentKeyID]; !exists {
+		keyID, secret, err := generateSigningKey()
+		if err != nil {
+			return Session{}, err
+		}
+		s.setSigningKey(keyID, secret)
+	}
+	key := s.signingKeys[s.currentKeyID]
+
+	tokenID, err := randomHex(tokenIDSize)
+	if err != nil {
+		return Session{}, err
+	}
+
+	// JWT timestamps have second precision
+	now := s.now().Truncate(time.Second)
+	expiresAt := now.Add(s.sessionTTL)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, sessionClaims{
+		ETHAddress: address.Hex(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    sessionTokenIssuer,
+			Subject:   strconv.FormatUint(userID, 10),
+			ID:        tokenID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+	token.Header["kid"] = s.currentKeyID
+
+	signed, err := token.SignedString(key.secret)
+	if err != nil {
+		return Session{}, err
+	}
+
+	return Session{
+		Token:      signed,
+		TokenID:    tokenID,
+		UserID:     userID,
+		ETHAddress: address,
+		ExpiresAt:  expiresAt,
+	}, nil
+}
+
+// verifySession parses and validates a session token. The caller must hold s.mu.
+func (s *AuthService) verifySession(token string) (Session, error) {
+	var claims sessionClaims
+	parsed, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		keyID, _ := t.Header["kid"].(string)
+		key, exists := s.signingKeys[keyID]
+		if !exists {
+			return nil, errors.New("unknown signing key")
+		}
+		return key.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(sessionTokenIssuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.now),
+	)
+	if err != nil || !parsed.Valid {
+		return Session{}, fmt.Errorf("invalid session token: %w", err)
+	}
+
+	if _, revoked := s.revokedTokens[claims.ID]; revoked {
+		return Session{}, errors.New("session revoked")
+	}
+
+	userID, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil {
+		return Session{}, errors.New("invalid session subject")
+	}
+	if !common.IsHexAddress(claims.ETHAddress) {
+		return Session{}, errors.New("invalid session address")
+	}
+
+	return Session{
+		Token:      token,
+		TokenID:    claims.ID,
+		UserID:     userID,
+		ETHAddress: common.HexToAddress(claims.ETHAddress),
+		ExpiresAt:  claims.ExpiresAt.Time,
+	}, nil
+}
+
+// pruneSigningKeys drops retired keys whose tokens have all expired. The caller must hold s.mu.
+func (s *AuthService) pruneSigningKeys(now time.Time) {
+	for keyID, key := range s.signingKeys {
+		if !key.retiredAt.IsZero() && now.After(key.retiredAt.Add(s.sessionTTL)) {
+			delete(s.signingKeys, keyID)
+		}
+	}
+}
+
+// pruneRevokedTokens drops revoked token IDs that have expired anyway. The caller must hold s.mu.
+func (s *AuthService) pruneRevokedTokens(now time.Time) {
+	for tokenID, expiresAt := range s.revokedTokens {
+		if now.After(expiresAt) {
+			delete(s.revokedTokens, tokenID)
+		}
+	}
+}
+
+// generateSigningKey returns a random key ID and HMAC secret.
+func generateSigningKey() (string, []byte, error) {
+	secret := make([]byte, signingKeySize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+	keyID, err := randomHex(8)
+	if err != nil {
+		return "", nil, err
+	}
+	return keyID, secret, nil
+}
+
+// randomHex returns n random bytes encoded as hex.
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,128 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/auth"
+)
+
+// login registers a new user and authenticates them through a signed challenge.
+func login(t *testing.T, authService *auth.AuthService) (*ecdsa.PrivateKey, auth.Session) {
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	userID, err := authService.RegisterUserWithPubkey(crypto.FromECDSAPub(&privateKey.PublicKey))
+	require.NoError(t, err)
+
+	challenge, err := authService.IssueChallenge(userID)
+	require.NoError(t, err)
+	session, err := authService.Authenticate(userID, challenge.Nonce, signPersonalMessage(t, privateKey, challenge.Message))
+	require.NoError(t, err)
+
+	return privateKey, session
+}
+
+func TestVerifySession(t *testing.T) {
+	authService := auth.NewAuthService()
+	privateKey, session := login(t, authService)
+
+	verified, err := authService.VerifySession(session.Token)
+	require.NoError(t, err)
+	require.Equal(t, session.UserID, verified.UserID)
+	require.Equal(t, crypto.PubkeyToAddress(privateKey.PublicKey), verified.ETHAddress)
+	require.Equal(t, session.TokenID, verified.TokenID)
+	require.True(t, session.ExpiresAt.Equal(verified.ExpiresAt))
+
+	// Tampered tokens are rejected
+	tampered := session.Token[:len(session.Token)-2] + "xx"
+	_, err = authService.VerifySession(tampered)
+	require.Error(t, err)
+
+	// Tokens from another service instance (another key) are rejected
+	_, err = auth.NewAuthService().VerifySession(session.Token)
+	require.Error(t, err)
+
+	_, err = authService.VerifySession("not-a-token")
+	require.Error(t, err)
+}
+
+func TestVerifySession_Expired(t *testing.T) {
+	authService := auth.NewAuthService()
+	authService.SetSessionTTL(time.Second)
+	_, session := login(t, authService)
+
+	time.Sleep(2100 * time.Millisecond)
+	_, err := authService.VerifySession(session.Token)
+	require.ErrorContains(t, err, "token is expired")
+}
+
+func TestRevokeSession(t *testing.T) {
+	authService := auth.NewAuthService()
+	_, session := login(t, authService)
+	_, otherSession := login(t, authService)
+
+	require.NoError(t, authService.RevokeSession(session.Token))
+
+	_, err := authService.VerifySession(session.Token)
+	require.EqualError(t, err, "session revoked")
+
+	// Other sessions are unaffected
+	_, err = authService.VerifySession(otherSession.Token)
+	require.NoError(t, err)
+
+	// Revoking an invalid token fails
+	require.Error(t, authService.RevokeSession("not-a-token"))
+}
+
+func TestRotateSigningKey(t *testing.T) {
+	authService := auth.NewAuthService()
+	_, oldSession := login(t, authService)
+
+	keyID, err := authService.RotateSigningKey()
+	require.NoError(t, err)
+	require.NotEmpty(t, keyID)
+
+	// Tokens signed with the retired key remain valid until they expire
+	_, err = authService.VerifySession(oldSession.Token)
+	require.NoError(t, err)
+
+	// New tokens are signed with the new key
+	_, newSession := login(t, authService)
+	_, err = authService.VerifySession(newSession.Token)
+	require.NoError(t, err)
+	require.NotEqual(t, oldSession.Token, newSession.Token)
+}
+
+func TestSetSigningKey(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	// Two instances sharing a key accept each other's tokens
+	first := auth.NewAuthService()
+	second := auth.NewAuthService()
+	require.NoError(t, first.SetSigningKey("shared", secret))
+	require.NoError(t, second.SetSigningKey("shared", secret))
+
+	_, session := login(t, first)
+	verified, err := second.VerifySession(session.Token)
+	require.NoError(t, err)
+	require.Equal(t, session.UserID, verified.UserID)
+
+	// Short keys are rejected
+	require.Error(t, first.SetSigningKey("short", []byte("short")))
+	require.Error(t, first.SetSigningKey("", secret))
+}
+
+func TestSessionContext(t *testing.T) {
+	_, ok := auth.SessionFromContext(context.Background())
+	require.False(t, ok)
+
+	session := auth.Session{UserID: 42}
+	got, ok := auth.SessionFromContext(auth.ContextWithSession(context.Background(), session))
+	require.True(t, ok)
+	require.Equal(t, session, got)
+}
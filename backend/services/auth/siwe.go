@@ -0,0 +1,413 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// siweVersion is the only message version defined by EIP-4361.
+	siweVersion = "1"
+	// siwePreamble follows the domain on the first line of every SIWE message.
+	siwePreamble = " wants you to sign in with your Ethereum account:"
+	// DefaultSIWEMessageTTL is how long a SIWE message created by NewSIWEMessage stays valid.
+	DefaultSIWEMessageTTL = 5 * time.Minute
+	// siweClockSkew tolerates small differences between the client and server clocks.
+	siweClockSkew = 30 * time.Second
+)
+
+// siweNoncePattern matches the nonce grammar of EIP-4361: at least 8 alphanumeric characters.
+var siweNoncePattern = regexp.MustCompile(`^[a-zA-Z0-9]{8,}$`)
+
+// siweNonce is an outstanding SIWE nonce, bound to the address it was issued for.
+type siweNonce struct {
+	address   common.Address
+	expiresAt time.Time
+}
+
+// SIWEConfig holds the values a Sign-In with Ethereum message must be bound to.
+type SIWEConfig struct {
+	Domain  string // RFC 3986 authority requesting the signing, e.g. "app.genomicdao.com".
+	URI     string // RFC 3986 URI of the resource that is the subject of the signing.
+	ChainID uint64 // EIP-155 chain ID the session is bound to.
+}
+
+// SIWEMessage is an EIP-4361 Sign-In with Ethereum message.
+type SIWEMessage struct {
+	Scheme         string // Optional URI scheme of the origin, e.g. "https".
+	Domain         string
+	Address        common.Address
+	Statement      string // Optional human-readable assertion; must not contain new lines.
+	URI            string
+	Version        string
+	ChainID        uint64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// String renders the message in the exact EIP-4361 text format that the wallet signs.
+func (m *SIWEMessage) String() string {
+	var sb strings.Builder
+
+	if m.Scheme != "" {
+		sb.WriteString(m.Scheme + "://")
+	}
+	sb.WriteString(m.Domain + siwePreamble + "\n")
+	sb.WriteString(m.Address.Hex() + "\n")
+	sb.WriteString("\n")
+	if m.Statement != "" {
+		sb.WriteString(m.Statement + "\n")
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("URI: " + m.URI + "\n")
+	sb.WriteString("Version: " + m.Version + "\n")
+	sb.WriteString("Chain ID: " + strconv.FormatUint(m.ChainID, 10) + "\n")
+	sb.WriteString("Nonce: " + m.Nonce + "\n")
+	sb.WriteString("Issued At: " + formatSIWETime(m.IssuedAt))
+	if m.ExpirationTime != nil {
+		sb.WriteString("\nExpiration Time: " + formatSIWETime(*m.ExpirationTime))
+	}
+	if m.NotBefore != nil {
+		sb.WriteString("\nNot Before: " + formatSIWETime(*m.NotBefore))
+	}
+	if m.RequestID != "" {
+		sb.WriteString("\nRequest ID: " + m.RequestID)
+	}
+	if len(m.Resources) > 0 {
+		sb.WriteString("\nResources:")
+		for _, resource := range m.Resources {
+			sb.WriteString("\n- " + resource)
+		}
+	}
+
+	return sb.String()
+}
+
+// ParseSIWEMessage parses the EIP-4361 text format into a SIWEMessage.
+// It checks the syntax of every field but not whether the message is acceptable to this
+// service; use AuthService.SignInWithEthereum for that.
+func ParseSIWEMessage(message string) (*SIWEMessage, error) {
+	lines := strings.Split(message, "\n")
+	p := &siweParser{lines: lines}
+	m := &SIWEMessage{}
+
+	// Header: "[scheme://]domain wants you to sign in with your Ethereum account:"
+	header, ok := p.next()
+	if !ok || !strings.HasSuffix(header, siwePreamble) {
+		return nil, errors.New("siwe: invalid message header")
+	}
+	origin := strings.TrimSuffix(header, siwePreamble)
+	if scheme, domain, found := strings.Cut(origin, "://"); found {
+		m.Scheme, origin = scheme, domain
+	}
+	if origin == "" {
+		return nil, errors.New("siwe: missing domain")
+	}
+	m.Domain = origin
+
+	// Address, which must be EIP-55 checksummed
+	address, _ := p.next()
+	if !common.IsHexAddress(address) || common.HexToAddress(address).Hex() != address {
+		return nil, errors.New("siwe: address must be an EIP-55 checksummed Ethereum address")
+	}
+	m.Address = common.HexToAddress(address)
+
+	if line, _ := p.next(); line != "" {
+		return nil, errors.New("siwe: expected empty line after address")
+	}
+
+	// Optional statement followed by an empty line
+	line, _ := p.next()
+	if line != "" {
+		m.Statement = line
+		if line, _ = p.next(); line != "" {
+			return nil, errors.New("siwe: expected empty line after statement")
+		}
+	}
+
+	var err error
+	if m.URI, err = p.field("URI", true); err != nil {
+		return nil, err
+	}
+	if _, err := url.Parse(m.URI); err != nil || !strings.Contains(m.URI, ":") {
+		return nil, errors.New("siwe: invalid URI")
+	}
+	if m.Version, err = p.field("Version", true); err != nil {
+		return nil, err
+	}
+
+	chainID, err := p.field("Chain ID", true)
+	if err != nil {
+		return nil, err
+	}
+	if m.ChainID, err = strconv.ParseUint(chainID, 10, 64); err != nil {
+		return nil, errors.New("siwe: invalid chain ID")
+	}
+
+	if m.Nonce, err = p.field("Nonce", true); err != nil {
+		return nil, err
+	}
+	if !siweNoncePattern.MatchString(m.Nonce) {
+		return nil, errors.New("siwe: nonce must be at least 8 alphanumeric characters")
+	}
+
+	issuedAt, err := p.field("Issued At", true)
+	if err != nil {
+		return nil, err
+	}
+	if m.IssuedAt, err = parseSIWETime(issuedAt); err != nil {
+		return nil, errors.New("siwe: invalid issued at time")
+	}
+
+	if expirationTime, err := p.field("Expiration Time", false); err != nil {
+		return nil, err
+	} else if expirationTime != "" {
+		t, err := parseSIWETime(expirationTime)
+		if err != nil {
+			return nil, errors.New("siwe: invalid expiration time")
+		}
+		m.ExpirationTime = &t
+	}
+
+	if notBefore, err := p.field("Not Before", false); err != nil {
+		return nil, err
+	} else if notBefore != "" {
+		t, err := parseSIWETime(notBefore)
+		if err != nil {
+			return nil, errors.New("siwe: invalid not before time")
+		}
+		m.NotBefore = &t
+	}
+
+	if m.RequestID, err = p.field("Request ID", false); err != nil {
+		return nil, err
+	}
+
+	if line, ok := p.peek(); ok && line == "Resources:" {
+		p.next()
+		for {
+			line, ok := p.peek()
+			if !ok || !strings.HasPrefix(line, "- ") {
+				break
+			}
+			p.next()
+			resource := strings.TrimPrefix(line, "- ")
+			if parsed, err := url.Parse(resource); err != nil || parsed.Scheme == "" {
+				return nil, fmt.Errorf("siwe: invalid resource URI %q", resource)
+			}
+			m.Resources = append(m.Resources, resource)
+		}
+	}
+
+	if _, ok := p.peek(); ok {
+		return nil, errors.New("siwe: unexpected trailing content")
+	}
+
+	return m, nil
+}
+
+// siweParser walks the lines of a SIWE message.
+type siweParser struct {
+	lines []string
+	pos   int
+}
+
+func (p *siweParser) peek() (string, bool) {
+	if p.pos >= len(p.lines) {
+		return "", false
+	}
+	return p.lines[p.pos], true
+}
+
+func (p *siweParser) next() (string, bool) {
+	line, ok := p.peek()
+	if ok {
+		p.pos++
+	}
+	return line, ok
+}
+
+// field consumes a "Name: value" line. Optional fields that are absent return an empty value.
+func (p *siweParser) field(name string, required bool) (string, error) {
+	prefix := name + ": "
+	line, ok := p.peek()
+	if !ok || !strings.HasPrefix(line, prefix) {
+		if required {
+			return "", fmt.Errorf("siwe: missing %s", name)
+		}
+		return "", nil
+	}
+	p.next()
+
+	value := strings.TrimPrefix(line, prefix)
+	if value == "" {
+		return "", fmt.Errorf("siwe: empty %s", name)
+	}
+	return value, nil
+}
+
+// formatSIWETime formats a timestamp as an RFC 3339 date-time in UTC.
+func formatSIWETime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// parseSIWETime parses an RFC 3339 date-time.
+func parseSIWETime(value string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// ConfigureSIWE sets the domain, URI and chain ID that SIWE messages must be bound to.
+// Sign-In with Ethereum is disabled until it is configured.
+func (s *AuthService) ConfigureSIWE(config SIWEConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.siweConfig = config
+}
+
+// NewSIWEMessage creates a SIWE message for the given address with a fresh server-issued nonce.
+// The message expires after DefaultSIWEMessageTTL and must be signed with personal_sign. The nonce
+// is bound to the address, so it cannot be used to sign in with another account.
+func (s *AuthService) NewSIWEMessage(address common.Address, statement string, resources []string) (*SIWEMessage, error) {
+	if strings.Contains(statement, "\n") {
+		return nil, errors.New("siwe: statement must not contain new lines")
+	}
+
+	nonceBytes := make([]byte, challengeNonceSize)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return nil, err
+	}
+	nonce := hex.EncodeToString(nonceBytes)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.siweConfig.Domain == "" {
+		return nil, errors.New("siwe: sign-in with ethereum is not configured")
+	}
+
+	now := s.now()
+	expirationTime := now.Add(DefaultSIWEMessageTTL)
+	s.pruneExpiredSIWENonces(now)
+	s.siweNonces[nonce] = siweNonce{address: address, expiresAt: expirationTime}
+
+	return &SIWEMessage{
+		Domain:         s.siweConfig.Domain,
+		Address:        address,
+		Statement:      statement,
+		URI:            s.siweConfig.URI,
+		Version:        siweVersion,
+		ChainID:        s.siweConfig.ChainID,
+		Nonce:          nonce,
+		IssuedAt:       now,
+		ExpirationTime: &expirationTime,
+		Resources:      resources,
+	}, nil
+}
+
+// SignInWithEthereum validates a signed SIWE message and opens a session for its signer.
+// The message must be bound to the configured domain, URI and chain ID, carry a nonce issued by
+// NewSIWEMessage for the address it names that has not been used yet, be within its validity
+// window, and be signed by that address. The signer is bound to the User record with the same
+// address, which is created from the recovered public key on first sign-in.
+func (s *AuthService) SignInWithEthereum(message string, signature []byte) (Session, error) {
+	m, err := ParseSIWEMessage(message)
+	if err != nil {
+		return Session{}, err
+	}
+
+	// Recover the signer and its public key from the personal_sign signature
+	publicKey, err := recoverPersonalSignPubkey(message, signature)
+	if err != nil {
+		return Session{}, err
+	}
+	if crypto.PubkeyToAddress(*publicKey) != m.Address {
+		return Session{}, errors.New("siwe: signature does not match message address")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.validateSIWEMessage(m); err != nil {
+		return Session{}, err
+	}
+
+	// Bind the signer to an existing user or register a new one
+	userID, exists := s.userIDs[m.Address]
+	if !exists {
+		userID = s.newUserID()
+		s.addUser(User{UserID: userID, PublicKey: crypto.FromECDSAPub(publicKey)}, m.Address)
+	}
+
+	return s.newSession(userID, m.Address)
+}
+
+// validateSIWEMessage checks a parsed message against the service configuration and consumes its nonce.
+// The caller must hold s.mu.
+func (s *AuthService) validateSIWEMessage(m *SIWEMessage) error {
+	if s.siweConfig.Domain == "" {
+		return errors.New("siwe: sign-in with ethereum is not configured")
+	}
+	if m.Version != siweVersion {
+		return fmt.Errorf("siwe: unsupported version %q", m.Version)
+	}
+	if m.Domain != s.siweConfig.Domain {
+		return errors.New("siwe: domain mismatch")
+	}
+	if m.URI != s.siweConfig.URI {
+		return errors.New("siwe: URI mismatch")
+	}
+	if m.ChainID != s.siweConfig.ChainID {
+		return errors.New("siwe: chain ID mismatch")
+	}
+
+	// The nonce is single-use, whatever the outcome of the time checks below
+	nonce, exists := s.siweNonces[m.Nonce]
+	if !exists {
+		return errors.New("siwe: unknown or already used nonce")
+	}
+	delete(s.siweNonces, m.Nonce)
+	if nonce.address != m.Address {
+		return errors.New("siwe: nonce was issued for another address")
+	}
+
+	now := s.now()
+	if !now.Before(nonce.expiresAt) {
+		return errors.New("siwe: nonce expired")
+	}
+	if m.IssuedAt.After(now.Add(siweClockSkew)) {
+		return errors.New("siwe: message issued in the future")
+	}
+	if m.ExpirationTime != nil && !now.Before(*m.ExpirationTime) {
+		return errors.New("siwe: message expired")
+	}
+	if m.NotBefore != nil && now.Add(siweClockSkew).Before(*m.NotBefore) {
+		return errors.New("siwe: message not yet valid")
+	}
+
+	return nil
+}
+
+// pruneExpiredSIWENonces drops nonces that can no longer be used. The caller must hold s.mu.
+func (s *AuthService) pruneExpiredSIWENonces(now time.Time) {
+	for value, nonce := range s.siweNonces {
+		if !now.Before(nonce.expiresAt) {
+			delete(s.siweNonces, value)
+		}
+	}
+}
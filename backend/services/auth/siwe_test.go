@@ -0,0 +1,244 @@
+package auth_test
+
+import (
+	"crypto/ecdsa"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/auth"
+)
+
+var testSIWEConfig = auth.SIWEConfig{
+	Domain:  "app.genomicdao.com",
+	URI:     "https://app.genomicdao.com/login",
+	ChainID: 11155420,
+}
+
+func TestSIWEMessage_RoundTrip(t *testing.T) {
+	issuedAt := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	expirationTime := issuedAt.Add(time.Hour)
+	notBefore := issuedAt.Add(time.Minute)
+
+	message := &auth.SIWEMessage{
+		Scheme:         "https",
+		Domain:         "app.genomicdao.com",
+		Address:        common.HexToAddress("0x8A8937171197A78f47d8C2eE9A3C92FD33644B63"),
+		Statement:      "Sign in to manage your gene data.",
+		URI:            "https://app.genomicdao.com/login",
+		Version:        "1",
+		ChainID:        11155420,
+		Nonce:          "32891756abcdef12",
+		IssuedAt:       issuedAt,
+		ExpirationTime: &expirationTime,
+		NotBefore:      &notBefore,
+		RequestID:      "request-1",
+		Resources:      []string{"ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq", "https://app.genomicdao.com/gene-data"},
+	}
+
+	expected := "https://app.genomicdao.com wants you to sign in with your Ethereum account:\n" +
+		"0x8A8937171197A78f47d8C2eE9A3C92FD33644B63\n" +
+		"\n" +
+		"Sign in to manage your gene data.\n" +
+		"\n" +
+		"URI: https://app.genomicdao.com/login\n" +
+		"Version: 1\n" +
+		"Chain ID: 11155420\n" +
+		"Nonce: 32891756abcdef12\n" +
+		"Issued At: 2024-09-01T12:00:00Z\n" +
+		"Expiration Time: 2024-09-01T13:00:00Z\n" +
+		"Not Before: 2024-09-01T12:01:00Z\n" +
+		"Request ID: request-1\n" +
+		"Resources:\n" +
+		"- ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq\n" +
+		"- https://app.genomicdao.com/gene-data"
+	require.Equal(t, expected, message.String())
+
+	parsed, err := auth.ParseSIWEMessage(expected)
+	require.NoError(t, err)
+	require.Equal(t, message.Scheme, parsed.Scheme)
+	require.Equal(t, message.Domain, parsed.Domain)
+	require.Equal(t, message.Address, parsed.Address)
+	require.Equal(t, message.Statement, parsed.Statement)
+	require.Equal(t, message.URI, parsed.URI)
+	require.Equal(t, message.ChainID, parsed.ChainID)
+	require.Equal(t, message.Nonce, parsed.Nonce)
+	require.True(t, message.IssuedAt.Equal(parsed.IssuedAt))
+	require.True(t, message.ExpirationTime.Equal(*parsed.ExpirationTime))
+	require.True(t, message.NotBefore.Equal(*parsed.NotBefore))
+	require.Equal(t, message.RequestID, parsed.RequestID)
+	require.Equal(t, message.Resources, parsed.Resources)
+	require.Equal(t, expected, parsed.String())
+
+	// Messages without a statement and optional fields
+	minimal := "app.genomicdao.com wants you to sign in with your Ethereum account:\n" +
+		"0x8A8937171197A78f47d8C2eE9A3C92FD33644B63\n" +
+		"\n" +
+		"\n" +
+		"URI: https://app.genomicdao.com/login\n" +
+		"Version: 1\n" +
+		"Chain ID: 1\n" +
+		"Nonce: 32891756abcdef12\n" +
+		"Issued At: 2024-09-01T12:00:00Z"
+	parsed, err = auth.ParseSIWEMessage(minimal)
+	require.NoError(t, err)
+	require.Empty(t, parsed.Statement)
+	require.Nil(t, parsed.ExpirationTime)
+	require.Empty(t, parsed.Resources)
+	require.Equal(t, minimal, parsed.String())
+}
+
+func TestParseSIWEMessage_Invalid(t *testing.T) {
+	valid := &auth.SIWEMessage{
+		Domain:   "app.genomicdao.com",
+		Address:  common.HexToAddress("0x8A8937171197A78f47d8C2eE9A3C92FD33644B63"),
+		URI:      "https://app.genomicdao.com/login",
+		Version:  "1",
+		ChainID:  1,
+		Nonce:    "32891756abcdef12",
+		IssuedAt: time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	testCases := []struct {
+		name   string
+		mutate func(m *auth.SIWEMessage) string
+	}{
+		{"lowercase address", func(m *auth.SIWEMessage) string {
+			return replaceLine(m.String(), 1, "0x8a8937171197a78f47d8c2ee9a3c92fd33644b63")
+		}},
+		{"short nonce", func(m *auth.SIWEMessage) string { m.Nonce = "abc"; return m.String() }},
+		{"missing header", func(m *auth.SIWEMessage) string { return replaceLine(m.String(), 0, "app.genomicdao.com") }},
+		{"invalid resource", func(m *auth.SIWEMessage) string { m.Resources = []string{"not a uri"}; return m.String() }},
+		{"trailing content", func(m *auth.SIWEMessage) string { return m.String() + "\nextra" }},
+		{"missing chain ID", func(m *auth.SIWEMessage) string { return replaceLine(m.String(), 6, "Chain: 1") }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := *valid
+			_, err := auth.ParseSIWEMessage(tc.mutate(&m))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestSignInWithEthereum(t *testing.T) {
+	authService := auth.NewAuthService()
+	authService.ConfigureSIWE(testSIWEConfig)
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+
+	// First sign-in registers the user with the recovered public key
+	message, err := authService.NewSIWEMessage(address, "Sign in to GenomicDAO.", []string{"https://app.genomicdao.com/gene-data"})
+	require.NoError(t, err)
+	session, err := authService.SignInWithEthereum(message.String(), signPersonalMessage(t, privateKey, message.String()))
+	require.NoError(t, err)
+	require.NotEmpty(t, session.Token)
+	require.Equal(t, address, session.ETHAddress)
+
+	user := authService.QueryUserByUserID(session.UserID)
+	require.Equal(t, crypto.FromECDSAPub(&privateKey.PublicKey), user.PublicKey)
+
+	// The session token can be verified
+	verified, err := authService.VerifySession(session.Token)
+	require.NoError(t, err)
+	require.Equal(t, session, verified)
+
+	// Later sign-ins bind to the same user
+	message, err = authService.NewSIWEMessage(address, "", nil)
+	require.NoError(t, err)
+	secondSession, err := authService.SignInWithEthereum(message.String(), signPersonalMessage(t, privateKey, message.String()))
+	require.NoError(t, err)
+	require.Equal(t, session.UserID, secondSession.UserID)
+	require.NotEqual(t, session.Token, secondSession.Token)
+
+	// Replaying a message is rejected because its nonce was consumed
+	_, err = authService.SignInWithEthereum(message.String(), signPersonalMessage(t, privateKey, message.String()))
+	assert.EqualError(t, err, "siwe: unknown or already used nonce")
+
+	_, err = authService.VerifySession("unknown")
+	assert.Error(t, err)
+}
+
+func TestSignInWithEthereum_ExistingUser(t *testing.T) {
+	authService := auth.NewAuthService()
+	authService.ConfigureSIWE(testSIWEConfig)
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	userID, err := authService.RegisterUserWithPubkey(crypto.FromECDSAPub(&privateKey.PublicKey))
+	require.NoError(t, err)
+
+	message, err := authService.NewSIWEMessage(crypto.PubkeyToAddress(privateKey.PublicKey), "", nil)
+	require.NoError(t, err)
+	session, err := authService.SignInWithEthereum(message.String(), signPersonalMessage(t, privateKey, message.String()))
+	require.NoError(t, err)
+	require.Equal(t, userID, session.UserID)
+}
+
+func TestSignInWithEthereum_Invalid(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+
+	attackerKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		mutate func(m *auth.SIWEMessage)
+		signer *ecdsa.PrivateKey
+		err    string
+	}{
+		{"wrong signer", func(m *auth.SIWEMessage) {}, attackerKey, "siwe: signature does not match message address"},
+		{"domain mismatch", func(m *auth.SIWEMessage) { m.Domain = "evil.example.com" }, privateKey, "siwe: domain mismatch"},
+		{"URI mismatch", func(m *auth.SIWEMessage) { m.URI = "https://evil.example.com" }, privateKey, "siwe: URI mismatch"},
+		{"chain ID mismatch", func(m *auth.SIWEMessage) { m.ChainID = 1 }, privateKey, "siwe: chain ID mismatch"},
+		{"unknown nonce", func(m *auth.SIWEMessage) { m.Nonce = "0123456789abcdef" }, privateKey, "siwe: unknown or already used nonce"},
+		{"nonce of another address", func(m *auth.SIWEMessage) { m.Address = crypto.PubkeyToAddress(attackerKey.PublicKey) }, attackerKey, "siwe: nonce was issued for another address"},
+		{"expired", func(m *auth.SIWEMessage) {
+			expired := time.Now().Add(-time.Minute)
+			m.ExpirationTime = &expired
+		}, privateKey, "siwe: message expired"},
+		{"not yet valid", func(m *auth.SIWEMessage) {
+			notBefore := time.Now().Add(time.Hour)
+			m.NotBefore = &notBefore
+		}, privateKey, "siwe: message not yet valid"},
+		{"issued in the future", func(m *auth.SIWEMessage) { m.IssuedAt = time.Now().Add(time.Hour) }, privateKey, "siwe: message issued in the future"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			authService := auth.NewAuthService()
+			authService.ConfigureSIWE(testSIWEConfig)
+
+			message, err := authService.NewSIWEMessage(address, "", nil)
+			require.NoError(t, err)
+			tc.mutate(message)
+
+			_, err = authService.SignInWithEthereum(message.String(), signPersonalMessage(t, tc.signer, message.String()))
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestSignInWithEthereum_NotConfigured(t *testing.T) {
+	authService := auth.NewAuthService()
+
+	_, err := authService.NewSIWEMessage(common.Address{}, "", nil)
+	require.EqualError(t, err, "siwe: sign-in with ethereum is not configured")
+}
+
+// replaceLine replaces the line at index i of a multi-line string.
+func replaceLine(s string, i int, line string) string {
+	lines := strings.Split(s, "\n")
+	lines[i] = line
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,101 @@
+package blockchain
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CheckpointStore persists the last block a watcher has processed, so that it resumes from there
+// after a restart.
+type CheckpointStore interface {
+	// LoadCheckpoint returns the last processed block, or false if there is none yet.
+	LoadCheckpoint() (uint64, bool, error)
+	// SaveCheckpoint records the last processed block.
+	SaveCheckpoint(block uint64) error
+}
+
+// MemoryCheckpointStore is a CheckpointStore that keeps the checkpoint in memory.
+type MemoryCheckpointStore struct {
+	mu    sync.Mutex
+	block uint64
+	saved bool
+}
+
+// NewMemoryCheckpointStore creates an empty MemoryCheckpointStore.
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{}
+}
+
+// LoadCheckpoint returns the last saved block.
+func (s *MemoryCheckpointStore) LoadCheckpoint() (uint64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.block, s.saved, nil
+}
+
+// SaveCheckpoint records the last processed block.
+func (s *MemoryCheckpointStore) SaveCheckpoint(block uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.block = block
+	s.saved = true
+	return nil
+}
+
+// FileCheckpointStore is a CheckpointStore that keeps the checkpoint as a decimal block number in
+// a file.
+type FileCheckpointStore struct {
+	path string
+}
+
+// NewFileCheckpointStore creates a FileCheckpointStore for the file at path. The file is created
+// on the first save.
+func NewFileCheckpointStore(path string) (*FileCheckpointStore, error) {
+	if path == "" {
+		return nil, errors.New("checkpoint path is required")
+	}
+	return &FileCheckpointStore{path: path}, nil
+}
+
+// LoadCheckpoint reads the last saved block from the file.
+func (s *FileCheckpointStore) LoadCheckpoint() (uint64, bool, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	block, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false, errors.New("invalid checkpoint file")
+	}
+	return block, true, nil
+}
+
+// SaveCheckpoint writes the block to a temporary file and renames it over the checkpoint, so a
+// crash never leaves a partial checkpoint behind.
+func (s *FileCheckpointStore) SaveCheckpoint(block uint64) error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(strconv.FormatUint(block, 10) + "\n"); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
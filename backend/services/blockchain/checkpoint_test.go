@@ -0,0 +1,40 @@
+package blockchain_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/blockchain"
+)
+
+func TestFileCheckpointStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	store, err := blockchain.NewFileCheckpointStore(path)
+	require.NoError(t, err)
+
+	// There is no checkpoint until one is saved
+	_, ok, err := store.LoadCheckpoint()
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// The last saved checkpoint is loaded, also by another store of the file
+	require.NoError(t, store.SaveCheckpoint(41))
+	require.NoError(t, store.SaveCheckpoint(42))
+	reopened, err := blockchain.NewFileCheckpointStore(path)
+	require.NoError(t, err)
+	block, ok, err := reopened.LoadCheckpoint()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint64(42), block)
+
+	// A corrupted checkpoint is reported
+	require.NoError(t, os.WriteFile(path, []byte("not a block"), 0o600))
+	_, _, err = store.LoadCheckpoint()
+	require.ErrorContains(t, err, "invalid checkpoint file")
+
+	_, err = blockchain.NewFileCheckpointStore("")
+	require.ErrorContains(t, err, "checkpoint path is required")
+}
@@ -2,102 +2,120 @@ package blockchain
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
-	"strings"
 
-	"github.com/ethereum/go-ethereum"
-	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
-)
 
-const (
-	uploadDataEventABI = `[{
-		"anonymous":false,
-		"inputs":[
-			{"indexed":false,"internalType": "string","name":"docId","type":"string"},
-			{"indexed":false,"internalType": "uint256","name":"sessionId","type":"uint256"}
-		],
-		"name":"UploadData",
-		"type":"event"
-	}]`
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/contracts"
 )
 
+const defaultListenerBatchSize = 1000
+
+// ErrUploadNotFound is returned by ListenForUploadDataEvents when no UploadData event of the
+// document is found in the searched blocks.
+var ErrUploadNotFound = errors.New("no matching event found")
+
+// ControllerEventListenerConfig configures the blocks a ControllerEventListener searches.
+type ControllerEventListenerConfig struct {
+	// StartBlock is the first block to search, e.g. the block the Controller was deployed in.
+	StartBlock uint64
+	// BatchSize is the number of blocks searched per log query. Defaults to 1000.
+	BatchSize uint64
+}
+
+// ControllerEventListener looks up the UploadData events of the Controller.
 type ControllerEventListener struct {
-	client   *ethclient.Client
-	auth     *bind.TransactOpts
-	eventABI abi.ABI
+	client     Backend
+	auth       *bind.TransactOpts
+	controller *contracts.Controller
+	address    common.Address
+	config     ControllerEventListenerConfig
 }
 
-// NewControllerEventListener initializes the ControllerEventListener by loading the ABI from the specified file.
-func NewControllerEventListener(client *ethclient.Client, auth *bind.TransactOpts) (*ControllerEventListener, error) {
-	/// Parse the ABI for the UploadData event
-	eventABI, err := abi.JSON(strings.NewReader(uploadDataEventABI))
+// NewControllerEventListener initializes a ControllerEventListener for the Controller contract
+// deployed at the default address.
+func NewControllerEventListener(client Backend, auth *bind.TransactOpts) (*ControllerEventListener, error) {
+	return NewControllerEventListenerWithAddress(client, auth, common.HexToAddress(controllerAddress))
+}
+
+// NewControllerEventListenerWithAddress initializes a ControllerEventListener for the Controller
+// contract deployed at the given address, searching the whole chain.
+func NewControllerEventListenerWithAddress(client Backend, auth *bind.TransactOpts, address common.Address) (*ControllerEventListener, error) {
+	return NewControllerEventListenerWithConfig(client, auth, address, ControllerEventListenerConfig{})
+}
+
+// NewControllerEventListenerWithConfig initializes a ControllerEventListener for the Controller
+// contract deployed at the given address, searching the blocks of the config.
+func NewControllerEventListenerWithConfig(client Backend, auth *bind.TransactOpts, address common.Address, config ControllerEventListenerConfig) (*ControllerEventListener, error) {
+	controller, err := contracts.NewController(address, client)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse ABI: %w", err)
+		return nil, fmt.Errorf("failed to instantiate Controller contract: %w", err)
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = defaultListenerBatchSize
 	}
 
 	return &ControllerEventListener{
-		client:   client,
-		auth:     auth,
-		eventABI: eventABI,
+		client:     client,
+		auth:       auth,
+		controller: controller,
+		address:    address,
+		config:     config,
 	}, nil
 }
 
-// ListenForUploadDataEvents listens for the UploadData event and returns the sessionId if the fileID matches.
+// ListenForUploadDataEvents returns the session ID of the UploadData event of the document.
+// ControllerService.UploadData returns the session ID from its receipt, so this search is only a
+// recovery path, e.g. for resuming an upload whose receipt was lost. The blocks from StartBlock to
+// the latest one are searched newest first, BatchSize blocks at a time, and ErrUploadNotFound is
+// returned if the document has no event in them.
 func (s *ControllerEventListener) ListenForUploadDataEvents(fileID string) (*big.Int, error) {
+	ctx := context.Background()
+
 	// Get the latest block number
-	header, err := s.client.HeaderByNumber(context.Background(), nil)
+	header, err := s.client.HeaderByNumber(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get the latest block: %w", err)
 	}
-	latestBlock := header.Number
-
-	// Ensure the block range is valid
-	fromBlock := new(big.Int).Sub(latestBlock, big.NewInt(10))
-	if fromBlock.Sign() < 0 {
-		fromBlock = big.NewInt(0)
-	}
+	latest := header.Number.Uint64()
 
-	// Create a filter query for the contract address and UploadData event
-	query := ethereum.FilterQuery{
-		Addresses: []common.Address{common.HexToAddress(controllerAddress)},
-		FromBlock: fromBlock,
+	// A document is uploaded once, so the search can stop at the first match
+	for to := latest; to >= s.config.StartBlock; {
+		from := s.config.StartBlock
+		if to-from >= s.config.BatchSize {
+			from = to - s.config.BatchSize + 1
+		}
+		sessionID, err := s.findUpload(ctx, fileID, from, to)
+		if err != nil || sessionID != nil {
+			return sessionID, err
+		}
+		if from == 0 {
+			break
+		}
+		to = from - 1
 	}
+	return nil, ErrUploadNotFound
+}
 
-	// Poll for logs
-	logs, err := s.client.FilterLogs(context.Background(), query)
+// findUpload returns the session ID of the UploadData event of the document between the blocks
+// from and to, or nil if there is none.
+func (s *ControllerEventListener) findUpload(ctx context.Context, fileID string, from, to uint64) (*big.Int, error) {
+	events, err := s.controller.FilterUploadData(&bind.FilterOpts{Start: from, End: &to, Context: ctx})
 	if err != nil {
-		return nil, fmt.Errorf("failed to filter logs: %w", err)
+		return nil, fmt.Errorf("failed to filter UploadData events of blocks %d to %d: %w", from, to, err)
 	}
+	defer events.Close()
 
-	// Loop through the logs
-	for _, vLog := range logs {
-		// Unpack the non-indexed parameters
-		events, err := s.eventABI.Unpack("UploadData", vLog.Data)
-		if err != nil {
-			fmt.Printf("Error unpacking event data: %v\n", err)
-			continue
-		}
-		if len(events) == 2 {
-			docID, ok1 := events[0].(string)
-			sessionID, ok2 := events[1].(*big.Int)
-
-			if ok1 && ok2 {
-				fmt.Printf("Document ID: %s, Session ID: %s\n", docID, sessionID.String())
-			} else {
-				fmt.Println("Failed to cast event data to expected types")
-			}
-
-			// Compare the docId with the provided fileID
-			if docID == fileID {
-				fmt.Printf("Matching UploadData event found:\nDocument ID: %s\nSession ID: %s\n", docID, sessionID.String())
-				return sessionID, nil
-			}
+	for events.Next() {
+		if events.Event.DocId == fileID {
+			return events.Event.SessionId, nil
 		}
 	}
-
-	return nil, fmt.Errorf("no matching event found")
+	if err := events.Error(); err != nil {
+		return nil, fmt.Errorf("failed to decode UploadData events of blocks %d to %d: %w", from, to, err)
+	}
+	return nil, nil
 }
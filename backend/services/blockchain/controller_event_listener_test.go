@@ -0,0 +1,61 @@
+package blockchain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/blockchain"
+)
+
+func TestListenForUploadDataEvents(t *testing.T) {
+	h := newHarness(t, 1)
+	controllerService := h.controllerService(t, h.users[0])
+	listener, err := blockchain.NewControllerEventListenerWithAddress(h.client, h.users[0], h.controllerAddress)
+	require.NoError(t, err)
+
+	for _, docId := range []string{"doc1", "doc2"} {
+		_, err := controllerService.UploadData(docId)
+		require.NoError(t, err)
+	}
+
+	// The session of each upload is found by its doc ID
+	sessionID, err := listener.ListenForUploadDataEvents("doc1")
+	require.NoError(t, err)
+	require.Equal(t, int64(0), sessionID.Int64())
+	sessionID, err = listener.ListenForUploadDataEvents("doc2")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), sessionID.Int64())
+	_, err = listener.ListenForUploadDataEvents("doc3")
+	require.ErrorIs(t, err, blockchain.ErrUploadNotFound)
+
+	// Events of other contracts are ignored
+	other, err := blockchain.NewControllerEventListenerWithAddress(h.client, h.users[0], common.HexToAddress("0x01"))
+	require.NoError(t, err)
+	_, err = other.ListenForUploadDataEvents("doc1")
+	require.ErrorIs(t, err, blockchain.ErrUploadNotFound)
+
+	// Uploads older than a batch are found by searching back to the start block
+	for range 10 {
+		h.backend.Commit()
+	}
+	batched, err := blockchain.NewControllerEventListenerWithConfig(h.client, h.users[0], h.controllerAddress, blockchain.ControllerEventListenerConfig{
+		BatchSize: 2,
+	})
+	require.NoError(t, err)
+	sessionID, err = batched.ListenForUploadDataEvents("doc1")
+	require.NoError(t, err)
+	require.Equal(t, int64(0), sessionID.Int64())
+
+	// Blocks before the start block are not searched
+	header, err := h.client.HeaderByNumber(context.Background(), nil)
+	require.NoError(t, err)
+	later, err := blockchain.NewControllerEventListenerWithConfig(h.client, h.users[0], h.controllerAddress, blockchain.ControllerEventListenerConfig{
+		StartBlock: header.Number.Uint64() - 5,
+	})
+	require.NoError(t, err)
+	_, err = later.ListenForUploadDataEvents("doc1")
+	require.ErrorIs(t, err, blockchain.ErrUploadNotFound)
+}
@@ -1,29 +1,68 @@
 package blockchain
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/core/types"
 
 	"github.com/trungnt1811/blockchain-engineer-interview/backend/contracts"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/attestation"
 )
 
 const (
 	controllerAddress = "0x8A8937171197A78f47d8C2eE9A3C92FD33644B63"
 )
 
+// ErrDocAlreadySubmitted is returned by UploadData when the Controller already has an upload
+// session for the document.
+var ErrDocAlreadySubmitted = errors.New("Doc already been submitted")
+
+// ErrConfirmCancelled is returned by Confirm when the confirm transaction was cancelled with
+// TxManager.Cancel, so the transaction mined with its nonce is not a confirm.
+var ErrConfirmCancelled = errors.New("confirm transaction was cancelled")
+
+// Backend is the Ethereum client the blockchain services call contracts and wait for
+// transactions with. It is implemented by *ethclient.Client and by the client of go-ethereum's
+// simulated backend.
+type Backend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+}
+
 type ControllerService struct {
-	client     *ethclient.Client
-	auth       *bind.TransactOpts
+	client     Backend
+	txs        *TxManager
+	address    common.Address
 	controller *contracts.Controller
 }
 
+// UploadResult is the outcome of an UploadData transaction, read from its receipt.
+type UploadResult struct {
+	TxHash      common.Hash
+	BlockNumber uint64
+	SessionID   *big.Int // Upload session opened for the document, to confirm it with.
+	GasUsed     uint64
+}
+
 // NewControllerService initializes a new ControllerService with the given client, authentication options, and contract address.
-func NewControllerService(client *ethclient.Client, auth *bind.TransactOpts, address common.Address) (*ControllerService, error) {
+func NewControllerService(client Backend, auth *bind.TransactOpts, address common.Address) (*ControllerService, error) {
+	txs, err := NewTxManager(client, auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize transaction manager: %w", err)
+	}
+	return NewControllerServiceWithTxManager(client, txs, address)
+}
+
+// NewControllerServiceWithTxManager initializes a ControllerService that sends its transactions
+// through the given TxManager, which can be shared with other services of the same account.
+func NewControllerServiceWithTxManager(client Backend, txs *TxManager, address common.Address) (*ControllerService, error) {
 	controller, err := contracts.NewController(address, client)
 	if err != nil {
 		return nil, fmt.Errorf("failed to instantiate Controller contract: %w", err)
@@ -31,43 +70,183 @@ func NewControllerService(client *ethclient.Client, auth *bind.TransactOpts, add
 
 	return &ControllerService{
 		client:     client,
-		auth:       auth,
+		txs:        txs,
+		address:    address,
 		controller: controller,
 	}, nil
 }
 
-// UploadData uploads data to the contract and returns the transaction hash for tracking.
-func (s *ControllerService) UploadData(docId string) (common.Hash, error) {
-	// Send the transaction to upload data
-	tx, err := s.controller.UploadData(s.auth, docId)
+// UploadData uploads data to the contract and returns the upload session it opened, decoded
+// from the UploadData event in the transaction receipt. If the document was already submitted
+// the error wraps ErrDocAlreadySubmitted; ControllerEventListener can then recover the session
+// of an earlier upload.
+func (s *ControllerService) UploadData(docId string) (*UploadResult, error) {
+	// Send the transaction to upload data through the transaction queue
+	tx, err := s.txs.Send(context.Background(), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return s.controller.UploadData(opts, docId)
+	})
 	if err != nil {
-		return common.Hash{}, fmt.Errorf("failed to upload data: %w", err)
+		if strings.Contains(err.Error(), ErrDocAlreadySubmitted.Error()) {
+			return nil, fmt.Errorf("failed to upload data: %w", ErrDocAlreadySubmitted)
+		}
+		return nil, fmt.Errorf("failed to upload data: %w", err)
 	}
 
 	// Wait for the transaction receipt to ensure it's processed
-	_, err = bind.WaitMined(context.Background(), s.client, tx)
+	receipt, err := s.txs.WaitMined(context.Background(), tx)
 	if err != nil {
-		return common.Hash{}, fmt.Errorf("failed to mine transaction: %w", err)
+		return nil, fmt.Errorf("failed to mine transaction: %w", err)
 	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("upload data transaction %s reverted", receipt.TxHash.Hex())
+	}
+
+	// Read the session ID from the event the contract emitted for the document
+	sessionID, err := s.parseUploadData(receipt, docId)
+	if err != nil {
+		return nil, err
+	}
+	return &UploadResult{
+		TxHash:      receipt.TxHash, // A replacement if the transaction was stuck.
+		BlockNumber: receipt.BlockNumber.Uint64(),
+		SessionID:   sessionID,
+		GasUsed:     receipt.GasUsed,
+	}, nil
+}
 
-	// Log and return the transaction hash
-	return tx.Hash(), nil
+// parseUploadData returns the session ID of the document's UploadData event in the receipt.
+func (s *ControllerService) parseUploadData(receipt *types.Receipt, docId string) (*big.Int, error) {
+	for _, log := range receipt.Logs {
+		// Skip logs of other contracts and other Controller events
+		if log.Address != s.address {
+			continue
+		}
+		event, err := s.controller.ParseUploadData(*log)
+		if err != nil {
+			continue
+		}
+		if event.DocId == docId {
+			return event.SessionId, nil
+		}
+	}
+	return nil, fmt.Errorf("no UploadData event for doc %s in transaction %s", docId, receipt.TxHash.Hex())
 }
 
-// Confirm confirms the uploaded data and logs the transaction hash.
-func (s *ControllerService) Confirm(docId, contentHash, proof string, sessionId *big.Int, riskScore uint8) error {
+// Confirm confirms the uploaded data and waits for the confirm transaction to be mined. The TEE's
+// attestation quote for the risk score is passed to the contract as the proof, so its nonce must
+// be the ConfirmNonce of the document, content hash and session, and it must be signed by a
+// registered TEE signer. It fails if the transaction reverted, and with ErrConfirmCancelled if it
+// was cancelled meanwhile.
+func (s *ControllerService) Confirm(docId, contentHash string, quote *attestation.Quote, sessionId *big.Int, riskScore uint8) error {
+	proof, err := quote.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("failed to encode attestation quote: %w", err)
+	}
+
 	// Send the transaction to confirm data
-	tx, err := s.controller.Confirm(s.auth, docId, contentHash, proof, sessionId, big.NewInt(int64(riskScore)))
+	tx, err := s.txs.Send(context.Background(), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return s.controller.Confirm(opts, docId, contentHash, proof, sessionId, big.NewInt(int64(riskScore)))
+	})
 	if err != nil {
 		return fmt.Errorf("failed to confirm session: %w", err)
 	}
 
 	// Wait for the transaction receipt to ensure it's processed
-	_, err = bind.WaitMined(context.Background(), s.client, tx)
+	receipt, err := s.txs.WaitMined(context.Background(), tx)
+	if err != nil {
+		return fmt.Errorf("failed to mine transaction: %w", err)
+	}
+
+	// The mined transaction may be a cancellation instead of the confirm or one of its replacements
+	mined, err := s.txs.Transaction(receipt.TxHash)
+	if err != nil {
+		return fmt.Errorf("failed to get mined transaction: %w", err)
+	}
+	if mined.To() == nil || *mined.To() != s.address || !bytes.Equal(mined.Data(), tx.Data()) {
+		return fmt.Errorf("%w: %s was mined instead of %s", ErrConfirmCancelled, receipt.TxHash.Hex(), tx.Hash().Hex())
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fmt.Errorf("confirm transaction %s reverted", receipt.TxHash.Hex())
+	}
+	return nil
+}
+
+// ConfirmNonce returns the nonce the TEE must attest to for the contract to accept its quote as the
+// proof for confirming the document with the content hash in the session.
+func (s *ControllerService) ConfirmNonce(docId, contentHash string, sessionId *big.Int) (common.Hash, error) {
+	nonce, err := s.controller.ConfirmNonce(&bind.CallOpts{}, docId, contentHash, sessionId)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get confirm nonce: %w", err)
+	}
+	return nonce, nil
+}
+
+// ConfirmReceipt confirms the uploaded document of a TEE computation receipt with the receipt's
+// risk level, so the score submitted on-chain is the one the TEE computed from the document's gene
+// data. The quote must attest to the same model, data and risk level as the receipt.
+func (s *ControllerService) ConfirmReceipt(receipt *attestation.Receipt, contentHash string, quote *attestation.Quote, sessionId *big.Int) error {
+	if quote.Model != receipt.Model || quote.ModelVersion != receipt.ModelVersion ||
+		quote.InputHash != receipt.DataHash || quote.OutputHash != attestation.RiskScoreHash(receipt.RiskLevel) {
+		return errors.New("attestation quote does not match the receipt")
+	}
+	return s.Confirm(receipt.FileID, contentHash, quote, sessionId, receipt.RiskLevel)
+}
+
+// AnchoredContentID returns the content hash confirmed on-chain for the document, which is the
+// IPFS content ID of its encrypted gene data.
+func (s *ControllerService) AnchoredContentID(docId string) (string, error) {
+	doc, err := s.controller.GetDoc(&bind.CallOpts{}, docId)
+	if err != nil {
+		return "", fmt.Errorf("failed to get doc: %w", err)
+	}
+	if doc.HashContent == "" {
+		return "", fmt.Errorf("doc %s has not been confirmed", docId)
+	}
+	return doc.HashContent, nil
+}
+
+// AddTEESigner registers the attestation key of a TEE, identified by its address, as a signer of
+// confirm proofs. Only the contract owner can manage the signers.
+func (s *ControllerService) AddTEESigner(signer common.Address) error {
+	// Send the transaction to add the signer
+	tx, err := s.txs.Send(context.Background(), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return s.controller.AddTEESigner(opts, signer)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add TEE signer: %w", err)
+	}
+
+	// Wait for the transaction receipt to ensure it's processed
+	_, err = s.txs.WaitMined(context.Background(), tx)
 	if err != nil {
 		return fmt.Errorf("failed to mine transaction: %w", err)
 	}
+	return nil
+}
+
+// RemoveTEESigner revokes a TEE attestation key, so proofs it signs are no longer accepted.
+func (s *ControllerService) RemoveTEESigner(signer common.Address) error {
+	// Send the transaction to remove the signer
+	tx, err := s.txs.Send(context.Background(), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return s.controller.RemoveTEESigner(opts, signer)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove TEE signer: %w", err)
+	}
 
-	// Log the transaction hash
+	// Wait for the transaction receipt to ensure it's processed
+	_, err = s.txs.WaitMined(context.Background(), tx)
+	if err != nil {
+		return fmt.Errorf("failed to mine transaction: %w", err)
+	}
 	return nil
 }
+
+// IsTEESigner reports whether the TEE attestation key is a registered signer of confirm proofs.
+func (s *ControllerService) IsTEESigner(signer common.Address) (bool, error) {
+	registered, err := s.controller.TeeSigners(&bind.CallOpts{}, signer)
+	if err != nil {
+		return false, fmt.Errorf("failed to get TEE signer: %w", err)
+	}
+	return registered, nil
+}
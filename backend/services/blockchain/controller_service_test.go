@@ -0,0 +1,214 @@
+package blockchain_test
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/contracts"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/attestation"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/blockchain"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/tee"
+)
+
+func TestUploadData(t *testing.T) {
+	h := newHarness(t, 2)
+	controllerService := h.controllerService(t, h.users[0])
+	controller, err := contracts.NewController(h.controllerAddress, h.client)
+	require.NoError(t, err)
+
+	// Each upload opens the next session for the sender, read from the transaction receipt
+	for i, docId := range []string{"doc1", "doc2"} {
+		upload, err := controllerService.UploadData(docId)
+		require.NoError(t, err)
+		require.Equal(t, int64(i), upload.SessionID.Int64())
+		receipt, err := h.client.TransactionReceipt(context.Background(), upload.TxHash)
+		require.NoError(t, err)
+		require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+		require.Equal(t, receipt.BlockNumber.Uint64(), upload.BlockNumber)
+		require.Equal(t, receipt.GasUsed, upload.GasUsed)
+		require.Positive(t, upload.GasUsed)
+
+		session, err := controller.GetSession(&bind.CallOpts{}, upload.SessionID)
+		require.NoError(t, err)
+		require.Equal(t, h.users[0].From, session.User)
+		require.False(t, session.Confirmed)
+	}
+
+	// A document can only be uploaded once, by anyone
+	_, err = h.controllerService(t, h.users[1]).UploadData("doc1")
+	require.ErrorIs(t, err, blockchain.ErrDocAlreadySubmitted)
+}
+
+func TestConfirm(t *testing.T) {
+	h := newHarness(t, 2)
+	controllerService := h.controllerService(t, h.users[0])
+	controller, err := contracts.NewController(h.controllerAddress, h.client)
+	require.NoError(t, err)
+
+	_, err = controllerService.UploadData("doc1")
+	require.NoError(t, err)
+	_, err = controllerService.AnchoredContentID("doc1")
+	require.ErrorContains(t, err, "has not been confirmed")
+
+	// Only the owner of the session can confirm it
+	quote, riskScore := h.attest(t, "doc1", "cid1", big.NewInt(0), "high risk")
+	err = h.controllerService(t, h.users[1]).Confirm("doc1", "cid1", quote, big.NewInt(0), riskScore)
+	require.ErrorContains(t, err, "Invalid session owner")
+
+	// Confirming anchors the content hash, mints the NFT and closes the session
+	require.NoError(t, controllerService.Confirm("doc1", "cid1", quote, big.NewInt(0), riskScore))
+	contentID, err := controllerService.AnchoredContentID("doc1")
+	require.NoError(t, err)
+	require.Equal(t, "cid1", contentID)
+	nftOwner, err := h.geneNFT.OwnerOf(&bind.CallOpts{}, big.NewInt(0))
+	require.NoError(t, err)
+	require.Equal(t, h.users[0].From, nftOwner)
+	session, err := controller.GetSession(&bind.CallOpts{}, big.NewInt(0))
+	require.NoError(t, err)
+	require.True(t, session.Confirmed)
+	require.Equal(t, "success", session.Proof)
+
+	// A confirmed document or session cannot be confirmed again
+	err = controllerService.Confirm("doc1", "cid1", quote, big.NewInt(0), riskScore)
+	require.ErrorContains(t, err, "Doc already been submitted")
+	quote, riskScore = h.attest(t, "doc2", "cid2", big.NewInt(0), "high risk")
+	err = controllerService.Confirm("doc2", "cid2", quote, big.NewInt(0), riskScore)
+	require.ErrorContains(t, err, "Session is ended")
+}
+
+func TestConfirmCancelled(t *testing.T) {
+	h := newHarness(t, 1)
+	_, err := h.controllerService(t, h.users[0]).UploadData("doc1")
+	require.NoError(t, err)
+	client := h.backend.Client() // Does not mine on send.
+	txManager, err := blockchain.NewTxManager(client, h.users[0])
+	require.NoError(t, err)
+	controllerService, err := blockchain.NewControllerServiceWithTxManager(client, txManager, h.controllerAddress)
+	require.NoError(t, err)
+
+	// A stuck confirm is cancelled by an empty transfer to the account itself
+	quote, riskScore := h.attest(t, "doc1", "cid1", big.NewInt(0), "high risk")
+	errs := make(chan error, 1)
+	go func() {
+		errs <- controllerService.Confirm("doc1", "cid1", quote, big.NewInt(0), riskScore)
+	}()
+	var pending []blockchain.TxInfo
+	require.Eventually(t, func() bool {
+		pending, err = txManager.Pending(context.Background())
+		return err == nil && len(pending) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel, err := txManager.Cancel(context.Background(), pending[0].Hash)
+	require.NoError(t, err)
+	h.backend.Commit()
+
+	// Confirming fails although the cancellation was mined successfully
+	require.ErrorIs(t, <-errs, blockchain.ErrConfirmCancelled)
+	info, err := txManager.Status(context.Background(), cancel.Hash())
+	require.NoError(t, err)
+	require.Equal(t, blockchain.TxMined, info.Status)
+	_, err = controllerService.AnchoredContentID("doc1")
+	require.ErrorContains(t, err, "has not been confirmed")
+}
+
+func TestConfirmVerifiesProof(t *testing.T) {
+	h := newHarness(t, 1)
+	admin := h.controllerService(t, h.owner)
+	controllerService := h.controllerService(t, h.users[0])
+
+	// Only the owner manages the TEE signers
+	registered, err := controllerService.IsTEESigner(h.tee.AttestationKey())
+	require.NoError(t, err)
+	require.True(t, registered)
+	require.ErrorContains(t, controllerService.AddTEESigner(h.users[0].From), "Ownable: caller is not the owner")
+
+	_, err = controllerService.UploadData("doc1")
+	require.NoError(t, err)
+	sessionID := big.NewInt(0)
+
+	// The contract's nonce binds the quote to the chain, contract, document and session
+	nonce, err := controllerService.ConfirmNonce("doc1", "cid1", sessionID)
+	require.NoError(t, err)
+	expected, err := attestation.ConfirmNonce(h.chainID, h.controllerAddress, "doc1", "cid1", sessionID)
+	require.NoError(t, err)
+	require.Equal(t, expected, nonce)
+	quote, riskScore := h.attest(t, "doc1", "cid1", sessionID, "slightly high risk")
+	require.Equal(t, nonce, quote.Nonce)
+
+	// Quotes from unregistered TEEs, for another confirmation or for another risk score are rejected
+	untrusted := &attestation.Quote{Version: quote.Version, Measurement: quote.Measurement, Claims: quote.Claims}
+	digest, err := untrusted.Digest()
+	require.NoError(t, err)
+	untrustedKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	untrusted.Signature, err = crypto.Sign(digest.Bytes(), untrustedKey)
+	require.NoError(t, err)
+	err = controllerService.Confirm("doc1", "cid1", untrusted, sessionID, riskScore)
+	require.ErrorContains(t, err, "Proof is not signed by a TEE signer")
+
+	replayed, _ := h.attest(t, "doc1", "cid2", sessionID, "slightly high risk")
+	err = controllerService.Confirm("doc1", "cid1", replayed, sessionID, riskScore)
+	require.ErrorContains(t, err, "Proof is not for this confirmation")
+
+	err = controllerService.Confirm("doc1", "cid1", quote, sessionID, tee.RiskLevelExtremelyHigh.Score())
+	require.ErrorContains(t, err, "Proof does not attest the risk score")
+
+	// Tampering with the attested claims breaks the signature
+	tampered := *quote
+	tampered.InputHash = crypto.Keccak256Hash([]byte("low risk"))
+	err = controllerService.Confirm("doc1", "cid1", &tampered, sessionID, riskScore)
+	require.ErrorContains(t, err, "Proof is not signed by a TEE signer")
+
+	// The TEE's quote for this confirmation is accepted
+	require.NoError(t, controllerService.Confirm("doc1", "cid1", quote, sessionID, riskScore))
+
+	// Proofs of a removed signer are no longer accepted
+	require.NoError(t, admin.RemoveTEESigner(h.tee.AttestationKey()))
+	_, err = controllerService.UploadData("doc2")
+	require.NoError(t, err)
+	quote, riskScore = h.attest(t, "doc2", "cid2", big.NewInt(1), "slightly high risk")
+	err = controllerService.Confirm("doc2", "cid2", quote, big.NewInt(1), riskScore)
+	require.ErrorContains(t, err, "Proof is not signed by a TEE signer")
+}
+
+func TestConfirmReceipt(t *testing.T) {
+	h := newHarness(t, 1)
+	controllerService := h.controllerService(t, h.users[0])
+
+	// Score the owner's encrypted gene data in the TEE
+	owner, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	var encryptedData bytes.Buffer
+	wrappedKeys, err := h.tee.EncryptGeneDataWithDataKey(&encryptedData, strings.NewReader("low risk"),
+		[][]byte{crypto.FromECDSAPub(&owner.PublicKey), h.tee.EnclavePublicKey()}, nil)
+	require.NoError(t, err)
+	result, receipt, err := h.tee.ScoreEncryptedGeneData(context.Background(), encryptedData.Bytes(), wrappedKeys, "", "")
+	require.NoError(t, err)
+
+	_, err = controllerService.UploadData(receipt.FileID)
+	require.NoError(t, err)
+	nonce, err := controllerService.ConfirmNonce(receipt.FileID, "cid", big.NewInt(0))
+	require.NoError(t, err)
+
+	// The quote must be for the receipt's data and risk level
+	mismatched := result
+	mismatched.RiskLevel = tee.RiskLevelHigh
+	other, err := h.tee.Attest(mismatched, nonce)
+	require.NoError(t, err)
+	require.ErrorContains(t, controllerService.ConfirmReceipt(receipt, "cid", other, big.NewInt(0)), "does not match the receipt")
+
+	quote, err := h.tee.Attest(result, nonce)
+	require.NoError(t, err)
+	require.NoError(t, controllerService.ConfirmReceipt(receipt, "cid", quote, big.NewInt(0)))
+	contentID, err := controllerService.AnchoredContentID(receipt.FileID)
+	require.NoError(t, err)
+	require.Equal(t, "cid", contentID)
+}
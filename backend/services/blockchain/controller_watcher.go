@@ -0,0 +1,292 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/contracts"
+)
+
+const (
+	defaultWatcherPollInterval = 5 * time.Second
+	defaultWatcherBatchSize    = 1000
+)
+
+// UploadDataEvent is an UploadData event of the Controller, with the log it was emitted in.
+type UploadDataEvent struct {
+	DocID       string
+	SessionID   *big.Int
+	BlockNumber uint64
+	BlockHash   common.Hash
+	TxHash      common.Hash
+	LogIndex    uint
+}
+
+// ControllerWatcherConfig configures a ControllerWatcher.
+type ControllerWatcherConfig struct {
+	// StartBlock is the first block to scan when the checkpoint store has no checkpoint yet.
+	StartBlock uint64
+	// PollInterval is how often new blocks are scanned when the node does not support
+	// subscriptions, and how long to wait before retrying after an error. Defaults to 5s.
+	PollInterval time.Duration
+	// BatchSize is the number of blocks scanned per log query. Defaults to 1000.
+	BatchSize uint64
+	// Checkpoint persists the last processed block. Defaults to an in-memory store.
+	Checkpoint CheckpointStore
+	// OnError is called with the errors the watcher recovers from, such as failures of the node
+	// that are retried after the poll interval, and with rpc.ErrNotificationsUnsupported when it
+	// falls back to polling. Errors are ignored if it is nil.
+	OnError func(error)
+}
+
+// eventKey identifies a log on the chain.
+type eventKey struct {
+	txHash   common.Hash
+	logIndex uint
+}
+
+// ControllerWatcher watches the Controller for UploadData events and delivers each of them to
+// the subscribed channels. It subscribes to new events when the node supports it, e.g. over
+// websockets, and otherwise polls for them in block ranges, e.g. over HTTP.
+//
+// Events are delivered in chain order and deduplicated by transaction hash and log index while
+// the watcher runs. The last processed block is checkpointed, so after a restart the watcher
+// resumes from there; events of a block that was only partly processed may then be delivered
+// again.
+type ControllerWatcher struct {
+	client     Backend
+	address    common.Address
+	controller *contracts.Controller
+	config     ControllerWatcherConfig
+	feed       event.Feed
+
+	mu      sync.Mutex
+	next    uint64 // Next block to scan.
+	polling bool   // Whether the node does not support subscriptions.
+	seen    map[eventKey]uint64
+}
+
+// NewControllerWatcher initializes a ControllerWatcher for the Controller contract deployed at
+// the given address.
+func NewControllerWatcher(client Backend, address common.Address, config ControllerWatcherConfig) (*ControllerWatcher, error) {
+	controller, err := contracts.NewController(address, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate Controller contract: %w", err)
+	}
+
+	// Apply the defaults
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultWatcherPollInterval
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = defaultWatcherBatchSize
+	}
+	if config.Checkpoint == nil {
+		config.Checkpoint = NewMemoryCheckpointStore()
+	}
+	if config.OnError == nil {
+		config.OnError = func(error) {}
+	}
+
+	return &ControllerWatcher{
+		client:     client,
+		address:    address,
+		controller: controller,
+		config:     config,
+		seen:       make(map[eventKey]uint64),
+	}, nil
+}
+
+// Subscribe registers a channel to receive the watched events. The watcher blocks until every
+// subscribed channel has received an event, so the channels should be buffered or drained
+// promptly.
+func (w *ControllerWatcher) Subscribe(sink chan<- UploadDataEvent) event.Subscription {
+	return w.feed.Subscribe(sink)
+}
+
+// Run watches for events until the context is cancelled, and then returns the context's error.
+// Failures of the node are retried after the poll interval.
+func (w *ControllerWatcher) Run(ctx context.Context) error {
+	// Resume after the checkpoint
+	checkpoint, ok, err := w.config.Checkpoint.LoadCheckpoint()
+	if err != nil {
+		return fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+	w.mu.Lock()
+	w.next = w.config.StartBlock
+	if ok {
+		w.next = checkpoint + 1
+	}
+	w.mu.Unlock()
+
+	for {
+		if err := w.watch(ctx); err != nil && ctx.Err() == nil {
+			w.config.OnError(err)
+		}
+
+		// Wait before scanning again
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(w.config.PollInterval):
+		}
+	}
+}
+
+// watch subscribes to new events, catches up with the events emitted since the last processed
+// block and then delivers the subscribed events until the subscription fails. If the node does
+// not support subscriptions it only catches up.
+func (w *ControllerWatcher) watch(ctx context.Context) error {
+	// Subscribe first, so that no event is missed between catching up and subscribing
+	var sub event.Subscription
+	logs := make(chan *contracts.ControllerUploadData)
+	if !w.polling {
+		var err error
+		sub, err = w.controller.WatchUploadData(&bind.WatchOpts{Context: ctx}, logs)
+		if errors.Is(err, rpc.ErrNotificationsUnsupported) {
+			w.config.OnError(fmt.Errorf("falling back to polling: %w", err))
+			w.polling = true
+		} else if err != nil {
+			return fmt.Errorf("failed to subscribe to UploadData events: %w", err)
+		} else {
+			defer sub.Unsubscribe()
+		}
+	}
+
+	if err := w.catchUp(ctx); err != nil {
+		return err
+	}
+	if sub == nil {
+		return nil
+	}
+
+	// Deliver the subscribed events
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-sub.Err():
+			return fmt.Errorf("UploadData subscription failed: %w", err)
+		case log := <-logs:
+			// Logs removed by a reorg have already been delivered
+			if log.Raw.Removed {
+				continue
+			}
+			w.deliver(log)
+
+			// Subscribed events arrive in order, so the blocks before this one are processed and
+			// their events are not delivered again
+			w.mu.Lock()
+			w.prune(log.Raw.BlockNumber)
+			w.mu.Unlock()
+			if err := w.advance(log.Raw.BlockNumber); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// catchUp delivers the events from the next block to scan up to the latest block, in ranges of
+// the batch size.
+func (w *ControllerWatcher) catchUp(ctx context.Context) error {
+	header, err := w.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get the latest block: %w", err)
+	}
+	head := header.Number.Uint64()
+
+	// Events of the blocks before the next one are neither scanned nor subscribed again
+	w.mu.Lock()
+	w.prune(w.next)
+	w.mu.Unlock()
+
+	for {
+		w.mu.Lock()
+		from := w.next
+		w.mu.Unlock()
+		if from > head {
+			return nil
+		}
+		to := min(from+w.config.BatchSize-1, head)
+
+		iter, err := w.controller.FilterUploadData(&bind.FilterOpts{Start: from, End: &to, Context: ctx})
+		if err != nil {
+			return fmt.Errorf("failed to filter UploadData events: %w", err)
+		}
+		for iter.Next() {
+			w.deliver(iter.Event)
+		}
+		err = iter.Error()
+		iter.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read UploadData events: %w", err)
+		}
+
+		// The whole range has been processed
+		if err := w.advance(to + 1); err != nil {
+			return err
+		}
+	}
+}
+
+// deliver sends the event to the subscribers unless it was already delivered.
+func (w *ControllerWatcher) deliver(log *contracts.ControllerUploadData) {
+	// Events of another contract are not the Controller's
+	if log.Raw.Address != w.address {
+		return
+	}
+	key := eventKey{txHash: log.Raw.TxHash, logIndex: log.Raw.Index}
+	block := log.Raw.BlockNumber
+
+	w.mu.Lock()
+	_, seen := w.seen[key]
+	if !seen {
+		w.seen[key] = block
+	}
+	w.mu.Unlock()
+
+	if !seen {
+		w.feed.Send(UploadDataEvent{
+			DocID:       log.DocId,
+			SessionID:   log.SessionId,
+			BlockNumber: block,
+			BlockHash:   log.Raw.BlockHash,
+			TxHash:      log.Raw.TxHash,
+			LogIndex:    log.Raw.Index,
+		})
+	}
+}
+
+// advance moves the next block to scan forward and checkpoints the blocks before it.
+func (w *ControllerWatcher) advance(next uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if next <= w.next {
+		return nil
+	}
+	w.next = next
+
+	if err := w.config.Checkpoint.SaveCheckpoint(next - 1); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// prune forgets the delivered events of the blocks before the given block, which are not
+// delivered again while the watcher runs.
+func (w *ControllerWatcher) prune(before uint64) {
+	for key, block := range w.seen {
+		if block < before {
+			delete(w.seen, key)
+		}
+	}
+}
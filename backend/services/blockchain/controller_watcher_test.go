@@ -0,0 +1,216 @@
+package blockchain_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/blockchain"
+)
+
+// pollingClient is a client of a node without subscriptions, like a node served over HTTP.
+type pollingClient struct {
+	autoMiningClient
+}
+
+func (c pollingClient) SubscribeFilterLogs(context.Context, ethereum.FilterQuery, chan<- types.Log) (ethereum.Subscription, error) {
+	return nil, rpc.ErrNotificationsUnsupported
+}
+
+// duplicatingClient is a client of a node without subscriptions that returns every log twice.
+type duplicatingClient struct {
+	pollingClient
+}
+
+func (c duplicatingClient) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	logs, err := c.pollingClient.FilterLogs(ctx, query)
+	return append(logs, logs...), err
+}
+
+// errNodeUnavailable is the error of failingClient.
+var errNodeUnavailable = errors.New("node unavailable")
+
+// failingClient is a client of a node without subscriptions whose log queries fail.
+type failingClient struct {
+	pollingClient
+}
+
+func (c failingClient) FilterLogs(context.Context, ethereum.FilterQuery) ([]types.Log, error) {
+	return nil, errNodeUnavailable
+}
+
+// startWatcher runs the watcher until the end of the test and returns a channel receiving its
+// events.
+func startWatcher(t *testing.T, watcher *blockchain.ControllerWatcher) <-chan blockchain.UploadDataEvent {
+	events := make(chan blockchain.UploadDataEvent, 16)
+	sub := watcher.Subscribe(events)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- watcher.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+		sub.Unsubscribe()
+	})
+	return events
+}
+
+// receive returns the next event of the channel.
+func receive(t *testing.T, events <-chan blockchain.UploadDataEvent) blockchain.UploadDataEvent {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no event received")
+		return blockchain.UploadDataEvent{}
+	}
+}
+
+// requireNoEvent asserts that the channel receives no more events.
+func requireNoEvent(t *testing.T, events <-chan blockchain.UploadDataEvent) {
+	select {
+	case event := <-events:
+		require.FailNow(t, "unexpected event", "doc %s", event.DocID)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+// requireUpload asserts that the event is the UploadData event of the upload.
+func requireUpload(t *testing.T, h *harness, upload *blockchain.UploadResult, docId string, event blockchain.UploadDataEvent) {
+	require.Equal(t, docId, event.DocID)
+	require.Equal(t, upload.SessionID.Int64(), event.SessionID.Int64())
+	require.Equal(t, upload.TxHash, event.TxHash)
+	require.Equal(t, upload.BlockNumber, event.BlockNumber)
+	header, err := h.client.HeaderByNumber(context.Background(), new(big.Int).SetUint64(event.BlockNumber))
+	require.NoError(t, err)
+	require.Equal(t, header.Hash(), event.BlockHash)
+}
+
+func TestControllerWatcher(t *testing.T) {
+	for name, client := range map[string]func(h *harness) blockchain.Backend{
+		"subscription": func(h *harness) blockchain.Backend { return h.client },
+		"polling":      func(h *harness) blockchain.Backend { return pollingClient{h.client} },
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := newHarness(t, 1)
+			controllerService := h.controllerService(t, h.users[0])
+			checkpoint := blockchain.NewMemoryCheckpointStore()
+			watcher, err := blockchain.NewControllerWatcher(client(h), h.controllerAddress, blockchain.ControllerWatcherConfig{
+				PollInterval: 10 * time.Millisecond,
+				BatchSize:    2,
+				Checkpoint:   checkpoint,
+			})
+			require.NoError(t, err)
+
+			// Earlier events are caught up with and every subscriber receives each event
+			upload1, err := controllerService.UploadData("doc1")
+			require.NoError(t, err)
+			other := make(chan blockchain.UploadDataEvent, 16)
+			sub := watcher.Subscribe(other)
+			defer sub.Unsubscribe()
+			events := startWatcher(t, watcher)
+			requireUpload(t, h, upload1, "doc1", receive(t, events))
+			requireUpload(t, h, upload1, "doc1", receive(t, other))
+
+			// New events are delivered as they are mined
+			upload2, err := controllerService.UploadData("doc2")
+			require.NoError(t, err)
+			requireUpload(t, h, upload2, "doc2", receive(t, events))
+			requireUpload(t, h, upload2, "doc2", receive(t, other))
+			requireNoEvent(t, events)
+
+			// The blocks before the last event are checkpointed
+			block, ok, err := checkpoint.LoadCheckpoint()
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.GreaterOrEqual(t, block, upload2.BlockNumber-1)
+		})
+	}
+}
+
+func TestControllerWatcherReportsErrors(t *testing.T) {
+	h := newHarness(t, 1)
+	errs := make(chan error, 16)
+	watcher, err := blockchain.NewControllerWatcher(failingClient{pollingClient{h.client}}, h.controllerAddress, blockchain.ControllerWatcherConfig{
+		PollInterval: 10 * time.Millisecond,
+		OnError: func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		},
+	})
+	require.NoError(t, err)
+	startWatcher(t, watcher)
+
+	// The fallback to polling and the failures of the node are reported
+	require.ErrorIs(t, <-errs, rpc.ErrNotificationsUnsupported)
+	require.ErrorIs(t, <-errs, errNodeUnavailable)
+	require.ErrorIs(t, <-errs, errNodeUnavailable)
+}
+
+func TestControllerWatcherResumesFromCheckpoint(t *testing.T) {
+	h := newHarness(t, 1)
+	controllerService := h.controllerService(t, h.users[0])
+	checkpoint, err := blockchain.NewFileCheckpointStore(filepath.Join(t.TempDir(), "checkpoint"))
+	require.NoError(t, err)
+	config := blockchain.ControllerWatcherConfig{PollInterval: 10 * time.Millisecond, Checkpoint: checkpoint}
+
+	// Watch the first uploads until the watcher is stopped
+	upload1, err := controllerService.UploadData("doc1")
+	require.NoError(t, err)
+	upload2, err := controllerService.UploadData("doc2")
+	require.NoError(t, err)
+	watcher, err := blockchain.NewControllerWatcher(h.client, h.controllerAddress, config)
+	require.NoError(t, err)
+	events := make(chan blockchain.UploadDataEvent, 16)
+	sub := watcher.Subscribe(events)
+	defer sub.Unsubscribe()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- watcher.Run(ctx) }()
+	requireUpload(t, h, upload1, "doc1", receive(t, events))
+	requireUpload(t, h, upload2, "doc2", receive(t, events))
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+	block, ok, err := checkpoint.LoadCheckpoint()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, upload2.BlockNumber, block)
+
+	// A new watcher resumes after the checkpoint
+	upload3, err := controllerService.UploadData("doc3")
+	require.NoError(t, err)
+	watcher, err = blockchain.NewControllerWatcher(h.client, h.controllerAddress, config)
+	require.NoError(t, err)
+	resumed := startWatcher(t, watcher)
+	requireUpload(t, h, upload3, "doc3", receive(t, resumed))
+	requireNoEvent(t, resumed)
+}
+
+func TestControllerWatcherDeduplicatesEvents(t *testing.T) {
+	h := newHarness(t, 1)
+	controllerService := h.controllerService(t, h.users[0])
+	watcher, err := blockchain.NewControllerWatcher(duplicatingClient{pollingClient{h.client}}, h.controllerAddress, blockchain.ControllerWatcherConfig{
+		PollInterval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	// Every log is returned twice but delivered once
+	upload1, err := controllerService.UploadData("doc1")
+	require.NoError(t, err)
+	events := startWatcher(t, watcher)
+	requireUpload(t, h, upload1, "doc1", receive(t, events))
+	upload2, err := controllerService.UploadData("doc2")
+	require.NoError(t, err)
+	requireUpload(t, h, upload2, "doc2", receive(t, events))
+	requireNoEvent(t, events)
+}
@@ -0,0 +1,272 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	// gasPriceOracleABI is the L1 fee function of the OP Stack GasPriceOracle predeploy.
+	gasPriceOracleABI = `[{
+		"inputs":[{"internalType":"bytes","name":"_data","type":"bytes"}],
+		"name":"getL1Fee",
+		"outputs":[{"internalType":"uint256","name":"","type":"uint256"}],
+		"stateMutability":"view",
+		"type":"function"
+	}]`
+
+	defaultBaseFeeMultiplier = 200
+	defaultBumpPercent       = 15
+	minBumpPercent           = 10 // Nodes reject replacements bumping the fees less.
+	defaultMaxReplacements   = 3
+	defaultReplaceAfter      = time.Minute
+	defaultWaitTimeout       = 10 * time.Minute
+	defaultTxPollInterval    = time.Second
+)
+
+// GasPriceOracleAddress is the address of the GasPriceOracle predeploy of OP Stack chains.
+var GasPriceOracleAddress = common.HexToAddress("0x420000000000000000000000000000000000000F")
+
+// Errors returned when a transaction cannot be priced within a FeePolicy.
+var (
+	ErrNoBaseFee        = errors.New("network does not support EIP-1559 fees")
+	ErrFeeCapExceeded   = errors.New("fees exceed the fee policy's caps")
+	ErrL1FeeCapExceeded = errors.New("L1 data fee exceeds the fee policy's cap")
+)
+
+// FeePolicy is how a TxManager prices the transactions of a network and replaces the ones that
+// are not mined in time. Nil caps are unlimited and zero values take the defaults.
+type FeePolicy struct {
+	// BaseFeeMultiplier is the percentage of the latest base fee the fee cap covers, on top of
+	// the tip, so that transactions stay includable while the base fee rises. Defaults to 200.
+	BaseFeeMultiplier uint64
+	// TipCap is the priority fee. Defaults to the node's suggestion.
+	TipCap *big.Int
+	// MinTipCap and MaxTipCap bound the priority fee.
+	MinTipCap *big.Int
+	MaxTipCap *big.Int
+	// MaxFeeCap bounds the fee cap (max fee per gas), also of replacements.
+	MaxFeeCap *big.Int
+
+	// OPStack enables the L1 data fee of OP Stack chains, read from the GasPriceOracle.
+	OPStack bool
+	// GasPriceOracle is the address of the GasPriceOracle. Defaults to the predeploy.
+	GasPriceOracle common.Address
+	// MaxL1Fee bounds the L1 data fee of a transaction.
+	MaxL1Fee *big.Int
+
+	// ReplaceAfter is how long a transaction may stay pending before it is replaced with bumped
+	// fees. Defaults to a minute.
+	ReplaceAfter time.Duration
+	// BumpPercent is how much a replacement raises the fees, at least 10. Defaults to 15.
+	BumpPercent uint64
+	// MaxReplacements is how many times a transaction is replaced. Defaults to 3; negative
+	// disables replacements.
+	MaxReplacements int
+	// WaitTimeout is how long to wait for a transaction to be mined. Defaults to 10 minutes.
+	WaitTimeout time.Duration
+	// PollInterval is how often receipts are polled while waiting. Defaults to a second.
+	PollInterval time.Duration
+}
+
+// FeePolicyForChain returns the fee policy of a known network, or the default policy.
+func FeePolicyForChain(chainID *big.Int) FeePolicy {
+	gwei := func(n int64) *big.Int { return new(big.Int).Mul(big.NewInt(n), big.NewInt(params.GWei)) }
+
+	switch chainID.Uint64() {
+	case 1, 11155111: // Ethereum mainnet and Sepolia
+		return FeePolicy{
+			MaxTipCap:    gwei(5),
+			MaxFeeCap:    gwei(300),
+			ReplaceAfter: 3 * time.Minute,
+			WaitTimeout:  15 * time.Minute,
+		}
+	case 10, 11155420: // OP mainnet and OP Sepolia
+		// Blocks are 2s apart and L2 fees are low, so the L1 data fee dominates the cost
+		return FeePolicy{
+			MinTipCap:    big.NewInt(1000),
+			MaxTipCap:    gwei(1),
+			MaxFeeCap:    gwei(10),
+			OPStack:      true,
+			ReplaceAfter: 30 * time.Second,
+			WaitTimeout:  5 * time.Minute,
+		}
+	}
+	return FeePolicy{}
+}
+
+// withDefaults returns the policy with its zero values set to the defaults.
+func (p FeePolicy) withDefaults() FeePolicy {
+	if p.BaseFeeMultiplier == 0 {
+		p.BaseFeeMultiplier = defaultBaseFeeMultiplier
+	}
+	if p.OPStack && p.GasPriceOracle == (common.Address{}) {
+		p.GasPriceOracle = GasPriceOracleAddress
+	}
+	if p.ReplaceAfter <= 0 {
+		p.ReplaceAfter = defaultReplaceAfter
+	}
+	if p.BumpPercent == 0 {
+		p.BumpPercent = defaultBumpPercent
+	}
+	p.BumpPercent = max(p.BumpPercent, minBumpPercent)
+	if p.MaxReplacements == 0 {
+		p.MaxReplacements = defaultMaxReplacements
+	}
+	if p.WaitTimeout <= 0 {
+		p.WaitTimeout = defaultWaitTimeout
+	}
+	if p.PollInterval <= 0 {
+		p.PollInterval = defaultTxPollInterval
+	}
+	return p
+}
+
+// FeeOracle prices EIP-1559 transactions following a FeePolicy.
+type FeeOracle struct {
+	client    Backend
+	policy    FeePolicy
+	oracleABI abi.ABI
+}
+
+// NewFeeOracle initializes a FeeOracle for the network of the client.
+func NewFeeOracle(client Backend, policy FeePolicy) (*FeeOracle, error) {
+	oracleABI, err := abi.JSON(strings.NewReader(gasPriceOracleABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ABI: %w", err)
+	}
+
+	return &FeeOracle{
+		client:    client,
+		policy:    policy.withDefaults(),
+		oracleABI: oracleABI,
+	}, nil
+}
+
+// Policy returns the fee policy of the oracle, with its defaults.
+func (o *FeeOracle) Policy() FeePolicy {
+	return o.policy
+}
+
+// Fees returns the tip and fee cap to price a new transaction with: the tip suggested by the
+// node or set by the policy, and the latest base fee times the multiplier plus the tip, within
+// the policy's caps. It returns ErrFeeCapExceeded if the fee cap does not cover the base fee.
+func (o *FeeOracle) Fees(ctx context.Context) (*big.Int, *big.Int, error) {
+	header, err := o.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get the latest block: %w", err)
+	}
+	if header.BaseFee == nil {
+		return nil, nil, ErrNoBaseFee
+	}
+
+	// Choose the tip within its bounds
+	tipCap := o.policy.TipCap
+	if tipCap == nil {
+		tipCap, err = o.client.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to suggest gas tip cap: %w", err)
+		}
+	}
+	tipCap = new(big.Int).Set(tipCap)
+	if o.policy.MinTipCap != nil && tipCap.Cmp(o.policy.MinTipCap) < 0 {
+		tipCap.Set(o.policy.MinTipCap)
+	}
+	if o.policy.MaxTipCap != nil && tipCap.Cmp(o.policy.MaxTipCap) > 0 {
+		tipCap.Set(o.policy.MaxTipCap)
+	}
+
+	// Cover a rising base fee, up to the cap
+	feeCap := new(big.Int).Mul(header.BaseFee, new(big.Int).SetUint64(o.policy.BaseFeeMultiplier))
+	feeCap.Div(feeCap, big.NewInt(100))
+	feeCap.Add(feeCap, tipCap)
+	if o.policy.MaxFeeCap != nil && feeCap.Cmp(o.policy.MaxFeeCap) > 0 {
+		feeCap.Set(o.policy.MaxFeeCap)
+	}
+	if feeCap.Cmp(header.BaseFee) < 0 {
+		return nil, nil, fmt.Errorf("%w: base fee %s is above the fee cap %s", ErrFeeCapExceeded, header.BaseFee, feeCap)
+	}
+	if tipCap.Cmp(feeCap) > 0 {
+		tipCap.Set(feeCap)
+	}
+	return tipCap, feeCap, nil
+}
+
+// Bump returns the fees to replace a transaction priced with the tip and fee cap: both raised
+// by the policy's bump percentage, and at least the fees of a new transaction. It returns
+// ErrFeeCapExceeded if the bumped fees exceed the policy's caps.
+func (o *FeeOracle) Bump(ctx context.Context, tipCap, feeCap *big.Int) (*big.Int, *big.Int, error) {
+	newTipCap, newFeeCap := bump(tipCap, o.policy.BumpPercent), bump(feeCap, o.policy.BumpPercent)
+
+	// Follow the market if it moved further
+	currentTipCap, currentFeeCap, err := o.Fees(ctx)
+	if err != nil && !errors.Is(err, ErrFeeCapExceeded) {
+		return nil, nil, err
+	}
+	if err == nil {
+		newTipCap, newFeeCap = bigMax(newTipCap, currentTipCap), bigMax(newFeeCap, currentFeeCap)
+	}
+
+	if o.policy.MaxTipCap != nil && newTipCap.Cmp(o.policy.MaxTipCap) > 0 ||
+		o.policy.MaxFeeCap != nil && newFeeCap.Cmp(o.policy.MaxFeeCap) > 0 {
+		return nil, nil, fmt.Errorf("%w: replacement needs tip %s and fee cap %s", ErrFeeCapExceeded, newTipCap, newFeeCap)
+	}
+	return newTipCap, newFeeCap, nil
+}
+
+// L1Fee returns the L1 data fee of a signed transaction on OP Stack chains, or nil on other
+// networks. It returns ErrL1FeeCapExceeded if the fee exceeds the policy's cap.
+func (o *FeeOracle) L1Fee(ctx context.Context, tx *types.Transaction) (*big.Int, error) {
+	if !o.policy.OPStack {
+		return nil, nil
+	}
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode transaction: %w", err)
+	}
+
+	// Ask the GasPriceOracle what posting the transaction to L1 costs
+	input, err := o.oracleABI.Pack("getL1Fee", data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack L1 fee call: %w", err)
+	}
+	output, err := o.client.CallContract(ctx, ethereum.CallMsg{To: &o.policy.GasPriceOracle, Data: input}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get L1 fee: %w", err)
+	}
+	results, err := o.oracleABI.Unpack("getL1Fee", output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack L1 fee: %w", err)
+	}
+	l1Fee := results[0].(*big.Int)
+
+	if o.policy.MaxL1Fee != nil && l1Fee.Cmp(o.policy.MaxL1Fee) > 0 {
+		return nil, fmt.Errorf("%w: %s is above %s", ErrL1FeeCapExceeded, l1Fee, o.policy.MaxL1Fee)
+	}
+	return l1Fee, nil
+}
+
+// bump raises the fee by the percentage, rounding up.
+func bump(fee *big.Int, percent uint64) *big.Int {
+	bumped := new(big.Int).Mul(fee, new(big.Int).SetUint64(100+percent))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+// bigMax returns the larger of the two numbers.
+func bigMax(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,137 @@
+package blockchain_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/blockchain"
+)
+
+// gasPriceOracleCode is the code of a GasPriceOracle whose getL1Fee returns the fee for any data.
+func gasPriceOracleCode(l1Fee *big.Int) []byte {
+	// PUSH32 fee, PUSH1 0, MSTORE, PUSH1 32, PUSH1 0, RETURN
+	code := append([]byte{0x7f}, common.BigToHash(l1Fee).Bytes()...)
+	return append(code, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3)
+}
+
+// newOPStackClient returns a client of a simulated chain with a GasPriceOracle predeploy
+// charging the L1 fee, and a funded account.
+func newOPStackClient(t *testing.T, l1Fee *big.Int) (simulated.Client, *bind.TransactOpts) {
+	alloc := types.GenesisAlloc{blockchain.GasPriceOracleAddress: {Code: gasPriceOracleCode(l1Fee), Balance: big.NewInt(0)}}
+	opts := newTransactor(t, alloc)
+	backend := simulated.NewBackend(alloc)
+	t.Cleanup(func() { backend.Close() })
+
+	// Receipts are only served once the node has indexed a block past genesis
+	backend.Commit()
+	client := backend.Client()
+	require.Eventually(t, func() bool {
+		_, err := client.TransactionReceipt(context.Background(), common.Hash{})
+		return errors.Is(err, ethereum.NotFound)
+	}, 5*time.Second, 10*time.Millisecond)
+	return client, opts
+}
+
+func TestFeeOracleFees(t *testing.T) {
+	h := newHarness(t, 0)
+	header, err := h.client.HeaderByNumber(context.Background(), nil)
+	require.NoError(t, err)
+	baseFee := header.BaseFee
+
+	// The fee cap covers twice the base fee on top of the tip
+	oracle, err := blockchain.NewFeeOracle(h.client, blockchain.FeePolicy{TipCap: big.NewInt(params.GWei)})
+	require.NoError(t, err)
+	tipCap, feeCap, err := oracle.Fees(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(params.GWei), tipCap)
+	require.Equal(t, new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tipCap), feeCap)
+
+	// The tip and the fee cap stay within their caps
+	oracle, err = blockchain.NewFeeOracle(h.client, blockchain.FeePolicy{
+		TipCap:            big.NewInt(params.GWei),
+		MaxTipCap:         big.NewInt(100),
+		BaseFeeMultiplier: 1000,
+		MaxFeeCap:         new(big.Int).Add(baseFee, big.NewInt(50)),
+	})
+	require.NoError(t, err)
+	tipCap, feeCap, err = oracle.Fees(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(100), tipCap)
+	require.Equal(t, new(big.Int).Add(baseFee, big.NewInt(50)), feeCap)
+
+	// A fee cap below the base fee cannot price transactions
+	oracle, err = blockchain.NewFeeOracle(h.client, blockchain.FeePolicy{MaxFeeCap: big.NewInt(1)})
+	require.NoError(t, err)
+	_, _, err = oracle.Fees(context.Background())
+	require.ErrorIs(t, err, blockchain.ErrFeeCapExceeded)
+}
+
+func TestFeeOracleBump(t *testing.T) {
+	h := newHarness(t, 0)
+	oracle, err := blockchain.NewFeeOracle(h.client, blockchain.FeePolicy{
+		TipCap:    big.NewInt(1),
+		MaxFeeCap: big.NewInt(100 * params.GWei),
+	})
+	require.NoError(t, err)
+
+	// Replacements raise both fees by 15%, rounding up
+	tipCap, feeCap, err := oracle.Bump(context.Background(), big.NewInt(10*params.GWei), big.NewInt(50*params.GWei))
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(11_500_000_000), tipCap)
+	require.Equal(t, big.NewInt(57_500_000_000), feeCap)
+	tipCap, _, err = oracle.Bump(context.Background(), big.NewInt(1), big.NewInt(50*params.GWei))
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(2), tipCap)
+
+	// ... but not beyond the fee cap
+	_, _, err = oracle.Bump(context.Background(), big.NewInt(10*params.GWei), big.NewInt(90*params.GWei))
+	require.ErrorIs(t, err, blockchain.ErrFeeCapExceeded)
+}
+
+func TestFeeOracleL1Fee(t *testing.T) {
+	l1Fee := big.NewInt(123456789)
+	client, opts := newOPStackClient(t, l1Fee)
+	to := common.HexToAddress("0x01")
+	tx, err := opts.Signer(opts.From, types.NewTx(&types.DynamicFeeTx{ChainID: big.NewInt(1337), To: &to, Gas: params.TxGas}))
+	require.NoError(t, err)
+
+	// Only OP Stack chains have an L1 data fee
+	oracle, err := blockchain.NewFeeOracle(client, blockchain.FeePolicy{})
+	require.NoError(t, err)
+	fee, err := oracle.L1Fee(context.Background(), tx)
+	require.NoError(t, err)
+	require.Nil(t, fee)
+
+	oracle, err = blockchain.NewFeeOracle(client, blockchain.FeePolicy{OPStack: true})
+	require.NoError(t, err)
+	fee, err = oracle.L1Fee(context.Background(), tx)
+	require.NoError(t, err)
+	require.Equal(t, l1Fee, fee)
+
+	oracle, err = blockchain.NewFeeOracle(client, blockchain.FeePolicy{OPStack: true, MaxL1Fee: big.NewInt(1000)})
+	require.NoError(t, err)
+	_, err = oracle.L1Fee(context.Background(), tx)
+	require.ErrorIs(t, err, blockchain.ErrL1FeeCapExceeded)
+}
+
+func TestFeePolicyForChain(t *testing.T) {
+	// OP Stack networks are L1 fee aware
+	require.True(t, blockchain.FeePolicyForChain(big.NewInt(11155420)).OPStack)
+	require.True(t, blockchain.FeePolicyForChain(big.NewInt(10)).OPStack)
+	require.False(t, blockchain.FeePolicyForChain(big.NewInt(1)).OPStack)
+	require.NotNil(t, blockchain.FeePolicyForChain(big.NewInt(1)).MaxFeeCap)
+
+	// Unknown networks use the defaults
+	require.Equal(t, blockchain.FeePolicy{}, blockchain.FeePolicyForChain(big.NewInt(1337)))
+}
@@ -0,0 +1,117 @@
+package blockchain_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/contracts"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/attestation"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/blockchain"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/tee"
+)
+
+// autoMiningClient commits a block after every transaction so that waiting for it to be mined
+// returns right away.
+type autoMiningClient struct {
+	simulated.Client
+	backend *simulated.Backend
+}
+
+func (c autoMiningClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := c.Client.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	c.backend.Commit()
+	return nil
+}
+
+// harness is the GeneNFT, PCSP and Controller contracts deployed on a simulated chain as on the
+// real network: the Controller owns both tokens and a TEE is registered as its proof signer.
+type harness struct {
+	backend           *simulated.Backend
+	client            autoMiningClient
+	chainID           *big.Int
+	owner             *bind.TransactOpts // Deployer of the contracts.
+	users             []*bind.TransactOpts
+	controllerAddress common.Address
+	pcspAddress       common.Address
+	geneNFT           *contracts.GeneNFT
+	pcsp              *contracts.PCSP
+	tee               *tee.TEEService
+}
+
+// newHarness deploys the contracts on a new simulated chain with a funded owner and users.
+func newHarness(t *testing.T, users int) *harness {
+	h := &harness{tee: tee.NewTEEService()}
+
+	// Fund the owner and the users in the genesis block
+	alloc := types.GenesisAlloc{}
+	h.owner = newTransactor(t, alloc)
+	for range users {
+		h.users = append(h.users, newTransactor(t, alloc))
+	}
+	h.backend = simulated.NewBackend(alloc)
+	t.Cleanup(func() { h.backend.Close() })
+	h.client = autoMiningClient{Client: h.backend.Client(), backend: h.backend}
+	var err error
+	h.chainID, err = h.client.ChainID(context.Background())
+	require.NoError(t, err)
+
+	// Deploy the tokens and hand them over to the Controller
+	nftAddress, _, geneNFT, err := contracts.DeployGeneNFT(h.owner, h.client)
+	require.NoError(t, err)
+	pcspAddress, _, pcsp, err := contracts.DeployPCSP(h.owner, h.client)
+	require.NoError(t, err)
+	controllerAddress, _, controller, err := contracts.DeployController(h.owner, h.client, nftAddress, pcspAddress)
+	require.NoError(t, err)
+	_, err = geneNFT.TransferOwnership(h.owner, controllerAddress)
+	require.NoError(t, err)
+	_, err = pcsp.TransferOwnership(h.owner, controllerAddress)
+	require.NoError(t, err)
+	_, err = controller.AddTEESigner(h.owner, h.tee.AttestationKey())
+	require.NoError(t, err)
+
+	h.controllerAddress = controllerAddress
+	h.pcspAddress = pcspAddress
+	h.geneNFT = geneNFT
+	h.pcsp = pcsp
+	return h
+}
+
+// newTransactor returns transaction options for a new account funded in the genesis allocation.
+func newTransactor(t *testing.T, alloc types.GenesisAlloc) *bind.TransactOpts {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	opts, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	require.NoError(t, err)
+	alloc[opts.From] = types.Account{Balance: new(big.Int).Lsh(big.NewInt(1), 100)}
+	return opts
+}
+
+// controllerService returns a ControllerService sending transactions from the account.
+func (h *harness) controllerService(t *testing.T, opts *bind.TransactOpts) *blockchain.ControllerService {
+	controllerService, err := blockchain.NewControllerService(h.client, opts, h.controllerAddress)
+	require.NoError(t, err)
+	return controllerService
+}
+
+// attest scores the gene data in the harness TEE and returns its quote for confirming the document
+// with the content hash in the session, together with the risk score.
+func (h *harness) attest(t *testing.T, docId, contentHash string, sessionId *big.Int, geneData string) (*attestation.Quote, uint8) {
+	result, err := h.tee.ScoreGeneData(context.Background(), "", "", strings.NewReader(geneData))
+	require.NoError(t, err)
+	nonce, err := attestation.ConfirmNonce(h.chainID, h.controllerAddress, docId, contentHash, sessionId)
+	require.NoError(t, err)
+	quote, err := h.tee.Attest(result, nonce)
+	require.NoError(t, err)
+	return quote, result.RiskLevel.Score()
+}
@@ -5,19 +5,18 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 
 	"github.com/trungnt1811/blockchain-engineer-interview/backend/contracts"
 )
 
 type PCSPService struct {
-	client    *ethclient.Client
+	client    Backend
 	auth      *bind.TransactOpts
 	pcspToken *contracts.PCSP
 }
 
 // NewPCSPService initializes a new PostCovidStrokePreventionService with the given client, authentication options, and contract address.
-func NewPCSPService(client *ethclient.Client, auth *bind.TransactOpts, address common.Address) (*PCSPService, error) {
+func NewPCSPService(client Backend, auth *bind.TransactOpts, address common.Address) (*PCSPService, error) {
 	pcspToken, err := contracts.NewPCSP(address, client)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,54 @@
+package blockchain_test
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/blockchain"
+)
+
+// pcsp returns the given whole number of PCSP tokens in their smallest unit.
+func pcsp(amount int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(amount), new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
+}
+
+func TestGetBalance(t *testing.T) {
+	h := newHarness(t, 2)
+	pcspService, err := blockchain.NewPCSPService(h.client, h.users[0], h.pcspAddress)
+	require.NoError(t, err)
+
+	// The deployer holds the initial supply
+	balance, err := pcspService.GetBalance(h.owner.From)
+	require.NoError(t, err)
+	require.Equal(t, pcsp(1000000000), balance)
+	balance, err = pcspService.GetBalance(h.users[0].From)
+	require.NoError(t, err)
+	require.Zero(t, balance.Sign())
+
+	// Confirming gene data rewards the sender according to the risk score
+	for i, tc := range []struct {
+		user     int
+		geneData string
+		reward   *big.Int
+	}{
+		{user: 0, geneData: "extremely high risk", reward: pcsp(15000)},
+		{user: 1, geneData: "slightly high risk", reward: pcsp(225)},
+		{user: 0, geneData: "low risk", reward: pcsp(30)},
+	} {
+		controllerService := h.controllerService(t, h.users[tc.user])
+		docId := fmt.Sprintf("doc%d", i+1)
+		_, err := controllerService.UploadData(docId)
+		require.NoError(t, err)
+		quote, riskScore := h.attest(t, docId, "cid", big.NewInt(int64(i)), tc.geneData)
+
+		before, err := pcspService.GetBalance(h.users[tc.user].From)
+		require.NoError(t, err)
+		require.NoError(t, controllerService.Confirm(docId, "cid", quote, big.NewInt(int64(i)), riskScore))
+		after, err := pcspService.GetBalance(h.users[tc.user].From)
+		require.NoError(t, err)
+		require.Equal(t, tc.reward, new(big.Int).Sub(after, before))
+	}
+}
@@ -0,0 +1,485 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// maxTrackedTxs is how many transactions a TxManager keeps the status of. The oldest finished
+// transactions are forgotten first.
+const maxTrackedTxs = 1024
+
+// errNonceTooLow is the message nodes reject a transaction with when its nonce has been used.
+const errNonceTooLow = "nonce too low"
+
+// ErrUnknownTx is returned for transactions a TxManager did not send or no longer tracks.
+var ErrUnknownTx = errors.New("unknown transaction")
+
+// TxStatus is the status of a transaction sent by a TxManager.
+type TxStatus string
+
+const (
+	TxPending  TxStatus = "pending"  // Broadcast but not mined yet.
+	TxMined    TxStatus = "mined"    // Mined and successful.
+	TxReverted TxStatus = "reverted" // Mined but reverted.
+	TxReplaced TxStatus = "replaced" // Superseded by a transaction with the same nonce.
+)
+
+// TxInfo is the tracked state of a transaction sent by a TxManager.
+type TxInfo struct {
+	Hash        common.Hash
+	Nonce       uint64
+	Status      TxStatus
+	SentAt      time.Time
+	GasTipCap   *big.Int
+	GasFeeCap   *big.Int
+	L1Fee       *big.Int    // L1 data fee on OP Stack chains, nil elsewhere.
+	ReplacedBy  common.Hash // Replacement or cancellation, once replaced.
+	BlockNumber uint64      // Block the transaction was mined in, once mined.
+	GasUsed     uint64      // Gas used by the transaction, once mined.
+}
+
+// trackedTx is a transaction sent by a TxManager with its state.
+type trackedTx struct {
+	tx   *types.Transaction
+	info TxInfo
+}
+
+// TxBuilder builds and signs a transaction with the options, typically by calling a contract
+// binding. The options carry the nonce and the fees to use.
+type TxBuilder func(opts *bind.TransactOpts) (*types.Transaction, error)
+
+// TxManagerConfig configures a TxManager.
+type TxManagerConfig struct {
+	// FeePolicy prices and replaces the transactions, see FeePolicyForChain.
+	FeePolicy FeePolicy
+	// OnError is called with the errors WaitMined recovers from, such as failures to get a
+	// receipt or to replace a stuck transaction, which are retried. Errors are ignored if it is
+	// nil.
+	OnError func(error)
+}
+
+// TxManager sends the transactions of one account. It assigns nonces locally, so that concurrent
+// callers sharing the account do not collide on nonces, prices the transactions with a FeeOracle,
+// broadcasts them in the order they are submitted and tracks them until they are mined. After a
+// failure it resyncs the next nonce from the node's pending nonce. Transactions that are not
+// mined in time are replaced with bumped fees, and pending transactions can be cancelled.
+type TxManager struct {
+	client  Backend
+	auth    *bind.TransactOpts
+	fees    *FeeOracle
+	onError func(error)
+
+	send   sync.Mutex // Serializes submissions, so nonces are broadcast in order.
+	nonce  uint64     // Next nonce to assign.
+	synced bool       // Whether nonce is in sync with the node.
+
+	mu        sync.Mutex
+	txs       map[common.Hash]*trackedTx
+	order     []common.Hash   // Tracked transactions, oldest first.
+	replacing map[uint64]bool // Nonces a waiter is replacing the latest transaction of.
+}
+
+// NewTxManager initializes a TxManager for the account of the authentication options, with the
+// default fee policy.
+func NewTxManager(client Backend, auth *bind.TransactOpts) (*TxManager, error) {
+	return NewTxManagerWithFeePolicy(client, auth, FeePolicy{})
+}
+
+// NewTxManagerWithFeePolicy initializes a TxManager that prices and replaces transactions with
+// the fee policy of the network, see FeePolicyForChain.
+func NewTxManagerWithFeePolicy(client Backend, auth *bind.TransactOpts, policy FeePolicy) (*TxManager, error) {
+	return NewTxManagerWithConfig(client, auth, TxManagerConfig{FeePolicy: policy})
+}
+
+// NewTxManagerWithConfig initializes a TxManager with the fee policy and the error callback of
+// the config.
+func NewTxManagerWithConfig(client Backend, auth *bind.TransactOpts, config TxManagerConfig) (*TxManager, error) {
+	fees, err := NewFeeOracle(client, config.FeePolicy)
+	if err != nil {
+		return nil, err
+	}
+	if config.OnError == nil {
+		config.OnError = func(error) {}
+	}
+
+	return &TxManager{
+		client:    client,
+		auth:      auth,
+		fees:      fees,
+		onError:   config.OnError,
+		txs:       make(map[common.Hash]*trackedTx),
+		replacing: make(map[uint64]bool),
+	}, nil
+}
+
+// From returns the account the TxManager sends transactions from.
+func (m *TxManager) From() common.Address {
+	return m.auth.From
+}
+
+// Send builds a transaction with the account's next nonce and broadcasts it. Submissions are
+// queued, so transactions are broadcast in the order of their nonces. If the node rejects the
+// nonce as used, for example because the account also sent transactions elsewhere, the nonce is
+// resynced and the transaction rebuilt once. Errors of the builder, such as reverts found while
+// estimating gas, are returned as they are.
+func (m *TxManager) Send(ctx context.Context, build TxBuilder) (*types.Transaction, error) {
+	m.send.Lock()
+	defer m.send.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		tx, err := m.sendNext(ctx, build)
+		if err == nil {
+			return tx, nil
+		}
+
+		// Resync the nonce on any failure, and retry if the nonce was the problem
+		m.synced = false
+		if attempt > 0 || !strings.Contains(err.Error(), errNonceTooLow) {
+			return nil, err
+		}
+	}
+}
+
+// sendNext builds and broadcasts a transaction with the next nonce, and advances the nonce once
+// the transaction is accepted.
+func (m *TxManager) sendNext(ctx context.Context, build TxBuilder) (*types.Transaction, error) {
+	if !m.synced {
+		nonce, err := m.client.PendingNonceAt(ctx, m.auth.From)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pending nonce: %w", err)
+		}
+		m.nonce = nonce
+		m.synced = true
+	}
+
+	tipCap, feeCap, err := m.fees.Fees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build and sign the transaction without sending it
+	opts := *m.auth
+	opts.Nonce = new(big.Int).SetUint64(m.nonce)
+	opts.GasTipCap = tipCap
+	opts.GasFeeCap = feeCap
+	opts.NoSend = true
+	opts.Context = ctx
+	tx, err := build(&opts)
+	if err != nil {
+		return nil, err
+	}
+	if tx.Nonce() != m.nonce {
+		return nil, fmt.Errorf("transaction built with nonce %d instead of %d", tx.Nonce(), m.nonce)
+	}
+	l1Fee, err := m.fees.L1Fee(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.client.SendTransaction(ctx, tx); err != nil {
+		return nil, fmt.Errorf("failed to send transaction: %w", err)
+	}
+	m.nonce++
+	m.track(tx, l1Fee)
+	return tx, nil
+}
+
+// Cancel replaces a pending transaction sent by the TxManager, or its latest replacement, with an
+// empty transfer to the account itself with bumped fees, and returns the cancellation. Wait for
+// it with WaitMined; the transaction is cancelled unless it is mined first.
+func (m *TxManager) Cancel(ctx context.Context, hash common.Hash) (*types.Transaction, error) {
+	// Follow the replacements to the pending transaction
+	m.mu.Lock()
+	tracked, ok := m.txs[hash]
+	for ok && tracked.info.Status == TxReplaced {
+		tracked, ok = m.txs[tracked.info.ReplacedBy]
+	}
+	var tx *types.Transaction
+	if ok && tracked.info.Status == TxPending {
+		tx = tracked.tx
+	}
+	m.mu.Unlock()
+	if !ok {
+		return nil, ErrUnknownTx
+	}
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %s is not pending", hash.Hex())
+	}
+
+	to := m.auth.From
+	return m.replace(ctx, tx, &types.DynamicFeeTx{To: &to, Gas: params.TxGas})
+}
+
+// replace broadcasts a replacement of the transaction with the same nonce and bumped fees. The
+// replacement repeats the transaction unless a call is given.
+func (m *TxManager) replace(ctx context.Context, tx *types.Transaction, call *types.DynamicFeeTx) (*types.Transaction, error) {
+	tipCap, feeCap, err := m.fees.Bump(ctx, tx.GasTipCap(), tx.GasFeeCap())
+	if err != nil {
+		return nil, err
+	}
+	if call == nil {
+		call = &types.DynamicFeeTx{To: tx.To(), Value: tx.Value(), Gas: tx.Gas(), Data: tx.Data(), AccessList: tx.AccessList()}
+	}
+	call.ChainID = tx.ChainId()
+	call.Nonce = tx.Nonce()
+	call.GasTipCap = tipCap
+	call.GasFeeCap = feeCap
+
+	replacement, err := m.auth.Signer(m.auth.From, types.NewTx(call))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign replacement: %w", err)
+	}
+	l1Fee, err := m.fees.L1Fee(ctx, replacement)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.client.SendTransaction(ctx, replacement); err != nil {
+		return nil, fmt.Errorf("failed to send replacement: %w", err)
+	}
+
+	// Track the replacement in place of the transaction
+	m.track(replacement, l1Fee)
+	m.mu.Lock()
+	if tracked, ok := m.txs[tx.Hash()]; ok && tracked.info.Status == TxPending {
+		tracked.info.Status = TxReplaced
+		tracked.info.ReplacedBy = replacement.Hash()
+	}
+	m.mu.Unlock()
+	return replacement, nil
+}
+
+// WaitMined waits for a transaction sent by the TxManager, or one of its replacements, to be
+// mined, records its status and returns its receipt. While the transaction is pending it is
+// replaced with bumped fees every ReplaceAfter of the fee policy, up to MaxReplacements times,
+// and waiting fails after the policy's WaitTimeout. Concurrent waiters of one nonce share its
+// replacements, so only one of them bumps the fees of a stuck transaction. Failures to get a
+// receipt or to replace the transaction are reported to OnError and retried.
+func (m *TxManager) WaitMined(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
+	policy := m.fees.Policy()
+	ctx, cancel := context.WithTimeout(ctx, policy.WaitTimeout)
+	defer cancel()
+	ticker := time.NewTicker(policy.PollInterval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	replaceAt := start.Add(policy.ReplaceAfter)
+	for {
+		// Any of the transactions with the nonce may be mined, including replacements and
+		// cancellations sent meanwhile
+		candidates := m.withNonce(tx)
+		for _, candidate := range candidates {
+			receipt, err := m.client.TransactionReceipt(ctx, candidate.Hash())
+			if err == nil {
+				m.update(receipt)
+				return receipt, nil
+			}
+			if !errors.Is(err, ethereum.NotFound) && ctx.Err() == nil {
+				m.onError(fmt.Errorf("failed to get receipt of %s: %w", candidate.Hash().Hex(), err))
+			}
+		}
+
+		// Replace the latest transaction if it is stuck
+		if time.Now().After(replaceAt) {
+			m.replaceStuck(ctx, tx, start, policy)
+			replaceAt = time.Now().Add(policy.ReplaceAfter)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("transaction %s was not mined: %w", tx.Hash().Hex(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// replaceStuck replaces the latest transaction with the nonce of tx with bumped fees if it has
+// been pending for the policy's ReplaceAfter and the nonce has been replaced fewer than
+// MaxReplacements times. Only one waiter replaces a nonce at a time, and the others then see its
+// replacement as the latest transaction. An untracked tx counts as sent at since.
+func (m *TxManager) replaceStuck(ctx context.Context, tx *types.Transaction, since time.Time, policy FeePolicy) {
+	m.mu.Lock()
+	if m.replacing[tx.Nonce()] {
+		m.mu.Unlock()
+		return
+	}
+	m.replacing[tx.Nonce()] = true
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.replacing, tx.Nonce())
+		m.mu.Unlock()
+	}()
+
+	candidates := m.withNonce(tx)
+	latest := candidates[len(candidates)-1]
+	m.mu.Lock()
+	sentAt := since
+	if tracked, ok := m.txs[latest.Hash()]; ok {
+		sentAt = tracked.info.SentAt
+	}
+	m.mu.Unlock()
+	if len(candidates)-1 >= policy.MaxReplacements || time.Since(sentAt) < policy.ReplaceAfter {
+		return
+	}
+
+	if _, err := m.replace(ctx, latest, nil); err != nil {
+		m.onError(fmt.Errorf("failed to replace %s: %w", latest.Hash().Hex(), err))
+	}
+}
+
+// withNonce returns the transaction and the tracked transactions sent with its nonce, oldest
+// first.
+func (m *TxManager) withNonce(tx *types.Transaction) []*types.Transaction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var txs []*types.Transaction
+	if _, ok := m.txs[tx.Hash()]; !ok {
+		txs = append(txs, tx)
+	}
+	for _, hash := range m.order {
+		if tracked := m.txs[hash]; tracked.tx.Nonce() == tx.Nonce() {
+			txs = append(txs, tracked.tx)
+		}
+	}
+	return txs
+}
+
+// Status returns the status of a transaction sent by the TxManager, refreshed from the node
+// while it is pending: once any transaction with its nonce is mined, the others are reported as
+// replaced by it. It returns ErrUnknownTx if the transaction is not tracked.
+func (m *TxManager) Status(ctx context.Context, hash common.Hash) (TxInfo, error) {
+	m.mu.Lock()
+	tracked, ok := m.txs[hash]
+	var current TxInfo
+	if ok {
+		current = tracked.info
+	}
+	m.mu.Unlock()
+	if !ok {
+		return TxInfo{}, ErrUnknownTx
+	}
+	if current.Status != TxPending {
+		return current, nil
+	}
+
+	// Check whether the pending transaction, or another one with its nonce, has been mined since
+	for _, candidate := range m.withNonce(tracked.tx) {
+		receipt, err := m.client.TransactionReceipt(ctx, candidate.Hash())
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return TxInfo{}, fmt.Errorf("failed to get transaction receipt: %w", err)
+		}
+		m.update(receipt)
+		break
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return tracked.info, nil
+}
+
+// Transaction returns a transaction sent by the TxManager, including replacements and
+// cancellations.
+func (m *TxManager) Transaction(hash common.Hash) (*types.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tracked, ok := m.txs[hash]
+	if !ok {
+		return nil, ErrUnknownTx
+	}
+	return tracked.tx, nil
+}
+
+// Pending returns the transactions sent by the TxManager that are not mined yet, by nonce.
+func (m *TxManager) Pending(ctx context.Context) ([]TxInfo, error) {
+	m.mu.Lock()
+	var hashes []common.Hash
+	for _, hash := range m.order {
+		if m.txs[hash].info.Status == TxPending {
+			hashes = append(hashes, hash)
+		}
+	}
+	m.mu.Unlock()
+
+	pending := []TxInfo{}
+	for _, hash := range hashes {
+		info, err := m.Status(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if info.Status == TxPending {
+			pending = append(pending, info)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Nonce < pending[j].Nonce })
+	return pending, nil
+}
+
+// track starts tracking a sent transaction, and forgets the oldest finished transactions beyond
+// maxTrackedTxs.
+func (m *TxManager) track(tx *types.Transaction, l1Fee *big.Int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.txs[tx.Hash()] = &trackedTx{tx: tx, info: TxInfo{
+		Hash:      tx.Hash(),
+		Nonce:     tx.Nonce(),
+		Status:    TxPending,
+		SentAt:    time.Now(),
+		GasTipCap: tx.GasTipCap(),
+		GasFeeCap: tx.GasFeeCap(),
+		L1Fee:     l1Fee,
+	}}
+	m.order = append(m.order, tx.Hash())
+
+	for i := 0; len(m.order) > maxTrackedTxs && i < len(m.order); {
+		hash := m.order[i]
+		if m.txs[hash].info.Status == TxPending {
+			i++
+			continue
+		}
+		delete(m.txs, hash)
+		m.order = append(m.order[:i], m.order[i+1:]...)
+	}
+}
+
+// update records the receipt of a tracked transaction and returns its status. The other
+// transactions with its nonce are recorded as replaced by it.
+func (m *TxManager) update(receipt *types.Receipt) TxInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tracked, ok := m.txs[receipt.TxHash]
+	if !ok {
+		return TxInfo{}
+	}
+	info := &tracked.info
+	info.Status = TxMined
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		info.Status = TxReverted
+	}
+	info.BlockNumber = receipt.BlockNumber.Uint64()
+	info.GasUsed = receipt.GasUsed
+	info.ReplacedBy = common.Hash{}
+
+	for hash, other := range m.txs {
+		if hash != receipt.TxHash && other.info.Nonce == info.Nonce && (other.info.Status == TxPending || other.info.Status == TxReplaced) {
+			other.info.Status = TxReplaced
+			other.info.ReplacedBy = receipt.TxHash
+		}
+	}
+	return *info
+}
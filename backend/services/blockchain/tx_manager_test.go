@@ -0,0 +1,378 @@
+package blockchain_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/contracts"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/blockchain"
+)
+
+func TestTxManagerConcurrentSends(t *testing.T) {
+	h := newHarness(t, 1)
+	txManager, err := blockchain.NewTxManager(h.client, h.users[0])
+	require.NoError(t, err)
+	controllerService, err := blockchain.NewControllerServiceWithTxManager(h.client, txManager, h.controllerAddress)
+	require.NoError(t, err)
+
+	// Concurrent uploads of one account each get their own nonce
+	var wg sync.WaitGroup
+	uploads := make([]*blockchain.UploadResult, 8)
+	errs := make([]error, len(uploads))
+	for i := range uploads {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			uploads[i], errs[i] = controllerService.UploadData(fmt.Sprintf("doc%d", i))
+		}()
+	}
+	wg.Wait()
+
+	sessions := map[int64]bool{}
+	for i, upload := range uploads {
+		require.NoError(t, errs[i])
+		info, err := txManager.Status(context.Background(), upload.TxHash)
+		require.NoError(t, err)
+		require.Equal(t, blockchain.TxMined, info.Status)
+		require.Equal(t, upload.BlockNumber, info.BlockNumber)
+		sessions[upload.SessionID.Int64()] = true
+	}
+	require.Len(t, sessions, len(uploads))
+	nonce, err := h.client.NonceAt(context.Background(), h.users[0].From, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(len(uploads)), nonce)
+}
+
+func TestTxManagerStatus(t *testing.T) {
+	h := newHarness(t, 1)
+	client := h.backend.Client() // Does not mine on send.
+	controller, err := contracts.NewController(h.controllerAddress, client)
+	require.NoError(t, err)
+	txManager, err := blockchain.NewTxManager(client, h.users[0])
+	require.NoError(t, err)
+	upload := func(docId string) blockchain.TxBuilder {
+		return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return controller.UploadData(opts, docId)
+		}
+	}
+
+	// Sent transactions are pending until they are mined
+	tx1, err := txManager.Send(context.Background(), upload("doc1"))
+	require.NoError(t, err)
+	tx2, err := txManager.Send(context.Background(), upload("doc2"))
+	require.NoError(t, err)
+	require.Equal(t, tx1.Nonce()+1, tx2.Nonce())
+	pending, err := txManager.Pending(context.Background())
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	require.Equal(t, tx1.Hash(), pending[0].Hash)
+	require.Equal(t, blockchain.TxPending, pending[0].Status)
+
+	h.backend.Commit()
+	info, err := txManager.Status(context.Background(), tx1.Hash())
+	require.NoError(t, err)
+	require.Equal(t, blockchain.TxMined, info.Status)
+	require.Positive(t, info.GasUsed)
+	pending, err = txManager.Pending(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	// Mined transactions that failed are reported as reverted
+	tx3, err := txManager.Send(context.Background(), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		opts.GasLimit = 100000 // Skip estimation, which would fail.
+		return controller.RemoveTEESigner(opts, common.HexToAddress("0x01"))
+	})
+	require.NoError(t, err)
+	h.backend.Commit()
+	info, err = txManager.Status(context.Background(), tx3.Hash())
+	require.NoError(t, err)
+	require.Equal(t, blockchain.TxReverted, info.Status)
+
+	_, err = txManager.Status(context.Background(), common.HexToHash("0x01"))
+	require.ErrorIs(t, err, blockchain.ErrUnknownTx)
+}
+
+func TestTxManagerResyncsNonce(t *testing.T) {
+	h := newHarness(t, 1)
+	txManager, err := blockchain.NewTxManager(h.client, h.users[0])
+	require.NoError(t, err)
+	controllerService, err := blockchain.NewControllerServiceWithTxManager(h.client, txManager, h.controllerAddress)
+	require.NoError(t, err)
+	_, err = controllerService.UploadData("doc1")
+	require.NoError(t, err)
+
+	// The account sends a transaction elsewhere, so the local nonce is used up
+	_, err = h.controllerService(t, h.users[0]).UploadData("doc2")
+	require.NoError(t, err)
+	upload, err := controllerService.UploadData("doc3")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), upload.SessionID.Int64())
+
+	// Failures of the builder do not use up a nonce
+	_, err = controllerService.UploadData("doc3")
+	require.ErrorIs(t, err, blockchain.ErrDocAlreadySubmitted)
+	tx, err := txManager.Send(context.Background(), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return types.NewTx(&types.LegacyTx{Nonce: opts.Nonce.Uint64(), GasPrice: big.NewInt(0), Gas: 21000}), nil
+	})
+	require.ErrorContains(t, err, "failed to send transaction")
+	require.Nil(t, tx)
+	upload, err = controllerService.UploadData("doc4")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), upload.SessionID.Int64())
+}
+
+// transfer builds a transfer of nothing to the address.
+func transfer(to common.Address) blockchain.TxBuilder {
+	return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return opts.Signer(opts.From, types.NewTx(&types.DynamicFeeTx{
+			ChainID:   big.NewInt(1337),
+			Nonce:     opts.Nonce.Uint64(),
+			GasTipCap: opts.GasTipCap,
+			GasFeeCap: opts.GasFeeCap,
+			Gas:       params.TxGas,
+			To:        &to,
+		}))
+	}
+}
+
+func TestTxManagerReplacesStuckTransactions(t *testing.T) {
+	h := newHarness(t, 1)
+	client := h.backend.Client() // Does not mine on send.
+	txManager, err := blockchain.NewTxManagerWithFeePolicy(client, h.users[0], blockchain.FeePolicy{
+		ReplaceAfter: 50 * time.Millisecond,
+		PollInterval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	controller, err := contracts.NewController(h.controllerAddress, client)
+	require.NoError(t, err)
+
+	// A transaction that stays pending is replaced with bumped fees
+	tx, err := txManager.Send(context.Background(), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return controller.UploadData(opts, "doc1")
+	})
+	require.NoError(t, err)
+	receipts := make(chan *types.Receipt, 1)
+	go func() {
+		receipt, err := txManager.WaitMined(context.Background(), tx)
+		assert.NoError(t, err)
+		receipts <- receipt
+	}()
+	require.Eventually(t, func() bool {
+		info, err := txManager.Status(context.Background(), tx.Hash())
+		return err == nil && info.Status == blockchain.TxReplaced
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Waiting returns the receipt of the replacement that was mined
+	h.backend.Commit()
+	receipt := <-receipts
+	require.NotEqual(t, tx.Hash(), receipt.TxHash)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	info, err := txManager.Status(context.Background(), tx.Hash())
+	require.NoError(t, err)
+	require.Equal(t, blockchain.TxReplaced, info.Status)
+	require.Equal(t, receipt.TxHash, info.ReplacedBy)
+	replacement, err := txManager.Status(context.Background(), receipt.TxHash)
+	require.NoError(t, err)
+	require.Equal(t, blockchain.TxMined, replacement.Status)
+	require.Equal(t, tx.Nonce(), replacement.Nonce)
+	require.GreaterOrEqual(t, replacement.GasTipCap.Cmp(new(big.Int).Div(new(big.Int).Mul(tx.GasTipCap(), big.NewInt(115)), big.NewInt(100))), 0)
+	require.GreaterOrEqual(t, replacement.GasFeeCap.Cmp(new(big.Int).Div(new(big.Int).Mul(tx.GasFeeCap(), big.NewInt(115)), big.NewInt(100))), 0)
+}
+
+// countingClient is a client that does not mine on send and counts the sent transactions.
+type countingClient struct {
+	simulated.Client
+	sent atomic.Int32
+}
+
+func (c *countingClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	c.sent.Add(1)
+	return c.Client.SendTransaction(ctx, tx)
+}
+
+func TestTxManagerConcurrentWaiters(t *testing.T) {
+	h := newHarness(t, 1)
+	client := &countingClient{Client: h.backend.Client()}
+	txManager, err := blockchain.NewTxManagerWithFeePolicy(client, h.users[0], blockchain.FeePolicy{
+		ReplaceAfter:    50 * time.Millisecond,
+		MaxReplacements: 1,
+		PollInterval:    5 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	// Two waiters of a stuck transaction replace it once between them
+	tx, err := txManager.Send(context.Background(), transfer(h.users[0].From))
+	require.NoError(t, err)
+	receipts := make(chan *types.Receipt, 2)
+	for range 2 {
+		go func() {
+			receipt, err := txManager.WaitMined(context.Background(), tx)
+			assert.NoError(t, err)
+			receipts <- receipt
+		}()
+	}
+	require.Eventually(t, func() bool {
+		info, err := txManager.Status(context.Background(), tx.Hash())
+		return err == nil && info.Status == blockchain.TxReplaced
+	}, 5*time.Second, 5*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+	require.Equal(t, int32(2), client.sent.Load())
+
+	// Both get the receipt of the replacement, which the original reports it was replaced by
+	h.backend.Commit()
+	receipt := <-receipts
+	require.Equal(t, receipt.TxHash, (<-receipts).TxHash)
+	require.NotEqual(t, tx.Hash(), receipt.TxHash)
+	info, err := txManager.Status(context.Background(), tx.Hash())
+	require.NoError(t, err)
+	require.Equal(t, blockchain.TxReplaced, info.Status)
+	require.Equal(t, receipt.TxHash, info.ReplacedBy)
+	pending, err := txManager.Pending(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
+
+func TestTxManagerCancel(t *testing.T) {
+	h := newHarness(t, 1)
+	client := h.backend.Client() // Does not mine on send.
+	txManager, err := blockchain.NewTxManager(client, h.users[0])
+	require.NoError(t, err)
+	controller, err := contracts.NewController(h.controllerAddress, client)
+	require.NoError(t, err)
+
+	// A pending upload is replaced by an empty transfer to the account itself
+	tx, err := txManager.Send(context.Background(), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return controller.UploadData(opts, "doc1")
+	})
+	require.NoError(t, err)
+	cancel, err := txManager.Cancel(context.Background(), tx.Hash())
+	require.NoError(t, err)
+	require.Equal(t, tx.Nonce(), cancel.Nonce())
+	require.Equal(t, h.users[0].From, *cancel.To())
+	h.backend.Commit()
+
+	receipt, err := txManager.WaitMined(context.Background(), tx)
+	require.NoError(t, err)
+	require.Equal(t, cancel.Hash(), receipt.TxHash)
+	info, err := txManager.Status(context.Background(), tx.Hash())
+	require.NoError(t, err)
+	require.Equal(t, blockchain.TxReplaced, info.Status)
+	require.Equal(t, cancel.Hash(), info.ReplacedBy)
+	_, err = txManager.Cancel(context.Background(), tx.Hash())
+	require.ErrorContains(t, err, "is not pending")
+	_, err = txManager.Cancel(context.Background(), common.HexToHash("0x01"))
+	require.ErrorIs(t, err, blockchain.ErrUnknownTx)
+
+	// The document was never uploaded
+	_, err = h.controllerService(t, h.users[0]).UploadData("doc1")
+	require.NoError(t, err)
+}
+
+func TestTxManagerWaitTimeout(t *testing.T) {
+	h := newHarness(t, 1)
+	client := h.backend.Client() // Does not mine on send.
+	txManager, err := blockchain.NewTxManagerWithFeePolicy(client, h.users[0], blockchain.FeePolicy{
+		MaxReplacements: -1,
+		WaitTimeout:     100 * time.Millisecond,
+		PollInterval:    10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	// Waiting for a transaction that is not mined gives up
+	tx, err := txManager.Send(context.Background(), transfer(h.users[0].From))
+	require.NoError(t, err)
+	_, err = txManager.WaitMined(context.Background(), tx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	info, err := txManager.Status(context.Background(), tx.Hash())
+	require.NoError(t, err)
+	require.Equal(t, blockchain.TxPending, info.Status)
+}
+
+// unreliableClient is a client that does not mine on send, whose receipt queries fail and which
+// fails to send transactions once failSends is set.
+type unreliableClient struct {
+	simulated.Client
+	failSends bool
+}
+
+func (c *unreliableClient) TransactionReceipt(context.Context, common.Hash) (*types.Receipt, error) {
+	return nil, errNodeUnavailable
+}
+
+func (c *unreliableClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if c.failSends {
+		return errNodeUnavailable
+	}
+	return c.Client.SendTransaction(ctx, tx)
+}
+
+func TestTxManagerReportsErrors(t *testing.T) {
+	h := newHarness(t, 1)
+	client := &unreliableClient{Client: h.backend.Client()}
+	var errs []error
+	txManager, err := blockchain.NewTxManagerWithConfig(client, h.users[0], blockchain.TxManagerConfig{
+		FeePolicy: blockchain.FeePolicy{
+			ReplaceAfter: 10 * time.Millisecond,
+			WaitTimeout:  200 * time.Millisecond,
+			PollInterval: 10 * time.Millisecond,
+		},
+		OnError: func(err error) { errs = append(errs, err) },
+	})
+	require.NoError(t, err)
+
+	// Failures to get the receipt and to replace the stuck transaction are reported
+	tx, err := txManager.Send(context.Background(), transfer(h.users[0].From))
+	require.NoError(t, err)
+	client.failSends = true
+	_, err = txManager.WaitMined(context.Background(), tx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.True(t, slices.ContainsFunc(errs, func(err error) bool {
+		return errors.Is(err, errNodeUnavailable) && strings.HasPrefix(err.Error(), "failed to get receipt of "+tx.Hash().Hex())
+	}))
+	require.True(t, slices.ContainsFunc(errs, func(err error) bool {
+		return errors.Is(err, errNodeUnavailable) && strings.HasPrefix(err.Error(), "failed to replace "+tx.Hash().Hex())
+	}))
+}
+
+func TestTxManagerL1Fee(t *testing.T) {
+	l1Fee := big.NewInt(5 * params.GWei)
+	client, opts := newOPStackClient(t, l1Fee)
+	to := common.HexToAddress("0x01")
+
+	// The L1 data fee of OP Stack transactions is recorded
+	txManager, err := blockchain.NewTxManagerWithFeePolicy(client, opts, blockchain.FeePolicy{OPStack: true})
+	require.NoError(t, err)
+	tx, err := txManager.Send(context.Background(), transfer(to))
+	require.NoError(t, err)
+	info, err := txManager.Status(context.Background(), tx.Hash())
+	require.NoError(t, err)
+	require.Equal(t, l1Fee, info.L1Fee)
+
+	// Transactions above the L1 fee cap are not sent and do not use up a nonce
+	capped, err := blockchain.NewTxManagerWithFeePolicy(client, opts, blockchain.FeePolicy{OPStack: true, MaxL1Fee: big.NewInt(params.GWei)})
+	require.NoError(t, err)
+	_, err = capped.Send(context.Background(), transfer(to))
+	require.ErrorIs(t, err, blockchain.ErrL1FeeCapExceeded)
+	pending, err := capped.Pending(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, pending)
+	next, err := txManager.Send(context.Background(), transfer(to))
+	require.NoError(t, err)
+	require.Equal(t, tx.Nonce()+1, next.Nonce())
+}
@@ -0,0 +1,311 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/storage"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/tee"
+)
+
+type grantConsentRequest struct {
+	Grantee   common.Address `json:"grantee"`
+	Purpose   string         `json:"purpose"`
+	ExpiresAt time.Time      `json:"expires_at"`
+	Nonce     string         `json:"nonce"`
+	Signature hexutil.Bytes  `json:"signature"` // personal_sign signature of the grant message by the owner.
+}
+
+type consentGrantResponse struct {
+	ID        string         `json:"id"`
+	FileID    string         `json:"file_id"`
+	Grantee   common.Address `json:"grantee"`
+	Purpose   string         `json:"purpose"`
+	ExpiresAt time.Time      `json:"expires_at"`
+	Nonce     string         `json:"nonce"`
+	GrantedAt time.Time      `json:"granted_at"`
+	RevokedAt *time.Time     `json:"revoked_at,omitempty"`
+	Active    bool           `json:"active"`
+}
+
+type auditEventResponse struct {
+	Time    time.Time      `json:"time"`
+	Action  string         `json:"action"`
+	UserID  uint64         `json:"user_id,string"`
+	Address common.Address `json:"address"`
+	GrantID string         `json:"grant_id,omitempty"`
+	Purpose string         `json:"purpose,omitempty"`
+}
+
+type revokeConsentResponse struct {
+	Revoked bool `json:"revoked"`
+}
+
+type shareDataKeyRequest struct {
+	PublicKey hexutil.Bytes `json:"public_key"` // Uncompressed public key of the grantee.
+}
+
+type removeWrappedKeyResponse struct {
+	Removed bool `json:"removed"`
+}
+
+// handleGrantConsent stores a consent grant for the gene data signed by its owner.
+func (s *Server) handleGrantConsent(w http.ResponseWriter, r *http.Request) {
+	fileID := r.PathValue("fileID")
+
+	var req grantConsentRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	geneData, err := s.services.Storage.GetGeneData(fileID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if geneData.UserID != sessionFromRequest(r).UserID {
+		writeError(w, http.StatusForbidden, "gene data does not belong to user")
+		return
+	}
+	ownerPublicKey, err := s.services.Auth.GetUserPubkey(geneData.UserID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	grant, err := s.services.Storage.GrantConsent(storage.ConsentGrant{
+		FileID:    fileID,
+		Grantee:   req.Grantee,
+		Purpose:   req.Purpose,
+		ExpiresAt: req.ExpiresAt,
+		Nonce:     req.Nonce,
+		Signature: req.Signature,
+	}, ownerPublicKey)
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newConsentGrantResponse(grant))
+}
+
+// handleListConsentGrants lists the consent grants of the gene data to its owner.
+func (s *Server) handleListConsentGrants(w http.ResponseWriter, r *http.Request) {
+	grants, err := s.services.Storage.ConsentGrants(r.PathValue("fileID"), accessorFromRequest(r))
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	resp := make([]consentGrantResponse, 0, len(grants))
+	for _, grant := range grants {
+		resp = append(resp, newConsentGrantResponse(grant))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleRevokeConsent revokes a consent grant of the gene data on behalf of its owner.
+func (s *Server) handleRevokeConsent(w http.ResponseWriter, r *http.Request) {
+	err := s.services.Storage.RevokeConsent(r.PathValue("fileID"), r.PathValue("grantID"), accessorFromRequest(r))
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, revokeConsentResponse{Revoked: true})
+}
+
+// handleAuditTrail returns the audit trail of the gene data to its owner.
+func (s *Server) handleAuditTrail(w http.ResponseWriter, r *http.Request) {
+	trail, err := s.services.Storage.AuditTrail(r.PathValue("fileID"), accessorFromRequest(r))
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	resp := make([]auditEventResponse, 0, len(trail))
+	for _, event := range trail {
+		resp = append(resp, auditEventResponse{
+			Time:    event.Time,
+			Action:  string(event.Action),
+			UserID:  event.UserID,
+			Address: event.Address,
+			GrantID: event.GrantID,
+			Purpose: event.Purpose,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleSetWrappedKey adds a recipient of gene data encrypted with a data key, or replaces their
+// wrapped key, on behalf of the owner.
+func (s *Server) handleSetWrappedKey(w http.ResponseWriter, r *http.Request) {
+	var req wrappedKeyBody
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	key := storage.WrappedKey{Recipient: req.Recipient, Key: req.Key}
+	if err := s.services.Storage.SetWrappedKey(r.PathValue("fileID"), key, accessorFromRequest(r)); err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, req)
+}
+
+// handleShareDataKey wraps the data key of gene data to the grantee of a consent grant, on
+// behalf of the grantee. The TEE unwraps the data key with its enclave key, so the owner must
+// have wrapped it to the TEE. The grant is checked before the access, by the TEE itself, and again
+// when the wrapped key is stored.
+func (s *Server) handleShareDataKey(w http.ResponseWriter, r *http.Request) {
+	fileID, grantID := r.PathValue("fileID"), r.PathValue("grantID")
+
+	var req shareDataKeyRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	publicKey, err := crypto.UnmarshalPubkey(req.PublicKey)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid public key")
+		return
+	}
+
+	accessor := accessorFromRequest(r)
+	grant, err := s.services.Storage.ActiveGrant(fileID, grantID, accessor)
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+	if crypto.PubkeyToAddress(*publicKey) != grant.Grantee {
+		writeError(w, http.StatusBadRequest, "public key does not belong to the grantee")
+		return
+	}
+
+	// Read the data under the grant so that the access is audited
+	accessor.Purpose = grant.Purpose
+	geneData, err := s.services.Storage.AccessGeneData(fileID, accessor)
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	ownerPublicKey, err := s.services.Auth.GetUserPubkey(geneData.UserID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// The TEE checks the grant again itself before unwrapping the data key
+	wrappedKey, err := s.services.TEE.ShareDataKey(geneData.EncryptedData, toTEEWrappedKeys(geneData.WrappedKeys),
+		grant, ownerPublicKey, req.PublicKey)
+	if errors.Is(err, tee.ErrConsentInactive) || errors.Is(err, tee.ErrConsentMismatch) {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if errors.Is(err, tee.ErrNotDataKeyEnvelope) || errors.Is(err, tee.ErrNoWrappedKey) {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to share data key: %v", err))
+		return
+	}
+
+	if err := s.services.Storage.AddGranteeWrappedKey(fileID, grantID, storage.WrappedKey(wrappedKey)); err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, wrappedKeyBody{Recipient: wrappedKey.Recipient, Key: wrappedKey.Key})
+}
+
+// handleRemoveWrappedKey removes a recipient of gene data encrypted with a data key on behalf of
+// the owner.
+func (s *Server) handleRemoveWrappedKey(w http.ResponseWriter, r *http.Request) {
+	address := r.PathValue("address")
+	if !common.IsHexAddress(address) {
+		writeError(w, http.StatusBadRequest, "invalid address")
+		return
+	}
+
+	err := s.services.Storage.RemoveWrappedKey(r.PathValue("fileID"), common.HexToAddress(address), accessorFromRequest(r))
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, removeWrappedKeyResponse{Removed: true})
+}
+
+// newConsentGrantResponse converts a consent grant to its JSON representation.
+func newConsentGrantResponse(grant storage.ConsentGrant) consentGrantResponse {
+	return consentGrantResponse{
+		ID:        grant.ID,
+		FileID:    grant.FileID,
+		Grantee:   grant.Grantee,
+		Purpose:   grant.Purpose,
+		ExpiresAt: grant.ExpiresAt,
+		Nonce:     grant.Nonce,
+		GrantedAt: grant.GrantedAt,
+		RevokedAt: grant.RevokedAt,
+		Active:    grant.Active(time.Now()),
+	}
+}
+
+// newWrappedKeyBodies converts wrapped keys to their JSON representation.
+func newWrappedKeyBodies(keys []storage.WrappedKey) []wrappedKeyBody {
+	if len(keys) == 0 {
+		return nil
+	}
+	bodies := make([]wrappedKeyBody, 0, len(keys))
+	for _, key := range keys {
+		bodies = append(bodies, wrappedKeyBody{Recipient: key.Recipient, Key: key.Key})
+	}
+	return bodies
+}
+
+// toTEEWrappedKeys converts stored wrapped keys to the wrapped keys the TEE unwraps.
+func toTEEWrappedKeys(keys []storage.WrappedKey) []tee.WrappedKey {
+	teeKeys := make([]tee.WrappedKey, 0, len(keys))
+	for _, key := range keys {
+		teeKeys = append(teeKeys, tee.WrappedKey(key))
+	}
+	return teeKeys
+}
+
+// fromTEEWrappedKeys converts wrapped keys returned by the TEE to stored wrapped keys.
+func fromTEEWrappedKeys(keys []tee.WrappedKey) []storage.WrappedKey {
+	storageKeys := make([]storage.WrappedKey, 0, len(keys))
+	for _, key := range keys {
+		storageKeys = append(storageKeys, storage.WrappedKey(key))
+	}
+	return storageKeys
+}
+
+// writeStorageError maps errors of the storage consent and wrapped key subsystems to HTTP status codes.
+func writeStorageError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, storage.ErrGeneDataNotFound), errors.Is(err, storage.ErrConsentNotFound),
+		errors.Is(err, storage.ErrWrappedKeyNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, storage.ErrAccessDenied), errors.Is(err, storage.ErrConsentInactive):
+		writeError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, storage.ErrConsentGrantExists):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, storage.ErrInvalidConsentGrant), errors.Is(err, storage.ErrGrantSignerMismatch),
+		errors.Is(err, storage.ErrInvalidWrappedKey):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
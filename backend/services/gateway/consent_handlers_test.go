@@ -0,0 +1,357 @@
+package gateway_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/storage"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/tee"
+)
+
+type grantBody struct {
+	ID        string         `json:"id"`
+	Grantee   common.Address `json:"grantee"`
+	Purpose   string         `json:"purpose"`
+	RevokedAt *time.Time     `json:"revoked_at"`
+	Active    bool           `json:"active"`
+}
+
+type wrappedKey struct {
+	Recipient common.Address `json:"recipient"`
+	Key       hexutil.Bytes  `json:"key"`
+}
+
+func TestConsentGrants(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	ownerKey, ownerID := registerUser(t, server.URL)
+	ownerToken := login(t, server.URL, ownerKey, ownerID)
+	fileID := uploadGeneData(t, server.URL, ownerToken, ownerKey, ownerID, "low risk")
+
+	researcherKey, researcherID := registerUser(t, server.URL)
+	researcherToken := login(t, server.URL, researcherKey, researcherID)
+	researcher := crypto.PubkeyToAddress(researcherKey.PublicKey)
+	readURL := server.URL + "/gene-data/" + fileID + "?purpose=stroke%20research"
+
+	// Without a grant the researcher cannot read the data
+	var errResp errorBody
+	status := doJSON(t, http.MethodGet, readURL, researcherToken, nil, &errResp)
+	require.Equal(t, http.StatusForbidden, status)
+	require.Equal(t, "access to gene data denied", errResp.Error)
+
+	// The owner signs a grant for stroke research
+	grant := storage.ConsentGrant{
+		FileID:    fileID,
+		Grantee:   researcher,
+		Purpose:   "stroke research",
+		ExpiresAt: time.Now().Add(time.Hour).Truncate(time.Second),
+		Nonce:     "1",
+	}
+	signature, err := crypto.Sign(accounts.TextHash([]byte(grant.Message())), ownerKey)
+	require.NoError(t, err)
+	grantRequest := map[string]any{
+		"grantee":    researcher,
+		"purpose":    grant.Purpose,
+		"expires_at": grant.ExpiresAt,
+		"nonce":      grant.Nonce,
+		"signature":  hexutil.Encode(signature),
+	}
+
+	// Only the owner can submit it
+	status = doJSON(t, http.MethodPost, server.URL+"/gene-data/"+fileID+"/grants", researcherToken, grantRequest, &errResp)
+	require.Equal(t, http.StatusForbidden, status)
+
+	var granted grantBody
+	status = doJSON(t, http.MethodPost, server.URL+"/gene-data/"+fileID+"/grants", ownerToken, grantRequest, &granted)
+	require.Equal(t, http.StatusCreated, status)
+	require.NotEmpty(t, granted.ID)
+	require.Equal(t, researcher, granted.Grantee)
+	require.True(t, granted.Active)
+
+	status = doJSON(t, http.MethodPost, server.URL+"/gene-data/"+fileID+"/grants", ownerToken, grantRequest, &errResp)
+	require.Equal(t, http.StatusConflict, status)
+
+	// A grant for another purpose than the one signed is rejected
+	grantRequest["purpose"] = "marketing"
+	status = doJSON(t, http.MethodPost, server.URL+"/gene-data/"+fileID+"/grants", ownerToken, grantRequest, &errResp)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, storage.ErrGrantSignerMismatch.Error(), errResp.Error)
+
+	// The researcher can now read the data for the granted purpose only
+	var geneResp struct {
+		FileID        string        `json:"file_id"`
+		EncryptedData hexutil.Bytes `json:"encrypted_data"`
+	}
+	status = doJSON(t, http.MethodGet, readURL, researcherToken, nil, &geneResp)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, fileID, geneResp.FileID)
+	require.NotEmpty(t, geneResp.EncryptedData)
+
+	status = doJSON(t, http.MethodGet, server.URL+"/gene-data/"+fileID+"?purpose=marketing", researcherToken, nil, &errResp)
+	require.Equal(t, http.StatusForbidden, status)
+
+	// The owner lists and revokes grants, the researcher cannot
+	var grants []grantBody
+	status = doJSON(t, http.MethodGet, server.URL+"/gene-data/"+fileID+"/grants", ownerToken, nil, &grants)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, grants, 1)
+	status = doJSON(t, http.MethodGet, server.URL+"/gene-data/"+fileID+"/grants", researcherToken, nil, &errResp)
+	require.Equal(t, http.StatusForbidden, status)
+
+	revokeURL := server.URL + "/gene-data/" + fileID + "/grants/" + granted.ID
+	status = doJSON(t, http.MethodDelete, revokeURL, researcherToken, nil, &errResp)
+	require.Equal(t, http.StatusForbidden, status)
+
+	var revokeResp struct {
+		Revoked bool `json:"revoked"`
+	}
+	status = doJSON(t, http.MethodDelete, revokeURL, ownerToken, nil, &revokeResp)
+	require.Equal(t, http.StatusOK, status)
+	require.True(t, revokeResp.Revoked)
+	status = doJSON(t, http.MethodDelete, revokeURL, ownerToken, nil, &errResp)
+	require.Equal(t, http.StatusNotFound, status)
+
+	status = doJSON(t, http.MethodGet, readURL, researcherToken, nil, &errResp)
+	require.Equal(t, http.StatusForbidden, status)
+
+	status = doJSON(t, http.MethodGet, server.URL+"/gene-data/"+fileID+"/grants", ownerToken, nil, &grants)
+	require.Equal(t, http.StatusOK, status)
+	require.NotNil(t, grants[0].RevokedAt)
+	require.False(t, grants[0].Active)
+
+	// The audit trail records the grant, the revocation and every read attempt
+	var trail []struct {
+		Action  string         `json:"action"`
+		Address common.Address `json:"address"`
+		GrantID string         `json:"grant_id"`
+	}
+	status = doJSON(t, http.MethodGet, server.URL+"/gene-data/"+fileID+"/audit", ownerToken, nil, &trail)
+	require.Equal(t, http.StatusOK, status)
+	actions := make([]string, 0, len(trail))
+	for _, event := range trail {
+		actions = append(actions, event.Action)
+	}
+	require.Equal(t, []string{"access_denied", "grant", "access", "access_denied", "revoke", "access_denied"}, actions)
+	require.Equal(t, researcher, trail[2].Address)
+	require.Equal(t, granted.ID, trail[2].GrantID)
+
+	status = doJSON(t, http.MethodGet, server.URL+"/gene-data/"+fileID+"/audit", researcherToken, nil, &errResp)
+	require.Equal(t, http.StatusForbidden, status)
+}
+
+// uploadWithDataKey encrypts gene data under a data key in the TEE and uploads it with the wrapped
+// keys, returning the file ID, the encrypted data and the wrapped keys.
+func uploadWithDataKey(t *testing.T, serverURL, token string, ownerKey *ecdsa.PrivateKey, ownerID, geneData string) (string, []byte, []wrappedKey) {
+	var encrypted struct {
+		EncryptedData hexutil.Bytes `json:"encrypted_data"`
+		WrappedKeys   []wrappedKey  `json:"wrapped_keys"`
+	}
+	status := doJSON(t, http.MethodPost, serverURL+"/users/"+ownerID+"/gene-data/encrypt", token, map[string]any{
+		"gene_data": geneData,
+		"data_key":  true,
+	}, &encrypted)
+	require.Equal(t, http.StatusOK, status)
+
+	fileID := storeGeneData(t, serverURL, token, ownerKey, encrypted.EncryptedData, encrypted.WrappedKeys)
+	return fileID, encrypted.EncryptedData, encrypted.WrappedKeys
+}
+
+// grantConsent signs a grant of the gene data to the grantee as the owner and stores it,
+// returning the grant ID.
+func grantConsent(t *testing.T, serverURL, token string, ownerKey *ecdsa.PrivateKey, fileID string, grantee common.Address, purpose string) string {
+	grant := storage.ConsentGrant{
+		FileID:    fileID,
+		Grantee:   grantee,
+		Purpose:   purpose,
+		ExpiresAt: time.Now().Add(time.Hour).Truncate(time.Second),
+		Nonce:     "1",
+	}
+	signature, err := crypto.Sign(accounts.TextHash([]byte(grant.Message())), ownerKey)
+	require.NoError(t, err)
+
+	var granted grantBody
+	status := doJSON(t, http.MethodPost, serverURL+"/gene-data/"+fileID+"/grants", token, map[string]any{
+		"grantee":    grant.Grantee,
+		"purpose":    grant.Purpose,
+		"expires_at": grant.ExpiresAt,
+		"nonce":      grant.Nonce,
+		"signature":  hexutil.Encode(signature),
+	}, &granted)
+	require.Equal(t, http.StatusCreated, status)
+	return granted.ID
+}
+
+// uploadOwnerOnly encrypts gene data under a data key wrapped only to the owner, which the TEE
+// cannot unwrap, and stores it, returning the file ID.
+func uploadOwnerOnly(t *testing.T, serverURL, token string, ownerKey *ecdsa.PrivateKey, geneData string) string {
+	var encrypted bytes.Buffer
+	wrappedKeys, err := tee.NewTEEService().EncryptGeneDataWithDataKey(&encrypted, strings.NewReader(geneData),
+		[][]byte{crypto.FromECDSAPub(&ownerKey.PublicKey)}, nil)
+	require.NoError(t, err)
+
+	bodies := make([]wrappedKey, 0, len(wrappedKeys))
+	for _, key := range wrappedKeys {
+		bodies = append(bodies, wrappedKey{Recipient: key.Recipient, Key: key.Key})
+	}
+	return storeGeneData(t, serverURL, token, ownerKey, encrypted.Bytes(), bodies)
+}
+
+// teeInfo returns the enclave public key and its address from the TEE route.
+func teeInfo(t *testing.T, serverURL string) ([]byte, common.Address) {
+	var info struct {
+		EnclavePublicKey hexutil.Bytes `json:"enclave_public_key"`
+	}
+	require.Equal(t, http.StatusOK, doJSON(t, http.MethodGet, serverURL+"/tee", "", nil, &info))
+	publicKey, err := crypto.UnmarshalPubkey(info.EnclavePublicKey)
+	require.NoError(t, err)
+	return info.EnclavePublicKey, crypto.PubkeyToAddress(*publicKey)
+}
+
+func TestWrappedKeys(t *testing.T) {
+	server, _ := newTestServer(t)
+	teeService := tee.NewTEEService()
+	_, enclave := teeInfo(t, server.URL)
+
+	ownerKey, ownerID := registerUser(t, server.URL)
+	ownerToken := login(t, server.URL, ownerKey, ownerID)
+	owner := crypto.PubkeyToAddress(ownerKey.PublicKey)
+
+	researcherKey, researcherID := registerUser(t, server.URL)
+	researcherToken := login(t, server.URL, researcherKey, researcherID)
+	researcher := crypto.PubkeyToAddress(researcherKey.PublicKey)
+
+	// The TEE encrypts under a data key wrapped to the owner and to itself
+	fileID, encryptedData, wrappedKeys := uploadWithDataKey(t, server.URL, ownerToken, ownerKey, ownerID, "high risk")
+	require.Len(t, wrappedKeys, 2)
+	require.Equal(t, owner, wrappedKeys[0].Recipient)
+	require.Equal(t, enclave, wrappedKeys[1].Recipient)
+	keysURL := server.URL + "/gene-data/" + fileID + "/keys"
+
+	// The owner wraps the data key to the researcher, without re-encrypting the data
+	wrapped, err := teeService.WrapDataKey(ownerKey, encryptedData, toWrappedKeys(wrappedKeys),
+		crypto.FromECDSAPub(&researcherKey.PublicKey))
+	require.NoError(t, err)
+	researcherWrappedKey := wrappedKey{Recipient: wrapped.Recipient, Key: wrapped.Key}
+
+	var errResp errorBody
+	status := doJSON(t, http.MethodPut, keysURL, researcherToken, researcherWrappedKey, &errResp)
+	require.Equal(t, http.StatusForbidden, status)
+	status = doJSON(t, http.MethodPut, keysURL, ownerToken, wrappedKey{Recipient: researcher}, &errResp)
+	require.Equal(t, http.StatusBadRequest, status)
+	status = doJSON(t, http.MethodPut, keysURL, ownerToken, researcherWrappedKey, nil)
+	require.Equal(t, http.StatusOK, status)
+
+	// The owner's view lists all recipients and the researcher can decrypt with their key
+	var geneResp struct {
+		EncryptedData hexutil.Bytes `json:"encrypted_data"`
+		WrappedKeys   []wrappedKey  `json:"wrapped_keys"`
+	}
+	status = doJSON(t, http.MethodGet, server.URL+"/gene-data/"+fileID, ownerToken, nil, &geneResp)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, append(wrappedKeys, researcherWrappedKey), geneResp.WrappedKeys)
+
+	var decrypted bytes.Buffer
+	require.NoError(t, teeService.DecryptGeneDataWithDataKey(&decrypted, bytes.NewReader(geneResp.EncryptedData),
+		researcherKey, toWrappedKeys(geneResp.WrappedKeys)))
+	require.Equal(t, "high risk", decrypted.String())
+
+	// Removing the researcher only removes their wrapped key
+	status = doJSON(t, http.MethodDelete, keysURL+"/"+researcher.Hex(), researcherToken, nil, &errResp)
+	require.Equal(t, http.StatusForbidden, status)
+	status = doJSON(t, http.MethodDelete, keysURL+"/not-an-address", ownerToken, nil, &errResp)
+	require.Equal(t, http.StatusBadRequest, status)
+	status = doJSON(t, http.MethodDelete, keysURL+"/"+researcher.Hex(), ownerToken, nil, nil)
+	require.Equal(t, http.StatusOK, status)
+	status = doJSON(t, http.MethodDelete, keysURL+"/"+researcher.Hex(), ownerToken, nil, &errResp)
+	require.Equal(t, http.StatusNotFound, status)
+
+	status = doJSON(t, http.MethodGet, server.URL+"/gene-data/"+fileID, ownerToken, nil, &geneResp)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, wrappedKeys, geneResp.WrappedKeys)
+}
+
+func TestShareDataKey(t *testing.T) {
+	server, _ := newTestServer(t)
+	teeService := tee.NewTEEService()
+
+	ownerKey, ownerID := registerUser(t, server.URL)
+	ownerToken := login(t, server.URL, ownerKey, ownerID)
+
+	researcherKey, researcherID := registerUser(t, server.URL)
+	researcherToken := login(t, server.URL, researcherKey, researcherID)
+	researcher := crypto.PubkeyToAddress(researcherKey.PublicKey)
+	researcherPublicKey := hexutil.Encode(crypto.FromECDSAPub(&researcherKey.PublicKey))
+
+	fileID, encryptedData, _ := uploadWithDataKey(t, server.URL, ownerToken, ownerKey, ownerID, "high risk")
+	grantID := grantConsent(t, server.URL, ownerToken, ownerKey, fileID, researcher, "stroke research")
+	shareURL := server.URL + "/gene-data/" + fileID + "/grants/" + grantID + "/key"
+
+	// Only the grantee can have the data key wrapped to their own key
+	var errResp errorBody
+	status := doJSON(t, http.MethodPost, shareURL, ownerToken, map[string]any{"public_key": researcherPublicKey}, &errResp)
+	require.Equal(t, http.StatusForbidden, status)
+	status = doJSON(t, http.MethodPost, shareURL, researcherToken, map[string]any{
+		"public_key": hexutil.Encode(crypto.FromECDSAPub(&ownerKey.PublicKey)),
+	}, &errResp)
+	require.Equal(t, http.StatusBadRequest, status)
+	status = doJSON(t, http.MethodPost, server.URL+"/gene-data/"+fileID+"/grants/unknown/key", researcherToken,
+		map[string]any{"public_key": researcherPublicKey}, &errResp)
+	require.Equal(t, http.StatusNotFound, status)
+
+	// The TEE wraps the data key to the researcher without the owner's private key
+	var shared wrappedKey
+	status = doJSON(t, http.MethodPost, shareURL, researcherToken, map[string]any{"public_key": researcherPublicKey}, &shared)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, researcher, shared.Recipient)
+
+	var decrypted bytes.Buffer
+	require.NoError(t, teeService.DecryptGeneDataWithDataKey(&decrypted, bytes.NewReader(encryptedData),
+		researcherKey, toWrappedKeys([]wrappedKey{shared})))
+	require.Equal(t, "high risk", decrypted.String())
+
+	// The share is audited as an access under the grant
+	var trail []struct {
+		Action  string `json:"action"`
+		GrantID string `json:"grant_id"`
+	}
+	status = doJSON(t, http.MethodGet, server.URL+"/gene-data/"+fileID+"/audit", ownerToken, nil, &trail)
+	require.Equal(t, http.StatusOK, status)
+	actions := make([]string, 0, len(trail))
+	for _, event := range trail {
+		actions = append(actions, event.Action)
+	}
+	require.Equal(t, []string{"grant", "access", "add_recipient"}, actions)
+	require.Equal(t, grantID, trail[2].GrantID)
+
+	// Not once the grant is revoked
+	status = doJSON(t, http.MethodDelete, server.URL+"/gene-data/"+fileID+"/grants/"+grantID, ownerToken, nil, nil)
+	require.Equal(t, http.StatusOK, status)
+	status = doJSON(t, http.MethodPost, shareURL, researcherToken, map[string]any{"public_key": researcherPublicKey}, &errResp)
+	require.Equal(t, http.StatusForbidden, status)
+
+	// Nor for gene data whose data key is not wrapped to the TEE
+	otherFileID := uploadOwnerOnly(t, server.URL, ownerToken, ownerKey, "low risk")
+	otherGrantID := grantConsent(t, server.URL, ownerToken, ownerKey, otherFileID, researcher, "stroke research")
+	status = doJSON(t, http.MethodPost, server.URL+"/gene-data/"+otherFileID+"/grants/"+otherGrantID+"/key", researcherToken,
+		map[string]any{"public_key": researcherPublicKey}, &errResp)
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+}
+
+// toWrappedKeys converts wrapped keys from their JSON representation.
+func toWrappedKeys(keys []wrappedKey) []tee.WrappedKey {
+	wrappedKeys := make([]tee.WrappedKey, 0, len(keys))
+	for _, key := range keys {
+		wrappedKeys = append(wrappedKeys, tee.WrappedKey{Recipient: key.Recipient, Key: key.Key})
+	}
+	return wrappedKeys
+}
@@ -0,0 +1,176 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/attestation"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/auth"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/blockchain"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/storage"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/tee"
+)
+
+// maxRequestBodySize bounds request bodies; real gene files are around 40MB and are
+// hex encoded on the wire, so leave room for the encoding overhead.
+const maxRequestBodySize = 100 << 20
+
+// ControllerClient submits gene data to the Controller contract.
+// It is implemented by blockchain.ControllerService.
+type ControllerClient interface {
+	UploadData(docId string) (*blockchain.UploadResult, error)
+	ConfirmNonce(docId, contentHash string, sessionId *big.Int) (common.Hash, error)
+	ConfirmReceipt(receipt *attestation.Receipt, contentHash string, quote *attestation.Quote, sessionId *big.Int) error
+}
+
+// SessionFinder looks up the upload session created on-chain for a document, to resume a
+// submission whose upload was mined but not confirmed.
+// It is implemented by blockchain.ControllerEventListener.
+type SessionFinder interface {
+	ListenForUploadDataEvents(fileID string) (*big.Int, error)
+}
+
+// BalanceReader reads PCSP token balances.
+// It is implemented by blockchain.PCSPService.
+type BalanceReader interface {
+	GetBalance(address common.Address) (*big.Int, error)
+}
+
+// Services groups the services the gateway exposes over REST.
+type Services struct {
+	Auth       *auth.AuthService
+	Storage    *storage.GeneDataStorageService
+	TEE        *tee.TEEService
+	Controller ControllerClient
+	Sessions   SessionFinder
+	PCSP       BalanceReader
+}
+
+// Server is the REST gateway clients use to drive the end-user flow.
+type Server struct {
+	services Services
+	mux      *http.ServeMux
+}
+
+// NewServer creates a new Server and registers its routes.
+func NewServer(services Services) *Server {
+	s := &Server{
+		services: services,
+		mux:      http.NewServeMux(),
+	}
+
+	// Public routes
+	s.mux.HandleFunc("POST /users", s.handleRegisterUser)
+	s.mux.HandleFunc("POST /users/{userID}/challenge", s.handleIssueChallenge)
+	s.mux.HandleFunc("POST /users/{userID}/authenticate", s.handleAuthenticate)
+	s.mux.HandleFunc("POST /siwe/message", s.handleSIWEMessage)
+	s.mux.HandleFunc("POST /siwe/login", s.handleSIWELogin)
+	s.mux.HandleFunc("POST /gene-data/{fileID}/verify", s.handleVerifySignature)
+	s.mux.HandleFunc("GET /balances/{address}", s.handleGetBalance)
+	s.mux.HandleFunc("GET /risk-models", s.handleListRiskModels)
+	s.mux.HandleFunc("GET /tee", s.handleTEEInfo)
+
+	// Routes that require a session token
+	s.mux.HandleFunc("POST /sessions/revoke", s.requireSession(s.handleRevokeSession))
+	s.mux.HandleFunc("POST /users/{userID}/gene-data/encrypt", s.requireSession(s.handleEncryptGeneData))
+	s.mux.HandleFunc("POST /gene-data", s.requireSession(s.handleUploadGeneData))
+	s.mux.HandleFunc("GET /gene-data/{fileID}", s.requireSession(s.handleRetrieveGeneData))
+	s.mux.HandleFunc("GET /gene-data/{fileID}/car", s.requireSession(s.handleExportGeneData))
+	s.mux.HandleFunc("POST /risk-score", s.requireSession(s.handleCalculateRiskScore))
+	s.mux.HandleFunc("POST /gene-data/{fileID}/submit", s.requireSession(s.handleSubmitOnChain))
+	s.mux.HandleFunc("POST /gene-data/{fileID}/grants", s.requireSession(s.handleGrantConsent))
+	s.mux.HandleFunc("GET /gene-data/{fileID}/grants", s.requireSession(s.handleListConsentGrants))
+	s.mux.HandleFunc("DELETE /gene-data/{fileID}/grants/{grantID}", s.requireSession(s.handleRevokeConsent))
+	s.mux.HandleFunc("POST /gene-data/{fileID}/grants/{grantID}/key", s.requireSession(s.handleShareDataKey))
+	s.mux.HandleFunc("GET /gene-data/{fileID}/audit", s.requireSession(s.handleAuditTrail))
+	s.mux.HandleFunc("PUT /gene-data/{fileID}/keys", s.requireSession(s.handleSetWrappedKey))
+	s.mux.HandleFunc("DELETE /gene-data/{fileID}/keys/{address}", s.requireSession(s.handleRemoveWrappedKey))
+
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// requireSession rejects requests without a valid "Authorization: Bearer <token>" header and
+// stores the verified session in the request context for the next handler.
+func (s *Server) requireSession(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || token == "" {
+			writeError(w, http.StatusUnauthorized, "missing bearer token")
+			return
+		}
+
+		session, err := s.services.Auth.VerifySession(token)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		next(w, r.WithContext(auth.ContextWithSession(r.Context(), session)))
+	}
+}
+
+// sessionFromRequest returns the session stored by requireSession.
+func sessionFromRequest(r *http.Request) auth.Session {
+	session, _ := auth.SessionFromContext(r.Context())
+	return session
+}
+
+// accessorFromRequest identifies the session user as an accessor of gene data, with the purpose
+// taken from the "purpose" query parameter.
+func accessorFromRequest(r *http.Request) storage.Accessor {
+	session := sessionFromRequest(r)
+	return storage.Accessor{
+		UserID:  session.UserID,
+		Address: session.ETHAddress,
+		Purpose: r.URL.Query().Get("purpose"),
+	}
+}
+
+// errorResponse is the JSON body returned for every failed request.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a JSON error response with the given status code.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+// decodeJSON decodes the request body into v, rejecting unknown fields and oversized bodies.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return errors.New("invalid request body: " + err.Error())
+	}
+	return nil
+}
+
+// parseUserID parses the userID path parameter.
+func parseUserID(r *http.Request) (uint64, error) {
+	userID, err := strconv.ParseUint(r.PathValue("userID"), 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid user ID")
+	}
+	return userID, nil
+}
@@ -0,0 +1,888 @@
+package gateway_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/attestation"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/auth"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/blockchain"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/gateway"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/storage"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/tee"
+)
+
+// fakeChain is an in-memory stand-in for the Controller and PCSP contracts.
+type fakeChain struct {
+	sessions   map[string]*big.Int
+	confirmed  map[string]uint8
+	anchored   map[string]string
+	quotes     map[string]*attestation.Quote
+	receipts   map[string]*attestation.Receipt
+	balances   map[common.Address]*big.Int
+	verifier   *attestation.Verifier
+	uploadErr  error
+	confirmErr error
+}
+
+func newFakeChain(verifier *attestation.Verifier) *fakeChain {
+	return &fakeChain{
+		sessions:  make(map[string]*big.Int),
+		confirmed: make(map[string]uint8),
+		anchored:  make(map[string]string),
+		quotes:    make(map[string]*attestation.Quote),
+		receipts:  make(map[string]*attestation.Receipt),
+		verifier:  verifier,
+		balances:  make(map[common.Address]*big.Int),
+	}
+}
+
+func (c *fakeChain) UploadData(docId string) (*blockchain.UploadResult, error) {
+	if c.uploadErr != nil {
+		return nil, c.uploadErr
+	}
+	if _, exists := c.sessions[docId]; exists {
+		return nil, fmt.Errorf("failed to upload data: %w", blockchain.ErrDocAlreadySubmitted)
+	}
+	sessionID := big.NewInt(int64(len(c.sessions)))
+	c.sessions[docId] = sessionID
+	return &blockchain.UploadResult{
+		TxHash:      crypto.Keccak256Hash([]byte(docId)),
+		BlockNumber: sessionID.Uint64() + 1,
+		SessionID:   sessionID,
+		GasUsed:     21000,
+	}, nil
+}
+
+// fakeChainID and fakeControllerAddress identify the fake Controller in confirm nonces.
+var (
+	fakeChainID           = big.NewInt(1337)
+	fakeControllerAddress = common.HexToAddress("0x8A8937171197A78f47d8C2eE9A3C92FD33644B63")
+)
+
+func (c *fakeChain) ConfirmNonce(docId, contentHash string, sessionId *big.Int) (common.Hash, error) {
+	return attestation.ConfirmNonce(fakeChainID, fakeControllerAddress, docId, contentHash, sessionId)
+}
+
+func (c *fakeChain) ConfirmReceipt(receipt *attestation.Receipt, contentHash string, quote *attestation.Quote, sessionId *big.Int) error {
+	// Like ControllerService, only confirm quotes for the receipt's data and risk level
+	if quote.Model != receipt.Model || quote.ModelVersion != receipt.ModelVersion ||
+		quote.InputHash != receipt.DataHash || quote.OutputHash != attestation.RiskScoreHash(receipt.RiskLevel) {
+		return errors.New("attestation quote does not match the receipt")
+	}
+	if err := c.confirm(receipt.FileID, contentHash, quote, sessionId, receipt.RiskLevel); err != nil {
+		return err
+	}
+	c.receipts[receipt.FileID] = receipt
+	return nil
+}
+
+func (c *fakeChain) confirm(docId, contentHash string, quote *attestation.Quote, sessionId *big.Int, riskScore uint8) error {
+	if c.confirmErr != nil {
+		return c.confirmErr
+	}
+	if _, exists := c.confirmed[docId]; exists {
+		return errors.New("Doc already been submitted")
+	}
+
+	// Like the contract, only accept quotes for this document, session and risk score
+	nonce, err := c.ConfirmNonce(docId, contentHash, sessionId)
+	if err != nil {
+		return err
+	}
+	err = c.verifier.Verify(quote, attestation.Claims{
+		Model:        quote.Model,
+		ModelVersion: quote.ModelVersion,
+		InputHash:    quote.InputHash,
+		OutputHash:   attestation.RiskScoreHash(riskScore),
+		Nonce:        nonce,
+	})
+	if err != nil {
+		return err
+	}
+	c.confirmed[docId] = riskScore
+	c.quotes[docId] = quote
+	c.anchored[docId] = contentHash
+	return nil
+}
+
+func (c *fakeChain) ListenForUploadDataEvents(fileID string) (*big.Int, error) {
+	sessionID, exists := c.sessions[fileID]
+	if !exists {
+		return nil, blockchain.ErrUploadNotFound
+	}
+	return sessionID, nil
+}
+
+func (c *fakeChain) GetBalance(address common.Address) (*big.Int, error) {
+	if balance, exists := c.balances[address]; exists {
+		return balance, nil
+	}
+	return big.NewInt(0), nil
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, *fakeChain) {
+	teeService := tee.NewTEEService()
+	chain := newFakeChain(attestation.NewVerifier(teeService.AttestationKey(), teeService.Measurement()))
+	server := httptest.NewServer(gateway.NewServer(gateway.Services{
+		Auth:       auth.NewAuthService(),
+		Storage:    storage.NewGeneDataStorageService(),
+		TEE:        teeService,
+		Controller: chain,
+		Sessions:   chain,
+		PCSP:       chain,
+	}))
+	t.Cleanup(server.Close)
+	return server, chain
+}
+
+// doJSON sends a JSON request, authorized with the session token unless it is empty, and decodes
+// the JSON response into out, returning the status code.
+func doJSON(t *testing.T, method, url, token string, body, out any) int {
+	var reader *bytes.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(payload)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	if out != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp.StatusCode
+}
+
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// registerUser registers a fresh key pair and returns the private key and user ID.
+func registerUser(t *testing.T, baseURL string) (*ecdsa.PrivateKey, string) {
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	var resp struct {
+		UserID     string `json:"user_id"`
+		ETHAddress string `json:"eth_address"`
+	}
+	status := doJSON(t, http.MethodPost, baseURL+"/users", "", map[string]any{
+		"public_key": hexutil.Encode(crypto.FromECDSAPub(&privateKey.PublicKey)),
+	}, &resp)
+	require.Equal(t, http.StatusCreated, status)
+	require.NotEmpty(t, resp.UserID)
+	require.Equal(t, crypto.PubkeyToAddress(privateKey.PublicKey).Hex(), resp.ETHAddress)
+
+	return privateKey, resp.UserID
+}
+
+// signChallenge requests a login challenge for the user and signs it with personal_sign.
+func signChallenge(t *testing.T, baseURL string, privateKey *ecdsa.PrivateKey, userID string) (string, []byte) {
+	var challenge struct {
+		Nonce     string    `json:"nonce"`
+		Message   string    `json:"message"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	status := doJSON(t, http.MethodPost, baseURL+"/users/"+userID+"/challenge", "", nil, &challenge)
+	require.Equal(t, http.StatusOK, status)
+	require.NotEmpty(t, challenge.Nonce)
+	require.True(t, challenge.ExpiresAt.After(time.Now()))
+
+	signature, err := crypto.Sign(accounts.TextHash([]byte(challenge.Message)), privateKey)
+	require.NoError(t, err)
+	signature[crypto.RecoveryIDOffset] += 27
+
+	return challenge.Nonce, signature
+}
+
+// login authenticates the user with a signed challenge and returns the session token.
+func login(t *testing.T, baseURL string, privateKey *ecdsa.PrivateKey, userID string) string {
+	nonce, signature := signChallenge(t, baseURL, privateKey, userID)
+
+	var session struct {
+		Token      string         `json:"token"`
+		UserID     string         `json:"user_id"`
+		ETHAddress common.Address `json:"eth_address"`
+		ExpiresAt  time.Time      `json:"expires_at"`
+	}
+	status := doJSON(t, http.MethodPost, baseURL+"/users/"+userID+"/authenticate", "", map[string]any{
+		"nonce":     nonce,
+		"signature": hexutil.Encode(signature),
+	}, &session)
+	require.Equal(t, http.StatusOK, status)
+	require.NotEmpty(t, session.Token)
+	require.Equal(t, userID, session.UserID)
+	require.Equal(t, crypto.PubkeyToAddress(privateKey.PublicKey), session.ETHAddress)
+	require.True(t, session.ExpiresAt.After(time.Now()))
+
+	return session.Token
+}
+
+// uploadGeneData encrypts gene data for the user under a data key wrapped to the user and the TEE,
+// signs and stores it and returns the file ID.
+func uploadGeneData(t *testing.T, baseURL, token string, privateKey *ecdsa.PrivateKey, userID, geneData string) string {
+	fileID, _, _ := uploadWithDataKey(t, baseURL, token, privateKey, userID, geneData)
+	return fileID
+}
+
+// storeGeneData signs and stores encrypted gene data with its wrapped keys and returns the file ID.
+func storeGeneData(t *testing.T, baseURL, token string, privateKey *ecdsa.PrivateKey, encryptedData []byte, wrappedKeys []wrappedKey) string {
+	hash := crypto.Keccak256Hash(encryptedData).Bytes()
+	signature, err := crypto.Sign(hash, privateKey)
+	require.NoError(t, err)
+
+	var uploaded struct {
+		FileID string `json:"file_id"`
+	}
+	status := doJSON(t, http.MethodPost, baseURL+"/gene-data", token, map[string]any{
+		"encrypted_data": hexutil.Encode(encryptedData),
+		"signature":      hexutil.Encode(signature),
+		"hash":           hexutil.Encode(hash),
+		"wrapped_keys":   wrappedKeys,
+	}, &uploaded)
+	require.Equal(t, http.StatusCreated, status)
+	require.NotEmpty(t, uploaded.FileID)
+
+	return uploaded.FileID
+}
+
+func TestEndToEndFlow(t *testing.T) {
+	server, chain := newTestServer(t)
+
+	// Register and authenticate the user
+	privateKey, userID := registerUser(t, server.URL)
+	ethAddress := crypto.PubkeyToAddress(privateKey.PublicKey)
+
+	token := login(t, server.URL, privateKey, userID)
+
+	// Encrypt, sign and upload gene data
+	fileID := uploadGeneData(t, server.URL, token, privateKey, userID, "high risk")
+
+	// Verify the stored signature
+	var verifyResp struct {
+		Valid bool `json:"valid"`
+	}
+	status := doJSON(t, http.MethodPost, server.URL+"/gene-data/"+fileID+"/verify", "", nil, &verifyResp)
+	require.Equal(t, http.StatusOK, status)
+	require.True(t, verifyResp.Valid)
+
+	// Calculate the risk score
+	var riskResp struct {
+		RiskScore    uint8  `json:"risk_score"`
+		RiskLevel    string `json:"risk_level"`
+		Model        string `json:"model"`
+		ModelVersion string `json:"model_version"`
+	}
+	status = doJSON(t, http.MethodPost, server.URL+"/risk-score", token, map[string]any{
+		"gene_data": "high risk",
+	}, &riskResp)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, uint8(2), riskResp.RiskScore)
+	require.Equal(t, "high risk", riskResp.RiskLevel)
+	require.Equal(t, tee.GStrokeModelName, riskResp.Model)
+	require.Equal(t, tee.GStrokeModelVersion, riskResp.ModelVersion)
+
+	// Submit on-chain
+	var submitResp struct {
+		TxHash       common.Hash `json:"tx_hash"`
+		BlockNumber  uint64      `json:"block_number"`
+		GasUsed      uint64      `json:"gas_used"`
+		SessionID    string      `json:"session_id"`
+		RiskScore    uint8       `json:"risk_score"`
+		Model        string      `json:"model"`
+		ModelVersion string      `json:"model_version"`
+		Proof        string      `json:"proof"`
+	}
+	status = doJSON(t, http.MethodPost, server.URL+"/gene-data/"+fileID+"/submit", token, map[string]any{
+		"gene_data":     "high risk",
+		"risk_score":    riskResp.RiskScore,
+		"model":         riskResp.Model,
+		"model_version": riskResp.ModelVersion,
+	}, &submitResp)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, crypto.Keccak256Hash([]byte(fileID)), submitResp.TxHash)
+	require.Equal(t, uint64(1), submitResp.BlockNumber)
+	require.Equal(t, uint64(21000), submitResp.GasUsed)
+	require.Equal(t, "0", submitResp.SessionID)
+	require.Equal(t, riskResp.RiskScore, chain.confirmed[fileID])
+	require.Equal(t, riskResp.Model, submitResp.Model)
+	require.Equal(t, riskResp.ModelVersion, submitResp.ModelVersion)
+	require.Equal(t, riskResp.RiskScore, submitResp.RiskScore)
+
+	// The proof is the TEE's quote for the gene data, the risk score, the document and the session
+	quote, err := attestation.ParseProof(submitResp.Proof)
+	require.NoError(t, err)
+	require.Equal(t, chain.quotes[fileID], quote)
+	require.Equal(t, crypto.Keccak256Hash([]byte("high risk")), quote.InputHash)
+	nonce, err := chain.ConfirmNonce(fileID, chain.anchored[fileID], big.NewInt(0))
+	require.NoError(t, err)
+	require.Equal(t, nonce, quote.Nonce)
+
+	// The document is confirmed with the TEE's receipt for the stored file
+	receipt := chain.receipts[fileID]
+	require.NotNil(t, receipt)
+	require.Equal(t, fileID, receipt.FileID)
+	require.Equal(t, quote.InputHash, receipt.DataHash)
+	require.Equal(t, riskResp.RiskScore, receipt.RiskLevel)
+
+	// Submitting the same document twice is rejected by the chain
+	var errResp errorBody
+	status = doJSON(t, http.MethodPost, server.URL+"/gene-data/"+fileID+"/submit", token, map[string]any{
+		"gene_data":  "high risk",
+		"risk_score": riskResp.RiskScore,
+	}, &errResp)
+	require.Equal(t, http.StatusBadGateway, status)
+	require.Contains(t, errResp.Error, "Doc already been submitted")
+
+	// Read the PCSP balance
+	chain.balances[ethAddress] = big.NewInt(3000)
+	var balanceResp struct {
+		Address common.Address `json:"address"`
+		Balance string         `json:"balance"`
+	}
+	status = doJSON(t, http.MethodGet, server.URL+"/balances/"+ethAddress.Hex(), "", nil, &balanceResp)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, ethAddress, balanceResp.Address)
+	require.Equal(t, "3000", balanceResp.Balance)
+
+	// Retrieve the encrypted gene data and decrypt it with the user's private key
+	var geneResp struct {
+		FileID        string        `json:"file_id"`
+		UserID        string        `json:"user_id"`
+		ContentID     string        `json:"content_id"`
+		EncryptedData hexutil.Bytes `json:"encrypted_data"`
+		WrappedKeys   []wrappedKey  `json:"wrapped_keys"`
+
+		RiskAssessment struct {
+			RiskScore    uint8  `json:"risk_score"`
+			Model        string `json:"model"`
+			ModelVersion string `json:"model_version"`
+		} `json:"risk_assessment"`
+	}
+	status = doJSON(t, http.MethodGet, server.URL+"/gene-data/"+fileID, token, nil, &geneResp)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, fileID, geneResp.FileID)
+	require.Equal(t, userID, geneResp.UserID)
+
+	// The model that produced the confirmed score is recorded
+	require.Equal(t, riskResp.RiskScore, geneResp.RiskAssessment.RiskScore)
+	require.Equal(t, tee.GStrokeModelName, geneResp.RiskAssessment.Model)
+	require.Equal(t, tee.GStrokeModelVersion, geneResp.RiskAssessment.ModelVersion)
+
+	var decrypted bytes.Buffer
+	require.NoError(t, tee.NewTEEService().DecryptGeneDataWithDataKey(&decrypted, bytes.NewReader(geneResp.EncryptedData),
+		privateKey, toWrappedKeys(geneResp.WrappedKeys)))
+	require.Equal(t, "high risk", decrypted.String())
+
+	// The content ID of the encrypted data is anchored on-chain
+	require.Equal(t, storage.ContentID(geneResp.EncryptedData), geneResp.ContentID)
+	require.Equal(t, geneResp.ContentID, chain.anchored[fileID])
+
+	// Export the encrypted data as a CAR archive and verify it against the anchored content ID
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/gene-data/"+fileID+"/car", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/vnd.ipld.car; version=1", resp.Header.Get("Content-Type"))
+
+	exported, err := storage.ReadCAR(resp.Body, chain.anchored[fileID])
+	require.NoError(t, err)
+	require.Equal(t, []byte(geneResp.EncryptedData), exported)
+}
+
+func TestRegisterUser_InvalidPublicKey(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	var errResp errorBody
+	status := doJSON(t, http.MethodPost, server.URL+"/users", "", map[string]any{
+		"public_key": "0x1234",
+	}, &errResp)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "invalid public key", errResp.Error)
+
+	// Unknown fields are rejected
+	status = doJSON(t, http.MethodPost, server.URL+"/users", "", map[string]any{
+		"pubkey": "0x1234",
+	}, &errResp)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Contains(t, errResp.Error, "invalid request body")
+}
+
+func TestRegisterUser_Duplicate(t *testing.T) {
+	server, _ := newTestServer(t)
+	privateKey, _ := registerUser(t, server.URL)
+
+	// A public key registered before cannot be registered again, e.g. by someone else
+	var errResp errorBody
+	status := doJSON(t, http.MethodPost, server.URL+"/users", "", map[string]any{
+		"public_key": hexutil.Encode(crypto.FromECDSAPub(&privateKey.PublicKey)),
+	}, &errResp)
+	require.Equal(t, http.StatusConflict, status)
+	require.Equal(t, auth.ErrPublicKeyRegistered.Error(), errResp.Error)
+}
+
+func TestAuthenticate_Failures(t *testing.T) {
+	server, _ := newTestServer(t)
+	privateKey, userID := registerUser(t, server.URL)
+
+	// Signature from another key
+	attackerKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	nonce, signature := signChallenge(t, server.URL, attackerKey, userID)
+
+	var errResp errorBody
+	status := doJSON(t, http.MethodPost, server.URL+"/users/"+userID+"/authenticate", "", map[string]any{
+		"nonce":     nonce,
+		"signature": hexutil.Encode(signature),
+	}, &errResp)
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Equal(t, "signature does not match user", errResp.Error)
+
+	// Replaying a consumed challenge
+	_, signature = signChallenge(t, server.URL, privateKey, userID)
+	status = doJSON(t, http.MethodPost, server.URL+"/users/"+userID+"/authenticate", "", map[string]any{
+		"nonce":     nonce,
+		"signature": hexutil.Encode(signature),
+	}, &errResp)
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Equal(t, "challenge not found or already used", errResp.Error)
+
+	status = doJSON(t, http.MethodPost, server.URL+"/users/not-a-number/authenticate", "", map[string]any{
+		"nonce": nonce,
+	}, &errResp)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "invalid user ID", errResp.Error)
+
+	// Challenges are only issued to registered users
+	status = doJSON(t, http.MethodPost, server.URL+"/users/1/challenge", "", nil, &errResp)
+	require.Equal(t, http.StatusNotFound, status)
+	require.Equal(t, "user not found", errResp.Error)
+}
+
+func TestUploadGeneData_Failures(t *testing.T) {
+	server, _ := newTestServer(t)
+	privateKey, userID := registerUser(t, server.URL)
+	token := login(t, server.URL, privateKey, userID)
+
+	encryptedData := []byte("encrypted_gene_data")
+	hash := crypto.Keccak256Hash(encryptedData).Bytes()
+	signature, err := crypto.Sign(hash, privateKey)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		token  string
+		body   map[string]any
+		status int
+		error  string
+	}{
+		{
+			name: "missing token",
+			body: map[string]any{
+				"encrypted_data": hexutil.Encode(encryptedData),
+				"signature":      hexutil.Encode(signature),
+				"hash":           hexutil.Encode(hash),
+			},
+			status: http.StatusUnauthorized,
+			error:  "missing bearer token",
+		},
+		{
+			name:  "invalid hash",
+			token: token,
+			body: map[string]any{
+				"encrypted_data": hexutil.Encode(encryptedData),
+				"signature":      hexutil.Encode(signature),
+				"hash":           "0x1234",
+			},
+			status: http.StatusBadRequest,
+			error:  "invalid hash length",
+		},
+		{
+			name:  "mismatching hash",
+			token: token,
+			body: map[string]any{
+				"encrypted_data": hexutil.Encode(encryptedData),
+				"signature":      hexutil.Encode(signature),
+				"hash":           hexutil.Encode(crypto.Keccak256([]byte("other_gene_data"))),
+			},
+			status: http.StatusBadRequest,
+			error:  "hash does not match encrypted data",
+		},
+		{
+			name:  "invalid signature",
+			token: token,
+			body: map[string]any{
+				"encrypted_data": hexutil.Encode(encryptedData),
+				"signature":      hexutil.Encode(signature[:64]),
+				"hash":           hexutil.Encode(hash),
+			},
+			status: http.StatusBadRequest,
+			error:  "invalid signature length",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var errResp errorBody
+			status := doJSON(t, http.MethodPost, server.URL+"/gene-data", tc.token, tc.body, &errResp)
+			require.Equal(t, tc.status, status)
+			require.Equal(t, tc.error, errResp.Error)
+		})
+	}
+
+	// Uploading the same data twice conflicts
+	body := map[string]any{
+		"encrypted_data": hexutil.Encode(encryptedData),
+		"signature":      hexutil.Encode(signature),
+		"hash":           hexutil.Encode(hash),
+	}
+	status := doJSON(t, http.MethodPost, server.URL+"/gene-data", token, body, nil)
+	require.Equal(t, http.StatusCreated, status)
+
+	var errResp errorBody
+	status = doJSON(t, http.MethodPost, server.URL+"/gene-data", token, body, &errResp)
+	require.Equal(t, http.StatusConflict, status)
+	require.Equal(t, "gene data with the same hash already exists", errResp.Error)
+}
+
+func TestGeneData_NotFound(t *testing.T) {
+	server, _ := newTestServer(t)
+	privateKey, userID := registerUser(t, server.URL)
+	token := login(t, server.URL, privateKey, userID)
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		body   any
+	}{
+		{http.MethodGet, "/gene-data/unknown", nil},
+		{http.MethodGet, "/gene-data/unknown/car", nil},
+		{http.MethodPost, "/gene-data/unknown/verify", nil},
+		{http.MethodPost, "/gene-data/unknown/submit", map[string]any{"risk_score": 1}},
+	} {
+		t.Run(fmt.Sprintf("%s %s", tc.method, tc.path), func(t *testing.T) {
+			var errResp errorBody
+			status := doJSON(t, tc.method, server.URL+tc.path, token, tc.body, &errResp)
+			require.Equal(t, http.StatusNotFound, status)
+			require.NotEmpty(t, errResp.Error)
+		})
+	}
+}
+
+func TestCalculateRiskScore_UnrecognizedGeneData(t *testing.T) {
+	server, _ := newTestServer(t)
+	privateKey, userID := registerUser(t, server.URL)
+	token := login(t, server.URL, privateKey, userID)
+
+	var errResp errorBody
+	status := doJSON(t, http.MethodPost, server.URL+"/risk-score", token, map[string]any{
+		"gene_data": "ACGTACGT",
+	}, &errResp)
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+	require.Equal(t, "unrecognized gene data", errResp.Error)
+}
+
+func TestRiskModels(t *testing.T) {
+	server, _ := newTestServer(t)
+	privateKey, userID := registerUser(t, server.URL)
+	token := login(t, server.URL, privateKey, userID)
+
+	var models []struct {
+		Name        string `json:"name"`
+		Version     string `json:"version"`
+		Default     bool   `json:"default"`
+		InputSchema struct {
+			MediaType string `json:"media_type"`
+			MaxSize   int64  `json:"max_size"`
+		} `json:"input_schema"`
+	}
+	status := doJSON(t, http.MethodGet, server.URL+"/risk-models", "", nil, &models)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, models, 1)
+	require.Equal(t, tee.GStrokeModelName, models[0].Name)
+	require.Equal(t, tee.GStrokeModelVersion, models[0].Version)
+	require.True(t, models[0].Default)
+	require.Equal(t, "text/plain", models[0].InputSchema.MediaType)
+	require.Positive(t, models[0].InputSchema.MaxSize)
+
+	// Unknown model versions are rejected
+	var errResp errorBody
+	status = doJSON(t, http.MethodPost, server.URL+"/risk-score", token, map[string]any{
+		"gene_data":     "low risk",
+		"model":         tee.GStrokeModelName,
+		"model_version": "9.9.9",
+	}, &errResp)
+	require.Equal(t, http.StatusNotFound, status)
+	require.Equal(t, "risk model not found", errResp.Error)
+
+	fileID := uploadGeneData(t, server.URL, token, privateKey, userID, "low risk")
+	status = doJSON(t, http.MethodPost, server.URL+"/gene-data/"+fileID+"/submit", token, map[string]any{
+		"risk_score":    4,
+		"model":         tee.GStrokeModelName,
+		"model_version": "9.9.9",
+	}, &errResp)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "risk model not found", errResp.Error)
+}
+
+func TestSubmitOnChain_Failures(t *testing.T) {
+	server, chain := newTestServer(t)
+	privateKey, userID := registerUser(t, server.URL)
+	token := login(t, server.URL, privateKey, userID)
+	fileID := uploadGeneData(t, server.URL, token, privateKey, userID, "low risk")
+
+	// Risk score out of range
+	var errResp errorBody
+	status := doJSON(t, http.MethodPost, server.URL+"/gene-data/"+fileID+"/submit", token, map[string]any{
+		"risk_score": 5,
+	}, &errResp)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "risk score must be between 1 and 4", errResp.Error)
+
+	// The stored gene data is scored inside the TEE and must match the requested risk score
+	status = doJSON(t, http.MethodPost, server.URL+"/gene-data/"+fileID+"/submit", token, map[string]any{
+		"risk_score": 1,
+	}, &errResp)
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+	require.Equal(t, "risk score does not match the TEE result", errResp.Error)
+
+	unknownFileID := uploadGeneData(t, server.URL, token, privateKey, userID, "unknown")
+	status = doJSON(t, http.MethodPost, server.URL+"/gene-data/"+unknownFileID+"/submit", token, map[string]any{}, &errResp)
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+
+	// The TEE cannot score gene data whose data key is not wrapped to it
+	ownerOnlyFileID := uploadOwnerOnly(t, server.URL, token, privateKey, "low risk")
+	status = doJSON(t, http.MethodPost, server.URL+"/gene-data/"+ownerOnlyFileID+"/submit", token, map[string]any{}, &errResp)
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+	require.Equal(t, tee.ErrNoWrappedKey.Error(), errResp.Error)
+
+	// Chain errors surface as bad gateway
+	chain.uploadErr = errors.New("failed to upload data: rpc unavailable")
+	status = doJSON(t, http.MethodPost, server.URL+"/gene-data/"+fileID+"/submit", token, map[string]any{
+		"gene_data":  "low risk",
+		"risk_score": 4,
+	}, &errResp)
+	require.Equal(t, http.StatusBadGateway, status)
+	require.Equal(t, "failed to upload data: rpc unavailable", errResp.Error)
+}
+
+func TestSubmitOnChain_MismatchedGeneData(t *testing.T) {
+	server, chain := newTestServer(t)
+	privateKey, userID := registerUser(t, server.URL)
+	token := login(t, server.URL, privateKey, userID)
+	fileID := uploadGeneData(t, server.URL, token, privateKey, userID, "low risk")
+
+	// Gene data other than the stored file cannot be confirmed, even with its own risk score
+	var errResp errorBody
+	status := doJSON(t, http.MethodPost, server.URL+"/gene-data/"+fileID+"/submit", token, map[string]any{
+		"gene_data":  "extremely high risk",
+		"risk_score": tee.RiskLevelExtremelyHigh.Score(),
+	}, &errResp)
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+	require.Equal(t, "gene data does not match the stored file", errResp.Error)
+	require.NotContains(t, chain.sessions, fileID)
+	require.NotContains(t, chain.confirmed, fileID)
+
+	// The stored gene data is confirmed with the score the TEE computed from it
+	var submitResp struct {
+		RiskScore uint8 `json:"risk_score"`
+	}
+	status = doJSON(t, http.MethodPost, server.URL+"/gene-data/"+fileID+"/submit", token, map[string]any{
+		"gene_data": "low risk",
+	}, &submitResp)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, tee.RiskLevelLow.Score(), submitResp.RiskScore)
+	require.Equal(t, tee.RiskLevelLow.Score(), chain.confirmed[fileID])
+	require.Equal(t, crypto.Keccak256Hash([]byte("low risk")), chain.receipts[fileID].DataHash)
+}
+
+func TestSubmitOnChain_ResumesUploadSession(t *testing.T) {
+	server, chain := newTestServer(t)
+	privateKey, userID := registerUser(t, server.URL)
+	token := login(t, server.URL, privateKey, userID)
+	fileID := uploadGeneData(t, server.URL, token, privateKey, userID, "high risk")
+
+	// The upload is mined but confirming fails
+	chain.confirmErr = errors.New("failed to confirm session: rpc unavailable")
+	var errResp errorBody
+	status := doJSON(t, http.MethodPost, server.URL+"/gene-data/"+fileID+"/submit", token, map[string]any{
+		"gene_data": "high risk",
+	}, &errResp)
+	require.Equal(t, http.StatusBadGateway, status)
+	require.Equal(t, "failed to confirm session: rpc unavailable", errResp.Error)
+	require.Contains(t, chain.sessions, fileID)
+
+	// Retrying confirms the session of the earlier upload, without a new upload transaction
+	chain.confirmErr = nil
+	var submitResp map[string]any
+	status = doJSON(t, http.MethodPost, server.URL+"/gene-data/"+fileID+"/submit", token, map[string]any{
+		"gene_data": "high risk",
+	}, &submitResp)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "0", submitResp["session_id"])
+	require.NotContains(t, submitResp, "tx_hash")
+	require.Equal(t, tee.RiskLevelHigh.Score(), chain.confirmed[fileID])
+
+	// A confirmed session cannot be resumed
+	status = doJSON(t, http.MethodPost, server.URL+"/gene-data/"+fileID+"/submit", token, map[string]any{
+		"gene_data": "high risk",
+	}, &errResp)
+	require.Equal(t, http.StatusBadGateway, status)
+	require.Contains(t, errResp.Error, "Doc already been submitted")
+}
+
+func TestSessionAuthorization(t *testing.T) {
+	server, _ := newTestServer(t)
+	ownerKey, ownerID := registerUser(t, server.URL)
+	ownerToken := login(t, server.URL, ownerKey, ownerID)
+	fileID := uploadGeneData(t, server.URL, ownerToken, ownerKey, ownerID, "low risk")
+
+	otherKey, otherID := registerUser(t, server.URL)
+	otherToken := login(t, server.URL, otherKey, otherID)
+
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		body   any
+		status int
+		error  string
+	}{
+		{"missing token", http.MethodGet, "/gene-data/" + fileID, "", nil, http.StatusUnauthorized, "missing bearer token"},
+		{"invalid token", http.MethodGet, "/gene-data/" + fileID, "not-a-token", nil, http.StatusUnauthorized, ""},
+		{"read other user's data", http.MethodGet, "/gene-data/" + fileID, otherToken, nil, http.StatusForbidden, "access to gene data denied"},
+		{"export other user's data", http.MethodGet, "/gene-data/" + fileID + "/car", otherToken, nil, http.StatusForbidden, "access to gene data denied"},
+		{"submit other user's data", http.MethodPost, "/gene-data/" + fileID + "/submit", otherToken, map[string]any{"risk_score": 1}, http.StatusForbidden, "gene data does not belong to user"},
+		{"encrypt for other user", http.MethodPost, "/users/" + ownerID + "/gene-data/encrypt", otherToken, map[string]any{"gene_data": "low risk"}, http.StatusForbidden, "session does not belong to user"},
+		{"risk score without token", http.MethodPost, "/risk-score", "", map[string]any{"gene_data": "low risk"}, http.StatusUnauthorized, "missing bearer token"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var errResp errorBody
+			status := doJSON(t, tc.method, server.URL+tc.path, tc.token, tc.body, &errResp)
+			require.Equal(t, tc.status, status)
+			if tc.error != "" {
+				require.Equal(t, tc.error, errResp.Error)
+			} else {
+				require.NotEmpty(t, errResp.Error)
+			}
+		})
+	}
+
+	// A revoked token is rejected
+	var revokeResp struct {
+		Revoked bool `json:"revoked"`
+	}
+	status := doJSON(t, http.MethodPost, server.URL+"/sessions/revoke", ownerToken, nil, &revokeResp)
+	require.Equal(t, http.StatusOK, status)
+	require.True(t, revokeResp.Revoked)
+
+	var errResp errorBody
+	status = doJSON(t, http.MethodGet, server.URL+"/gene-data/"+fileID, ownerToken, nil, &errResp)
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Equal(t, "session revoked", errResp.Error)
+}
+
+func TestGetBalance_InvalidAddress(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	var errResp errorBody
+	status := doJSON(t, http.MethodGet, server.URL+"/balances/not-an-address", "", nil, &errResp)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "invalid address", errResp.Error)
+}
+
+func TestSignInWithEthereum(t *testing.T) {
+	authService := auth.NewAuthService()
+	authService.ConfigureSIWE(auth.SIWEConfig{
+		Domain:  "app.genomicdao.com",
+		URI:     "https://app.genomicdao.com",
+		ChainID: 11155420,
+	})
+	server := httptest.NewServer(gateway.NewServer(gateway.Services{
+		Auth:    authService,
+		Storage: storage.NewGeneDataStorageService(),
+		TEE:     tee.NewTEEService(),
+	}))
+	defer server.Close()
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+
+	// Request a SIWE message for the wallet
+	var messageResp struct {
+		Message string `json:"message"`
+	}
+	status := doJSON(t, http.MethodPost, server.URL+"/siwe/message", "", map[string]any{
+		"address":   address.Hex(),
+		"statement": "Sign in to GenomicDAO.",
+	}, &messageResp)
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, messageResp.Message, address.Hex())
+
+	// Sign it and log in
+	signature, err := crypto.Sign(accounts.TextHash([]byte(messageResp.Message)), privateKey)
+	require.NoError(t, err)
+
+	var sessionResp struct {
+		Token      string         `json:"token"`
+		UserID     string         `json:"user_id"`
+		ETHAddress common.Address `json:"eth_address"`
+	}
+	status = doJSON(t, http.MethodPost, server.URL+"/siwe/login", "", map[string]any{
+		"message":   messageResp.Message,
+		"signature": hexutil.Encode(signature),
+	}, &sessionResp)
+	require.Equal(t, http.StatusOK, status)
+	require.NotEmpty(t, sessionResp.Token)
+	require.NotEmpty(t, sessionResp.UserID)
+	require.Equal(t, address, sessionResp.ETHAddress)
+
+	// The session token authorizes user-scoped routes
+	status = doJSON(t, http.MethodPost, server.URL+"/users/"+sessionResp.UserID+"/gene-data/encrypt", sessionResp.Token, map[string]any{
+		"gene_data": "low risk",
+	}, nil)
+	require.Equal(t, http.StatusOK, status)
+
+	// The message cannot be replayed
+	var errResp errorBody
+	status = doJSON(t, http.MethodPost, server.URL+"/siwe/login", "", map[string]any{
+		"message":   messageResp.Message,
+		"signature": hexutil.Encode(signature),
+	}, &errResp)
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Equal(t, "siwe: unknown or already used nonce", errResp.Error)
+}
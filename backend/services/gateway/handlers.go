@@ -0,0 +1,667 @@
+package gateway
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/auth"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/blockchain"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/storage"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/tee"
+)
+
+type registerUserRequest struct {
+	PublicKey hexutil.Bytes `json:"public_key"`
+}
+
+type registerUserResponse struct {
+	UserID     uint64 `json:"user_id,string"`
+	ETHAddress string `json:"eth_address"`
+}
+
+// handleRegisterUser registers a new user with their uncompressed secp256k1 public key.
+func (s *Server) handleRegisterUser(w http.ResponseWriter, r *http.Request) {
+	var req registerUserRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	publicKey, err := crypto.UnmarshalPubkey(req.PublicKey)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid public key")
+		return
+	}
+
+	userID, err := s.services.Auth.RegisterUserWithPubkey(req.PublicKey)
+	if errors.Is(err, auth.ErrPublicKeyRegistered) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, registerUserResponse{
+		UserID:     userID,
+		ETHAddress: crypto.PubkeyToAddress(*publicKey).Hex(),
+	})
+}
+
+type challengeResponse struct {
+	Nonce     string    `json:"nonce"`
+	Message   string    `json:"message"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// handleIssueChallenge issues a single-use login challenge for the user to sign with personal_sign.
+func (s *Server) handleIssueChallenge(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUserID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	challenge, err := s.services.Auth.IssueChallenge(userID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, challengeResponse{
+		Nonce:     challenge.Nonce,
+		Message:   challenge.Message,
+		ExpiresAt: challenge.ExpiresAt,
+	})
+}
+
+type authenticateRequest struct {
+	Nonce     string        `json:"nonce"`
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+// handleAuthenticate authenticates a user by their signature over a previously issued challenge
+// and returns a session token.
+func (s *Server) handleAuthenticate(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUserID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req authenticateRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	session, err := s.services.Auth.Authenticate(userID, req.Nonce, req.Signature)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newSessionResponse(session))
+}
+
+type siweMessageRequest struct {
+	Address   common.Address `json:"address"`
+	Statement string         `json:"statement"`
+	Resources []string       `json:"resources"`
+}
+
+type siweMessageResponse struct {
+	Message string `json:"message"`
+}
+
+// handleSIWEMessage creates an EIP-4361 message with a fresh nonce for the wallet to sign.
+func (s *Server) handleSIWEMessage(w http.ResponseWriter, r *http.Request) {
+	var req siweMessageRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	message, err := s.services.Auth.NewSIWEMessage(req.Address, req.Statement, req.Resources)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, siweMessageResponse{Message: message.String()})
+}
+
+type siweLoginRequest struct {
+	Message   string        `json:"message"`
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+type sessionResponse struct {
+	Token      string         `json:"token"`
+	UserID     uint64         `json:"user_id,string"`
+	ETHAddress common.Address `json:"eth_address"`
+	ExpiresAt  time.Time      `json:"expires_at"`
+}
+
+// handleSIWELogin validates a signed EIP-4361 message and returns a session token.
+func (s *Server) handleSIWELogin(w http.ResponseWriter, r *http.Request) {
+	var req siweLoginRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	session, err := s.services.Auth.SignInWithEthereum(req.Message, req.Signature)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newSessionResponse(session))
+}
+
+// newSessionResponse converts a session into its JSON representation.
+func newSessionResponse(session auth.Session) sessionResponse {
+	return sessionResponse{
+		Token:      session.Token,
+		UserID:     session.UserID,
+		ETHAddress: session.ETHAddress,
+		ExpiresAt:  session.ExpiresAt,
+	}
+}
+
+type revokeSessionResponse struct {
+	Revoked bool `json:"revoked"`
+}
+
+// handleRevokeSession revokes the session token presented with the request.
+func (s *Server) handleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	if err := s.services.Auth.RevokeSession(sessionFromRequest(r).Token); err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, revokeSessionResponse{Revoked: true})
+}
+
+type encryptGeneDataRequest struct {
+	GeneData string `json:"gene_data"`
+	DataKey  bool   `json:"data_key"` // Encrypt under a per-file data key wrapped to the user's and the TEE's public keys.
+}
+
+type encryptGeneDataResponse struct {
+	EncryptedData hexutil.Bytes    `json:"encrypted_data"`
+	WrappedKeys   []wrappedKeyBody `json:"wrapped_keys,omitempty"`
+}
+
+type wrappedKeyBody struct {
+	Recipient common.Address `json:"recipient"`
+	Key       hexutil.Bytes  `json:"key"`
+}
+
+// handleEncryptGeneData encrypts gene data to the user's registered public key inside the TEE.
+func (s *Server) handleEncryptGeneData(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUserID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if sessionFromRequest(r).UserID != userID {
+		writeError(w, http.StatusForbidden, "session does not belong to user")
+		return
+	}
+
+	var req encryptGeneDataRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	publicKeyBytes, err := s.services.Auth.GetUserPubkey(userID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if req.DataKey {
+		var encrypted bytes.Buffer
+		// The TEE needs the data key to score and share the data without the user's private key
+		recipients := [][]byte{publicKeyBytes, s.services.TEE.EnclavePublicKey()}
+		wrappedKeys, err := s.services.TEE.EncryptGeneDataWithDataKey(&encrypted, strings.NewReader(req.GeneData), recipients, nil)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to encrypt gene data: %v", err))
+			return
+		}
+		writeJSON(w, http.StatusOK, encryptGeneDataResponse{
+			EncryptedData: encrypted.Bytes(),
+			WrappedKeys:   newWrappedKeyBodies(fromTEEWrappedKeys(wrappedKeys)),
+		})
+		return
+	}
+
+	encryptedData, err := s.services.TEE.EncryptGeneData(publicKeyBytes, req.GeneData)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to encrypt gene data: %v", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, encryptGeneDataResponse{EncryptedData: encryptedData})
+}
+
+type uploadGeneDataRequest struct {
+	EncryptedData hexutil.Bytes `json:"encrypted_data"`
+	Signature     hexutil.Bytes `json:"signature"`
+	Hash          hexutil.Bytes `json:"hash"`
+
+	WrappedKeys []wrappedKeyBody `json:"wrapped_keys"` // Only for gene data encrypted with a data key.
+}
+
+type uploadGeneDataResponse struct {
+	FileID string `json:"file_id"`
+}
+
+// handleUploadGeneData stores encrypted gene data, owned by the session's user, along with the
+// owner's signature over its hash.
+func (s *Server) handleUploadGeneData(w http.ResponseWriter, r *http.Request) {
+	userID := sessionFromRequest(r).UserID
+
+	var req uploadGeneDataRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(req.EncryptedData) == 0 {
+		writeError(w, http.StatusBadRequest, "encrypted data is required")
+		return
+	}
+	if len(req.Hash) != common.HashLength {
+		writeError(w, http.StatusBadRequest, "invalid hash length")
+		return
+	}
+	if len(req.Signature) != crypto.SignatureLength {
+		writeError(w, http.StatusBadRequest, "invalid signature length")
+		return
+	}
+
+	wrappedKeys := make([]storage.WrappedKey, 0, len(req.WrappedKeys))
+	for _, key := range req.WrappedKeys {
+		wrappedKeys = append(wrappedKeys, storage.WrappedKey{Recipient: key.Recipient, Key: key.Key})
+	}
+
+	fileID, err := s.services.Storage.StoreGeneDataWithKeys(userID, req.EncryptedData, req.Signature, req.Hash, wrappedKeys)
+	if errors.Is(err, storage.ErrHashMismatch) || errors.Is(err, storage.ErrInvalidWrappedKey) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, storage.ErrGeneDataExists) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, uploadGeneDataResponse{FileID: fileID})
+}
+
+type geneDataResponse struct {
+	FileID        string        `json:"file_id"`
+	UserID        uint64        `json:"user_id,string"`
+	Hash          hexutil.Bytes `json:"hash"`
+	Signature     hexutil.Bytes `json:"signature"`
+	ContentID     string        `json:"content_id"`
+	EncryptedData hexutil.Bytes `json:"encrypted_data"`
+
+	WrappedKeys    []wrappedKeyBody        `json:"wrapped_keys,omitempty"`
+	RiskAssessment *riskAssessmentResponse `json:"risk_assessment,omitempty"`
+}
+
+type riskAssessmentResponse struct {
+	RiskScore    uint8  `json:"risk_score"`
+	Model        string `json:"model"`
+	ModelVersion string `json:"model_version"`
+}
+
+// handleRetrieveGeneData returns the stored encrypted gene data and its metadata to its owner, or
+// to a grantee with an active consent grant for the purpose given in the query.
+func (s *Server) handleRetrieveGeneData(w http.ResponseWriter, r *http.Request) {
+	geneData, err := s.services.Storage.AccessGeneData(r.PathValue("fileID"), accessorFromRequest(r))
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	resp := geneDataResponse{
+		FileID:        geneData.FileID,
+		UserID:        geneData.UserID,
+		Hash:          geneData.DataHash,
+		Signature:     geneData.Signature,
+		ContentID:     geneData.ContentID,
+		EncryptedData: geneData.EncryptedData,
+		WrappedKeys:   newWrappedKeyBodies(geneData.WrappedKeys),
+	}
+	if assessment := geneData.RiskAssessment; assessment != nil {
+		resp.RiskAssessment = &riskAssessmentResponse{
+			RiskScore:    assessment.RiskScore,
+			Model:        assessment.Model,
+			ModelVersion: assessment.ModelVersion,
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleExportGeneData returns the stored encrypted gene data as a CARv1 archive whose root is
+// the content ID anchored on-chain, so it can be imported into IPFS. Access is the same as for
+// handleRetrieveGeneData.
+func (s *Server) handleExportGeneData(w http.ResponseWriter, r *http.Request) {
+	geneData, err := s.services.Storage.AccessGeneData(r.PathValue("fileID"), accessorFromRequest(r))
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.ipld.car; version=1")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", geneData.ContentID+".car"))
+	_, _ = storage.WriteCAR(w, geneData.EncryptedData)
+}
+
+type verifySignatureResponse struct {
+	Valid bool `json:"valid"`
+}
+
+// handleVerifySignature verifies the stored signature against the owner's registered public key.
+func (s *Server) handleVerifySignature(w http.ResponseWriter, r *http.Request) {
+	fileID := r.PathValue("fileID")
+
+	geneData, err := s.services.Storage.GetGeneData(fileID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	publicKeyBytes, err := s.services.Auth.GetUserPubkey(geneData.UserID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	isValid, err := s.services.Storage.VerifyGeneDataSignature(fileID, publicKeyBytes)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, verifySignatureResponse{Valid: isValid})
+}
+
+type riskModelResponse struct {
+	Name        string              `json:"name"`
+	Version     string              `json:"version"`
+	InputSchema inputSchemaResponse `json:"input_schema"`
+	Default     bool                `json:"default"`
+}
+
+type inputSchemaResponse struct {
+	MediaType   string `json:"media_type"`
+	MaxSize     int64  `json:"max_size"`
+	Description string `json:"description"`
+}
+
+// handleListRiskModels lists the risk models registered in the TEE.
+func (s *Server) handleListRiskModels(w http.ResponseWriter, r *http.Request) {
+	defaultModel, err := s.services.TEE.RiskModel("", "")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models := s.services.TEE.RiskModels()
+	resp := make([]riskModelResponse, 0, len(models))
+	for _, model := range models {
+		schema := model.InputSchema()
+		resp = append(resp, riskModelResponse{
+			Name:    model.Name(),
+			Version: model.Version(),
+			InputSchema: inputSchemaResponse{
+				MediaType:   schema.MediaType,
+				MaxSize:     schema.MaxSize,
+				Description: schema.Description,
+			},
+			Default: model.Name() == defaultModel.Name() && model.Version() == defaultModel.Version(),
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+type teeInfoResponse struct {
+	EnclavePublicKey hexutil.Bytes  `json:"enclave_public_key"`
+	AttestationKey   common.Address `json:"attestation_key"`
+	Measurement      common.Hash    `json:"measurement"`
+}
+
+// handleTEEInfo returns the public key data keys are wrapped to for the TEE, and the key and
+// measurement its attestation quotes can be verified against.
+func (s *Server) handleTEEInfo(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, teeInfoResponse{
+		EnclavePublicKey: s.services.TEE.EnclavePublicKey(),
+		AttestationKey:   s.services.TEE.AttestationKey(),
+		Measurement:      s.services.TEE.Measurement(),
+	})
+}
+
+type calculateRiskScoreRequest struct {
+	GeneData     string `json:"gene_data"`
+	Model        string `json:"model"`         // Optional, defaults to the default model.
+	ModelVersion string `json:"model_version"` // Required if Model is set.
+}
+
+type calculateRiskScoreResponse struct {
+	RiskScore    uint8  `json:"risk_score"`
+	RiskLevel    string `json:"risk_level"`
+	Model        string `json:"model"`
+	ModelVersion string `json:"model_version"`
+}
+
+// handleCalculateRiskScore calculates the stroke risk score of the gene data inside the TEE with
+// the requested risk model.
+func (s *Server) handleCalculateRiskScore(w http.ResponseWriter, r *http.Request) {
+	var req calculateRiskScoreRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := s.services.TEE.ScoreGeneData(r.Context(), req.Model, req.ModelVersion, strings.NewReader(req.GeneData))
+	if errors.Is(err, tee.ErrRiskModelNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, calculateRiskScoreResponse{
+		RiskScore:    result.RiskLevel.Score(),
+		RiskLevel:    result.RiskLevel.String(),
+		Model:        result.Model,
+		ModelVersion: result.ModelVersion,
+	})
+}
+
+type submitOnChainRequest struct {
+	GeneData     string `json:"gene_data"`     // Optional, must be the stored gene data the TEE scores if set.
+	RiskScore    uint8  `json:"risk_score"`    // Optional, must match the TEE's risk score if set.
+	Model        string `json:"model"`         // Model to score with, defaults to the default model.
+	ModelVersion string `json:"model_version"` // Required if Model is set.
+}
+
+type submitOnChainResponse struct {
+	// Upload transaction, omitted when an earlier upload session is resumed.
+	TxHash       *common.Hash `json:"tx_hash,omitempty"`
+	BlockNumber  uint64       `json:"block_number,omitempty"`
+	GasUsed      uint64       `json:"gas_used,omitempty"`
+	SessionID    string       `json:"session_id"`
+	RiskScore    uint8        `json:"risk_score"`
+	Model        string       `json:"model"`
+	ModelVersion string       `json:"model_version"`
+	Proof        string       `json:"proof"` // The attestation quote passed to the Controller contract.
+}
+
+// handleSubmitOnChain scores the stored gene data inside the TEE, which unwraps its data key with
+// the enclave key, records the TEE's computation receipt, uploads the document to the Controller
+// contract and confirms the resulting session with the receipt's risk level and the TEE's
+// attestation quote for it, which mints the G-NFT and rewards PCSP tokens. The quote's nonce is the
+// Controller's confirm nonce of the document, content ID and session, so it cannot be replayed for
+// another confirmation. If the document was uploaded by an earlier submission that was not
+// confirmed, its session is resumed. The risk model that produced the confirmed score is recorded
+// with the gene data.
+func (s *Server) handleSubmitOnChain(w http.ResponseWriter, r *http.Request) {
+	fileID := r.PathValue("fileID")
+
+	var req submitOnChainRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.RiskScore != 0 && !tee.RiskLevel(req.RiskScore).Valid() {
+		writeError(w, http.StatusBadRequest, "risk score must be between 1 and 4")
+		return
+	}
+	model, err := s.services.TEE.RiskModel(req.Model, req.ModelVersion)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	geneData, err := s.services.Storage.GetGeneData(fileID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if geneData.UserID != sessionFromRequest(r).UserID {
+		writeError(w, http.StatusForbidden, "gene data does not belong to user")
+		return
+	}
+
+	// Score the stored file before uploading so that invalid gene data does not open an on-chain
+	// session
+	result, receipt, err := s.services.TEE.ScoreEncryptedGeneData(r.Context(), geneData.EncryptedData,
+		toTEEWrappedKeys(geneData.WrappedKeys), model.Name(), model.Version())
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if req.GeneData != "" && crypto.Keccak256Hash([]byte(req.GeneData)) != receipt.DataHash {
+		writeError(w, http.StatusUnprocessableEntity, "gene data does not match the stored file")
+		return
+	}
+	riskScore := receipt.RiskLevel
+	if req.RiskScore != 0 && req.RiskScore != riskScore {
+		writeError(w, http.StatusUnprocessableEntity, "risk score does not match the TEE result")
+		return
+	}
+	if err := s.services.Storage.RecordReceipt(fileID, *receipt); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var response submitOnChainResponse
+	var sessionID *big.Int
+	upload, err := s.services.Controller.UploadData(fileID)
+	switch {
+	case errors.Is(err, blockchain.ErrDocAlreadySubmitted):
+		// Recover the session of an earlier upload; confirming fails if it was already confirmed
+		// or was opened by another sender
+		sessionID, err = s.services.Sessions.ListenForUploadDataEvents(fileID)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, fmt.Sprintf("%v: failed to recover its session: %v", blockchain.ErrDocAlreadySubmitted, err))
+			return
+		}
+	case err != nil:
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	default:
+		sessionID = upload.SessionID
+		response.TxHash = &upload.TxHash
+		response.BlockNumber = upload.BlockNumber
+		response.GasUsed = upload.GasUsed
+	}
+
+	// The contract only accepts a quote for this document, content ID and session
+	nonce, err := s.services.Controller.ConfirmNonce(fileID, geneData.ContentID, sessionID)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	quote, err := s.services.TEE.Attest(result, nonce)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	proof, err := quote.Proof()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Anchor the content ID so the data can be verified against the chain alone
+	err = s.services.Controller.ConfirmReceipt(receipt, geneData.ContentID, quote, sessionID)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	err = s.services.Storage.RecordRiskAssessment(fileID, storage.RiskAssessment{
+		RiskScore:    riskScore,
+		Model:        model.Name(),
+		ModelVersion: model.Version(),
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.SessionID = sessionID.String()
+	response.RiskScore = riskScore
+	response.Model = model.Name()
+	response.ModelVersion = model.Version()
+	response.Proof = proof
+	writeJSON(w, http.StatusOK, response)
+}
+
+type balanceResponse struct {
+	Address common.Address `json:"address"`
+	Balance string         `json:"balance"`
+}
+
+// handleGetBalance returns the PCSP balance of an address.
+func (s *Server) handleGetBalance(w http.ResponseWriter, r *http.Request) {
+	address := r.PathValue("address")
+	if !common.IsHexAddress(address) {
+		writeError(w, http.StatusBadRequest, "invalid address")
+		return
+	}
+
+	balance, err := s.services.PCSP.GetBalance(common.HexToAddress(address))
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, balanceResponse{
+		Address: common.HexToAddress(address),
+		Balance: balance.String(),
+	})
+}
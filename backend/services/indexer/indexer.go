@@ -0,0 +1,536 @@
+// Package indexer keeps a local, reorg-aware index of the Controller's uploads, the gene NFTs
+// and the PCSP rewards, so they can be queried without calling the node.
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/contracts"
+)
+
+const (
+	defaultReorgDepth   = 64
+	defaultPollInterval = 5 * time.Second
+	defaultBatchSize    = 1000
+)
+
+// Errors returned by the Indexer.
+var (
+	ErrNotFound     = errors.New("not found in the index")
+	ErrReorgTooDeep = errors.New("reorg is deeper than the indexer can roll back")
+)
+
+// Backend is the Ethereum client the Indexer reads blocks, logs and transactions with. It is
+// implemented by *ethclient.Client and by the client of go-ethereum's simulated backend.
+type Backend interface {
+	ethereum.LogFilterer
+	ethereum.TransactionReader
+	ethereum.ChainIDReader
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// Addresses are the addresses of the indexed contracts.
+type Addresses struct {
+	Controller common.Address
+	GeneNFT    common.Address
+	PCSP       common.Address
+}
+
+// Config configures an Indexer.
+type Config struct {
+	// StartBlock is the first block to index, e.g. the block the contracts were deployed in.
+	StartBlock uint64
+	// ReorgDepth is how many blocks below the head can be rolled back. Defaults to 64.
+	ReorgDepth uint64
+	// PollInterval is how often Run indexes new blocks. Defaults to 5s.
+	PollInterval time.Duration
+	// BatchSize is the number of blocks below the reorg window indexed per log query. Defaults
+	// to 1000.
+	BatchSize uint64
+	// OnError is called with the errors of the syncs Run retries. Errors are ignored if it is nil.
+	OnError func(error)
+	// OnRollback is called with the number and hash of every block rolled back after a reorg.
+	OnRollback func(number uint64, hash common.Hash)
+}
+
+// Upload is an indexed UploadData event: a document submitted to the Controller.
+// The event does not name the session's owner, so Sender is the transaction's sender: it owns
+// the session when it called the Controller directly, but uploads made through another contract,
+// e.g. a smart wallet or a relayer, are attributed to the account that sent the transaction.
+type Upload struct {
+	DocID       string
+	SessionID   *big.Int
+	Sender      common.Address // Account that sent the upload transaction.
+	BlockNumber uint64
+	TxHash      common.Hash
+	LogIndex    uint
+}
+
+// NFT is an indexed gene NFT. A token minted before Config.StartBlock is indexed from its first
+// transfer, without its document and mint block and transaction.
+type NFT struct {
+	TokenID    *big.Int
+	DocID      string // Document confirmed when the token was minted, empty if minted otherwise.
+	Owner      common.Address
+	MintBlock  uint64
+	MintTxHash common.Hash
+}
+
+// Indexer indexes the UploadData events of the Controller and the Transfer events of GeneNFT
+// and PCSP into a bbolt database. Blocks deeper than the reorg depth below the chain's head are
+// final and indexed in ranges of BatchSize blocks per log query. Blocks within the reorg depth are
+// indexed block by block, recording their hash and events, and when the chain no longer builds
+// on such a block it is rolled back before indexing the new chain.
+type Indexer struct {
+	client        Backend
+	store         *store
+	addresses     Addresses
+	config        Config
+	controllerABI *abi.ABI
+	transferTopic common.Hash // Topic of the Transfer events of both tokens.
+	controller    *contracts.ControllerFilterer
+	geneNFT       *contracts.GeneNFTFilterer
+	pcsp          *contracts.PCSPFilterer
+
+	mu     sync.Mutex // Serializes syncs.
+	signer types.Signer
+}
+
+// NewIndexer opens (or creates) the index database at path and initializes an Indexer of the
+// contracts at the given addresses.
+func NewIndexer(client Backend, path string, addresses Addresses, config Config) (*Indexer, error) {
+	// Parse the events and the confirm call of the contracts
+	controllerABI, err := contracts.ControllerMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Controller ABI: %w", err)
+	}
+	pcspABI, err := contracts.PCSPMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PCSP ABI: %w", err)
+	}
+	controller, err := contracts.NewControllerFilterer(addresses.Controller, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate Controller contract: %w", err)
+	}
+	geneNFT, err := contracts.NewGeneNFTFilterer(addresses.GeneNFT, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate GeneNFT contract: %w", err)
+	}
+	pcsp, err := contracts.NewPCSPFilterer(addresses.PCSP, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate PCSP contract: %w", err)
+	}
+
+	// Apply the defaults
+	if config.ReorgDepth == 0 {
+		config.ReorgDepth = defaultReorgDepth
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultPollInterval
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = defaultBatchSize
+	}
+	if config.OnError == nil {
+		config.OnError = func(error) {}
+	}
+	if config.OnRollback == nil {
+		config.OnRollback = func(uint64, common.Hash) {}
+	}
+
+	store, err := openStore(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open index database: %w", err)
+	}
+
+	return &Indexer{
+		client:        client,
+		store:         store,
+		addresses:     addresses,
+		config:        config,
+		controllerABI: controllerABI,
+		transferTopic: pcspABI.Events["Transfer"].ID,
+		controller:    controller,
+		geneNFT:       geneNFT,
+		pcsp:          pcsp,
+	}, nil
+}
+
+// Close closes the index database.
+func (ix *Indexer) Close() error {
+	return ix.store.db.Close()
+}
+
+// Run indexes new blocks every poll interval until the context is cancelled, and then returns
+// the context's error. Failed syncs are retried on the next interval.
+func (ix *Indexer) Run(ctx context.Context) error {
+	for {
+		if err := ix.Sync(ctx); err != nil && ctx.Err() == nil {
+			ix.config.OnError(err)
+		}
+
+		// Wait before indexing again
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(ix.config.PollInterval):
+		}
+	}
+}
+
+// Sync indexes the blocks up to the latest one. Indexed blocks the chain no longer builds on
+// are rolled back first, and blocks below the reorg window are indexed in ranges.
+func (ix *Indexer) Sync(ctx context.Context) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	// Sign transactions of this chain to recover their senders
+	if ix.signer == nil {
+		chainID, err := ix.client.ChainID(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get the chain ID: %w", err)
+		}
+		ix.signer = types.LatestSignerForChainID(chainID)
+	}
+
+	latest, err := ix.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get the latest block: %w", err)
+	}
+	// Blocks up to final are deeper than the reorg window
+	final, hasFinal := uint64(0), latest.Number.Uint64() >= ix.config.ReorgDepth
+	if hasFinal {
+		final = latest.Number.Uint64() - ix.config.ReorgDepth
+	}
+
+	for {
+		head, indexed, err := ix.store.head()
+		if err != nil {
+			return fmt.Errorf("failed to read the indexed head: %w", err)
+		}
+		next := ix.config.StartBlock
+		if indexed {
+			next = head.Number + 1
+		}
+
+		// Once caught up, check that the chain still includes the indexed head
+		if next > latest.Number.Uint64() {
+			if !indexed {
+				return nil
+			}
+			header, err := ix.client.HeaderByNumber(ctx, new(big.Int).SetUint64(head.Number))
+			if err != nil && !errors.Is(err, ethereum.NotFound) {
+				return fmt.Errorf("failed to get block %d: %w", head.Number, err)
+			}
+			if err == nil && header.Hash() == head.Hash {
+				return nil
+			}
+			if err := ix.rollback(head); err != nil {
+				return err
+			}
+			continue
+		}
+
+		header, err := ix.client.HeaderByNumber(ctx, new(big.Int).SetUint64(next))
+		if err != nil {
+			return fmt.Errorf("failed to get block %d: %w", next, err)
+		}
+
+		// A block that does not build on the indexed head means the head was reorged out
+		if indexed && header.ParentHash != head.Hash {
+			if err := ix.rollback(head); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if hasFinal && next <= final {
+			if err := ix.indexRange(ctx, next, min(next+ix.config.BatchSize-1, final)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := ix.index(ctx, header); err != nil {
+			return err
+		}
+	}
+}
+
+// rollback rolls back the indexed head block.
+func (ix *Indexer) rollback(head blockRef) error {
+	if err := ix.store.rollback(head); err != nil {
+		return fmt.Errorf("failed to roll back block %d: %w", head.Number, err)
+	}
+	ix.config.OnRollback(head.Number, head.Hash)
+	return nil
+}
+
+// indexRange indexes the events of the blocks from and to, which must be below the reorg window,
+// with one log query. The blocks are not recorded, so they can no longer be rolled back.
+func (ix *Indexer) indexRange(ctx context.Context, from, to uint64) error {
+	header, err := ix.client.HeaderByNumber(ctx, new(big.Int).SetUint64(to))
+	if err != nil {
+		return fmt.Errorf("failed to get block %d: %w", to, err)
+	}
+	logs, err := ix.client.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+		Addresses: []common.Address{ix.addresses.Controller, ix.addresses.GeneNFT, ix.addresses.PCSP},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to filter logs of blocks %d to %d: %w", from, to, err)
+	}
+	sort.Slice(logs, func(i, j int) bool {
+		if logs[i].BlockNumber != logs[j].BlockNumber {
+			return logs[i].BlockNumber < logs[j].BlockNumber
+		}
+		return logs[i].Index < logs[j].Index
+	})
+
+	events, err := ix.decodeLogs(ctx, logs)
+	if err != nil {
+		return err
+	}
+	if err := ix.store.applyRange(events, blockRef{Number: to, Hash: header.Hash()}); err != nil {
+		return fmt.Errorf("failed to index blocks %d to %d: %w", from, to, err)
+	}
+	return nil
+}
+
+// index indexes the events of the block.
+func (ix *Indexer) index(ctx context.Context, header *types.Header) error {
+	// Query the logs of exactly this block, so that they cannot be from another chain
+	hash := header.Hash()
+	logs, err := ix.client.FilterLogs(ctx, ethereum.FilterQuery{
+		BlockHash: &hash,
+		Addresses: []common.Address{ix.addresses.Controller, ix.addresses.GeneNFT, ix.addresses.PCSP},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to filter logs of block %d: %w", header.Number.Uint64(), err)
+	}
+	sort.Slice(logs, func(i, j int) bool { return logs[i].Index < logs[j].Index })
+
+	block := blockRecord{Number: header.Number.Uint64(), Hash: hash, ParentHash: header.ParentHash}
+	block.Events, err = ix.decodeLogs(ctx, logs)
+	if err != nil {
+		return err
+	}
+
+	if err := ix.store.apply(block, ix.config.ReorgDepth); err != nil {
+		return fmt.Errorf("failed to index block %d: %w", block.Number, err)
+	}
+	return nil
+}
+
+// decodeLogs decodes the logs, in order, into the events they apply to the index.
+func (ix *Indexer) decodeLogs(ctx context.Context, logs []types.Log) ([]eventRecord, error) {
+	var events []eventRecord
+	txs := make(map[common.Hash]*types.Transaction)
+	for _, log := range logs {
+		event, ok, err := ix.decode(ctx, log, txs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode log %d of block %d: %w", log.Index, log.BlockNumber, err)
+		}
+		if ok {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// decode decodes a log of the indexed contracts into the event it applies to the index, if any.
+// The transactions of the block are fetched once into txs.
+func (ix *Indexer) decode(ctx context.Context, log types.Log, txs map[common.Hash]*types.Transaction) (eventRecord, bool, error) {
+	record := eventRecord{TxHash: log.TxHash, LogIndex: log.Index, Block: log.BlockNumber}
+	if len(log.Topics) == 0 {
+		return record, false, nil
+	}
+
+	switch {
+	case log.Address == ix.addresses.Controller && log.Topics[0] == ix.controllerABI.Events["UploadData"].ID:
+		// The sender of the upload transaction owns the session if it called the Controller itself
+		event, err := ix.controller.ParseUploadData(log)
+		if err != nil {
+			return record, false, err
+		}
+		tx, err := ix.transaction(ctx, log.TxHash, txs)
+		if err != nil {
+			return record, false, err
+		}
+		sender, err := types.Sender(ix.signer, tx)
+		if err != nil {
+			return record, false, fmt.Errorf("failed to recover sender: %w", err)
+		}
+		record.Kind = kindUpload
+		record.Upload = &Upload{
+			DocID:       event.DocId,
+			SessionID:   event.SessionId,
+			Sender:      sender,
+			BlockNumber: log.BlockNumber,
+			TxHash:      log.TxHash,
+			LogIndex:    log.Index,
+		}
+		return record, true, nil
+
+	// ERC-721 and ERC-20 Transfer events share their topic; the token ID is indexed too
+	case log.Address == ix.addresses.GeneNFT && log.Topics[0] == ix.transferTopic && len(log.Topics) == 4:
+		event, err := ix.geneNFT.ParseTransfer(log)
+		if err != nil {
+			return record, false, err
+		}
+		record.Kind = kindNFT
+		record.From, record.To, record.Value = event.From, event.To, event.TokenId
+
+		// A token minted by Controller.confirm certifies the confirmed document
+		if event.From == (common.Address{}) {
+			record.DocID, err = ix.confirmedDoc(ctx, log.TxHash, txs)
+			if err != nil {
+				return record, false, err
+			}
+		}
+		return record, true, nil
+
+	case log.Address == ix.addresses.PCSP && log.Topics[0] == ix.transferTopic:
+		event, err := ix.pcsp.ParseTransfer(log)
+		if err != nil {
+			return record, false, err
+		}
+
+		// Only tokens minted by Controller.confirm are rewards
+		if event.From != (common.Address{}) {
+			return record, false, nil
+		}
+		docId, err := ix.confirmedDoc(ctx, log.TxHash, txs)
+		if err != nil || docId == "" {
+			return record, false, err
+		}
+		record.Kind = kindPCSPMint
+		record.To, record.Value, record.DocID = event.To, event.Value, docId
+		return record, true, nil
+	}
+	return record, false, nil
+}
+
+// confirmedDoc returns the document confirmed by the transaction, or an empty string if it is
+// not a call of Controller.confirm.
+func (ix *Indexer) confirmedDoc(ctx context.Context, txHash common.Hash, txs map[common.Hash]*types.Transaction) (string, error) {
+	tx, err := ix.transaction(ctx, txHash, txs)
+	if err != nil {
+		return "", err
+	}
+	if tx.To() == nil || *tx.To() != ix.addresses.Controller || len(tx.Data()) < 4 {
+		return "", nil
+	}
+	method, err := ix.controllerABI.MethodById(tx.Data()[:4])
+	if err != nil || method.Name != "confirm" {
+		return "", nil
+	}
+	args, err := method.Inputs.Unpack(tx.Data()[4:])
+	if err != nil {
+		return "", fmt.Errorf("failed to unpack confirm call: %w", err)
+	}
+	docId, _ := args[0].(string)
+	return docId, nil
+}
+
+// transaction returns the transaction with the hash, fetching it once into txs.
+func (ix *Indexer) transaction(ctx context.Context, hash common.Hash, txs map[common.Hash]*types.Transaction) (*types.Transaction, error) {
+	if tx, ok := txs[hash]; ok {
+		return tx, nil
+	}
+	tx, _, err := ix.client.TransactionByHash(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction %s: %w", hash.Hex(), err)
+	}
+	txs[hash] = tx
+	return tx, nil
+}
+
+// Head returns the number and hash of the last indexed block, or false if no block is indexed
+// yet.
+func (ix *Indexer) Head() (uint64, common.Hash, bool, error) {
+	head, ok, err := ix.store.head()
+	return head.Number, head.Hash, ok, err
+}
+
+// Upload returns the upload of the document, or ErrNotFound.
+func (ix *Indexer) Upload(docId string) (Upload, error) {
+	var upload Upload
+	err := ix.store.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(uploadsBucket).Get([]byte(docId))
+		if value == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(value, &upload)
+	})
+	return upload, err
+}
+
+// DocsByAddress returns the uploads of the documents the address submitted, in chain order.
+func (ix *Indexer) DocsByAddress(address common.Address) ([]Upload, error) {
+	var uploads []Upload
+	err := ix.store.db.View(func(tx *bolt.Tx) error {
+		prefix := address.Bytes()
+		cursor := tx.Bucket(addressDocBucket).Cursor()
+		for key, _ := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, _ = cursor.Next() {
+			var upload Upload
+			if err := json.Unmarshal(tx.Bucket(uploadsBucket).Get(key[len(prefix):]), &upload); err != nil {
+				return err
+			}
+			uploads = append(uploads, upload)
+		}
+		return nil
+	})
+	sort.Slice(uploads, func(i, j int) bool {
+		if uploads[i].BlockNumber != uploads[j].BlockNumber {
+			return uploads[i].BlockNumber < uploads[j].BlockNumber
+		}
+		return uploads[i].LogIndex < uploads[j].LogIndex
+	})
+	return uploads, err
+}
+
+// NFT returns the gene NFT with the token ID, with the document it certifies, or ErrNotFound.
+func (ix *Indexer) NFT(tokenId *big.Int) (NFT, error) {
+	var nft NFT
+	err := ix.store.db.View(func(tx *bolt.Tx) error {
+		var err error
+		nft, err = getNFT(tx.Bucket(nftsBucket), common.BigToHash(tokenId).Bytes())
+		return err
+	})
+	return nft, err
+}
+
+// PCSPRewarded returns the amount of PCSP rewarded to the address for confirmed documents.
+func (ix *Indexer) PCSPRewarded(address common.Address) (*big.Int, error) {
+	return ix.rewarded(address.Bytes())
+}
+
+// TotalPCSPRewarded returns the amount of PCSP rewarded for all confirmed documents.
+func (ix *Indexer) TotalPCSPRewarded() (*big.Int, error) {
+	return ix.rewarded(totalKey)
+}
+
+// rewarded reads a rewarded amount.
+func (ix *Indexer) rewarded(key []byte) (*big.Int, error) {
+	amount := new(big.Int)
+	err := ix.store.db.View(func(tx *bolt.Tx) error {
+		amount.SetBytes(tx.Bucket(rewardsBucket).Get(key))
+		return nil
+	})
+	return amount, err
+}
@@ -0,0 +1,456 @@
+package indexer_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/contracts"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/blockchain"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/indexer"
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/tee"
+)
+
+// autoMiningClient commits a block after every transaction so that waiting for it to be mined
+// returns right away.
+type autoMiningClient struct {
+	simulated.Client
+	backend *simulated.Backend
+}
+
+func (c autoMiningClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := c.Client.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	c.backend.Commit()
+	return nil
+}
+
+// queryRecordingClient records the log queries of the indexer and fails them with err if set.
+type queryRecordingClient struct {
+	autoMiningClient
+	queries []ethereum.FilterQuery
+	err     error
+}
+
+func (c *queryRecordingClient) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.queries = append(c.queries, query)
+	return c.autoMiningClient.FilterLogs(ctx, query)
+}
+
+// chain is the GeneNFT, PCSP and Controller contracts deployed on a simulated chain, with two
+// funded users.
+type chain struct {
+	backend   *simulated.Backend
+	client    autoMiningClient
+	owner     *bind.TransactOpts
+	users     [2]*bind.TransactOpts
+	addresses indexer.Addresses
+	geneNFT   *contracts.GeneNFT
+	pcsp      *contracts.PCSP
+	tee       *tee.TEEService
+}
+
+// newChain deploys the contracts on a new simulated chain like the real deployment.
+func newChain(t *testing.T) *chain {
+	c := &chain{tee: tee.NewTEEService()}
+
+	// Fund the owner and the users in the genesis block
+	alloc := types.GenesisAlloc{}
+	c.owner = newTransactor(t, alloc)
+	for i := range c.users {
+		c.users[i] = newTransactor(t, alloc)
+	}
+	c.backend = simulated.NewBackend(alloc)
+	t.Cleanup(func() { c.backend.Close() })
+	c.client = autoMiningClient{Client: c.backend.Client(), backend: c.backend}
+
+	// Deploy the tokens and hand them over to the Controller
+	var err error
+	c.addresses.GeneNFT, _, c.geneNFT, err = contracts.DeployGeneNFT(c.owner, c.client)
+	require.NoError(t, err)
+	c.addresses.PCSP, _, c.pcsp, err = contracts.DeployPCSP(c.owner, c.client)
+	require.NoError(t, err)
+	var controller *contracts.Controller
+	c.addresses.Controller, _, controller, err = contracts.DeployController(c.owner, c.client, c.addresses.GeneNFT, c.addresses.PCSP)
+	require.NoError(t, err)
+	_, err = c.geneNFT.TransferOwnership(c.owner, c.addresses.Controller)
+	require.NoError(t, err)
+	_, err = c.pcsp.TransferOwnership(c.owner, c.addresses.Controller)
+	require.NoError(t, err)
+	_, err = controller.AddTEESigner(c.owner, c.tee.AttestationKey())
+	require.NoError(t, err)
+	return c
+}
+
+// newTransactor returns transaction options for a new account funded in the genesis allocation.
+func newTransactor(t *testing.T, alloc types.GenesisAlloc) *bind.TransactOpts {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	opts, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	require.NoError(t, err)
+	alloc[opts.From] = types.Account{Balance: new(big.Int).Lsh(big.NewInt(1), 100)}
+	return opts
+}
+
+// upload uploads the document from the account and returns its session.
+func (c *chain) upload(t *testing.T, opts *bind.TransactOpts, docId string) *big.Int {
+	controllerService, err := blockchain.NewControllerService(c.client, opts, c.addresses.Controller)
+	require.NoError(t, err)
+	upload, err := controllerService.UploadData(docId)
+	require.NoError(t, err)
+	return upload.SessionID
+}
+
+// confirm confirms the document with a quote of the TEE for the gene data, and returns the PCSP
+// rewarded for it.
+func (c *chain) confirm(t *testing.T, opts *bind.TransactOpts, docId string, sessionId *big.Int, geneData string) *big.Int {
+	controllerService, err := blockchain.NewControllerService(c.client, opts, c.addresses.Controller)
+	require.NoError(t, err)
+	before, err := c.pcsp.BalanceOf(&bind.CallOpts{}, opts.From)
+	require.NoError(t, err)
+
+	result, err := c.tee.ScoreGeneData(context.Background(), "", "", strings.NewReader(geneData))
+	require.NoError(t, err)
+	nonce, err := controllerService.ConfirmNonce(docId, "cid-"+docId, sessionId)
+	require.NoError(t, err)
+	quote, err := c.tee.Attest(result, nonce)
+	require.NoError(t, err)
+	require.NoError(t, controllerService.Confirm(docId, "cid-"+docId, quote, sessionId, result.RiskLevel.Score()))
+
+	after, err := c.pcsp.BalanceOf(&bind.CallOpts{}, opts.From)
+	require.NoError(t, err)
+	return after.Sub(after, before)
+}
+
+// head returns the latest block of the chain.
+func (c *chain) head(t *testing.T) *types.Header {
+	header, err := c.client.HeaderByNumber(context.Background(), nil)
+	require.NoError(t, err)
+	return header
+}
+
+// reorg replaces the blocks after the ancestor with a longer chain of empty blocks.
+func (c *chain) reorg(t *testing.T, ancestor *types.Header) {
+	replaced := c.head(t).Number.Uint64() - ancestor.Number.Uint64()
+	require.NoError(t, c.backend.Fork(ancestor.Hash()))
+	for range replaced + 1 {
+		c.backend.Commit()
+	}
+	require.NotEqual(t, ancestor.Hash(), c.head(t).ParentHash)
+}
+
+// newIndexer opens an Indexer of the chain's contracts with its database in dir.
+func newIndexer(t *testing.T, c *chain, dir string, config indexer.Config) *indexer.Indexer {
+	ix, err := indexer.NewIndexer(c.client, filepath.Join(dir, "index.db"), c.addresses, config)
+	require.NoError(t, err)
+	return ix
+}
+
+// docIDs returns the document IDs of the uploads.
+func docIDs(uploads []indexer.Upload) []string {
+	ids := []string{}
+	for _, upload := range uploads {
+		ids = append(ids, upload.DocID)
+	}
+	return ids
+}
+
+func TestIndexer(t *testing.T) {
+	c := newChain(t)
+	dir := t.TempDir()
+	ix := newIndexer(t, c, dir, indexer.Config{})
+	ctx := context.Background()
+
+	// Uploads are indexed by their sender
+	session1 := c.upload(t, c.users[0], "doc1")
+	c.upload(t, c.users[0], "doc2")
+	session3 := c.upload(t, c.users[1], "doc3")
+	require.NoError(t, ix.Sync(ctx))
+	uploads, err := ix.DocsByAddress(c.users[0].From)
+	require.NoError(t, err)
+	require.Equal(t, []string{"doc1", "doc2"}, docIDs(uploads))
+	require.Equal(t, c.users[0].From, uploads[0].Sender)
+	require.Equal(t, session1.Int64(), uploads[0].SessionID.Int64())
+	uploads, err = ix.DocsByAddress(c.users[1].From)
+	require.NoError(t, err)
+	require.Equal(t, []string{"doc3"}, docIDs(uploads))
+	upload, err := ix.Upload("doc3")
+	require.NoError(t, err)
+	require.Equal(t, session3.Int64(), upload.SessionID.Int64())
+	_, err = ix.Upload("doc4")
+	require.ErrorIs(t, err, indexer.ErrNotFound)
+
+	// Confirming mints the NFT of the document and rewards PCSP
+	reward1 := c.confirm(t, c.users[0], "doc1", session1, "high risk")
+	reward3 := c.confirm(t, c.users[1], "doc3", session3, "low risk")
+	require.NoError(t, ix.Sync(ctx))
+	nft, err := ix.NFT(big.NewInt(0))
+	require.NoError(t, err)
+	require.Equal(t, "doc1", nft.DocID)
+	require.Equal(t, c.users[0].From, nft.Owner)
+	nft, err = ix.NFT(big.NewInt(1))
+	require.NoError(t, err)
+	require.Equal(t, "doc3", nft.DocID)
+	_, err = ix.NFT(big.NewInt(2))
+	require.ErrorIs(t, err, indexer.ErrNotFound)
+	rewarded, err := ix.PCSPRewarded(c.users[0].From)
+	require.NoError(t, err)
+	require.Equal(t, reward1, rewarded)
+	total, err := ix.TotalPCSPRewarded()
+	require.NoError(t, err)
+	require.Equal(t, new(big.Int).Add(reward1, reward3), total)
+
+	// The supply minted to the deployer is not a reward
+	rewarded, err = ix.PCSPRewarded(c.owner.From)
+	require.NoError(t, err)
+	require.Zero(t, rewarded.Sign())
+
+	// Transfers change the owner of the NFT
+	_, err = c.geneNFT.TransferFrom(c.users[0], c.users[0].From, c.users[1].From, big.NewInt(0))
+	require.NoError(t, err)
+	require.NoError(t, ix.Sync(ctx))
+	nft, err = ix.NFT(big.NewInt(0))
+	require.NoError(t, err)
+	require.Equal(t, "doc1", nft.DocID)
+	require.Equal(t, c.users[1].From, nft.Owner)
+
+	// The index survives restarts
+	number, hash, ok, err := ix.Head()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, c.head(t).Number.Uint64(), number)
+	require.Equal(t, c.head(t).Hash(), hash)
+	require.NoError(t, ix.Close())
+	ix = newIndexer(t, c, dir, indexer.Config{})
+	defer ix.Close()
+	uploads, err = ix.DocsByAddress(c.users[0].From)
+	require.NoError(t, err)
+	require.Equal(t, []string{"doc1", "doc2"}, docIDs(uploads))
+	nft, err = ix.NFT(big.NewInt(0))
+	require.NoError(t, err)
+	require.Equal(t, c.users[1].From, nft.Owner)
+}
+
+func TestIndexerRollsBackReorgs(t *testing.T) {
+	c := newChain(t)
+	ix := newIndexer(t, c, t.TempDir(), indexer.Config{})
+	defer ix.Close()
+	ctx := context.Background()
+
+	session1 := c.upload(t, c.users[0], "doc1")
+	beforeConfirm := c.head(t)
+	reward := c.confirm(t, c.users[0], "doc1", session1, "high risk")
+	beforeTransfer := c.head(t)
+	_, err := c.geneNFT.TransferFrom(c.users[0], c.users[0].From, c.users[1].From, big.NewInt(0))
+	require.NoError(t, err)
+	c.upload(t, c.users[1], "doc2")
+	require.NoError(t, ix.Sync(ctx))
+	nft, err := ix.NFT(big.NewInt(0))
+	require.NoError(t, err)
+	require.Equal(t, c.users[1].From, nft.Owner)
+
+	// A reorg of the transfer and the second upload gives the NFT back and drops the upload
+	c.reorg(t, beforeTransfer)
+	require.NoError(t, ix.Sync(ctx))
+	nft, err = ix.NFT(big.NewInt(0))
+	require.NoError(t, err)
+	require.Equal(t, c.users[0].From, nft.Owner)
+	_, err = ix.Upload("doc2")
+	require.ErrorIs(t, err, indexer.ErrNotFound)
+	uploads, err := ix.DocsByAddress(c.users[1].From)
+	require.NoError(t, err)
+	require.Empty(t, uploads)
+	number, hash, _, err := ix.Head()
+	require.NoError(t, err)
+	require.Equal(t, c.head(t).Number.Uint64(), number)
+	require.Equal(t, c.head(t).Hash(), hash)
+	rewarded, err := ix.PCSPRewarded(c.users[0].From)
+	require.NoError(t, err)
+	require.Equal(t, reward, rewarded)
+
+	// A reorg of the confirmation unmints the NFT and takes the reward back
+	c.reorg(t, beforeConfirm)
+	require.NoError(t, ix.Sync(ctx))
+	_, err = ix.NFT(big.NewInt(0))
+	require.ErrorIs(t, err, indexer.ErrNotFound)
+	rewarded, err = ix.PCSPRewarded(c.users[0].From)
+	require.NoError(t, err)
+	require.Zero(t, rewarded.Sign())
+	total, err := ix.TotalPCSPRewarded()
+	require.NoError(t, err)
+	require.Zero(t, total.Sign())
+	uploads, err = ix.DocsByAddress(c.users[0].From)
+	require.NoError(t, err)
+	require.Equal(t, []string{"doc1"}, docIDs(uploads))
+
+	// The document can be confirmed again on the new chain
+	reward = c.confirm(t, c.users[0], "doc1", session1, "low risk")
+	require.NoError(t, ix.Sync(ctx))
+	nft, err = ix.NFT(big.NewInt(0))
+	require.NoError(t, err)
+	require.Equal(t, "doc1", nft.DocID)
+	require.Equal(t, c.users[0].From, nft.Owner)
+	rewarded, err = ix.PCSPRewarded(c.users[0].From)
+	require.NoError(t, err)
+	require.Equal(t, reward, rewarded)
+}
+
+func TestIndexerReorgTooDeep(t *testing.T) {
+	c := newChain(t)
+	ix := newIndexer(t, c, t.TempDir(), indexer.Config{ReorgDepth: 2})
+	defer ix.Close()
+
+	// Blocks deeper than the reorg depth can no longer be rolled back
+	ancestor := c.head(t)
+	c.upload(t, c.users[0], "doc1")
+	for range 3 {
+		c.backend.Commit()
+	}
+	require.NoError(t, ix.Sync(context.Background()))
+	c.reorg(t, ancestor)
+	require.ErrorIs(t, ix.Sync(context.Background()), indexer.ErrReorgTooDeep)
+
+	// The indexed upload is kept
+	upload, err := ix.Upload("doc1")
+	require.NoError(t, err)
+	require.Equal(t, c.users[0].From, upload.Sender)
+	require.NotEqual(t, common.Hash{}, upload.TxHash)
+}
+
+func TestIndexerBackfillsInRanges(t *testing.T) {
+	c := newChain(t)
+	session1 := c.upload(t, c.users[0], "doc1")
+	reward := c.confirm(t, c.users[0], "doc1", session1, "high risk")
+	c.upload(t, c.users[1], "doc2")
+	for range 10 {
+		c.backend.Commit()
+	}
+	client := &queryRecordingClient{autoMiningClient: c.client}
+	var rolledBack []uint64
+	ix, err := indexer.NewIndexer(client, filepath.Join(t.TempDir(), "index.db"), c.addresses, indexer.Config{
+		ReorgDepth: 3,
+		BatchSize:  4,
+		OnRollback: func(number uint64, hash common.Hash) { rolledBack = append(rolledBack, number) },
+	})
+	require.NoError(t, err)
+	defer ix.Close()
+	ctx := context.Background()
+
+	// Blocks below the reorg window are queried in ranges, the others one by one
+	latest := c.head(t).Number.Uint64()
+	require.NoError(t, ix.Sync(ctx))
+	perBlock := 0
+	next := uint64(0)
+	for _, query := range client.queries {
+		if query.BlockHash != nil {
+			perBlock++
+			continue
+		}
+		require.Equal(t, next, query.FromBlock.Uint64())
+		require.LessOrEqual(t, query.ToBlock.Uint64()-query.FromBlock.Uint64(), uint64(3))
+		require.LessOrEqual(t, query.ToBlock.Uint64(), latest-3)
+		next = query.ToBlock.Uint64() + 1
+	}
+	require.Equal(t, latest-3+1, next)
+	require.Equal(t, 3, perBlock)
+
+	// The events of the ranges are indexed
+	upload, err := ix.Upload("doc1")
+	require.NoError(t, err)
+	require.Equal(t, c.users[0].From, upload.Sender)
+	uploads, err := ix.DocsByAddress(c.users[1].From)
+	require.NoError(t, err)
+	require.Equal(t, []string{"doc2"}, docIDs(uploads))
+	nft, err := ix.NFT(big.NewInt(0))
+	require.NoError(t, err)
+	require.Equal(t, "doc1", nft.DocID)
+	rewarded, err := ix.PCSPRewarded(c.users[0].From)
+	require.NoError(t, err)
+	require.Equal(t, reward, rewarded)
+	number, hash, _, err := ix.Head()
+	require.NoError(t, err)
+	require.Equal(t, latest, number)
+	require.Equal(t, c.head(t).Hash(), hash)
+
+	// Blocks within the reorg window are still rolled back and reported
+	ancestor, err := c.client.HeaderByNumber(ctx, new(big.Int).SetUint64(latest-2))
+	require.NoError(t, err)
+	c.reorg(t, ancestor)
+	require.NoError(t, ix.Sync(ctx))
+	require.Equal(t, []uint64{latest, latest - 1}, rolledBack)
+	number, hash, _, err = ix.Head()
+	require.NoError(t, err)
+	require.Equal(t, c.head(t).Number.Uint64(), number)
+	require.Equal(t, c.head(t).Hash(), hash)
+}
+
+func TestIndexerRunReportsErrors(t *testing.T) {
+	c := newChain(t)
+	errNodeUnavailable := errors.New("node unavailable")
+	client := &queryRecordingClient{autoMiningClient: c.client, err: errNodeUnavailable}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var reported error
+	ix, err := indexer.NewIndexer(client, filepath.Join(t.TempDir(), "index.db"), c.addresses, indexer.Config{
+		OnError: func(err error) {
+			reported = err
+			cancel()
+		},
+	})
+	require.NoError(t, err)
+	defer ix.Close()
+
+	// Run reports the failed sync and keeps going until it is stopped
+	require.ErrorIs(t, ix.Run(ctx), context.Canceled)
+	require.ErrorIs(t, reported, errNodeUnavailable)
+}
+
+func TestIndexerTransferMintedBeforeStartBlock(t *testing.T) {
+	c := newChain(t)
+	session1 := c.upload(t, c.users[0], "doc1")
+	c.confirm(t, c.users[0], "doc1", session1, "high risk")
+	beforeTransfer := c.head(t)
+	ix := newIndexer(t, c, t.TempDir(), indexer.Config{StartBlock: beforeTransfer.Number.Uint64() + 1})
+	defer ix.Close()
+	ctx := context.Background()
+
+	// The first transfer of a token minted before the start block indexes it without mint data
+	_, err := c.geneNFT.TransferFrom(c.users[0], c.users[0].From, c.users[1].From, big.NewInt(0))
+	require.NoError(t, err)
+	require.NoError(t, ix.Sync(ctx))
+	nft, err := ix.NFT(big.NewInt(0))
+	require.NoError(t, err)
+	require.Equal(t, c.users[1].From, nft.Owner)
+	require.Empty(t, nft.DocID)
+	require.Zero(t, nft.MintBlock)
+	require.Equal(t, common.Hash{}, nft.MintTxHash)
+
+	// Later transfers change its owner
+	_, err = c.geneNFT.TransferFrom(c.users[1], c.users[1].From, c.users[0].From, big.NewInt(0))
+	require.NoError(t, err)
+	require.NoError(t, ix.Sync(ctx))
+	nft, err = ix.NFT(big.NewInt(0))
+	require.NoError(t, err)
+	require.Equal(t, c.users[0].From, nft.Owner)
+
+	// Rolling the transfers back drops the token again
+	c.reorg(t, beforeTransfer)
+	require.NoError(t, ix.Sync(ctx))
+	_, err = ix.NFT(big.NewInt(0))
+	require.ErrorIs(t, err, indexer.ErrNotFound)
+}
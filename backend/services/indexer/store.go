@@ -0,0 +1,296 @@
+package indexer
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Buckets of the index database.
+var (
+	metaBucket       = []byte("meta")          // headKey: the last indexed block.
+	blocksBucket     = []byte("blocks")        // Block number: the block's events, to roll it back.
+	uploadsBucket    = []byte("uploads")       // Doc ID: Upload.
+	addressDocBucket = []byte("address_docs")  // Sender address and doc ID: nothing.
+	nftsBucket       = []byte("nfts")          // Token ID: NFT.
+	rewardsBucket    = []byte("pcsp_rewarded") // Address, or totalKey: rewarded amount.
+
+	headKey  = []byte("head")
+	totalKey = []byte("total")
+)
+
+// Event kinds recorded in a block.
+const (
+	kindUpload   = "upload"
+	kindNFT      = "nft_transfer"
+	kindPCSPMint = "pcsp_reward"
+)
+
+// blockRef identifies an indexed block.
+type blockRef struct {
+	Number uint64
+	Hash   common.Hash
+}
+
+// blockRecord is an indexed block with the events applied from it, in log order.
+type blockRecord struct {
+	Number     uint64
+	Hash       common.Hash
+	ParentHash common.Hash
+	Events     []eventRecord
+}
+
+// eventRecord is an event applied to the index. Each kind carries what is needed to revert it.
+type eventRecord struct {
+	Kind     string
+	Upload   *Upload        `json:",omitempty"` // kindUpload
+	From     common.Address `json:",omitempty"` // kindNFT
+	To       common.Address `json:",omitempty"` // kindNFT, kindPCSPMint
+	Value    *big.Int       `json:",omitempty"` // Token ID of kindNFT, amount of kindPCSPMint.
+	DocID    string         `json:",omitempty"` // Document of a minted NFT.
+	Created  bool           `json:",omitempty"` // Whether a kindNFT transfer created the NFT, minted before StartBlock.
+	TxHash   common.Hash    `json:",omitempty"`
+	LogIndex uint           `json:",omitempty"`
+	Block    uint64         `json:",omitempty"`
+}
+
+// store is the bbolt database of the index.
+type store struct {
+	db *bolt.DB
+}
+
+// openStore opens (or creates) the index database at path.
+func openStore(path string) (*store, error) {
+	// Fail instead of blocking forever if another process holds the file lock
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{metaBucket, blocksBucket, uploadsBucket, addressDocBucket, nftsBucket, rewardsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &store{db: db}, nil
+}
+
+// head returns the last indexed block, or false if no block is indexed yet.
+func (s *store) head() (blockRef, bool, error) {
+	var head blockRef
+	var ok bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(metaBucket).Get(headKey)
+		if value == nil {
+			return nil
+		}
+		ok = true
+		return json.Unmarshal(value, &head)
+	})
+	return head, ok, err
+}
+
+// apply indexes the block's events and makes it the head. The records of blocks deeper than
+// reorgDepth below it are dropped, so those blocks can no longer be rolled back.
+func (s *store) apply(block blockRecord, reorgDepth uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for i := range block.Events {
+			if err := applyEvent(tx, &block.Events[i]); err != nil {
+				return err
+			}
+		}
+
+		// Record the block to be able to roll it back
+		if err := putJSON(tx.Bucket(blocksBucket), blockKey(block.Number), block); err != nil {
+			return err
+		}
+		if block.Number > reorgDepth {
+			if err := tx.Bucket(blocksBucket).Delete(blockKey(block.Number - reorgDepth - 1)); err != nil {
+				return err
+			}
+		}
+		return putJSON(tx.Bucket(metaBucket), headKey, blockRef{Number: block.Number, Hash: block.Hash})
+	})
+}
+
+// applyRange indexes the events of a range of blocks below the reorg window and makes the last
+// block of the range the head. No block is recorded, and the records of the blocks before are
+// dropped, since blocks below a final head can no longer be rolled back.
+func (s *store) applyRange(events []eventRecord, head blockRef) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for i := range events {
+			if err := applyEvent(tx, &events[i]); err != nil {
+				return err
+			}
+		}
+
+		// Bucket keys cannot be deleted while iterating with a cursor
+		var keys [][]byte
+		cursor := tx.Bucket(blocksBucket).Cursor()
+		for key, _ := cursor.First(); key != nil; key, _ = cursor.Next() {
+			keys = append(keys, key)
+		}
+		for _, key := range keys {
+			if err := tx.Bucket(blocksBucket).Delete(key); err != nil {
+				return err
+			}
+		}
+		return putJSON(tx.Bucket(metaBucket), headKey, head)
+	})
+}
+
+// rollback reverts the events of the head block and makes its parent the head. It returns
+// ErrReorgTooDeep if the block is no longer recorded.
+func (s *store) rollback(head blockRef) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		var block blockRecord
+		value := tx.Bucket(blocksBucket).Get(blockKey(head.Number))
+		if value == nil {
+			return ErrReorgTooDeep
+		}
+		if err := json.Unmarshal(value, &block); err != nil {
+			return err
+		}
+
+		// Revert the events in reverse order
+		for i := len(block.Events) - 1; i >= 0; i-- {
+			if err := revertEvent(tx, block.Events[i]); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Bucket(blocksBucket).Delete(blockKey(block.Number)); err != nil {
+			return err
+		}
+		if block.Number == 0 {
+			return tx.Bucket(metaBucket).Delete(headKey)
+		}
+		return putJSON(tx.Bucket(metaBucket), headKey, blockRef{Number: block.Number - 1, Hash: block.ParentHash})
+	})
+}
+
+// applyEvent applies an event to the index, recording in the event what it needs to be reverted.
+func applyEvent(tx *bolt.Tx, event *eventRecord) error {
+	switch event.Kind {
+	case kindUpload:
+		upload := event.Upload
+		if err := putJSON(tx.Bucket(uploadsBucket), []byte(upload.DocID), upload); err != nil {
+			return err
+		}
+		return tx.Bucket(addressDocBucket).Put(addressDocKey(upload.Sender, upload.DocID), []byte{})
+	case kindNFT:
+		// A minted token is new; a transferred or burnt one changes its owner. A token minted
+		// before the first indexed block is new the first time it is transferred, without mint data.
+		nfts := tx.Bucket(nftsBucket)
+		key := common.BigToHash(event.Value).Bytes()
+		nft := NFT{TokenID: event.Value, DocID: event.DocID, MintBlock: event.Block, MintTxHash: event.TxHash}
+		if event.From != (common.Address{}) {
+			var err error
+			nft, err = getNFT(nfts, key)
+			if errors.Is(err, ErrNotFound) {
+				nft, event.Created = NFT{TokenID: event.Value}, true
+			} else if err != nil {
+				return err
+			}
+		}
+		nft.Owner = event.To
+		return putJSON(nfts, key, nft)
+	case kindPCSPMint:
+		return addReward(tx.Bucket(rewardsBucket), event.To, event.Value)
+	}
+	return errors.New("unknown event kind " + event.Kind)
+}
+
+// revertEvent reverts an event applied to the index.
+func revertEvent(tx *bolt.Tx, event eventRecord) error {
+	switch event.Kind {
+	case kindUpload:
+		upload := event.Upload
+		if err := tx.Bucket(uploadsBucket).Delete([]byte(upload.DocID)); err != nil {
+			return err
+		}
+		return tx.Bucket(addressDocBucket).Delete(addressDocKey(upload.Sender, upload.DocID))
+	case kindNFT:
+		// Unmint a minted token, drop one first seen in the transfer and give others back
+		nfts := tx.Bucket(nftsBucket)
+		key := common.BigToHash(event.Value).Bytes()
+		if event.From == (common.Address{}) || event.Created {
+			return nfts.Delete(key)
+		}
+		nft, err := getNFT(nfts, key)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		nft.Owner = event.From
+		return putJSON(nfts, key, nft)
+	case kindPCSPMint:
+		return addReward(tx.Bucket(rewardsBucket), event.To, new(big.Int).Neg(event.Value))
+	}
+	return errors.New("unknown event kind " + event.Kind)
+}
+
+// addReward adds the amount to the address's rewarded amount and to the total.
+func addReward(rewards *bolt.Bucket, to common.Address, amount *big.Int) error {
+	for _, key := range [][]byte{to.Bytes(), totalKey} {
+		total := new(big.Int).SetBytes(rewards.Get(key))
+		total.Add(total, amount)
+		if total.Sign() == 0 {
+			if err := rewards.Delete(key); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := rewards.Put(key, total.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getNFT reads and decodes an NFT from the bucket.
+func getNFT(nfts *bolt.Bucket, key []byte) (NFT, error) {
+	value := nfts.Get(key)
+	if value == nil {
+		return NFT{}, ErrNotFound
+	}
+	var nft NFT
+	if err := json.Unmarshal(value, &nft); err != nil {
+		return NFT{}, err
+	}
+	return nft, nil
+}
+
+// putJSON encodes and writes a value to the bucket.
+func putJSON(bucket *bolt.Bucket, key []byte, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(key, data)
+}
+
+// blockKey is the key of a block number, ordered like the numbers.
+func blockKey(number uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, number)
+}
+
+// addressDocKey is the key of a document submitted by the address, prefixed by the address.
+func addressDocKey(address common.Address, docId string) []byte {
+	return append(address.Bytes(), docId...)
+}
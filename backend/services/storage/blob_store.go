@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// blobIDSize is the size of a keccak256 digest, which is the content address of a blob.
+const blobIDSize = 32
+
+// Errors returned by BlobStore implementations.
+var (
+	ErrBlobNotFound  = errors.New("blob not found")
+	ErrBlobCorrupted = errors.New("blob failed integrity check")
+	ErrInvalidBlobID = errors.New("invalid blob ID")
+)
+
+// BlobStore is a content-addressed store for encrypted gene data. A blob is identified by the
+// hex-encoded keccak256 hash of its content, which is the same hash the owner signs. Storing the
+// same content twice yields the same ID and stores it once, and every read verifies the content
+// against its ID.
+type BlobStore interface {
+	// Put stores the blob and returns its ID.
+	Put(data []byte) (string, error)
+	// Get returns the blob with the given ID. It returns ErrBlobNotFound if there is no such blob
+	// and ErrBlobCorrupted if the stored content does not match the ID.
+	Get(id string) ([]byte, error)
+}
+
+// BlobID returns the content address of data.
+func BlobID(data []byte) string {
+	return hex.EncodeToString(crypto.Keccak256(data))
+}
+
+// validateBlobID checks that id is a lowercase hex-encoded keccak256 hash, which also keeps
+// it safe to use as a file name.
+func validateBlobID(id string) error {
+	decoded, err := hex.DecodeString(id)
+	if err != nil || len(decoded) != blobIDSize || hex.EncodeToString(decoded) != id {
+		return ErrInvalidBlobID
+	}
+	return nil
+}
+
+// verifyBlob checks that data matches the blob ID.
+func verifyBlob(id string, data []byte) error {
+	if BlobID(data) != id {
+		return ErrBlobCorrupted
+	}
+	return nil
+}
+
+// MemoryBlobStore is a BlobStore that keeps blobs in memory.
+type MemoryBlobStore struct {
+	blobs map[string][]byte
+	mu    sync.Mutex
+}
+
+// NewMemoryBlobStore creates a new, empty MemoryBlobStore.
+func NewMemoryBlobStore() *MemoryBlobStore {
+	return &MemoryBlobStore{
+		blobs: make(map[string][]byte),
+	}
+}
+
+// Put implements BlobStore.
+func (s *MemoryBlobStore) Put(data []byte) (string, error) {
+	id := BlobID(data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.blobs[id]; !exists {
+		s.blobs[id] = bytes.Clone(data)
+	}
+	return id, nil
+}
+
+// Get implements BlobStore.
+func (s *MemoryBlobStore) Get(id string) ([]byte, error) {
+	if err := validateBlobID(id); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	data, exists := s.blobs[id]
+	s.mu.Unlock()
+	if !exists {
+		return nil, ErrBlobNotFound
+	}
+
+	if err := verifyBlob(id, data); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(data), nil
+}
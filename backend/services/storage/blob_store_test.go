@@ -0,0 +1,124 @@
+package storage_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	service "github.com/trungnt1811/blockchain-engineer-interview/backend/services/storage"
+)
+
+// chunkSize mirrors the chunk size of DiskBlobStore.
+const chunkSize = 1 << 20
+
+// forEachBlobStore runs the test against every BlobStore implementation.
+func forEachBlobStore(t *testing.T, test func(t *testing.T, blobs service.BlobStore)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, service.NewMemoryBlobStore())
+	})
+	t.Run("disk", func(t *testing.T) {
+		blobs, err := service.NewDiskBlobStore(t.TempDir())
+		require.NoError(t, err)
+		test(t, blobs)
+	})
+	t.Run("s3", func(t *testing.T) {
+		_, blobs := newFakeS3(t)
+		test(t, blobs)
+	})
+}
+
+func TestBlobStore_PutGet(t *testing.T) {
+	forEachBlobStore(t, func(t *testing.T, blobs service.BlobStore) {
+		large := make([]byte, 2*chunkSize+123)
+		_, err := rand.Read(large)
+		require.NoError(t, err)
+
+		for _, data := range [][]byte{{}, []byte("encrypted_gene_data"), large} {
+			id, err := blobs.Put(data)
+			require.NoError(t, err)
+			require.Equal(t, crypto.Keccak256Hash(data).Hex()[2:], id)
+
+			// Storing the same content again yields the same ID
+			again, err := blobs.Put(data)
+			require.NoError(t, err)
+			require.Equal(t, id, again)
+
+			stored, err := blobs.Get(id)
+			require.NoError(t, err)
+			require.True(t, bytes.Equal(data, stored))
+		}
+
+		_, err = blobs.Get(service.BlobID([]byte("missing")))
+		require.ErrorIs(t, err, service.ErrBlobNotFound)
+
+		for _, id := range []string{"", "../../etc/passwd", "ABCD", crypto.Keccak256Hash(nil).Hex()} {
+			_, err = blobs.Get(id)
+			require.ErrorIs(t, err, service.ErrInvalidBlobID, id)
+		}
+	})
+}
+
+func TestDiskBlobStore_Dedup(t *testing.T) {
+	root := t.TempDir()
+	blobs, err := service.NewDiskBlobStore(root)
+	require.NoError(t, err)
+
+	// Two blobs sharing their first chunk
+	shared := bytes.Repeat([]byte{0xab}, chunkSize)
+	first := append(bytes.Clone(shared), []byte("first")...)
+	second := append(bytes.Clone(shared), []byte("second")...)
+
+	_, err = blobs.Put(first)
+	require.NoError(t, err)
+	_, err = blobs.Put(second)
+	require.NoError(t, err)
+	_, err = blobs.Put(first)
+	require.NoError(t, err)
+
+	chunks, err := filepath.Glob(filepath.Join(root, "chunks", "*", "*"))
+	require.NoError(t, err)
+	require.Len(t, chunks, 3)
+
+	manifests, err := filepath.Glob(filepath.Join(root, "blobs", "*", "*"))
+	require.NoError(t, err)
+	require.Len(t, manifests, 2)
+}
+
+func TestDiskBlobStore_Corruption(t *testing.T) {
+	root := t.TempDir()
+	blobs, err := service.NewDiskBlobStore(root)
+	require.NoError(t, err)
+
+	data := make([]byte, chunkSize+10)
+	_, err = rand.Read(data)
+	require.NoError(t, err)
+	id, err := blobs.Put(data)
+	require.NoError(t, err)
+
+	chunks, err := filepath.Glob(filepath.Join(root, "chunks", "*", "*"))
+	require.NoError(t, err)
+	require.Len(t, chunks, 2)
+
+	// Flip a bit in a chunk on disk
+	chunk, err := os.ReadFile(chunks[0])
+	require.NoError(t, err)
+	chunk[0] ^= 0x01
+	require.NoError(t, os.WriteFile(chunks[0], chunk, 0o600))
+	_, err = blobs.Get(id)
+	require.ErrorIs(t, err, service.ErrBlobCorrupted)
+
+	// Restore it, then lose the other chunk
+	chunk[0] ^= 0x01
+	require.NoError(t, os.WriteFile(chunks[0], chunk, 0o600))
+	_, err = blobs.Get(id)
+	require.NoError(t, err)
+
+	require.NoError(t, os.Remove(chunks[1]))
+	_, err = blobs.Get(id)
+	require.ErrorIs(t, err, service.ErrBlobCorrupted)
+}
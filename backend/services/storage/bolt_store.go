@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// geneDataBucket is the bbolt bucket holding JSON-encoded GeneData records keyed by file ID.
+	geneDataBucket = []byte("gene_data")
+	// auditLogBucket holds a nested bucket per file ID with its JSON-encoded AuditEvents keyed by
+	// big-endian sequence number, so events are appended without rewriting the record.
+	auditLogBucket = []byte("audit_log")
+)
+
+// BoltStore is a GeneDataStore backed by an embedded bbolt database file, so stored gene data
+// survives restarts.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens (or creates) the bbolt database at path.
+func NewBoltStore(path string) (*BoltStore, error) {
+	// Fail instead of blocking forever if another process holds the file lock
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{geneDataBucket, auditLogBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// Insert implements GeneDataStore.
+func (s *BoltStore) Insert(data GeneData) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(geneDataBucket)
+		if bucket.Get([]byte(data.FileID)) != nil {
+			return ErrGeneDataExists
+		}
+		return putGeneData(bucket, data)
+	})
+}
+
+// Get implements GeneDataStore.
+func (s *BoltStore) Get(fileID string) (GeneData, error) {
+	var data GeneData
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		data, err = getGeneData(tx.Bucket(geneDataBucket), fileID)
+		return err
+	})
+	return data, err
+}
+
+// Update implements GeneDataStore.
+func (s *BoltStore) Update(fileID string, fn func(data *GeneData)) error {
+	return s.UpdateAudited(fileID, func(data *GeneData) []AuditEvent {
+		fn(data)
+		return nil
+	})
+}
+
+// UpdateAudited implements GeneDataStore.
+func (s *BoltStore) UpdateAudited(fileID string, fn func(data *GeneData) []AuditEvent) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(geneDataBucket)
+		data, err := getGeneData(bucket, fileID)
+		if err != nil {
+			return err
+		}
+		events := fn(&data)
+		if err := putGeneData(bucket, data); err != nil {
+			return err
+		}
+		return appendAuditEvents(tx, fileID, events)
+	})
+}
+
+// AppendAuditEvents implements GeneDataStore.
+func (s *BoltStore) AppendAuditEvents(fileID string, events ...AuditEvent) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(geneDataBucket).Get([]byte(fileID)) == nil {
+			return ErrGeneDataNotFound
+		}
+		return appendAuditEvents(tx, fileID, events)
+	})
+}
+
+// AuditEvents implements GeneDataStore.
+func (s *BoltStore) AuditEvents(fileID string) ([]AuditEvent, error) {
+	events := []AuditEvent{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(geneDataBucket).Get([]byte(fileID)) == nil {
+			return ErrGeneDataNotFound
+		}
+		log := tx.Bucket(auditLogBucket).Bucket([]byte(fileID))
+		if log == nil {
+			return nil
+		}
+		return log.ForEach(func(_, value []byte) error {
+			var event AuditEvent
+			if err := json.Unmarshal(value, &event); err != nil {
+				return err
+			}
+			events = append(events, event)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Close implements GeneDataStore.
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// getGeneData reads and decodes a record from the bucket.
+func getGeneData(bucket *bolt.Bucket, fileID string) (GeneData, error) {
+	value := bucket.Get([]byte(fileID))
+	if value == nil {
+		return GeneData{}, ErrGeneDataNotFound
+	}
+
+	var data GeneData
+	if err := json.Unmarshal(value, &data); err != nil {
+		return GeneData{}, err
+	}
+	return data, nil
+}
+
+// appendAuditEvents appends the events to the audit log of the file ID.
+func appendAuditEvents(tx *bolt.Tx, fileID string, events []AuditEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	log, err := tx.Bucket(auditLogBucket).CreateBucketIfNotExists([]byte(fileID))
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		seq, err := log.NextSequence()
+		if err != nil {
+			return err
+		}
+		if err := log.Put(binary.BigEndian.AppendUint64(nil, seq), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putGeneData encodes and writes a record to the bucket.
+func putGeneData(bucket *bolt.Bucket, data GeneData) error {
+	value, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(data.FileID), value)
+}
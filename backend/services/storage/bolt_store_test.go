@@ -0,0 +1,80 @@
+package storage_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	service "github.com/trungnt1811/blockchain-engineer-interview/backend/services/storage"
+)
+
+func TestBoltStore_PersistsAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gene_data.db")
+	newBlobStore := func() service.BlobStore {
+		blobs, err := service.NewDiskBlobStore(filepath.Join(dir, "blobs"))
+		require.NoError(t, err)
+		return blobs
+	}
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	// Store gene data and an assessment, then close the database
+	store, err := service.NewBoltStore(path)
+	require.NoError(t, err)
+	storageService := service.NewGeneDataStorageServiceWithStore(store, newBlobStore())
+
+	encryptedData := []byte("encrypted_gene_data")
+	hashData := crypto.Keccak256Hash(encryptedData).Bytes()
+	signature, err := crypto.Sign(hashData, privateKey)
+	require.NoError(t, err)
+
+	fileID, err := storageService.StoreGeneData(uint64(7), encryptedData, signature, hashData)
+	require.NoError(t, err)
+	assessment := service.RiskAssessment{RiskScore: 1, Model: "g-stroke", ModelVersion: "1.0.0"}
+	require.NoError(t, storageService.RecordRiskAssessment(fileID, assessment))
+	_, err = storageService.RetrieveGeneData(fileID, service.Accessor{UserID: 7})
+	require.NoError(t, err)
+	require.NoError(t, storageService.Close())
+
+	// Reopen the database and read everything back
+	store, err = service.NewBoltStore(path)
+	require.NoError(t, err)
+	storageService = service.NewGeneDataStorageServiceWithStore(store, newBlobStore())
+	defer storageService.Close()
+
+	geneData, err := storageService.GetGeneData(fileID)
+	require.NoError(t, err)
+	require.Equal(t, service.GeneData{
+		FileID:         fileID,
+		UserID:         7,
+		DataHash:       hashData,
+		Signature:      signature,
+		BlobID:         fileID,
+		ContentID:      service.ContentID(encryptedData),
+		EncryptedData:  encryptedData,
+		RiskAssessment: &assessment,
+	}, geneData)
+
+	isValid, err := storageService.VerifyGeneDataSignature(fileID, crypto.FromECDSAPub(&privateKey.PublicKey))
+	require.NoError(t, err)
+	require.True(t, isValid)
+
+	// So is the audit log
+	trail, err := storageService.AuditTrail(fileID, service.Accessor{UserID: 7})
+	require.NoError(t, err)
+	require.Len(t, trail, 1)
+	require.Equal(t, service.AuditAccess, trail[0].Action)
+
+	// Duplicates are still detected after a restart
+	_, err = storageService.StoreGeneData(uint64(7), encryptedData, signature, hashData)
+	require.ErrorIs(t, err, service.ErrGeneDataExists)
+}
+
+func TestBoltStore_InvalidPath(t *testing.T) {
+	_, err := service.NewBoltStore(filepath.Join(t.TempDir(), "missing", "gene_data.db"))
+	require.Error(t, err)
+}
@@ -0,0 +1,317 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Errors returned by the consent subsystem.
+var (
+	ErrAccessDenied        = errors.New("access to gene data denied")
+	ErrInvalidConsentGrant = errors.New("invalid consent grant")
+	ErrConsentGrantExists  = errors.New("consent grant already exists")
+	ErrConsentNotFound     = errors.New("consent grant not found")
+	ErrConsentInactive     = errors.New("consent grant is expired or revoked")
+	ErrGrantSignerMismatch = errors.New("consent grant is not signed by the data owner")
+)
+
+// ConsentGrant lets a grantee, such as a researcher or clinician, read a user's gene data for a
+// stated purpose until it expires or is revoked. The owner signs the grant's Message with
+// personal_sign, and the signature is checked against the owner's public key.
+type ConsentGrant struct {
+	ID        string // Hex-encoded hash of the signed message, set by GrantConsent.
+	FileID    string
+	Grantee   common.Address
+	Purpose   string
+	ExpiresAt time.Time
+	Nonce     string // Chosen by the owner so identical grants can be issued again after revocation.
+	Signature []byte
+
+	GrantedAt time.Time
+	RevokedAt *time.Time // Set once the owner revokes the grant.
+}
+
+// Accessor identifies who is acting on gene data. The owner is identified by user ID and
+// grantees by Ethereum address, so both are usually taken from the caller's session.
+type Accessor struct {
+	UserID  uint64
+	Address common.Address
+	Purpose string // Purpose of the access, which must match the purpose of a grant.
+}
+
+// AuditAction is the kind of event recorded in the audit trail of gene data.
+type AuditAction string
+
+// Audited actions.
+const (
+	AuditGrant        AuditAction = "grant"
+	AuditRevoke       AuditAction = "revoke"
+	AuditAccess       AuditAction = "access"
+	AuditAccessDenied AuditAction = "access_denied"
+)
+
+// AuditEvent is an entry of the audit trail of gene data.
+type AuditEvent struct {
+	Time    time.Time
+	Action  AuditAction
+	UserID  uint64
+	Address common.Address
+	GrantID string // The grant that was created, revoked or used, if any.
+	Purpose string
+}
+
+// Message returns the text the owner signs with personal_sign to issue the grant.
+func (g ConsentGrant) Message() string {
+	return fmt.Sprintf("Grant access to gene data\n\nFile ID: %s\nGrantee: %s\nPurpose: %s\nExpires At: %s\nNonce: %s",
+		g.FileID, g.Grantee.Hex(), g.Purpose, g.ExpiresAt.UTC().Format(time.RFC3339), g.Nonce)
+}
+
+// Active reports whether the grant allows access at the given time.
+func (g ConsentGrant) Active(now time.Time) bool {
+	return g.RevokedAt == nil && now.Before(g.ExpiresAt)
+}
+
+// Verify checks that the grant is well-formed and signed by the owner's key. It does not check
+// whether the grant is still active.
+func (g ConsentGrant) Verify(ownerPublicKey []byte) error {
+	if g.Grantee == (common.Address{}) || g.Purpose == "" || g.Nonce == "" ||
+		strings.ContainsAny(g.Purpose+g.Nonce, "\r\n") || len(g.Signature) != crypto.SignatureLength {
+		return ErrInvalidConsentGrant
+	}
+
+	signer, err := crypto.SigToPub(g.hash(), normalizeRecoveryID(g.Signature))
+	if err != nil || !bytes.Equal(crypto.FromECDSAPub(signer), ownerPublicKey) {
+		return ErrGrantSignerMismatch
+	}
+	return nil
+}
+
+// hash returns the personal_sign hash of the grant message.
+func (g ConsentGrant) hash() []byte {
+	return accounts.TextHash([]byte(g.Message()))
+}
+
+// GrantConsent verifies a grant signed by the owner of the gene data and stores it. The owner's
+// public key must be the key that signed the gene data when its user uploaded it, so callers
+// cannot vouch for a key of someone else.
+func (s *GeneDataStorageService) GrantConsent(grant ConsentGrant, ownerPublicKey []byte) (ConsentGrant, error) {
+	now := time.Now()
+
+	// The signed message has second precision
+	grant.ExpiresAt = grant.ExpiresAt.UTC().Truncate(time.Second)
+	if !grant.ExpiresAt.After(now) {
+		return ConsentGrant{}, ErrInvalidConsentGrant
+	}
+	if err := grant.Verify(ownerPublicKey); err != nil {
+		return ConsentGrant{}, err
+	}
+	owner, err := crypto.UnmarshalPubkey(ownerPublicKey)
+	if err != nil {
+		return ConsentGrant{}, err
+	}
+
+	grant.ID = hex.EncodeToString(grant.hash())
+	grant.GrantedAt = now
+	grant.RevokedAt = nil
+
+	var exists, notOwner bool
+	err = s.store.UpdateAudited(grant.FileID, func(data *GeneData) []AuditEvent {
+		// The key must have signed the upload of the data's user
+		if len(data.Signature) != crypto.SignatureLength ||
+			!crypto.VerifySignature(ownerPublicKey, data.DataHash, data.Signature[:crypto.RecoveryIDOffset]) {
+			notOwner = true
+			return nil
+		}
+		for _, existing := range data.ConsentGrants {
+			if existing.ID == grant.ID {
+				exists = true
+				return nil
+			}
+		}
+		data.ConsentGrants = append(data.ConsentGrants, grant)
+		return []AuditEvent{{
+			Time:    now,
+			Action:  AuditGrant,
+			UserID:  data.UserID,
+			Address: crypto.PubkeyToAddress(*owner),
+			GrantID: grant.ID,
+			Purpose: grant.Purpose,
+		}}
+	})
+	if err != nil {
+		return ConsentGrant{}, err
+	}
+	if notOwner {
+		return ConsentGrant{}, ErrGrantSignerMismatch
+	}
+	if exists {
+		return ConsentGrant{}, ErrConsentGrantExists
+	}
+	return grant, nil
+}
+
+// RevokeConsent revokes a grant. Only the owner of the gene data can revoke its grants. The
+// grantee's wrapped data key is removed as well, unless another grant to them is still active.
+func (s *GeneDataStorageService) RevokeConsent(fileID, grantID string, accessor Accessor) error {
+	now := time.Now()
+
+	var err error
+	updateErr := s.store.UpdateAudited(fileID, func(data *GeneData) []AuditEvent {
+		if data.UserID != accessor.UserID {
+			err = ErrAccessDenied
+			return nil
+		}
+		for i := range data.ConsentGrants {
+			grant := &data.ConsentGrants[i]
+			if grant.ID != grantID || grant.RevokedAt != nil {
+				continue
+			}
+			grant.RevokedAt = &now
+			events := []AuditEvent{{
+				Time:    now,
+				Action:  AuditRevoke,
+				UserID:  accessor.UserID,
+				Address: accessor.Address,
+				GrantID: grantID,
+				Purpose: grant.Purpose,
+			}}
+			return append(events, revokeWrappedKey(data, grant.Grantee, now)...)
+		}
+		err = ErrConsentNotFound
+		return nil
+	})
+	if updateErr != nil {
+		return updateErr
+	}
+	return err
+}
+
+// revokeWrappedKey removes the wrapped key of a grantee who has no active grant left and returns
+// the audit event of the removal, if any.
+func revokeWrappedKey(data *GeneData, grantee common.Address, now time.Time) []AuditEvent {
+	for _, grant := range data.ConsentGrants {
+		if grant.Grantee == grantee && grant.Active(now) {
+			return nil
+		}
+	}
+	remaining := removeWrappedKey(data.WrappedKeys, grantee)
+	if len(remaining) == len(data.WrappedKeys) {
+		return nil
+	}
+	data.WrappedKeys = remaining
+	return []AuditEvent{{
+		Time:    now,
+		Action:  AuditRemoveRecipient,
+		UserID:  data.UserID,
+		Address: grantee,
+	}}
+}
+
+// AccessGeneData returns the gene data to its owner or to a grantee holding an active grant for
+// the accessor's purpose. Every attempt is appended to the audit log, without rewriting the gene
+// data record, and ErrAccessDenied is returned if the accessor is not allowed to read the data.
+func (s *GeneDataStorageService) AccessGeneData(fileID string, accessor Accessor) (GeneData, error) {
+	now := time.Now()
+
+	data, err := s.store.Get(fileID)
+	if err != nil {
+		return GeneData{}, err
+	}
+	event := AuditEvent{
+		Time:    now,
+		Action:  AuditAccessDenied,
+		UserID:  accessor.UserID,
+		Address: accessor.Address,
+		Purpose: accessor.Purpose,
+	}
+	if data.UserID == accessor.UserID {
+		event.Action = AuditAccess
+	} else {
+		for _, grant := range data.ConsentGrants {
+			if grant.Grantee == accessor.Address && grant.Purpose == accessor.Purpose && grant.Active(now) {
+				event.Action = AuditAccess
+				event.GrantID = grant.ID
+				break
+			}
+		}
+	}
+	if err := s.store.AppendAuditEvents(fileID, event); err != nil {
+		return GeneData{}, err
+	}
+	if event.Action != AuditAccess {
+		return GeneData{}, ErrAccessDenied
+	}
+
+	return s.GetGeneData(fileID)
+}
+
+// ActiveGrant returns a grant of the gene data to the accessor, which must still be active.
+func (s *GeneDataStorageService) ActiveGrant(fileID, grantID string, accessor Accessor) (ConsentGrant, error) {
+	data, err := s.store.Get(fileID)
+	if err != nil {
+		return ConsentGrant{}, err
+	}
+	return findActiveGrant(&data, grantID, accessor.Address, time.Now())
+}
+
+// findActiveGrant returns the grant of the gene data with the given ID if it was issued to the
+// grantee and is active at the given time.
+func findActiveGrant(data *GeneData, grantID string, grantee common.Address, now time.Time) (ConsentGrant, error) {
+	for _, grant := range data.ConsentGrants {
+		if grant.ID != grantID {
+			continue
+		}
+		if grant.Grantee != grantee {
+			return ConsentGrant{}, ErrAccessDenied
+		}
+		if !grant.Active(now) {
+			return ConsentGrant{}, ErrConsentInactive
+		}
+		return grant, nil
+	}
+	return ConsentGrant{}, ErrConsentNotFound
+}
+
+// ConsentGrants returns the grants issued for the gene data, including expired and revoked ones.
+// Only the owner can list them.
+func (s *GeneDataStorageService) ConsentGrants(fileID string, accessor Accessor) ([]ConsentGrant, error) {
+	data, err := s.store.Get(fileID)
+	if err != nil {
+		return nil, err
+	}
+	if data.UserID != accessor.UserID {
+		return nil, ErrAccessDenied
+	}
+	return data.ConsentGrants, nil
+}
+
+// AuditTrail returns the grants, revocations and access attempts recorded for the gene data in
+// chronological order. Only the owner can read it.
+func (s *GeneDataStorageService) AuditTrail(fileID string, accessor Accessor) ([]AuditEvent, error) {
+	data, err := s.store.Get(fileID)
+	if err != nil {
+		return nil, err
+	}
+	if data.UserID != accessor.UserID {
+		return nil, ErrAccessDenied
+	}
+	return s.store.AuditEvents(fileID)
+}
+
+// normalizeRecoveryID accepts signatures with a recovery ID of 27/28, as produced by wallets.
+func normalizeRecoveryID(signature []byte) []byte {
+	if signature[crypto.RecoveryIDOffset] < 27 {
+		return signature
+	}
+	normalized := bytes.Clone(signature)
+	normalized[crypto.RecoveryIDOffset] -= 27
+	return normalized
+}
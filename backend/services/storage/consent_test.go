@@ -0,0 +1,305 @@
+package storage_test
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	service "github.com/trungnt1811/blockchain-engineer-interview/backend/services/storage"
+)
+
+// signGrant signs the grant message the way wallets do for personal_sign, with V as 27/28.
+func signGrant(t *testing.T, grant service.ConsentGrant, privateKey *ecdsa.PrivateKey) service.ConsentGrant {
+	signature, err := crypto.Sign(accounts.TextHash([]byte(grant.Message())), privateKey)
+	require.NoError(t, err)
+	signature[crypto.RecoveryIDOffset] += 27
+	grant.Signature = signature
+	return grant
+}
+
+// storeOwnedGeneData stores gene data signed by the owner and returns its file ID.
+func storeOwnedGeneData(t *testing.T, storageService *service.GeneDataStorageService, userID uint64, owner *ecdsa.PrivateKey) string {
+	encryptedData := []byte("encrypted_gene_data")
+	hashData := crypto.Keccak256Hash(encryptedData).Bytes()
+	signature, err := crypto.Sign(hashData, owner)
+	require.NoError(t, err)
+
+	fileID, err := storageService.StoreGeneData(userID, encryptedData, signature, hashData)
+	require.NoError(t, err)
+	return fileID
+}
+
+func TestConsent_GrantAccessRevoke(t *testing.T) {
+	forEachStore(t, func(t *testing.T, storageService *service.GeneDataStorageService) {
+		owner, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		researcher, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		ownerPublicKey := crypto.FromECDSAPub(&owner.PublicKey)
+
+		ownerAccessor := service.Accessor{UserID: 1, Address: crypto.PubkeyToAddress(owner.PublicKey)}
+		researcherAccessor := service.Accessor{
+			UserID:  2,
+			Address: crypto.PubkeyToAddress(researcher.PublicKey),
+			Purpose: "stroke research",
+		}
+		fileID := storeOwnedGeneData(t, storageService, ownerAccessor.UserID, owner)
+
+		// Without a grant only the owner can read the data
+		_, err = storageService.RetrieveGeneData(fileID, ownerAccessor)
+		require.NoError(t, err)
+		_, err = storageService.RetrieveGeneData(fileID, researcherAccessor)
+		require.ErrorIs(t, err, service.ErrAccessDenied)
+
+		// Grant the researcher access for stroke research
+		grant, err := storageService.GrantConsent(signGrant(t, service.ConsentGrant{
+			FileID:    fileID,
+			Grantee:   researcherAccessor.Address,
+			Purpose:   "stroke research",
+			ExpiresAt: time.Now().Add(time.Hour),
+			Nonce:     "1",
+		}, owner), ownerPublicKey)
+		require.NoError(t, err)
+		require.NotEmpty(t, grant.ID)
+
+		encryptedData, err := storageService.RetrieveGeneData(fileID, researcherAccessor)
+		require.NoError(t, err)
+		require.Equal(t, []byte("encrypted_gene_data"), encryptedData)
+
+		// The grant is limited to its purpose
+		_, err = storageService.RetrieveGeneData(fileID, service.Accessor{
+			UserID:  researcherAccessor.UserID,
+			Address: researcherAccessor.Address,
+			Purpose: "marketing",
+		})
+		require.ErrorIs(t, err, service.ErrAccessDenied)
+
+		// Only the owner can list grants, read the audit trail and revoke grants
+		grants, err := storageService.ConsentGrants(fileID, ownerAccessor)
+		require.NoError(t, err)
+		require.Len(t, grants, 1)
+		_, err = storageService.ConsentGrants(fileID, researcherAccessor)
+		require.ErrorIs(t, err, service.ErrAccessDenied)
+		_, err = storageService.AuditTrail(fileID, researcherAccessor)
+		require.ErrorIs(t, err, service.ErrAccessDenied)
+		require.ErrorIs(t, storageService.RevokeConsent(fileID, grant.ID, researcherAccessor), service.ErrAccessDenied)
+
+		require.NoError(t, storageService.RevokeConsent(fileID, grant.ID, ownerAccessor))
+		_, err = storageService.RetrieveGeneData(fileID, researcherAccessor)
+		require.ErrorIs(t, err, service.ErrAccessDenied)
+		require.ErrorIs(t, storageService.RevokeConsent(fileID, grant.ID, ownerAccessor), service.ErrConsentNotFound)
+
+		// Every step is recorded in the audit trail
+		trail, err := storageService.AuditTrail(fileID, ownerAccessor)
+		require.NoError(t, err)
+		actions := make([]service.AuditAction, 0, len(trail))
+		for _, event := range trail {
+			actions = append(actions, event.Action)
+		}
+		require.Equal(t, []service.AuditAction{
+			service.AuditAccess,
+			service.AuditAccessDenied,
+			service.AuditGrant,
+			service.AuditAccess,
+			service.AuditAccessDenied,
+			service.AuditRevoke,
+			service.AuditAccessDenied,
+		}, actions)
+		require.Equal(t, grant.ID, trail[3].GrantID)
+		require.Equal(t, researcherAccessor.Address, trail[3].Address)
+		require.Equal(t, "stroke research", trail[3].Purpose)
+	})
+}
+
+func TestConsent_InvalidGrants(t *testing.T) {
+	storageService := service.NewGeneDataStorageService()
+
+	owner, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	ownerPublicKey := crypto.FromECDSAPub(&owner.PublicKey)
+	fileID := storeOwnedGeneData(t, storageService, 1, owner)
+
+	valid := service.ConsentGrant{
+		FileID:    fileID,
+		Grantee:   crypto.PubkeyToAddress(other.PublicKey),
+		Purpose:   "clinical care",
+		ExpiresAt: time.Now().Add(time.Hour),
+		Nonce:     "1",
+	}
+
+	// Grants that are incomplete, expired or inject extra lines into the signed message
+	for _, modify := range []func(g *service.ConsentGrant){
+		func(g *service.ConsentGrant) { g.Grantee = [20]byte{} },
+		func(g *service.ConsentGrant) { g.Purpose = "" },
+		func(g *service.ConsentGrant) { g.Purpose = "care\nGrantee: 0x0" },
+		func(g *service.ConsentGrant) { g.Nonce = "" },
+		func(g *service.ConsentGrant) { g.ExpiresAt = time.Now().Add(-time.Minute) },
+	} {
+		grant := valid
+		modify(&grant)
+		_, err := storageService.GrantConsent(signGrant(t, grant, owner), ownerPublicKey)
+		require.ErrorIs(t, err, service.ErrInvalidConsentGrant)
+	}
+
+	// A grant signed by someone other than the owner
+	_, err = storageService.GrantConsent(signGrant(t, valid, other), ownerPublicKey)
+	require.ErrorIs(t, err, service.ErrGrantSignerMismatch)
+
+	// A grant whose fields were changed after signing
+	tampered := signGrant(t, valid, owner)
+	tampered.Purpose = "marketing"
+	_, err = storageService.GrantConsent(tampered, ownerPublicKey)
+	require.ErrorIs(t, err, service.ErrGrantSignerMismatch)
+
+	// A grant signed by someone who passes their own key instead of the owner's
+	_, err = storageService.GrantConsent(signGrant(t, valid, other), crypto.FromECDSAPub(&other.PublicKey))
+	require.ErrorIs(t, err, service.ErrGrantSignerMismatch)
+
+	// A grant for unknown gene data
+	unknown := valid
+	unknown.FileID = "unknown"
+	_, err = storageService.GrantConsent(signGrant(t, unknown, owner), ownerPublicKey)
+	require.ErrorIs(t, err, service.ErrGeneDataNotFound)
+
+	// The same signed grant cannot be stored twice
+	_, err = storageService.GrantConsent(signGrant(t, valid, owner), ownerPublicKey)
+	require.NoError(t, err)
+	_, err = storageService.GrantConsent(signGrant(t, valid, owner), ownerPublicKey)
+	require.ErrorIs(t, err, service.ErrConsentGrantExists)
+}
+
+func TestConsent_Expiry(t *testing.T) {
+	storageService := service.NewGeneDataStorageService()
+
+	owner, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	clinician, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	fileID := storeOwnedGeneData(t, storageService, 1, owner)
+
+	accessor := service.Accessor{UserID: 2, Address: crypto.PubkeyToAddress(clinician.PublicKey), Purpose: "clinical care"}
+	_, err = storageService.GrantConsent(signGrant(t, service.ConsentGrant{
+		FileID:    fileID,
+		Grantee:   accessor.Address,
+		Purpose:   accessor.Purpose,
+		ExpiresAt: time.Now().Add(2 * time.Second),
+		Nonce:     "1",
+	}, owner), crypto.FromECDSAPub(&owner.PublicKey))
+	require.NoError(t, err)
+
+	_, err = storageService.RetrieveGeneData(fileID, accessor)
+	require.NoError(t, err)
+
+	// Expiry has second precision, so wait past the next full second
+	time.Sleep(2100 * time.Millisecond)
+	_, err = storageService.RetrieveGeneData(fileID, accessor)
+	require.ErrorIs(t, err, service.ErrAccessDenied)
+}
+
+// countingStore counts the record updates of a GeneDataStore.
+type countingStore struct {
+	service.GeneDataStore
+	updates int
+}
+
+func (s *countingStore) Update(fileID string, fn func(data *service.GeneData)) error {
+	s.updates++
+	return s.GeneDataStore.Update(fileID, fn)
+}
+
+func (s *countingStore) UpdateAudited(fileID string, fn func(data *service.GeneData) []service.AuditEvent) error {
+	s.updates++
+	return s.GeneDataStore.UpdateAudited(fileID, fn)
+}
+
+func TestConsent_AccessDoesNotRewriteRecord(t *testing.T) {
+	store := &countingStore{GeneDataStore: service.NewMemoryStore()}
+	storageService := service.NewGeneDataStorageServiceWithStore(store, service.NewMemoryBlobStore())
+
+	owner, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	fileID := storeOwnedGeneData(t, storageService, 1, owner)
+
+	// Reads, allowed or denied, only append to the audit log
+	for i := 0; i < 10; i++ {
+		_, err = storageService.RetrieveGeneData(fileID, service.Accessor{UserID: 1})
+		require.NoError(t, err)
+		_, err = storageService.RetrieveGeneData(fileID, service.Accessor{UserID: 2})
+		require.ErrorIs(t, err, service.ErrAccessDenied)
+	}
+	require.Zero(t, store.updates)
+	trail, err := storageService.AuditTrail(fileID, service.Accessor{UserID: 1})
+	require.NoError(t, err)
+	require.Len(t, trail, 20)
+
+	// Reads of unknown gene data are not logged
+	_, err = storageService.RetrieveGeneData("unknown", service.Accessor{UserID: 2})
+	require.ErrorIs(t, err, service.ErrGeneDataNotFound)
+}
+
+func TestConsent_ConcurrentRevokeAndAccess(t *testing.T) {
+	store := service.NewMemoryStore()
+	storageService := service.NewGeneDataStorageServiceWithStore(store, service.NewMemoryBlobStore())
+
+	owner, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	researcher, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	ownerAccessor := service.Accessor{UserID: 1, Address: crypto.PubkeyToAddress(owner.PublicKey)}
+	researcherAccessor := service.Accessor{UserID: 2, Address: crypto.PubkeyToAddress(researcher.PublicKey), Purpose: "stroke research"}
+	fileID := storeOwnedGeneData(t, storageService, ownerAccessor.UserID, owner)
+
+	var grantIDs []string
+	for i := range 20 {
+		grant, err := storageService.GrantConsent(signGrant(t, service.ConsentGrant{
+			FileID:    fileID,
+			Grantee:   researcherAccessor.Address,
+			Purpose:   "stroke research",
+			ExpiresAt: time.Now().Add(time.Hour),
+			Nonce:     fmt.Sprint(i),
+		}, owner), crypto.FromECDSAPub(&owner.PublicKey))
+		require.NoError(t, err)
+		grantIDs = append(grantIDs, grant.ID)
+	}
+	before, err := store.Get(fileID)
+	require.NoError(t, err)
+
+	// Revoking grants does not write into records read before, which accesses keep reading
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for _, grantID := range grantIDs {
+			assert.NoError(t, storageService.RevokeConsent(fileID, grantID, ownerAccessor))
+		}
+	}()
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				_, err := storageService.RetrieveGeneData(fileID, researcherAccessor)
+				if err != nil {
+					assert.ErrorIs(t, err, service.ErrAccessDenied)
+				}
+				_, _ = storageService.ActiveGrant(fileID, grantIDs[len(grantIDs)-1], researcherAccessor)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, grant := range before.ConsentGrants {
+		require.Nil(t, grant.RevokedAt)
+	}
+	_, err = storageService.RetrieveGeneData(fileID, researcherAccessor)
+	require.ErrorIs(t, err, service.ErrAccessDenied)
+}
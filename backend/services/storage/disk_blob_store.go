@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// diskChunkSize is the size of the chunks blobs are split into on disk.
+const diskChunkSize = 1 << 20
+
+// DiskBlobStore is a BlobStore on the local file system. Blobs are split into chunks that are
+// themselves addressed by their sha256 hash, so chunks shared between blobs are stored once:
+//
+//	<root>/chunks/<first 2 hex chars>/<sha256 of chunk>
+//	<root>/blobs/<first 2 hex chars>/<blob ID>  (JSON manifest listing the chunks)
+//
+// Files are written to a temporary name and renamed into place, so a crash never leaves a
+// partially written chunk or manifest behind.
+type DiskBlobStore struct {
+	root string
+}
+
+// blobManifest lists the chunks of a blob in order.
+type blobManifest struct {
+	Size   int      `json:"size"`
+	Chunks []string `json:"chunks"`
+}
+
+// NewDiskBlobStore creates a DiskBlobStore rooted at the given directory, creating it if needed.
+func NewDiskBlobStore(root string) (*DiskBlobStore, error) {
+	for _, dir := range []string{"chunks", "blobs"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o700); err != nil {
+			return nil, err
+		}
+	}
+	return &DiskBlobStore{root: root}, nil
+}
+
+// Put implements BlobStore.
+func (s *DiskBlobStore) Put(data []byte) (string, error) {
+	id := BlobID(data)
+
+	// The blob is already stored
+	if _, err := os.Stat(s.manifestPath(id)); err == nil {
+		return id, nil
+	}
+
+	manifest := blobManifest{Size: len(data), Chunks: []string{}}
+	for offset := 0; offset < len(data); offset += diskChunkSize {
+		chunk := data[offset:min(offset+diskChunkSize, len(data))]
+		digest := sha256.Sum256(chunk)
+		chunkID := hex.EncodeToString(digest[:])
+
+		// Identical chunks are stored only once
+		if _, err := os.Stat(s.chunkPath(chunkID)); errors.Is(err, fs.ErrNotExist) {
+			if err := writeFileAtomic(s.chunkPath(chunkID), chunk); err != nil {
+				return "", err
+			}
+		} else if err != nil {
+			return "", err
+		}
+		manifest.Chunks = append(manifest.Chunks, chunkID)
+	}
+
+	// Write the manifest last so a blob is only visible once all of its chunks are
+	encoded, err := json.Marshal(manifest)
+	if err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(s.manifestPath(id), encoded); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Get implements BlobStore.
+func (s *DiskBlobStore) Get(id string) ([]byte, error) {
+	if err := validateBlobID(id); err != nil {
+		return nil, err
+	}
+
+	encoded, err := os.ReadFile(s.manifestPath(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest blobManifest
+	if err := json.Unmarshal(encoded, &manifest); err != nil ||
+		manifest.Size < 0 || manifest.Size > len(manifest.Chunks)*diskChunkSize {
+		return nil, ErrBlobCorrupted
+	}
+
+	data := make([]byte, 0, manifest.Size)
+	for _, chunkID := range manifest.Chunks {
+		// Chunk IDs have the same format as blob IDs
+		if err := validateBlobID(chunkID); err != nil {
+			return nil, ErrBlobCorrupted
+		}
+
+		chunk, err := os.ReadFile(s.chunkPath(chunkID))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrBlobCorrupted
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Verify every chunk so corruption is reported where it happened
+		digest := sha256.Sum256(chunk)
+		if hex.EncodeToString(digest[:]) != chunkID {
+			return nil, ErrBlobCorrupted
+		}
+		data = append(data, chunk...)
+	}
+
+	if len(data) != manifest.Size {
+		return nil, ErrBlobCorrupted
+	}
+	if err := verifyBlob(id, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// manifestPath returns the path of a blob manifest.
+func (s *DiskBlobStore) manifestPath(id string) string {
+	return filepath.Join(s.root, "blobs", id[:2], id)
+}
+
+// chunkPath returns the path of a chunk.
+func (s *DiskBlobStore) chunkPath(chunkID string) string {
+	return filepath.Join(s.root, "chunks", chunkID[:2], chunkID)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it into place.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,9 @@
+package storage
+
+// Unexported Signature Version 4 helpers, exported for the known-answer tests.
+var (
+	SignV4          = signV4
+	SigV4SigningKey = sigV4SigningKey
+	CanonicalQuery  = canonicalQuery
+	URIEncode       = uriEncode
+)
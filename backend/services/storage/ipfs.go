@@ -0,0 +1,360 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+)
+
+// Encrypted gene data is exported as an IPFS UnixFS file with the same layout as
+// `ipfs add --cid-version=1`: the data is split into 256KB raw leaves, which are linked from
+// dag-pb nodes of at most 174 links in a balanced tree, and every block is addressed by a CIDv1
+// with a sha256 multihash. Data that fits in one leaf is addressed by the leaf itself.
+const (
+	unixfsChunkSize = 256 << 10
+	unixfsMaxLinks  = 174
+
+	// Multicodec codes used in CIDs
+	cidVersion1     = 0x01
+	codecRaw        = 0x55
+	codecDagPB      = 0x70
+	multihashSHA256 = 0x12
+
+	// unixfsTypeFile is the UnixFS data type of file nodes.
+	unixfsTypeFile = 2
+	// maxCARSectionSize bounds the size of a block read from a CAR file.
+	maxCARSectionSize = 2 << 20
+)
+
+// Errors returned when reading a CAR file.
+var (
+	ErrInvalidCID = errors.New("invalid CID")
+	ErrInvalidCAR = errors.New("invalid CAR file")
+	ErrMissingCAR = errors.New("CAR file is missing a block")
+)
+
+// cidBase32 is the multibase base32 encoding used for CIDv1 strings.
+var cidBase32 = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// dagNode is a block of a UnixFS file DAG.
+type dagNode struct {
+	cid      []byte
+	block    []byte
+	fileSize uint64     // Size of the file data below this node.
+	treeSize uint64     // Size of this block and all blocks below it, the dag-pb Tsize.
+	links    []*dagNode // Children of a dag-pb node, nil for raw leaves.
+}
+
+// ContentID returns the IPFS CIDv1 of data as a UnixFS file, which is what
+// `ipfs add --cid-version=1` reports for the same bytes. It is anchored on-chain so anyone can
+// verify an exported blob without trusting the storage service.
+func ContentID(data []byte) string {
+	return formatCID(buildFileDAG(data).cid)
+}
+
+// WriteCAR writes data to w as a CARv1 archive of its UnixFS file DAG, which can be imported
+// with `ipfs dag import`, and returns the CID of the root. Blocks are written in depth-first
+// order starting with the root, and blocks that occur more than once are written once.
+func WriteCAR(w io.Writer, data []byte) (string, error) {
+	root := buildFileDAG(data)
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write(carHeader(root.cid)); err != nil {
+		return "", err
+	}
+
+	written := make(map[string]bool)
+	var writeNode func(node *dagNode) error
+	writeNode = func(node *dagNode) error {
+		if written[string(node.cid)] {
+			return nil
+		}
+		written[string(node.cid)] = true
+
+		section := binary.AppendUvarint(nil, uint64(len(node.cid)+len(node.block)))
+		section = append(section, node.cid...)
+		if _, err := bw.Write(section); err != nil {
+			return err
+		}
+		if _, err := bw.Write(node.block); err != nil {
+			return err
+		}
+		for _, link := range node.links {
+			if err := writeNode(link); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := writeNode(root); err != nil {
+		return "", err
+	}
+
+	if err := bw.Flush(); err != nil {
+		return "", err
+	}
+	return formatCID(root.cid), nil
+}
+
+// ReadCAR reads a CARv1 archive produced by WriteCAR whose root is contentID, for example the
+// CID anchored on-chain, and returns the file data. Every block is verified against its CID as it
+// is read, so the data is only returned if it is exactly the content addressed by contentID.
+func ReadCAR(r io.Reader, contentID string) ([]byte, error) {
+	root, err := parseCID(contentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The dag-cbor header is canonical, so a single-root archive has exactly this header
+	br := bufio.NewReader(r)
+	header := carHeader(root)
+	got := make([]byte, len(header))
+	if _, err := io.ReadFull(br, got); err != nil || !bytes.Equal(got, header) {
+		return nil, ErrInvalidCAR
+	}
+
+	// Load the blocks into an in-memory blockstore
+	blocks := make(map[string][]byte)
+	for {
+		size, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil || size == 0 || size > maxCARSectionSize {
+			return nil, ErrInvalidCAR
+		}
+		section := make([]byte, size)
+		if _, err := io.ReadFull(br, section); err != nil {
+			return nil, ErrInvalidCAR
+		}
+
+		cidLen := cidSize(section)
+		if cidLen == 0 {
+			return nil, ErrInvalidCAR
+		}
+		cid, block := section[:cidLen], section[cidLen:]
+		if !bytes.Equal(cid, newCID(cid[1], block)) {
+			return nil, ErrBlobCorrupted
+		}
+		blocks[string(cid)] = block
+	}
+
+	// Reassemble the file from its leaves
+	var data []byte
+	var readNode func(cid []byte, depth int) error
+	readNode = func(cid []byte, depth int) error {
+		block, exists := blocks[string(cid)]
+		if !exists {
+			return ErrMissingCAR
+		}
+		if cid[1] == codecRaw {
+			data = append(data, block...)
+			return nil
+		}
+
+		// A file DAG is never deeper than a few levels, so this bounds malicious nesting
+		if depth > 8 {
+			return ErrInvalidCAR
+		}
+		links, err := decodeDagPBLinks(block)
+		if err != nil {
+			return err
+		}
+		for _, link := range links {
+			if err := readNode(link, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := readNode(root, 0); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// buildFileDAG splits data into raw leaves and links them into a balanced tree of dag-pb nodes.
+func buildFileDAG(data []byte) *dagNode {
+	var level []*dagNode
+	for offset := 0; offset < len(data) || offset == 0; offset += unixfsChunkSize {
+		chunk := data[offset:min(offset+unixfsChunkSize, len(data))]
+		level = append(level, &dagNode{
+			cid:      newCID(codecRaw, chunk),
+			block:    chunk,
+			fileSize: uint64(len(chunk)),
+			treeSize: uint64(len(chunk)),
+		})
+	}
+
+	// Group each level into parents until a single root is left
+	for len(level) > 1 {
+		var parents []*dagNode
+		for start := 0; start < len(level); start += unixfsMaxLinks {
+			parents = append(parents, newFileNode(level[start:min(start+unixfsMaxLinks, len(level))]))
+		}
+		level = parents
+	}
+	return level[0]
+}
+
+// newFileNode creates the dag-pb UnixFS file node linking to the given children.
+func newFileNode(children []*dagNode) *dagNode {
+	node := &dagNode{links: children}
+
+	// UnixFS Data message: Type, filesize and one blocksizes entry per child
+	var unixfsData []byte
+	unixfsData = appendProtoVarint(unixfsData, 1, unixfsTypeFile)
+	for _, child := range children {
+		node.fileSize += child.fileSize
+	}
+	unixfsData = appendProtoVarint(unixfsData, 3, node.fileSize)
+	for _, child := range children {
+		unixfsData = appendProtoVarint(unixfsData, 4, child.fileSize)
+	}
+
+	// dag-pb PBNode message: Links are encoded before Data
+	var block []byte
+	for _, child := range children {
+		var link []byte
+		link = appendProtoBytes(link, 1, child.cid)
+		link = appendProtoBytes(link, 2, nil)
+		link = appendProtoVarint(link, 3, child.treeSize)
+		block = appendProtoBytes(block, 2, link)
+		node.treeSize += child.treeSize
+	}
+	block = appendProtoBytes(block, 1, unixfsData)
+
+	node.block = block
+	node.cid = newCID(codecDagPB, block)
+	node.treeSize += uint64(len(block))
+	return node
+}
+
+// decodeDagPBLinks returns the CIDs of the links of a dag-pb node in order.
+func decodeDagPBLinks(block []byte) ([][]byte, error) {
+	var links [][]byte
+	for len(block) > 0 {
+		field, value, rest, err := readProtoBytes(block)
+		if err != nil {
+			return nil, err
+		}
+		block = rest
+		if field != 2 {
+			continue
+		}
+
+		// PBLink message: the Hash field holds the binary CID
+		for len(value) > 0 {
+			linkField, linkValue, linkRest, err := readProtoBytes(value)
+			if err != nil {
+				return nil, err
+			}
+			value = linkRest
+			if linkField == 1 {
+				if cidSize(linkValue) != len(linkValue) {
+					return nil, ErrInvalidCAR
+				}
+				links = append(links, linkValue)
+			}
+		}
+	}
+	return links, nil
+}
+
+// newCID returns the binary CIDv1 of a block with the given codec and a sha256 multihash.
+func newCID(codec byte, block []byte) []byte {
+	digest := sha256.Sum256(block)
+	return append([]byte{cidVersion1, codec, multihashSHA256, sha256.Size}, digest[:]...)
+}
+
+// cidSize returns the length of the binary CID at the start of b, or 0 if b does not start
+// with a CIDv1 of a raw or dag-pb block with a sha256 multihash.
+func cidSize(b []byte) int {
+	const size = 4 + sha256.Size
+	if len(b) < size || b[0] != cidVersion1 || (b[1] != codecRaw && b[1] != codecDagPB) ||
+		b[2] != multihashSHA256 || b[3] != sha256.Size {
+		return 0
+	}
+	return size
+}
+
+// formatCID returns the multibase base32 string form of a binary CID.
+func formatCID(cid []byte) string {
+	return "b" + cidBase32.EncodeToString(cid)
+}
+
+// parseCID parses the string form of a CID returned by ContentID.
+func parseCID(s string) ([]byte, error) {
+	encoded, found := strings.CutPrefix(s, "b")
+	if !found {
+		return nil, ErrInvalidCID
+	}
+	cid, err := cidBase32.DecodeString(encoded)
+	if err != nil || cidSize(cid) != len(cid) {
+		return nil, ErrInvalidCID
+	}
+	return cid, nil
+}
+
+// carHeader returns the CARv1 header for a single root: the length-prefixed dag-cbor encoding
+// of {"roots": [root], "version": 1}.
+func carHeader(root []byte) []byte {
+	// map(2), text(5) "roots", array(1), tag(42) for a CID
+	header := append([]byte{0xa2, 0x65}, "roots"...)
+	header = append(header, 0x81, 0xd8, 0x2a)
+	// bytes(len), the CID prefixed with the identity multibase
+	header = append(header, 0x58, byte(len(root)+1), 0x00)
+	header = append(header, root...)
+	// text(7) "version", 1
+	header = append(header, 0x67)
+	header = append(header, "version"...)
+	header = append(header, 0x01)
+
+	return append(binary.AppendUvarint(nil, uint64(len(header))), header...)
+}
+
+// appendProtoVarint appends a protobuf varint field.
+func appendProtoVarint(b []byte, field int, value uint64) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3)
+	return binary.AppendUvarint(b, value)
+}
+
+// appendProtoBytes appends a protobuf length-delimited field.
+func appendProtoBytes(b []byte, field int, value []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3|2)
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+// readProtoBytes reads a protobuf field, which must be length-delimited or a varint, and
+// returns its field number, its value (nil for varints) and the remaining input.
+func readProtoBytes(b []byte) (int, []byte, []byte, error) {
+	key, n := binary.Uvarint(b)
+	if n <= 0 {
+		return 0, nil, nil, ErrInvalidCAR
+	}
+	b = b[n:]
+
+	switch key & 7 {
+	case 0:
+		_, n := binary.Uvarint(b)
+		if n <= 0 {
+			return 0, nil, nil, ErrInvalidCAR
+		}
+		return int(key >> 3), nil, b[n:], nil
+	case 2:
+		size, n := binary.Uvarint(b)
+		if n <= 0 || size > uint64(len(b)-n) {
+			return 0, nil, nil, ErrInvalidCAR
+		}
+		b = b[n:]
+		return int(key >> 3), b[:size], b[size:], nil
+	default:
+		return 0, nil, nil, ErrInvalidCAR
+	}
+}
@@ -0,0 +1,99 @@
+package storage_test
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	service "github.com/trungnt1811/blockchain-engineer-interview/backend/services/storage"
+)
+
+// seededData returns the pseudo-random data the IPFS importer tests use, so the CIDs below are
+// the ones IPFS computes for the same bytes.
+func seededData(size int, seed int64) []byte {
+	r := rand.New(rand.NewSource(seed))
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(r.Intn(255))
+	}
+	return data
+}
+
+func TestContentID(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		cid  string
+	}{
+		{"empty", nil, "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku"},
+		{"10MB", seededData(10<<20, 0xdeadbeef), "bafybeieyxejezqto5xwcxtvh5tskowwxrn3hmbk3hcgredji3g7abtnfkq"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.cid, service.ContentID(test.data))
+		})
+	}
+}
+
+func TestCAR_RoundTrip(t *testing.T) {
+	// Sizes around one leaf (256KB) and around one full level of 174 leaves
+	sizes := []int{0, 1, 256 << 10, 256<<10 + 1, 174 * 256 << 10, 174*256<<10 + 1}
+	for _, size := range sizes {
+		data := seededData(size, int64(size))
+
+		var car bytes.Buffer
+		cid, err := service.WriteCAR(&car, data)
+		require.NoError(t, err)
+		require.Equal(t, service.ContentID(data), cid)
+
+		read, err := service.ReadCAR(bytes.NewReader(car.Bytes()), cid)
+		require.NoError(t, err)
+		require.True(t, bytes.Equal(data, read), size)
+	}
+}
+
+func TestCAR_DuplicateBlocks(t *testing.T) {
+	// Four identical leaves are written once
+	data := make([]byte, 4*256<<10)
+
+	var car bytes.Buffer
+	cid, err := service.WriteCAR(&car, data)
+	require.NoError(t, err)
+	require.Less(t, car.Len(), 2*256<<10)
+
+	read, err := service.ReadCAR(&car, cid)
+	require.NoError(t, err)
+	require.True(t, bytes.Equal(data, read))
+}
+
+func TestReadCAR_Invalid(t *testing.T) {
+	data := seededData(3*256<<10, 1)
+	var car bytes.Buffer
+	cid, err := service.WriteCAR(&car, data)
+	require.NoError(t, err)
+
+	// A different root
+	_, err = service.ReadCAR(bytes.NewReader(car.Bytes()), service.ContentID([]byte("other")))
+	require.ErrorIs(t, err, service.ErrInvalidCAR)
+
+	// Not a CID
+	_, err = service.ReadCAR(bytes.NewReader(car.Bytes()), "QmNotACIDv1")
+	require.ErrorIs(t, err, service.ErrInvalidCID)
+
+	// A flipped bit in the last leaf
+	tampered := bytes.Clone(car.Bytes())
+	tampered[len(tampered)-1] ^= 0x01
+	_, err = service.ReadCAR(bytes.NewReader(tampered), cid)
+	require.ErrorIs(t, err, service.ErrBlobCorrupted)
+
+	// The last leaf is missing
+	truncated := car.Bytes()[:car.Len()-(256<<10)-36-3]
+	_, err = service.ReadCAR(bytes.NewReader(truncated), cid)
+	require.ErrorIs(t, err, service.ErrMissingCAR)
+
+	// The archive ends in the middle of a block
+	_, err = service.ReadCAR(bytes.NewReader(car.Bytes()[:car.Len()-1]), cid)
+	require.ErrorIs(t, err, service.ErrInvalidCAR)
+}
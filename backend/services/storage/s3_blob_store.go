@@ -0,0 +1,319 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// s3MinPartSize is the smallest part size S3 accepts for every part but the last.
+	s3MinPartSize = 5 << 20
+	// DefaultS3PartSize is the part size used when S3Config.PartSize is not set. Blobs larger
+	// than one part, such as full 40MB gene files, are uploaded with a multipart upload.
+	DefaultS3PartSize = 8 << 20
+	// defaultS3Region is the region used to sign requests when S3Config.Region is not set.
+	defaultS3Region = "us-east-1"
+)
+
+// S3Config configures an S3BlobStore.
+type S3Config struct {
+	// Endpoint is the base URL of the S3 API, e.g. "https://s3.us-east-1.amazonaws.com" or
+	// "http://localhost:9000" for MinIO. Buckets are addressed path-style.
+	Endpoint string
+	// Region is the region requests are signed for. It defaults to us-east-1.
+	Region string
+	// Bucket is the name of an existing bucket.
+	Bucket string
+	// Prefix is prepended to the blob ID to form the object key.
+	Prefix string
+	// AccessKeyID and SecretAccessKey are the credentials requests are signed with.
+	AccessKeyID     string
+	SecretAccessKey string
+	// PartSize is the size of the parts of a multipart upload. It defaults to DefaultS3PartSize
+	// and must be at least 5MB.
+	PartSize int
+	// HTTPClient is the client used to send requests. It defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// S3BlobStore is a BlobStore on an S3 API-compatible object store such as AWS S3 or MinIO.
+// Each blob is stored as one object named after its ID. Only the encrypted data is stored in
+// the bucket; the gene data records (owner, hash and signature) stay in the GeneDataStore.
+//
+// Every upload carries a sha256 checksum of its content (of each part for multipart uploads)
+// that the server verifies before accepting it, and every read is verified against the blob ID.
+type S3BlobStore struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// S3Error is an error response returned by the S3 API.
+type S3Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+// Error implements error.
+func (e *S3Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("s3: unexpected status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("s3: %s: %s", e.Code, e.Message)
+}
+
+// s3CompletedPart is a part listed in a CompleteMultipartUpload request.
+type s3CompletedPart struct {
+	PartNumber     int    `xml:"PartNumber"`
+	ETag           string `xml:"ETag"`
+	ChecksumSHA256 string `xml:"ChecksumSHA256"`
+}
+
+// NewS3BlobStore creates an S3BlobStore and checks that the bucket is reachable.
+func NewS3BlobStore(config S3Config) (*S3BlobStore, error) {
+	if config.Bucket == "" {
+		return nil, errors.New("s3 bucket is required")
+	}
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, errors.New("s3 endpoint must be an http or https URL")
+	}
+
+	if config.Region == "" {
+		config.Region = defaultS3Region
+	}
+	if config.PartSize == 0 {
+		config.PartSize = DefaultS3PartSize
+	}
+	if config.PartSize < s3MinPartSize {
+		return nil, errors.New("s3 part size must be at least 5MB")
+	}
+	client := config.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	s := &S3BlobStore{config: config, endpoint: endpoint, client: client}
+
+	// Fail early on a wrong endpoint, bucket or credentials
+	resp, err := s.do(http.MethodHead, "", nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return s, nil
+}
+
+// Put implements BlobStore.
+func (s *S3BlobStore) Put(data []byte) (string, error) {
+	id := BlobID(data)
+
+	// The blob is already stored
+	resp, err := s.do(http.MethodHead, s.key(id), nil, nil, nil)
+	if err == nil {
+		resp.Body.Close()
+		return id, nil
+	}
+	var s3Err *S3Error
+	if !errors.As(err, &s3Err) || s3Err.StatusCode != http.StatusNotFound {
+		return "", err
+	}
+
+	if len(data) <= s.config.PartSize {
+		err = s.putObject(id, data)
+	} else {
+		err = s.putMultipart(id, data)
+	}
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Get implements BlobStore.
+func (s *S3BlobStore) Get(id string) ([]byte, error) {
+	if err := validateBlobID(id); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(http.MethodGet, s.key(id), nil, nil, nil)
+	var s3Err *S3Error
+	if errors.As(err, &s3Err) && s3Err.StatusCode == http.StatusNotFound {
+		return nil, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyBlob(id, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// putObject uploads a blob in a single PutObject request.
+func (s *S3BlobStore) putObject(id string, data []byte) error {
+	header := http.Header{}
+	header.Set("X-Amz-Checksum-Sha256", base64SHA256(data))
+
+	resp, err := s.do(http.MethodPut, s.key(id), nil, header, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// putMultipart uploads a blob in parts of PartSize bytes. The upload is aborted if any part fails
+// so the bucket is not left with orphaned parts.
+func (s *S3BlobStore) putMultipart(id string, data []byte) error {
+	key := s.key(id)
+
+	header := http.Header{}
+	header.Set("X-Amz-Checksum-Algorithm", "SHA256")
+	resp, err := s.do(http.MethodPost, key, url.Values{"uploads": {""}}, header, nil)
+	if err != nil {
+		return err
+	}
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	err = decodeXML(resp, &initiated)
+	if err != nil {
+		return err
+	}
+	if initiated.UploadID == "" {
+		return errors.New("s3 did not return an upload ID")
+	}
+
+	err = s.uploadParts(key, initiated.UploadID, data)
+	if err != nil {
+		// Best effort, the original error is more useful than an abort failure
+		if resp, abortErr := s.do(http.MethodDelete, key, url.Values{"uploadId": {initiated.UploadID}}, nil, nil); abortErr == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	return nil
+}
+
+// uploadParts uploads the parts of a multipart upload and completes it.
+func (s *S3BlobStore) uploadParts(key, uploadID string, data []byte) error {
+	var parts []s3CompletedPart
+	for offset := 0; offset < len(data); offset += s.config.PartSize {
+		part := data[offset:min(offset+s.config.PartSize, len(data))]
+		number := len(parts) + 1
+		checksum := base64SHA256(part)
+
+		header := http.Header{}
+		header.Set("X-Amz-Checksum-Sha256", checksum)
+		query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+		resp, err := s.do(http.MethodPut, key, query, header, part)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		parts = append(parts, s3CompletedPart{
+			PartNumber:     number,
+			ETag:           resp.Header.Get("ETag"),
+			ChecksumSHA256: checksum,
+		})
+	}
+
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+		Parts   []s3CompletedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(http.MethodPost, key, url.Values{"uploadId": {uploadID}}, nil, body)
+	if err != nil {
+		return err
+	}
+
+	// CompleteMultipartUpload can fail after the server has already sent a 200 status
+	raw, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	if bytes.Contains(raw, []byte("<Error>")) {
+		s3Err := &S3Error{StatusCode: resp.StatusCode}
+		_ = xml.Unmarshal(raw, s3Err)
+		return s3Err
+	}
+	return nil
+}
+
+// do sends a signed request for the object key (or the bucket itself if key is empty). Non-2xx
+// responses are returned as *S3Error.
+func (s *S3BlobStore) do(method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	target := *s.endpoint
+	target.Path = strings.TrimSuffix(target.Path, "/") + "/" + s.config.Bucket
+	if key != "" {
+		target.Path += "/" + key
+	}
+	target.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequest(method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	signV4(req, s.config.AccessKeyID, s.config.SecretAccessKey, s.config.Region, hexSHA256(body), time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		s3Err := &S3Error{StatusCode: resp.StatusCode}
+		if raw, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10)); err == nil {
+			_ = xml.Unmarshal(raw, s3Err)
+		}
+		return nil, s3Err
+	}
+	return resp, nil
+}
+
+// key returns the object key of a blob.
+func (s *S3BlobStore) key(id string) string {
+	return s.config.Prefix + id
+}
+
+// decodeXML decodes and closes an XML response body.
+func decodeXML(resp *http.Response, v any) error {
+	defer resp.Body.Close()
+	return xml.NewDecoder(resp.Body).Decode(v)
+}
+
+// base64SHA256 returns the base64-encoded sha256 of data, the format of S3 checksum headers.
+func base64SHA256(data []byte) string {
+	digest := sha256.Sum256(data)
+	return base64.StdEncoding.EncodeToString(digest[:])
+}
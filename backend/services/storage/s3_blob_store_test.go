@@ -0,0 +1,244 @@
+package storage_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	service "github.com/trungnt1811/blockchain-engineer-interview/backend/services/storage"
+)
+
+const (
+	fakeS3Bucket    = "gene-data"
+	fakeS3AccessKey = "test-access-key"
+)
+
+// fakeS3 is an in-process stand-in for an S3 API-compatible server. It implements the
+// requests S3BlobStore sends and verifies payload hashes and sha256 checksums like S3 does.
+type fakeS3 struct {
+	objects map[string][]byte
+	uploads map[string]map[int][]byte
+	nextID  int
+	mu      sync.Mutex
+
+	// corruptUploads flips a bit of every uploaded body before it is verified
+	corruptUploads bool
+	// completed and aborted count finished multipart uploads
+	completed int
+	aborted   int
+}
+
+// newFakeS3 starts a fakeS3 server and returns a store connected to it.
+func newFakeS3(t *testing.T) (*fakeS3, *service.S3BlobStore) {
+	fake := &fakeS3{
+		objects: make(map[string][]byte),
+		uploads: make(map[string]map[int][]byte),
+	}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	blobs, err := service.NewS3BlobStore(service.S3Config{
+		Endpoint:        server.URL,
+		Bucket:          fakeS3Bucket,
+		Prefix:          "blobs/",
+		AccessKeyID:     fakeS3AccessKey,
+		SecretAccessKey: "test-secret-key",
+	})
+	require.NoError(t, err)
+	return fake, blobs
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.Contains(r.Header.Get("Authorization"), "Credential="+fakeS3AccessKey+"/") {
+		writeS3Error(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeS3Error(w, http.StatusBadRequest, "IncompleteBody")
+		return
+	}
+	if f.corruptUploads && len(body) > 0 && r.Method == http.MethodPut {
+		body[0] ^= 0x01
+	}
+	digest := sha256.Sum256(body)
+	if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(digest[:]) {
+		writeS3Error(w, http.StatusBadRequest, "XAmzContentSHA256Mismatch")
+		return
+	}
+	if checksum := r.Header.Get("X-Amz-Checksum-Sha256"); checksum != "" && checksum != base64.StdEncoding.EncodeToString(digest[:]) {
+		writeS3Error(w, http.StatusBadRequest, "BadDigest")
+		return
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != fakeS3Bucket {
+		writeS3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	query := r.URL.Query()
+
+	switch {
+	case key == "" && r.Method == http.MethodHead:
+		// HeadBucket
+	case r.Method == http.MethodHead || r.Method == http.MethodGet:
+		object, exists := f.objects[key]
+		if !exists {
+			writeS3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(object)))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(object)
+		}
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.nextID++
+		uploadID := fmt.Sprint(f.nextID)
+		f.uploads[uploadID] = make(map[int][]byte)
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", uploadID)
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		parts, exists := f.uploads[query.Get("uploadId")]
+		if !exists {
+			writeS3Error(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		var number int
+		fmt.Sscan(query.Get("partNumber"), &number)
+		parts[number] = body
+		w.Header().Set("ETag", fmt.Sprintf("%q", hex.EncodeToString(digest[:16])))
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		parts, exists := f.uploads[query.Get("uploadId")]
+		if !exists {
+			writeS3Error(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		var complete struct {
+			Parts []struct {
+				PartNumber     int
+				ChecksumSHA256 string
+			} `xml:"Part"`
+		}
+		if err := xml.Unmarshal(body, &complete); err != nil || len(complete.Parts) != len(parts) {
+			writeS3Error(w, http.StatusBadRequest, "InvalidPart")
+			return
+		}
+		var object []byte
+		for i, part := range complete.Parts {
+			data := parts[part.PartNumber]
+			partDigest := sha256.Sum256(data)
+			if part.PartNumber != i+1 || part.ChecksumSHA256 != base64.StdEncoding.EncodeToString(partDigest[:]) {
+				writeS3Error(w, http.StatusBadRequest, "InvalidPart")
+				return
+			}
+			if i < len(complete.Parts)-1 && len(data) < 5<<20 {
+				writeS3Error(w, http.StatusBadRequest, "EntityTooSmall")
+				return
+			}
+			object = append(object, data...)
+		}
+		f.objects[key] = object
+		delete(f.uploads, query.Get("uploadId"))
+		f.completed++
+		fmt.Fprint(w, "<CompleteMultipartUploadResult></CompleteMultipartUploadResult>")
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(f.uploads, query.Get("uploadId"))
+		f.aborted++
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		f.objects[key] = body
+	default:
+		writeS3Error(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+// writeS3Error writes an S3 error response.
+func writeS3Error(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+func TestS3BlobStore_Multipart(t *testing.T) {
+	fake, blobs := newFakeS3(t)
+
+	// A full-sized gene file is uploaded in five parts
+	data := make([]byte, 40<<20)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	id, err := blobs.Put(data)
+	require.NoError(t, err)
+	require.Equal(t, 1, fake.completed)
+	require.Empty(t, fake.uploads)
+
+	stored, err := blobs.Get(id)
+	require.NoError(t, err)
+	require.True(t, bytes.Equal(data, stored))
+
+	// Storing it again does not upload it again
+	_, err = blobs.Put(data)
+	require.NoError(t, err)
+	require.Equal(t, 1, fake.completed)
+}
+
+func TestS3BlobStore_ChecksumMismatch(t *testing.T) {
+	fake, blobs := newFakeS3(t)
+	fake.corruptUploads = true
+
+	// Bodies corrupted in transit are rejected by the server
+	_, err := blobs.Put([]byte("encrypted_gene_data"))
+	require.Error(t, err)
+	require.Empty(t, fake.objects)
+
+	// Failed multipart uploads are aborted
+	_, err = blobs.Put(make([]byte, service.DefaultS3PartSize+1))
+	var s3Err *service.S3Error
+	require.ErrorAs(t, err, &s3Err)
+	require.Equal(t, http.StatusBadRequest, s3Err.StatusCode)
+	require.Equal(t, 1, fake.aborted)
+	require.Empty(t, fake.uploads)
+	require.Empty(t, fake.objects)
+}
+
+func TestS3BlobStore_Corruption(t *testing.T) {
+	fake, blobs := newFakeS3(t)
+
+	id, err := blobs.Put([]byte("encrypted_gene_data"))
+	require.NoError(t, err)
+
+	// Flip a bit in the stored object
+	fake.objects["blobs/"+id][0] ^= 0x01
+	_, err = blobs.Get(id)
+	require.ErrorIs(t, err, service.ErrBlobCorrupted)
+}
+
+func TestNewS3BlobStore_Invalid(t *testing.T) {
+	server := httptest.NewServer(&fakeS3{})
+	t.Cleanup(server.Close)
+
+	configs := []service.S3Config{
+		{Endpoint: server.URL, AccessKeyID: fakeS3AccessKey},
+		{Endpoint: "ftp://localhost", Bucket: fakeS3Bucket, AccessKeyID: fakeS3AccessKey},
+		{Endpoint: server.URL, Bucket: fakeS3Bucket, AccessKeyID: fakeS3AccessKey, PartSize: 1 << 20},
+		{Endpoint: server.URL, Bucket: "missing", AccessKeyID: fakeS3AccessKey},
+		{Endpoint: server.URL, Bucket: fakeS3Bucket, AccessKeyID: "wrong"},
+	}
+	for _, config := range configs {
+		_, err := service.NewS3BlobStore(config)
+		require.Error(t, err)
+	}
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWS Signature Version 4 constants for S3.
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat = "20060102T150405Z"
+	sigV4DateFormat = "20060102"
+	sigV4Service    = "s3"
+)
+
+// signV4 signs an S3 request with AWS Signature Version 4. payloadHash is the hex-encoded
+// sha256 of the request body, which the server verifies against the body it receives.
+func signV4(req *http.Request, accessKeyID, secretAccessKey, region, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format(sigV4TimeFormat)
+	date := now.UTC().Format(sigV4DateFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// Sign the host and every x-amz-* and content-* header
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-amz-") || strings.HasPrefix(name, "content-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + sigV4Service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		amzDate,
+		scope,
+		hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := sigV4SigningKey(secretAccessKey, date, region, sigV4Service)
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", sigV4Algorithm+
+		" Credential="+accessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+
+		", Signature="+signature)
+}
+
+// sigV4SigningKey derives the Signature Version 4 signing key for the date, region and service.
+func sigV4SigningKey(secretAccessKey, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+// canonicalQuery encodes the query parameters sorted by name, as required by Signature Version 4.
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(query))
+	for _, name := range names {
+		values := query[name]
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes every byte except the unreserved characters, and also except '/'
+// unless encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0x0f])
+		}
+	}
+	return b.String()
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data under key.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// hexSHA256 returns the hex-encoded sha256 of data.
+func hexSHA256(data []byte) string {
+	digest := sha256.Sum256(data)
+	return hex.EncodeToString(digest[:])
+}
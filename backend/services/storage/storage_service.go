@@ -1,92 +1,200 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/hex"
 	"errors"
-	"sync"
+	"io"
 
 	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/attestation"
 )
 
 // GeneData represents the structure to hold gene data and associated information.
 type GeneData struct {
 	FileID        string
 	UserID        uint64
-	DataHash      []byte // Hash of the encrypted gene data.
+	DataHash      []byte // Keccak256 hash of the encrypted gene data.
 	Signature     []byte // Digital signature of the gene data.
-	EncryptedData []byte // The encrypted gene data.
+	BlobID        string // Content address of the encrypted gene data in the BlobStore.
+	ContentID     string // IPFS CID of the encrypted gene data, anchored on-chain by the Controller contract.
+	EncryptedData []byte // The encrypted gene data, as a tee.Envelope. Kept in the BlobStore, not in the GeneDataStore.
+
+	RiskAssessment *RiskAssessment       // Set once a risk score has been confirmed on-chain.
+	Receipts       []attestation.Receipt // TEE computation receipts for the gene data, oldest first.
+
+	WrappedKeys []WrappedKey // Data key wrapped to each recipient, for gene data encrypted with a per-file data key.
+
+	ConsentGrants []ConsentGrant // Grants issued by the owner to read the gene data.
+}
+
+// RiskAssessment records the risk score sent to the Controller contract for the gene data and
+// the risk model that produced it.
+type RiskAssessment struct {
+	RiskScore    uint8
+	Model        string
+	ModelVersion string
 }
 
-// GeneDataStorageService manages the storage of encrypted gene data using an in-memory map to simulate a NoSQL database.
+// Errors returned by GeneDataStorageService.
+var (
+	ErrHashMismatch    = errors.New("hash does not match encrypted data")
+	ErrReceiptMismatch = errors.New("receipt is not for this gene data")
+)
+
+// GeneDataStorageService manages the storage of encrypted gene data. Metadata is kept in a
+// GeneDataStore and the encrypted data itself in a content-addressed BlobStore.
 type GeneDataStorageService struct {
-	dataStore map[string]GeneData
-	mu        sync.Mutex
+	store GeneDataStore
+	blobs BlobStore
 }
 
-// NewGeneDataStorageService creates a new instance of GeneDataStorageService.
+// NewGeneDataStorageService creates a new instance of GeneDataStorageService backed by in-memory stores.
 func NewGeneDataStorageService() *GeneDataStorageService {
+	return NewGeneDataStorageServiceWithStore(NewMemoryStore(), NewMemoryBlobStore())
+}
+
+// NewGeneDataStorageServiceWithStore creates a new instance of GeneDataStorageService backed by the given stores.
+func NewGeneDataStorageServiceWithStore(store GeneDataStore, blobs BlobStore) *GeneDataStorageService {
 	return &GeneDataStorageService{
-		dataStore: make(map[string]GeneData),
+		store: store,
+		blobs: blobs,
 	}
 }
 
+// Close closes the underlying store.
+func (s *GeneDataStorageService) Close() error {
+	return s.store.Close()
+}
+
 // StoreGeneData stores the encrypted gene data, its hash, and the associated signature.
+// The hash is computed from the encrypted data and must match the one supplied by the client,
+// since that is the hash the signature covers. The file ID is the hex-encoded hash.
 func (s *GeneDataStorageService) StoreGeneData(
 	userID uint64,
 	encryptedData []byte,
 	signatureBytes []byte,
 	hashBytes []byte,
+) (string, error) {
+	return s.storeGeneData(userID, encryptedData, signatureBytes, hashBytes, nil)
+}
+
+// storeGeneData stores the encrypted gene data and its metadata, see StoreGeneDataWithKeys.
+func (s *GeneDataStorageService) storeGeneData(
+	userID uint64,
+	encryptedData []byte,
+	signatureBytes []byte,
+	hashBytes []byte,
+	wrappedKeys []WrappedKey,
 ) (string, error) {
 	// Check the signature length (should be 65 bytes, including the recovery ID)
 	if len(signatureBytes) != crypto.SignatureLength {
 		return "", errors.New("invalid signature length")
 	}
 
-	dataHash := hashBytes
-
-	// Use part of the hash as a unique file identifier
-	fileID := hex.EncodeToString(dataHash[:16])
-
-	// Store the gene data in the in-memory map
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	// Never trust the client hash, compute it from the data
+	dataHash := crypto.Keccak256(encryptedData)
+	if !bytes.Equal(dataHash, hashBytes) {
+		return "", ErrHashMismatch
+	}
 
-	if _, exists := s.dataStore[fileID]; exists {
-		return "", errors.New("gene data with the same hash already exists")
+	// Use the full hash as a unique file identifier
+	fileID := hex.EncodeToString(dataHash)
+	if _, err := s.store.Get(fileID); err == nil {
+		return "", ErrGeneDataExists
 	}
 
-	s.dataStore[fileID] = GeneData{
-		FileID:        fileID,
-		UserID:        userID,
-		DataHash:      dataHash[:],
-		Signature:     signatureBytes,
-		EncryptedData: encryptedData,
+	// Store the encrypted data by content, then the metadata referencing it
+	blobID, err := s.blobs.Put(encryptedData)
+	if err != nil {
+		return "", err
+	}
+	err = s.store.Insert(GeneData{
+		FileID:      fileID,
+		UserID:      userID,
+		DataHash:    dataHash,
+		Signature:   signatureBytes,
+		BlobID:      blobID,
+		ContentID:   ContentID(encryptedData),
+		WrappedKeys: wrappedKeys,
+	})
+	if err != nil {
+		return "", err
 	}
 
 	return fileID, nil
 }
 
-// RetrieveGeneData retrieves the original encrypted gene data based on the file ID.
-func (s *GeneDataStorageService) RetrieveGeneData(fileID string) ([]byte, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	data, exists := s.dataStore[fileID]
-	if !exists {
-		return nil, errors.New("gene data not found")
+// RetrieveGeneData retrieves the original encrypted gene data based on the file ID. Only the
+// owner and grantees with an active consent grant for the accessor's purpose can retrieve it.
+func (s *GeneDataStorageService) RetrieveGeneData(fileID string, accessor Accessor) ([]byte, error) {
+	data, err := s.AccessGeneData(fileID, accessor)
+	if err != nil {
+		return nil, err
 	}
 
 	return data.EncryptedData, nil
 }
 
+// GetGeneData retrieves the full gene data record, including its hash and signature, based on the file ID.
+func (s *GeneDataStorageService) GetGeneData(fileID string) (GeneData, error) {
+	data, err := s.store.Get(fileID)
+	if err != nil {
+		return GeneData{}, err
+	}
+
+	// Records written before the blob layer keep the encrypted data inline
+	if data.BlobID != "" {
+		// The blob store verifies the content against its address on every read
+		data.EncryptedData, err = s.blobs.Get(data.BlobID)
+		if err != nil {
+			return GeneData{}, err
+		}
+	}
+
+	// Records written before content IDs were introduced do not have one
+	if data.ContentID == "" {
+		data.ContentID = ContentID(data.EncryptedData)
+	}
+	return data, nil
+}
+
+// ExportCAR writes the encrypted gene data to w as a CARv1 archive and returns its IPFS CID,
+// which is the CID anchored on-chain for the gene data.
+func (s *GeneDataStorageService) ExportCAR(fileID string, w io.Writer) (string, error) {
+	data, err := s.GetGeneData(fileID)
+	if err != nil {
+		return "", err
+	}
+	return WriteCAR(w, data.EncryptedData)
+}
+
+// RecordRiskAssessment stores the risk score confirmed on-chain for the gene data together with
+// the model name and version that produced it.
+func (s *GeneDataStorageService) RecordRiskAssessment(fileID string, assessment RiskAssessment) error {
+	return s.store.Update(fileID, func(data *GeneData) {
+		data.RiskAssessment = &assessment
+	})
+}
+
+// RecordReceipt stores a TEE computation receipt for the gene data, so auditors can later check
+// which data each risk score was computed from. The receipt's signature is not checked here;
+// callers verify it with an attestation.Verifier for the TEE they trust.
+func (s *GeneDataStorageService) RecordReceipt(fileID string, receipt attestation.Receipt) error {
+	if receipt.FileID != fileID {
+		return ErrReceiptMismatch
+	}
+	return s.store.Update(fileID, func(data *GeneData) {
+		data.Receipts = append(data.Receipts, receipt)
+	})
+}
+
 // VerifyGeneDataSignature verifies the digital signature of the stored gene data.
 func (s *GeneDataStorageService) VerifyGeneDataSignature(fileID string, publicKeyBytes []byte) (bool, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	data, exists := s.dataStore[fileID]
-	if !exists {
-		return false, errors.New("gene data not found")
+	data, err := s.store.Get(fileID)
+	if err != nil {
+		return false, err
 	}
 
 	// Extract the signature without the recovery ID
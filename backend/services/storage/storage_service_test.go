@@ -1,101 +1,297 @@
 package storage_test
 
 import (
+	"bytes"
 	"crypto/ecdsa"
+	"encoding/hex"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/require"
 
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/attestation"
 	service "github.com/trungnt1811/blockchain-engineer-interview/backend/services/storage"
 )
 
+// forEachStore runs the test against every GeneDataStore and BlobStore implementation.
+func forEachStore(t *testing.T, test func(t *testing.T, storageService *service.GeneDataStorageService)) {
+	stores := []struct {
+		name     string
+		newStore func(t *testing.T) (service.GeneDataStore, service.BlobStore)
+	}{
+		{"memory", func(t *testing.T) (service.GeneDataStore, service.BlobStore) {
+			return service.NewMemoryStore(), service.NewMemoryBlobStore()
+		}},
+		{"bolt", func(t *testing.T) (service.GeneDataStore, service.BlobStore) {
+			dir := t.TempDir()
+			store, err := service.NewBoltStore(filepath.Join(dir, "gene_data.db"))
+			require.NoError(t, err)
+			blobs, err := service.NewDiskBlobStore(filepath.Join(dir, "blobs"))
+			require.NoError(t, err)
+			return store, blobs
+		}},
+		{"bolt+s3", func(t *testing.T) (service.GeneDataStore, service.BlobStore) {
+			store, err := service.NewBoltStore(filepath.Join(t.TempDir(), "gene_data.db"))
+			require.NoError(t, err)
+			_, blobs := newFakeS3(t)
+			return store, blobs
+		}},
+	}
+
+	for _, store := range stores {
+		t.Run(store.name, func(t *testing.T) {
+			storageService := service.NewGeneDataStorageServiceWithStore(store.newStore(t))
+			t.Cleanup(func() { require.NoError(t, storageService.Close()) })
+			test(t, storageService)
+		})
+	}
+}
+
 func TestStoreGeneData(t *testing.T) {
-	service := service.NewGeneDataStorageService()
-
-	privateKey, err := crypto.GenerateKey()
-	require.NoError(t, err)
-
-	// Create a test user ID and gene data
-	userID := uint64(1)
-	encryptedData := []byte("encrypted_gene_data")
-	hashData := crypto.Keccak256Hash(encryptedData).Bytes()
-	signature, err := crypto.Sign(crypto.Keccak256Hash(encryptedData).Bytes(), privateKey)
-	require.NoError(t, err)
-
-	// Test storing the gene data
-	fileID, err := service.StoreGeneData(userID, encryptedData, signature, hashData)
-	require.NoError(t, err)
-	require.NotEmpty(t, fileID)
-
-	// Attempt to store the same data again, should return an error
-	_, err = service.StoreGeneData(userID, encryptedData, signature, hashData)
-	require.Error(t, err)
-	require.Equal(t, "gene data with the same hash already exists", err.Error())
+	forEachStore(t, func(t *testing.T, service *service.GeneDataStorageService) {
+		privateKey, err := crypto.GenerateKey()
+		require.NoError(t, err)
+
+		// Create a test user ID and gene data
+		userID := uint64(1)
+		encryptedData := []byte("encrypted_gene_data")
+		hashData := crypto.Keccak256Hash(encryptedData).Bytes()
+		signature, err := crypto.Sign(crypto.Keccak256Hash(encryptedData).Bytes(), privateKey)
+		require.NoError(t, err)
+
+		// Test storing the gene data
+		fileID, err := service.StoreGeneData(userID, encryptedData, signature, hashData)
+		require.NoError(t, err)
+		require.NotEmpty(t, fileID)
+
+		// Attempt to store the same data again, should return an error
+		_, err = service.StoreGeneData(userID, encryptedData, signature, hashData)
+		require.Error(t, err)
+		require.Equal(t, "gene data with the same hash already exists", err.Error())
+
+		// The file ID is the full hash of the encrypted data
+		require.Equal(t, hex.EncodeToString(hashData), fileID)
+	})
+}
+
+func TestStoreGeneData_HashMismatch(t *testing.T) {
+	forEachStore(t, func(t *testing.T, service *service.GeneDataStorageService) {
+		privateKey, err := crypto.GenerateKey()
+		require.NoError(t, err)
+
+		encryptedData := []byte("encrypted_gene_data")
+		signature, err := crypto.Sign(crypto.Keccak256(encryptedData), privateKey)
+		require.NoError(t, err)
+
+		// The client hash must be the keccak256 hash of the encrypted data
+		for _, hashData := range [][]byte{
+			crypto.Keccak256([]byte("other_gene_data")),
+			crypto.Keccak256(encryptedData)[:16],
+			nil,
+		} {
+			_, err = service.StoreGeneData(uint64(1), encryptedData, signature, hashData)
+			require.Equal(t, "hash does not match encrypted data", err.Error())
+		}
+	})
 }
 
 func TestRetrieveGeneData(t *testing.T) {
-	service := service.NewGeneDataStorageService()
-
-	privateKey, err := crypto.GenerateKey()
-	require.NoError(t, err)
-
-	// Create and store test gene data
-	userID := uint64(1)
-	encryptedData := []byte("encrypted_gene_data")
-	hashData := crypto.Keccak256Hash(encryptedData).Bytes()
-	signature, err := crypto.Sign(crypto.Keccak256Hash(encryptedData).Bytes(), privateKey)
-	require.NoError(t, err)
-
-	fileID, err := service.StoreGeneData(userID, encryptedData, signature, hashData)
-	require.NoError(t, err)
-
-	// Retrieve the gene data by file ID
-	retrievedData, err := service.RetrieveGeneData(fileID)
-	require.NoError(t, err)
-	require.Equal(t, encryptedData, retrievedData)
-
-	// Attempt to retrieve data with an invalid file ID
-	_, err = service.RetrieveGeneData("invalid_file_id")
-	require.Error(t, err)
-	require.Equal(t, "gene data not found", err.Error())
+	forEachStore(t, func(t *testing.T, storageService *service.GeneDataStorageService) {
+		privateKey, err := crypto.GenerateKey()
+		require.NoError(t, err)
+
+		// Create and store test gene data
+		userID := uint64(1)
+		encryptedData := []byte("encrypted_gene_data")
+		hashData := crypto.Keccak256Hash(encryptedData).Bytes()
+		signature, err := crypto.Sign(crypto.Keccak256Hash(encryptedData).Bytes(), privateKey)
+		require.NoError(t, err)
+
+		fileID, err := storageService.StoreGeneData(userID, encryptedData, signature, hashData)
+		require.NoError(t, err)
+
+		// Retrieve the gene data by file ID as its owner
+		retrievedData, err := storageService.RetrieveGeneData(fileID, service.Accessor{UserID: userID})
+		require.NoError(t, err)
+		require.Equal(t, encryptedData, retrievedData)
+
+		// Attempt to retrieve data with an invalid file ID
+		_, err = storageService.RetrieveGeneData("invalid_file_id", service.Accessor{UserID: userID})
+		require.Error(t, err)
+		require.Equal(t, "gene data not found", err.Error())
+	})
 }
 
 func TestVerifyGeneDataSignature(t *testing.T) {
-	service := service.NewGeneDataStorageService()
-
-	// Generate new key pair
-	privateKey, err := crypto.GenerateKey()
-	require.NoError(t, err)
-	publicKeyBytes := crypto.FromECDSAPub(&privateKey.PublicKey)
-
-	// Create and store test gene data
-	userID := uint64(1)
-	encryptedData := []byte("encrypted_gene_data")
-	hashData := crypto.Keccak256Hash(encryptedData).Bytes()
-	signature, err := crypto.Sign(hashData, privateKey)
-	require.NoError(t, err)
-
-	fileID, err := service.StoreGeneData(userID, encryptedData, signature, hashData)
-	require.NoError(t, err)
-
-	// Verify the signature
-	isValid, err := service.VerifyGeneDataSignature(fileID, publicKeyBytes)
-	require.NoError(t, err)
-	require.True(t, isValid)
-
-	// Verify the signature with an incorrect public key
-	anotherPrivateKey, err := crypto.GenerateKey()
-	require.NoError(t, err)
-	anotherPublicKey := anotherPrivateKey.Public().(*ecdsa.PublicKey)
-
-	isValid, err = service.VerifyGeneDataSignature(fileID, crypto.CompressPubkey(anotherPublicKey))
-	require.NoError(t, err)
-	require.False(t, isValid)
-
-	// Attempt to verify signature with an invalid file ID
-	isValid, err = service.VerifyGeneDataSignature("invalid_file_id", publicKeyBytes)
-	require.Error(t, err)
-	require.Equal(t, "gene data not found", err.Error())
-	require.False(t, isValid)
+	forEachStore(t, func(t *testing.T, service *service.GeneDataStorageService) {
+		// Generate new key pair
+		privateKey, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		publicKeyBytes := crypto.FromECDSAPub(&privateKey.PublicKey)
+
+		// Create and store test gene data
+		userID := uint64(1)
+		encryptedData := []byte("encrypted_gene_data")
+		hashData := crypto.Keccak256Hash(encryptedData).Bytes()
+		signature, err := crypto.Sign(hashData, privateKey)
+		require.NoError(t, err)
+
+		fileID, err := service.StoreGeneData(userID, encryptedData, signature, hashData)
+		require.NoError(t, err)
+
+		// Verify the signature
+		isValid, err := service.VerifyGeneDataSignature(fileID, publicKeyBytes)
+		require.NoError(t, err)
+		require.True(t, isValid)
+
+		// Verify the signature with an incorrect public key
+		anotherPrivateKey, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		anotherPublicKey := anotherPrivateKey.Public().(*ecdsa.PublicKey)
+
+		isValid, err = service.VerifyGeneDataSignature(fileID, crypto.CompressPubkey(anotherPublicKey))
+		require.NoError(t, err)
+		require.False(t, isValid)
+
+		// Attempt to verify signature with an invalid file ID
+		isValid, err = service.VerifyGeneDataSignature("invalid_file_id", publicKeyBytes)
+		require.Error(t, err)
+		require.Equal(t, "gene data not found", err.Error())
+		require.False(t, isValid)
+	})
+}
+
+func TestGetGeneData(t *testing.T) {
+	forEachStore(t, func(t *testing.T, service *service.GeneDataStorageService) {
+		privateKey, err := crypto.GenerateKey()
+		require.NoError(t, err)
+
+		// Create and store test gene data
+		userID := uint64(1)
+		encryptedData := []byte("encrypted_gene_data")
+		hashData := crypto.Keccak256Hash(encryptedData).Bytes()
+		signature, err := crypto.Sign(hashData, privateKey)
+		require.NoError(t, err)
+
+		fileID, err := service.StoreGeneData(userID, encryptedData, signature, hashData)
+		require.NoError(t, err)
+
+		// Retrieve the full record by file ID
+		geneData, err := service.GetGeneData(fileID)
+		require.NoError(t, err)
+		require.Equal(t, fileID, geneData.FileID)
+		require.Equal(t, userID, geneData.UserID)
+		require.Equal(t, hashData, geneData.DataHash)
+		require.Equal(t, signature, geneData.Signature)
+		require.Equal(t, encryptedData, geneData.EncryptedData)
+
+		// Attempt to retrieve a record with an invalid file ID
+		_, err = service.GetGeneData("invalid_file_id")
+		require.Error(t, err)
+		require.Equal(t, "gene data not found", err.Error())
+	})
+}
+
+func TestRecordRiskAssessment(t *testing.T) {
+	forEachStore(t, func(t *testing.T, storageService *service.GeneDataStorageService) {
+		privateKey, err := crypto.GenerateKey()
+		require.NoError(t, err)
+
+		// Create and store test gene data
+		encryptedData := []byte("encrypted_gene_data")
+		hashData := crypto.Keccak256Hash(encryptedData).Bytes()
+		signature, err := crypto.Sign(hashData, privateKey)
+		require.NoError(t, err)
+
+		fileID, err := storageService.StoreGeneData(uint64(1), encryptedData, signature, hashData)
+		require.NoError(t, err)
+
+		geneData, err := storageService.GetGeneData(fileID)
+		require.NoError(t, err)
+		require.Nil(t, geneData.RiskAssessment)
+
+		// Record the risk score confirmed on-chain
+		assessment := service.RiskAssessment{RiskScore: 2, Model: "g-stroke", ModelVersion: "1.0.0"}
+		require.NoError(t, storageService.RecordRiskAssessment(fileID, assessment))
+
+		geneData, err = storageService.GetGeneData(fileID)
+		require.NoError(t, err)
+		require.Equal(t, &assessment, geneData.RiskAssessment)
+
+		// Attempt to record an assessment for an invalid file ID
+		err = storageService.RecordRiskAssessment("invalid_file_id", assessment)
+		require.Error(t, err)
+		require.Equal(t, "gene data not found", err.Error())
+	})
+}
+
+func TestRecordReceipt(t *testing.T) {
+	forEachStore(t, func(t *testing.T, storageService *service.GeneDataStorageService) {
+		privateKey, err := crypto.GenerateKey()
+		require.NoError(t, err)
+
+		encryptedData := []byte("encrypted_gene_data")
+		hashData := crypto.Keccak256Hash(encryptedData).Bytes()
+		signature, err := crypto.Sign(hashData, privateKey)
+		require.NoError(t, err)
+		fileID, err := storageService.StoreGeneData(uint64(1), encryptedData, signature, hashData)
+		require.NoError(t, err)
+
+		receipt := attestation.Receipt{
+			FileID:       fileID,
+			DataHash:     crypto.Keccak256Hash([]byte("low risk")),
+			RiskLevel:    4,
+			Model:        "g-stroke",
+			ModelVersion: "1.0.0",
+			Timestamp:    time.Unix(1_700_000_000, 0).UTC(),
+			Signature:    bytes.Repeat([]byte{1}, crypto.SignatureLength),
+		}
+		require.NoError(t, storageService.RecordReceipt(fileID, receipt))
+
+		geneData, err := storageService.GetGeneData(fileID)
+		require.NoError(t, err)
+		require.Equal(t, []attestation.Receipt{receipt}, geneData.Receipts)
+
+		// A receipt is only recorded for the file it was issued for
+		other := receipt
+		other.FileID = "invalid_file_id"
+		require.ErrorIs(t, storageService.RecordReceipt(fileID, other), service.ErrReceiptMismatch)
+		require.ErrorIs(t, storageService.RecordReceipt("invalid_file_id", other), service.ErrGeneDataNotFound)
+	})
+}
+
+func TestExportCAR(t *testing.T) {
+	forEachStore(t, func(t *testing.T, storageService *service.GeneDataStorageService) {
+		privateKey, err := crypto.GenerateKey()
+		require.NoError(t, err)
+
+		// Create and store test gene data spanning several IPFS blocks
+		encryptedData := seededData(1<<20+17, 7)
+		hashData := crypto.Keccak256Hash(encryptedData).Bytes()
+		signature, err := crypto.Sign(hashData, privateKey)
+		require.NoError(t, err)
+
+		fileID, err := storageService.StoreGeneData(uint64(1), encryptedData, signature, hashData)
+		require.NoError(t, err)
+
+		geneData, err := storageService.GetGeneData(fileID)
+		require.NoError(t, err)
+		require.Equal(t, service.ContentID(encryptedData), geneData.ContentID)
+
+		// The exported archive verifies against the content ID alone
+		var car bytes.Buffer
+		cid, err := storageService.ExportCAR(fileID, &car)
+		require.NoError(t, err)
+		require.Equal(t, geneData.ContentID, cid)
+
+		exported, err := service.ReadCAR(&car, geneData.ContentID)
+		require.NoError(t, err)
+		require.True(t, bytes.Equal(encryptedData, exported))
+
+		// Attempt to export an invalid file ID
+		_, err = storageService.ExportCAR("invalid_file_id", &car)
+		require.ErrorIs(t, err, service.ErrGeneDataNotFound)
+	})
 }
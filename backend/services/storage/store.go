@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"errors"
+	"slices"
+	"sync"
+)
+
+// Errors returned by GeneDataStore implementations.
+var (
+	ErrGeneDataExists   = errors.New("gene data with the same hash already exists")
+	ErrGeneDataNotFound = errors.New("gene data not found")
+)
+
+// GeneDataStore persists gene data records keyed by file ID.
+type GeneDataStore interface {
+	// Insert stores a new record. It returns ErrGeneDataExists if the file ID is taken.
+	Insert(data GeneData) error
+	// Get returns the record with the given file ID or ErrGeneDataNotFound.
+	Get(fileID string) (GeneData, error)
+	// Update atomically applies fn to the record with the given file ID and stores the result.
+	// It returns ErrGeneDataNotFound if there is no such record.
+	Update(fileID string, fn func(data *GeneData)) error
+	// UpdateAudited is like Update, but also appends the audit events fn returns to the audit log
+	// of the record in the same transaction.
+	UpdateAudited(fileID string, fn func(data *GeneData) []AuditEvent) error
+	// AppendAuditEvents appends events to the audit log of the record with the given file ID
+	// without rewriting the record. It returns ErrGeneDataNotFound if there is no such record.
+	AppendAuditEvents(fileID string, events ...AuditEvent) error
+	// AuditEvents returns the audit log of the record with the given file ID, oldest first.
+	AuditEvents(fileID string) ([]AuditEvent, error)
+	// Close releases the resources held by the store.
+	Close() error
+}
+
+// MemoryStore is a GeneDataStore that keeps records in an in-memory map. Its contents are lost
+// when the process exits.
+type MemoryStore struct {
+	dataStore map[string]GeneData
+	auditLog  map[string][]AuditEvent
+	mu        sync.Mutex
+}
+
+// NewMemoryStore creates a new, empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		dataStore: make(map[string]GeneData),
+		auditLog:  make(map[string][]AuditEvent),
+	}
+}
+
+// Insert implements GeneDataStore.
+func (s *MemoryStore) Insert(data GeneData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.dataStore[data.FileID]; exists {
+		return ErrGeneDataExists
+	}
+	s.dataStore[data.FileID] = data.clone()
+	return nil
+}
+
+// Get implements GeneDataStore.
+func (s *MemoryStore) Get(fileID string) (GeneData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, exists := s.dataStore[fileID]
+	if !exists {
+		return GeneData{}, ErrGeneDataNotFound
+	}
+	return data.clone(), nil
+}
+
+// Update implements GeneDataStore.
+func (s *MemoryStore) Update(fileID string, fn func(data *GeneData)) error {
+	return s.UpdateAudited(fileID, func(data *GeneData) []AuditEvent {
+		fn(data)
+		return nil
+	})
+}
+
+// UpdateAudited implements GeneDataStore.
+func (s *MemoryStore) UpdateAudited(fileID string, fn func(data *GeneData) []AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, exists := s.dataStore[fileID]
+	if !exists {
+		return ErrGeneDataNotFound
+	}
+	// fn updates the slices of the record in place, which records returned by Get must not see
+	data = data.clone()
+	events := fn(&data)
+	s.dataStore[fileID] = data
+	s.auditLog[fileID] = append(s.auditLog[fileID], events...)
+	return nil
+}
+
+// AppendAuditEvents implements GeneDataStore.
+func (s *MemoryStore) AppendAuditEvents(fileID string, events ...AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.dataStore[fileID]; !exists {
+		return ErrGeneDataNotFound
+	}
+	s.auditLog[fileID] = append(s.auditLog[fileID], events...)
+	return nil
+}
+
+// AuditEvents implements GeneDataStore.
+func (s *MemoryStore) AuditEvents(fileID string) ([]AuditEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.dataStore[fileID]; !exists {
+		return nil, ErrGeneDataNotFound
+	}
+	return slices.Clone(s.auditLog[fileID]), nil
+}
+
+// clone returns a copy of the record that does not share the slices it updates in place with it.
+func (data GeneData) clone() GeneData {
+	data.ConsentGrants = slices.Clone(data.ConsentGrants)
+	data.WrappedKeys = slices.Clone(data.WrappedKeys)
+	data.Receipts = slices.Clone(data.Receipts)
+	return data
+}
+
+// Close implements GeneDataStore.
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Errors returned when managing wrapped data keys.
+var (
+	ErrInvalidWrappedKey  = errors.New("invalid wrapped key")
+	ErrWrappedKeyNotFound = errors.New("wrapped key not found")
+)
+
+// WrappedKey is the data encryption key of gene data sealed to one recipient. Gene data encrypted
+// with tee.EncryptGeneDataWithDataKey can only be decrypted with one of its wrapped keys, so
+// recipients are added or removed by changing the wrapped keys without re-encrypting the data.
+type WrappedKey struct {
+	Recipient common.Address
+	Key       []byte // The data key sealed to the recipient's public key, as a tee.Envelope.
+}
+
+// Audited changes to the wrapped keys.
+const (
+	AuditAddRecipient    AuditAction = "add_recipient"
+	AuditRemoveRecipient AuditAction = "remove_recipient"
+)
+
+// StoreGeneDataWithKeys is like StoreGeneData for gene data encrypted with a per-file data key,
+// and also stores the data key wrapped to its initial recipients, usually just the owner.
+func (s *GeneDataStorageService) StoreGeneDataWithKeys(
+	userID uint64,
+	encryptedData []byte,
+	signatureBytes []byte,
+	hashBytes []byte,
+	wrappedKeys []WrappedKey,
+) (string, error) {
+	for i, key := range wrappedKeys {
+		if err := validateWrappedKey(key); err != nil {
+			return "", err
+		}
+		for _, other := range wrappedKeys[:i] {
+			if other.Recipient == key.Recipient {
+				return "", ErrInvalidWrappedKey
+			}
+		}
+	}
+	return s.storeGeneData(userID, encryptedData, signatureBytes, hashBytes, wrappedKeys)
+}
+
+// SetWrappedKey adds a recipient of the gene data, or replaces the recipient's wrapped key if
+// there already is one. Only the owner can change the recipients.
+func (s *GeneDataStorageService) SetWrappedKey(fileID string, key WrappedKey, accessor Accessor) error {
+	if err := validateWrappedKey(key); err != nil {
+		return err
+	}
+	now := time.Now()
+
+	var err error
+	updateErr := s.store.UpdateAudited(fileID, func(data *GeneData) []AuditEvent {
+		if data.UserID != accessor.UserID {
+			err = ErrAccessDenied
+			return nil
+		}
+		data.WrappedKeys = append(removeWrappedKey(data.WrappedKeys, key.Recipient), key)
+		return []AuditEvent{{
+			Time:    now,
+			Action:  AuditAddRecipient,
+			UserID:  accessor.UserID,
+			Address: key.Recipient,
+		}}
+	})
+	if updateErr != nil {
+		return updateErr
+	}
+	return err
+}
+
+// AddGranteeWrappedKey adds the wrapped key of a grantee, or replaces their wrapped key if there
+// already is one, on the strength of their grant rather than on behalf of the owner. The grant is
+// checked again when the key is stored, so a grant revoked in the meantime does not add it.
+func (s *GeneDataStorageService) AddGranteeWrappedKey(fileID, grantID string, key WrappedKey) error {
+	if err := validateWrappedKey(key); err != nil {
+		return err
+	}
+	now := time.Now()
+
+	var err error
+	updateErr := s.store.UpdateAudited(fileID, func(data *GeneData) []AuditEvent {
+		var grant ConsentGrant
+		grant, err = findActiveGrant(data, grantID, key.Recipient, now)
+		if err != nil {
+			return nil
+		}
+		data.WrappedKeys = append(removeWrappedKey(data.WrappedKeys, key.Recipient), key)
+		return []AuditEvent{{
+			Time:    now,
+			Action:  AuditAddRecipient,
+			Address: key.Recipient,
+			GrantID: grant.ID,
+			Purpose: grant.Purpose,
+		}}
+	})
+	if updateErr != nil {
+		return updateErr
+	}
+	return err
+}
+
+// RemoveWrappedKey removes a recipient of the gene data. Only the owner can change the
+// recipients. A recipient who has already unwrapped the data key can still decrypt copies of the
+// data they obtained; re-encrypt the data under a new data key to rule that out.
+func (s *GeneDataStorageService) RemoveWrappedKey(fileID string, recipient common.Address, accessor Accessor) error {
+	now := time.Now()
+
+	var err error
+	updateErr := s.store.UpdateAudited(fileID, func(data *GeneData) []AuditEvent {
+		if data.UserID != accessor.UserID {
+			err = ErrAccessDenied
+			return nil
+		}
+		remaining := removeWrappedKey(data.WrappedKeys, recipient)
+		if len(remaining) == len(data.WrappedKeys) {
+			err = ErrWrappedKeyNotFound
+			return nil
+		}
+		data.WrappedKeys = remaining
+		return []AuditEvent{{
+			Time:    now,
+			Action:  AuditRemoveRecipient,
+			UserID:  accessor.UserID,
+			Address: recipient,
+		}}
+	})
+	if updateErr != nil {
+		return updateErr
+	}
+	return err
+}
+
+// validateWrappedKey checks that the wrapped key has a recipient and a key.
+func validateWrappedKey(key WrappedKey) error {
+	if key.Recipient == (common.Address{}) || len(key.Key) == 0 {
+		return ErrInvalidWrappedKey
+	}
+	return nil
+}
+
+// removeWrappedKey returns the wrapped keys without the one of the recipient.
+func removeWrappedKey(keys []WrappedKey, recipient common.Address) []WrappedKey {
+	remaining := make([]WrappedKey, 0, len(keys))
+	for _, key := range keys {
+		if key.Recipient != recipient {
+			remaining = append(remaining, key)
+		}
+	}
+	return remaining
+}
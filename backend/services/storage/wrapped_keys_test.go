@@ -0,0 +1,133 @@
+package storage_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	service "github.com/trungnt1811/blockchain-engineer-interview/backend/services/storage"
+)
+
+func TestWrappedKeys(t *testing.T) {
+	forEachStore(t, func(t *testing.T, storageService *service.GeneDataStorageService) {
+		owner, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		ownerAccessor := service.Accessor{UserID: 1, Address: crypto.PubkeyToAddress(owner.PublicKey)}
+		clinician := common.HexToAddress("0x00000000000000000000000000000000000000c1")
+		researcher := service.Accessor{UserID: 2, Address: common.HexToAddress("0x00000000000000000000000000000000000000e5")}
+
+		encryptedData := []byte("encrypted_gene_data")
+		hashData := crypto.Keccak256(encryptedData)
+		signature, err := crypto.Sign(hashData, owner)
+		require.NoError(t, err)
+
+		// Keys are validated before anything is stored
+		ownerKey := service.WrappedKey{Recipient: ownerAccessor.Address, Key: []byte("owner")}
+		_, err = storageService.StoreGeneDataWithKeys(1, encryptedData, signature, hashData, []service.WrappedKey{ownerKey, ownerKey})
+		require.ErrorIs(t, err, service.ErrInvalidWrappedKey)
+		_, err = storageService.StoreGeneDataWithKeys(1, encryptedData, signature, hashData, []service.WrappedKey{{Recipient: clinician}})
+		require.ErrorIs(t, err, service.ErrInvalidWrappedKey)
+
+		fileID, err := storageService.StoreGeneDataWithKeys(1, encryptedData, signature, hashData, []service.WrappedKey{ownerKey})
+		require.NoError(t, err)
+
+		// Only the owner adds, replaces and removes recipients
+		clinicianKey := service.WrappedKey{Recipient: clinician, Key: []byte("clinician")}
+		require.ErrorIs(t, storageService.SetWrappedKey(fileID, clinicianKey, researcher), service.ErrAccessDenied)
+		require.NoError(t, storageService.SetWrappedKey(fileID, clinicianKey, ownerAccessor))
+		clinicianKey.Key = []byte("clinician, rewrapped")
+		require.NoError(t, storageService.SetWrappedKey(fileID, clinicianKey, ownerAccessor))
+
+		data, err := storageService.GetGeneData(fileID)
+		require.NoError(t, err)
+		require.Equal(t, []service.WrappedKey{ownerKey, clinicianKey}, data.WrappedKeys)
+
+		require.ErrorIs(t, storageService.RemoveWrappedKey(fileID, clinician, researcher), service.ErrAccessDenied)
+		require.NoError(t, storageService.RemoveWrappedKey(fileID, clinician, ownerAccessor))
+		require.ErrorIs(t, storageService.RemoveWrappedKey(fileID, clinician, ownerAccessor), service.ErrWrappedKeyNotFound)
+
+		data, err = storageService.GetGeneData(fileID)
+		require.NoError(t, err)
+		require.Equal(t, []service.WrappedKey{ownerKey}, data.WrappedKeys)
+
+		// Revoking the last active grant of a grantee removes their wrapped key
+		grant, err := storageService.GrantConsent(signGrant(t, service.ConsentGrant{
+			FileID:    fileID,
+			Grantee:   researcher.Address,
+			Purpose:   "stroke research",
+			ExpiresAt: time.Now().Add(time.Hour),
+			Nonce:     "1",
+		}, owner), crypto.FromECDSAPub(&owner.PublicKey))
+		require.NoError(t, err)
+		require.NoError(t, storageService.SetWrappedKey(fileID, service.WrappedKey{Recipient: researcher.Address, Key: []byte("researcher")}, ownerAccessor))
+		require.NoError(t, storageService.RevokeConsent(fileID, grant.ID, ownerAccessor))
+
+		data, err = storageService.GetGeneData(fileID)
+		require.NoError(t, err)
+		require.Equal(t, []service.WrappedKey{ownerKey}, data.WrappedKeys)
+
+		trail, err := storageService.AuditTrail(fileID, ownerAccessor)
+		require.NoError(t, err)
+		actions := make([]service.AuditAction, 0, len(trail))
+		for _, event := range trail {
+			actions = append(actions, event.Action)
+		}
+		require.Equal(t, []service.AuditAction{
+			service.AuditAddRecipient, service.AuditAddRecipient, service.AuditRemoveRecipient,
+			service.AuditGrant, service.AuditAddRecipient, service.AuditRevoke, service.AuditRemoveRecipient,
+		}, actions)
+	})
+}
+
+func TestWrappedKeys_Grantee(t *testing.T) {
+	forEachStore(t, func(t *testing.T, storageService *service.GeneDataStorageService) {
+		owner, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		ownerAccessor := service.Accessor{UserID: 1, Address: crypto.PubkeyToAddress(owner.PublicKey)}
+		researcher := service.Accessor{UserID: 2, Address: common.HexToAddress("0x00000000000000000000000000000000000000e5")}
+		other := common.HexToAddress("0x00000000000000000000000000000000000000f0")
+		fileID := storeOwnedGeneData(t, storageService, ownerAccessor.UserID, owner)
+
+		grant, err := storageService.GrantConsent(signGrant(t, service.ConsentGrant{
+			FileID:    fileID,
+			Grantee:   researcher.Address,
+			Purpose:   "stroke research",
+			ExpiresAt: time.Now().Add(time.Hour),
+			Nonce:     "1",
+		}, owner), crypto.FromECDSAPub(&owner.PublicKey))
+		require.NoError(t, err)
+
+		// The grant only belongs to its grantee
+		active, err := storageService.ActiveGrant(fileID, grant.ID, researcher)
+		require.NoError(t, err)
+		require.Equal(t, grant.ID, active.ID)
+		_, err = storageService.ActiveGrant(fileID, grant.ID, service.Accessor{Address: other})
+		require.ErrorIs(t, err, service.ErrAccessDenied)
+		_, err = storageService.ActiveGrant(fileID, "unknown", researcher)
+		require.ErrorIs(t, err, service.ErrConsentNotFound)
+
+		// A grantee's key is only added for the grantee of the grant
+		researcherKey := service.WrappedKey{Recipient: researcher.Address, Key: []byte("researcher")}
+		require.ErrorIs(t, storageService.AddGranteeWrappedKey(fileID, grant.ID, service.WrappedKey{Recipient: other, Key: []byte("other")}), service.ErrAccessDenied)
+		require.NoError(t, storageService.AddGranteeWrappedKey(fileID, grant.ID, researcherKey))
+
+		data, err := storageService.GetGeneData(fileID)
+		require.NoError(t, err)
+		require.Equal(t, []service.WrappedKey{researcherKey}, data.WrappedKeys)
+
+		// Nor once the grant is revoked
+		require.NoError(t, storageService.RevokeConsent(fileID, grant.ID, ownerAccessor))
+		_, err = storageService.ActiveGrant(fileID, grant.ID, researcher)
+		require.ErrorIs(t, err, service.ErrConsentInactive)
+		require.ErrorIs(t, storageService.AddGranteeWrappedKey(fileID, grant.ID, researcherKey), service.ErrConsentInactive)
+
+		trail, err := storageService.AuditTrail(fileID, ownerAccessor)
+		require.NoError(t, err)
+		require.Len(t, trail, 4)
+		require.Equal(t, service.AuditAddRecipient, trail[1].Action)
+		require.Equal(t, grant.ID, trail[1].GrantID)
+	})
+}
@@ -0,0 +1,102 @@
+package tee
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/attestation"
+)
+
+// enclaveIdentity identifies the simulated enclave build. A hardware TEE would measure the
+// enclave code and configuration instead.
+const enclaveIdentity = "genomic-system/tee/simulated/1"
+
+// Measurement returns the measurement of the enclave the service runs in, which quotes report and
+// verifiers must trust.
+func (s *TEEService) Measurement() common.Hash {
+	return crypto.Keccak256Hash([]byte(enclaveIdentity))
+}
+
+// AttestationKey returns the address of the key that signs the service's quotes.
+func (s *TEEService) AttestationKey() common.Address {
+	return crypto.PubkeyToAddress(s.attestationKey.PublicKey)
+}
+
+// Attest produces a quote that the enclave computed the risk score result, as returned by
+// ScoreGeneData, with the verifier-chosen nonce. The nonce should be unique per verification,
+// e.g. the Controller's confirm nonce of the gene data being confirmed.
+//
+// The attestation key is local to the process, so the quote only simulates remote attestation:
+// it proves which model and input produced the score to anyone trusting this service's key, but
+// not that the service runs on TEE hardware.
+func (s *TEEService) Attest(result Result, nonce common.Hash) (*attestation.Quote, error) {
+	quote := &attestation.Quote{
+		Version:     attestation.QuoteVersion,
+		Measurement: s.Measurement(),
+		Claims: attestation.Claims{
+			Model:        result.Model,
+			ModelVersion: result.ModelVersion,
+			InputHash:    result.InputHash,
+			OutputHash:   attestation.RiskScoreHash(result.RiskLevel.Score()),
+			Nonce:        nonce,
+		},
+	}
+
+	digest, err := quote.Digest()
+	if err != nil {
+		return nil, err
+	}
+	quote.Signature, err = crypto.Sign(digest.Bytes(), s.attestationKey)
+	if err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
+
+// ScoreEncryptedGeneData decrypts gene data encrypted with EncryptGeneDataWithDataKey inside the
+// TEE, scores it with the requested model (or the default model if name and version are empty) and
+// returns the result together with a receipt signed by the attestation key. The data key is
+// unwrapped with the enclave key, so the owner must have wrapped it to EnclavePublicKey. The
+// receipt ties the risk level to the file ID of the encrypted data, so auditors can check that the
+// score came from that file.
+func (s *TEEService) ScoreEncryptedGeneData(
+	ctx context.Context,
+	encryptedData []byte,
+	wrappedKeys []WrappedKey,
+	name, version string,
+) (Result, *attestation.Receipt, error) {
+	var plaintext bytes.Buffer
+	// Do not leave the gene data in memory longer than needed
+	defer func() { clear(plaintext.Bytes()) }()
+	if err := s.DecryptGeneDataWithDataKey(&plaintext, bytes.NewReader(encryptedData), s.enclaveKey, wrappedKeys); err != nil {
+		return Result{}, nil, err
+	}
+
+	result, err := s.ScoreGeneData(ctx, name, version, &plaintext)
+	if err != nil {
+		return Result{}, nil, err
+	}
+
+	receipt := &attestation.Receipt{
+		FileID:       hex.EncodeToString(crypto.Keccak256(encryptedData)),
+		DataHash:     result.InputHash,
+		RiskLevel:    result.RiskLevel.Score(),
+		Model:        result.Model,
+		ModelVersion: result.ModelVersion,
+		Timestamp:    time.Now().UTC().Truncate(time.Second),
+	}
+	digest, err := receipt.Digest()
+	if err != nil {
+		return Result{}, nil, err
+	}
+	receipt.Signature, err = crypto.Sign(digest.Bytes(), s.attestationKey)
+	if err != nil {
+		return Result{}, nil, err
+	}
+	return result, receipt, nil
+}
@@ -0,0 +1,77 @@
+package tee_test
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/attestation"
+	service "github.com/trungnt1811/blockchain-engineer-interview/backend/services/tee"
+)
+
+func TestAttest(t *testing.T) {
+	attestationKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	teeService := service.NewTEEServiceWithAttestationKey(attestationKey)
+	require.Equal(t, crypto.PubkeyToAddress(attestationKey.PublicKey), teeService.AttestationKey())
+
+	result, err := teeService.ScoreGeneData(context.Background(), "", "", strings.NewReader("slightly high risk"))
+	require.NoError(t, err)
+	nonce := common.BigToHash(big.NewInt(42))
+	quote, err := teeService.Attest(result, nonce)
+	require.NoError(t, err)
+
+	// The quote attests to the model, input, output and nonce
+	claims := attestation.Claims{
+		Model:        service.GStrokeModelName,
+		ModelVersion: service.GStrokeModelVersion,
+		InputHash:    crypto.Keccak256Hash([]byte("slightly high risk")),
+		OutputHash:   attestation.RiskScoreHash(service.RiskLevelSlightlyHigh.Score()),
+		Nonce:        nonce,
+	}
+	verifier := attestation.NewVerifier(teeService.AttestationKey(), teeService.Measurement())
+	require.NoError(t, verifier.Verify(quote, claims))
+
+	// Quotes of another TEE instance are not trusted
+	other := service.NewTEEService()
+	require.Equal(t, teeService.Measurement(), other.Measurement())
+	otherQuote, err := other.Attest(result, nonce)
+	require.NoError(t, err)
+	require.ErrorIs(t, verifier.Verify(otherQuote, claims), attestation.ErrUntrustedSigner)
+}
+
+func TestScoreEncryptedGeneData(t *testing.T) {
+	teeService := service.NewTEEService()
+	verifier := attestation.NewVerifier(teeService.AttestationKey(), teeService.Measurement())
+
+	owner, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	encryptedData, wrappedKeys := encryptForTEE(t, teeService, []byte("extremely high risk"), nil, owner)
+
+	result, receipt, err := teeService.ScoreEncryptedGeneData(context.Background(), encryptedData, wrappedKeys, "", "")
+	require.NoError(t, err)
+	require.Equal(t, service.RiskLevelExtremelyHigh, result.RiskLevel)
+
+	// The receipt ties the risk level to the file and the data it was computed from
+	require.NoError(t, verifier.VerifyReceipt(receipt))
+	require.Equal(t, hex.EncodeToString(crypto.Keccak256(encryptedData)), receipt.FileID)
+	require.Equal(t, crypto.Keccak256Hash([]byte("extremely high risk")), receipt.DataHash)
+	require.Equal(t, service.RiskLevelExtremelyHigh.Score(), receipt.RiskLevel)
+	require.Equal(t, service.GStrokeModelName, receipt.Model)
+	require.Equal(t, service.GStrokeModelVersion, receipt.ModelVersion)
+	require.WithinDuration(t, time.Now(), receipt.Timestamp, 2*time.Second)
+
+	// The TEE only scores data whose key is wrapped to its enclave key
+	ownerOnly, ownerWrappedKeys := encryptWithDataKey(t, teeService, []byte("extremely high risk"), nil, owner)
+	_, _, err = teeService.ScoreEncryptedGeneData(context.Background(), ownerOnly, ownerWrappedKeys, "", "")
+	require.ErrorIs(t, err, service.ErrNoWrappedKey)
+	_, _, err = service.NewTEEService().ScoreEncryptedGeneData(context.Background(), encryptedData, wrappedKeys, "", "")
+	require.ErrorIs(t, err, service.ErrNoWrappedKey)
+}
@@ -0,0 +1,183 @@
+package tee
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Errors returned when encrypting or decrypting gene data with a data key.
+var (
+	ErrNotDataKeyEnvelope = errors.New("envelope is not encrypted with a data key")
+	ErrNoWrappedKey       = errors.New("no data key is wrapped to this key")
+	ErrInvalidWrappedKey  = errors.New("wrapped key does not belong to this gene data")
+)
+
+// WrappedKey is the data key of gene data encrypted with EncryptGeneDataWithDataKey, sealed to
+// one recipient.
+type WrappedKey struct {
+	Recipient common.Address
+	Key       []byte // The data key sealed to the recipient's public key, as an Envelope.
+}
+
+// EncryptGeneDataWithDataKey reads gene data from src and writes it to dst as a streaming envelope
+// sealed under a random data key, and returns the data key wrapped to each recipient's public key.
+// The first recipient is usually the owner. The optional AAD is stored in clear text and
+// authenticated like in EncryptGeneDataWithAAD.
+//
+// Each wrapped key is a one-shot envelope of the data key whose AAD is the header of the data
+// envelope, so it cannot be used with other gene data. Recipients can be added later with
+// WrapDataKey without re-encrypting the data.
+func (s *TEEService) EncryptGeneDataWithDataKey(dst io.Writer, src io.Reader, recipientPublicKeys [][]byte, aad []byte) ([]WrappedKey, error) {
+	if len(recipientPublicKeys) == 0 {
+		return nil, errors.New("at least one recipient is required")
+	}
+
+	dataKey := make([]byte, aesKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+	defer clear(dataKey)
+	noncePrefix := make([]byte, streamNoncePrefixSize)
+	if _, err := io.ReadFull(rand.Reader, noncePrefix); err != nil {
+		return nil, err
+	}
+
+	env := &Envelope{
+		Version:   CurrentEnvelopeVersion,
+		Algorithm: AlgorithmAES256GCMStreamDataKey,
+		KDFParams: KDFParams{KDF: KDFNone},
+		Nonce:     noncePrefix,
+		AAD:       aad,
+	}
+	header, err := env.Header()
+	if err != nil {
+		return nil, err
+	}
+
+	// Wrap the data key before writing anything, so an invalid recipient leaves dst untouched
+	wrappedKeys := make([]WrappedKey, 0, len(recipientPublicKeys))
+	for _, publicKey := range recipientPublicKeys {
+		wrappedKey, err := wrapDataKey(dataKey, header, publicKey)
+		if err != nil {
+			return nil, err
+		}
+		wrappedKeys = append(wrappedKeys, wrappedKey)
+	}
+
+	if _, err := dst.Write(header); err != nil {
+		return nil, err
+	}
+	if err := sealSegments(dst, src, dataKey, noncePrefix, header); err != nil {
+		return nil, err
+	}
+	return wrappedKeys, nil
+}
+
+// DecryptGeneDataWithDataKey reads gene data encrypted by EncryptGeneDataWithDataKey from src,
+// unwraps the data key with the recipient's private key and writes the decrypted gene data to
+// dst. As with DecryptGeneDataStream, dst may hold part of the data if an error is returned.
+func (s *TEEService) DecryptGeneDataWithDataKey(dst io.Writer, src io.Reader, privateKey *ecdsa.PrivateKey, wrappedKeys []WrappedKey) error {
+	env, err := readEnvelopeHeader(src)
+	if err != nil {
+		return err
+	}
+	header, err := dataKeyHeader(env)
+	if err != nil {
+		return err
+	}
+
+	dataKey, err := unwrapDataKey(privateKey, header, wrappedKeys)
+	if err != nil {
+		return err
+	}
+	defer clear(dataKey)
+	return openSegments(dst, src, dataKey, env.Nonce, header)
+}
+
+// WrapDataKey unwraps the data key of the encrypted gene data with the private key of one of
+// its recipients and wraps it to a new recipient's public key. Only the envelope header of the
+// encrypted data is read, so adding a recipient costs the same whatever the size of the data.
+func (s *TEEService) WrapDataKey(
+	privateKey *ecdsa.PrivateKey,
+	encryptedData []byte,
+	wrappedKeys []WrappedKey,
+	recipientPublicKey []byte,
+) (WrappedKey, error) {
+	env, err := readEnvelopeHeader(bytes.NewReader(encryptedData))
+	if err != nil {
+		return WrappedKey{}, err
+	}
+	header, err := dataKeyHeader(env)
+	if err != nil {
+		return WrappedKey{}, err
+	}
+
+	dataKey, err := unwrapDataKey(privateKey, header, wrappedKeys)
+	if err != nil {
+		return WrappedKey{}, err
+	}
+	defer clear(dataKey)
+	return wrapDataKey(dataKey, header, recipientPublicKey)
+}
+
+// dataKeyHeader returns the header of a data key envelope.
+func dataKeyHeader(env *Envelope) ([]byte, error) {
+	if env.Algorithm != AlgorithmAES256GCMStreamDataKey || env.KDFParams.KDF != KDFNone {
+		return nil, ErrNotDataKeyEnvelope
+	}
+	return env.Header()
+}
+
+// wrapDataKey seals the data key to the recipient's public key, bound to the data envelope header.
+func wrapDataKey(dataKey, header, recipientPublicKey []byte) (WrappedKey, error) {
+	recipientPubkey, err := crypto.UnmarshalPubkey(recipientPublicKey)
+	if err != nil {
+		return WrappedKey{}, errors.New("invalid public key")
+	}
+
+	env, err := sealEnvelope(recipientPublicKey, dataKey, header)
+	if err != nil {
+		return WrappedKey{}, err
+	}
+	key, err := env.MarshalBinary()
+	if err != nil {
+		return WrappedKey{}, err
+	}
+	return WrappedKey{Recipient: crypto.PubkeyToAddress(*recipientPubkey), Key: key}, nil
+}
+
+// unwrapDataKey finds the wrapped key of the private key's address and opens it.
+func unwrapDataKey(privateKey *ecdsa.PrivateKey, header []byte, wrappedKeys []WrappedKey) ([]byte, error) {
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+	for _, wrappedKey := range wrappedKeys {
+		if wrappedKey.Recipient != address {
+			continue
+		}
+
+		env, err := ParseEnvelope(wrappedKey.Key)
+		if err != nil {
+			return nil, err
+		}
+		// A key wrapped for other gene data must not be accepted
+		if env.Version == EnvelopeVersionLegacy || env.Algorithm != AlgorithmECIESSecp256k1AES256GCM ||
+			!bytes.Equal(env.AAD, header) {
+			return nil, ErrInvalidWrappedKey
+		}
+
+		dataKey, err := openEnvelope(privateKey, env)
+		if err != nil {
+			return nil, err
+		}
+		if len(dataKey) != aesKeySize {
+			return nil, ErrInvalidWrappedKey
+		}
+		return dataKey, nil
+	}
+	return nil, ErrNoWrappedKey
+}
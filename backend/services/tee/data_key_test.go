@@ -0,0 +1,164 @@
+package tee_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	service "github.com/trungnt1811/blockchain-engineer-interview/backend/services/tee"
+)
+
+// encryptWithDataKey encrypts data with EncryptGeneDataWithDataKey to the recipients.
+func encryptWithDataKey(t *testing.T, teeService *service.TEEService, data, aad []byte, recipients ...*ecdsa.PrivateKey) ([]byte, []service.WrappedKey) {
+	publicKeys := make([][]byte, 0, len(recipients))
+	for _, recipient := range recipients {
+		publicKeys = append(publicKeys, crypto.FromECDSAPub(&recipient.PublicKey))
+	}
+
+	var encrypted bytes.Buffer
+	wrappedKeys, err := teeService.EncryptGeneDataWithDataKey(&encrypted, bytes.NewReader(data), publicKeys, aad)
+	require.NoError(t, err)
+	return encrypted.Bytes(), wrappedKeys
+}
+
+// encryptForTEE encrypts data with EncryptGeneDataWithDataKey to the owner and the TEE's enclave key.
+func encryptForTEE(t *testing.T, teeService *service.TEEService, data, aad []byte, owner *ecdsa.PrivateKey) ([]byte, []service.WrappedKey) {
+	var encrypted bytes.Buffer
+	publicKeys := [][]byte{crypto.FromECDSAPub(&owner.PublicKey), teeService.EnclavePublicKey()}
+	wrappedKeys, err := teeService.EncryptGeneDataWithDataKey(&encrypted, bytes.NewReader(data), publicKeys, aad)
+	require.NoError(t, err)
+	return encrypted.Bytes(), wrappedKeys
+}
+
+// decryptWithDataKey decrypts data encrypted with EncryptGeneDataWithDataKey.
+func decryptWithDataKey(teeService *service.TEEService, encrypted []byte, privateKey *ecdsa.PrivateKey, wrappedKeys []service.WrappedKey) ([]byte, error) {
+	var decrypted bytes.Buffer
+	err := teeService.DecryptGeneDataWithDataKey(&decrypted, bytes.NewReader(encrypted), privateKey, wrappedKeys)
+	return decrypted.Bytes(), err
+}
+
+func TestEncryptGeneDataWithDataKey(t *testing.T) {
+	teeService := service.NewTEEService()
+
+	owner, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	clinician, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	data := make([]byte, 3*segmentSize+7)
+	_, err = rand.Read(data)
+	require.NoError(t, err)
+
+	encrypted, wrappedKeys := encryptWithDataKey(t, teeService, data, []byte("file-1"), owner, clinician)
+	require.Len(t, wrappedKeys, 2)
+	require.Equal(t, crypto.PubkeyToAddress(owner.PublicKey), wrappedKeys[0].Recipient)
+	require.Equal(t, crypto.PubkeyToAddress(clinician.PublicKey), wrappedKeys[1].Recipient)
+
+	env, err := service.ParseEnvelope(encrypted)
+	require.NoError(t, err)
+	require.Equal(t, service.AlgorithmAES256GCMStreamDataKey, env.Algorithm)
+	require.Equal(t, []byte("file-1"), env.AAD)
+	require.Empty(t, env.EphemeralPubkey)
+
+	// Every recipient can decrypt, anyone else cannot
+	for _, recipient := range []*ecdsa.PrivateKey{owner, clinician} {
+		decrypted, err := decryptWithDataKey(teeService, encrypted, recipient, wrappedKeys)
+		require.NoError(t, err)
+		require.Equal(t, data, decrypted)
+	}
+	_, err = decryptWithDataKey(teeService, encrypted, other, wrappedKeys)
+	require.ErrorIs(t, err, service.ErrNoWrappedKey)
+
+	// The data key is not usable through the single-recipient API
+	_, err = teeService.DecryptGeneData(owner, encrypted)
+	require.Error(t, err)
+
+	// Tampering with the data is detected
+	tampered := bytes.Clone(encrypted)
+	tampered[len(tampered)-1] ^= 0x01
+	_, err = decryptWithDataKey(teeService, tampered, owner, wrappedKeys)
+	require.Error(t, err)
+
+	_, err = teeService.EncryptGeneDataWithDataKey(&bytes.Buffer{}, bytes.NewReader(data), nil, nil)
+	require.Error(t, err)
+}
+
+func TestWrapDataKey(t *testing.T) {
+	teeService := service.NewTEEService()
+
+	owner, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	researcher, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	data := bytes.Repeat([]byte("ACGT"), segmentSize)
+	encrypted, wrappedKeys := encryptWithDataKey(t, teeService, data, nil, owner)
+
+	// Adding a recipient only needs the envelope header
+	env, err := service.ParseEnvelope(encrypted)
+	require.NoError(t, err)
+	header := encrypted[:len(encrypted)-len(env.Ciphertext)]
+	wrappedKey, err := teeService.WrapDataKey(owner, header, wrappedKeys, crypto.FromECDSAPub(&researcher.PublicKey))
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(researcher.PublicKey), wrappedKey.Recipient)
+
+	wrappedKeys = append(wrappedKeys, wrappedKey)
+	decrypted, err := decryptWithDataKey(teeService, encrypted, researcher, wrappedKeys)
+	require.NoError(t, err)
+	require.Equal(t, data, decrypted)
+
+	// Removing a recipient only removes their wrapped key
+	_, err = decryptWithDataKey(teeService, encrypted, researcher, wrappedKeys[:1])
+	require.ErrorIs(t, err, service.ErrNoWrappedKey)
+
+	// Only recipients can add recipients
+	_, err = teeService.WrapDataKey(other, encrypted, wrappedKeys, crypto.FromECDSAPub(&other.PublicKey))
+	require.ErrorIs(t, err, service.ErrNoWrappedKey)
+
+	// A key wrapped for other gene data is rejected
+	otherEncrypted, otherWrappedKeys := encryptWithDataKey(t, teeService, data, nil, owner)
+	_, err = decryptWithDataKey(teeService, encrypted, owner, otherWrappedKeys)
+	require.ErrorIs(t, err, service.ErrInvalidWrappedKey)
+	_, err = teeService.WrapDataKey(owner, otherEncrypted, wrappedKeys, crypto.FromECDSAPub(&researcher.PublicKey))
+	require.ErrorIs(t, err, service.ErrInvalidWrappedKey)
+
+	// Envelopes sealed directly to a public key have no data key
+	oneShot, err := teeService.EncryptGeneData(crypto.FromECDSAPub(&owner.PublicKey), "low risk")
+	require.NoError(t, err)
+	_, err = teeService.WrapDataKey(owner, oneShot, wrappedKeys, crypto.FromECDSAPub(&researcher.PublicKey))
+	require.ErrorIs(t, err, service.ErrNotDataKeyEnvelope)
+}
+
+func TestShareDataKey(t *testing.T) {
+	teeService := service.NewTEEService()
+
+	owner, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	grantee, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	granteePublicKey := crypto.FromECDSAPub(&grantee.PublicKey)
+
+	data := []byte("high risk")
+	encrypted, wrappedKeys := encryptForTEE(t, teeService, data, nil, owner)
+
+	ownerPublicKey := crypto.FromECDSAPub(&owner.PublicKey)
+	wrappedKey, err := teeService.ShareDataKey(encrypted, wrappedKeys, newGrant(t, owner, grantee, encrypted), ownerPublicKey, granteePublicKey)
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(grantee.PublicKey), wrappedKey.Recipient)
+	decrypted, err := decryptWithDataKey(teeService, encrypted, grantee, append(wrappedKeys, wrappedKey))
+	require.NoError(t, err)
+	require.Equal(t, data, decrypted)
+
+	// The data key must be wrapped to the TEE
+	ownerOnly, ownerWrappedKeys := encryptWithDataKey(t, teeService, data, nil, owner)
+	_, err = teeService.ShareDataKey(ownerOnly, ownerWrappedKeys, newGrant(t, owner, grantee, ownerOnly), ownerPublicKey, granteePublicKey)
+	require.ErrorIs(t, err, service.ErrNoWrappedKey)
+}
@@ -0,0 +1,271 @@
+package tee
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Envelope layout (version 1), all integers big-endian:
+//
+//	magic            4 bytes  "GENE"
+//	version          1 byte
+//	algorithm        1 byte
+//	kdf              1 byte
+//	kdf salt         2-byte length + bytes
+//	ephemeral pubkey 2-byte length + bytes
+//	nonce            1-byte length + bytes
+//	aad              4-byte length + bytes
+//	ciphertext       remaining bytes
+//
+// Everything before the ciphertext is the envelope header and is authenticated
+// by the AEAD, so none of the parameters can be swapped without detection.
+//
+// With AlgorithmECIESSecp256k1AES256GCMStream the nonce field holds the STREAM nonce
+// prefix and the ciphertext is a sequence of sealed segments (see EncryptGeneDataStream).
+//
+// With AlgorithmAES256GCMStreamDataKey the segments are sealed under a random data key instead
+// of an ECDH-derived key, so the KDF is KDFNone and the salt and ephemeral pubkey are empty. The
+// data key is wrapped to each recipient separately (see EncryptGeneDataWithDataKey).
+
+// EnvelopeVersion identifies the layout of an encrypted gene data blob.
+type EnvelopeVersion uint8
+
+const (
+	// EnvelopeVersionLegacy marks un-versioned data written before the envelope format was
+	// introduced. Its layout is given by a LegacyFormat (see ParseLegacyEnvelope).
+	EnvelopeVersionLegacy EnvelopeVersion = 0
+	// EnvelopeVersion1 is the first versioned envelope layout.
+	EnvelopeVersion1 EnvelopeVersion = 1
+
+	// CurrentEnvelopeVersion is the version written by EncryptGeneData.
+	CurrentEnvelopeVersion = EnvelopeVersion1
+)
+
+// LegacyFormat identifies the layout of un-versioned gene data. The layouts cannot be told apart
+// reliably from the data itself, so callers state which one they stored.
+type LegacyFormat uint8
+
+const (
+	// LegacyFormatBaseline is nonce || ciphertext sealed with AES-256-GCM under
+	// sha256(pubkey.X), as written by the original EncryptGeneData.
+	LegacyFormatBaseline LegacyFormat = 1
+	// LegacyFormatEphemeralECDH is ephemeralPubkey || nonce || ciphertext sealed with AES-256-GCM
+	// under an HKDF-SHA256 key derived from ephemeral ECDH, authenticating the ephemeral pubkey.
+	LegacyFormatEphemeralECDH LegacyFormat = 2
+)
+
+// Algorithm identifies the public-key encryption scheme used to seal the data.
+type Algorithm uint8
+
+const (
+	// AlgorithmECIESSecp256k1AES256GCM is ephemeral ECDH on secp256k1 followed by AES-256-GCM.
+	AlgorithmECIESSecp256k1AES256GCM Algorithm = 1
+	// AlgorithmECIESSecp256k1AES256GCMStream is like AlgorithmECIESSecp256k1AES256GCM, but the
+	// data is sealed in fixed-size segments so it can be encrypted and decrypted with bounded memory.
+	AlgorithmECIESSecp256k1AES256GCMStream Algorithm = 2
+	// AlgorithmAES256GCMStreamDataKey seals the data in segments like AlgorithmECIESSecp256k1AES256GCMStream,
+	// but under a random per-file data key that is wrapped to each recipient outside the envelope.
+	AlgorithmAES256GCMStreamDataKey Algorithm = 3
+
+	// AlgorithmBaselineAES256GCM is AES-256-GCM under a key derived from the recipient's public
+	// key alone, used by LegacyFormatBaseline. It never appears in envelope headers.
+	AlgorithmBaselineAES256GCM Algorithm = 0xff
+)
+
+// KDF identifies the key derivation function applied to the ECDH shared secret.
+type KDF uint8
+
+const (
+	// KDFNone is used by envelopes whose key is not derived from a shared secret.
+	KDFNone KDF = 0
+	// KDFHKDFSHA256 is HKDF (RFC 5869) instantiated with SHA-256.
+	KDFHKDFSHA256 KDF = 1
+
+	// KDFBaselineSHA256 is sha256 of the big-endian X coordinate of the recipient's public key,
+	// without leading zeros, used by LegacyFormatBaseline. It never appears in envelope headers.
+	KDFBaselineSHA256 KDF = 0xff
+)
+
+var envelopeMagic = []byte("GENE")
+
+// maxFieldPrealloc bounds the memory reserved up front for a length-prefixed header field.
+const maxFieldPrealloc = 4 << 10
+
+// Errors returned by ParseEnvelope.
+var (
+	ErrNotEnvelope        = errors.New("data is not a gene data envelope")
+	ErrMalformedEnvelope  = errors.New("malformed gene data envelope")
+	ErrUnsupportedVersion = errors.New("unsupported gene data envelope version")
+)
+
+// KDFParams describes how the symmetric key was derived from the shared secret.
+type KDFParams struct {
+	KDF  KDF
+	Salt []byte
+}
+
+// Envelope is the parsed form of an encrypted gene data blob.
+type Envelope struct {
+	Version         EnvelopeVersion
+	Algorithm       Algorithm
+	KDFParams       KDFParams
+	EphemeralPubkey []byte
+	Nonce           []byte
+	AAD             []byte // Caller-supplied associated data, authenticated but not encrypted.
+	Ciphertext      []byte
+}
+
+// Header returns the serialized envelope header, i.e. every field except the ciphertext.
+// It is the additional data authenticated by the AEAD for versioned envelopes.
+func (e *Envelope) Header() ([]byte, error) {
+	if e.Version == EnvelopeVersionLegacy {
+		return nil, errors.New("legacy envelopes have no header")
+	}
+	if len(e.KDFParams.Salt) > 0xffff || len(e.EphemeralPubkey) > 0xffff ||
+		len(e.Nonce) > 0xff || uint64(len(e.AAD)) > 0xffffffff {
+		return nil, errors.New("envelope field too large")
+	}
+
+	var buf bytes.Buffer
+	buf.Write(envelopeMagic)
+	buf.WriteByte(byte(e.Version))
+	buf.WriteByte(byte(e.Algorithm))
+	buf.WriteByte(byte(e.KDFParams.KDF))
+	_ = binary.Write(&buf, binary.BigEndian, uint16(len(e.KDFParams.Salt)))
+	buf.Write(e.KDFParams.Salt)
+	_ = binary.Write(&buf, binary.BigEndian, uint16(len(e.EphemeralPubkey)))
+	buf.Write(e.EphemeralPubkey)
+	buf.WriteByte(byte(len(e.Nonce)))
+	buf.Write(e.Nonce)
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(e.AAD)))
+	buf.Write(e.AAD)
+	return buf.Bytes(), nil
+}
+
+// MarshalBinary serializes the envelope. Legacy envelopes are serialized in their
+// original un-versioned layout.
+func (e *Envelope) MarshalBinary() ([]byte, error) {
+	if e.Version == EnvelopeVersionLegacy && e.Algorithm == AlgorithmBaselineAES256GCM {
+		return append(append([]byte{}, e.Nonce...), e.Ciphertext...), nil
+	}
+	if e.Version == EnvelopeVersionLegacy {
+		out := make([]byte, 0, len(e.EphemeralPubkey)+len(e.Nonce)+len(e.Ciphertext))
+		out = append(out, e.EphemeralPubkey...)
+		out = append(out, e.Nonce...)
+		return append(out, e.Ciphertext...), nil
+	}
+
+	header, err := e.Header()
+	if err != nil {
+		return nil, err
+	}
+	return append(header, e.Ciphertext...), nil
+}
+
+// IsEnvelope reports whether data starts with the versioned envelope magic.
+func IsEnvelope(data []byte) bool {
+	return bytes.HasPrefix(data, envelopeMagic)
+}
+
+// ParseEnvelope parses a versioned gene data envelope. Data without the envelope magic is
+// rejected with ErrNotEnvelope; un-versioned data is parsed with ParseLegacyEnvelope.
+func ParseEnvelope(data []byte) (*Envelope, error) {
+	r := bytes.NewReader(data)
+	env, err := readEnvelopeHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	env.Ciphertext = make([]byte, r.Len())
+	_, _ = r.Read(env.Ciphertext)
+	return env, nil
+}
+
+// readEnvelopeHeader reads a versioned envelope header from r, leaving r positioned at the
+// start of the ciphertext.
+func readEnvelopeHeader(r io.Reader) (*Envelope, error) {
+	magic := make([]byte, len(envelopeMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, envelopeMagic) {
+		return nil, ErrNotEnvelope
+	}
+
+	var fixed [3]byte
+	if _, err := io.ReadFull(r, fixed[:]); err != nil {
+		return nil, ErrMalformedEnvelope
+	}
+	env := &Envelope{
+		Version:   EnvelopeVersion(fixed[0]),
+		Algorithm: Algorithm(fixed[1]),
+		KDFParams: KDFParams{KDF: KDF(fixed[2])},
+	}
+	if env.Version != EnvelopeVersion1 {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, env.Version)
+	}
+
+	var err error
+	if env.KDFParams.Salt, err = readField(r, 2); err != nil {
+		return nil, err
+	}
+	if env.EphemeralPubkey, err = readField(r, 2); err != nil {
+		return nil, err
+	}
+	if env.Nonce, err = readField(r, 1); err != nil {
+		return nil, err
+	}
+	if env.AAD, err = readField(r, 4); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// ParseLegacyEnvelope splits un-versioned gene data stored in the given format into its fields
+// so that it can be decrypted and migrated.
+func ParseLegacyEnvelope(data []byte, format LegacyFormat) (*Envelope, error) {
+	switch format {
+	case LegacyFormatBaseline:
+		if len(data) < gcmNonceSize {
+			return nil, ErrMalformedEnvelope
+		}
+		return &Envelope{
+			Version:    EnvelopeVersionLegacy,
+			Algorithm:  AlgorithmBaselineAES256GCM,
+			KDFParams:  KDFParams{KDF: KDFBaselineSHA256},
+			Nonce:      data[:gcmNonceSize],
+			Ciphertext: data[gcmNonceSize:],
+		}, nil
+	case LegacyFormatEphemeralECDH:
+		if len(data) < ephemeralPubkeySize+gcmNonceSize {
+			return nil, ErrMalformedEnvelope
+		}
+	default:
+		return nil, fmt.Errorf("unknown legacy format: %d", format)
+	}
+
+	return &Envelope{
+		Version:         EnvelopeVersionLegacy,
+		Algorithm:       AlgorithmECIESSecp256k1AES256GCM,
+		KDFParams:       KDFParams{KDF: KDFHKDFSHA256},
+		EphemeralPubkey: data[:ephemeralPubkeySize],
+		Nonce:           data[ephemeralPubkeySize : ephemeralPubkeySize+gcmNonceSize],
+		Ciphertext:      data[ephemeralPubkeySize+gcmNonceSize:],
+	}, nil
+}
+
+// readField reads a length-prefixed field whose length is encoded in lenSize bytes.
+// The field is read incrementally so a corrupt length cannot trigger a huge allocation.
+func readField(r io.Reader, lenSize int) ([]byte, error) {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(r, lenBuf[4-lenSize:]); err != nil {
+		return nil, ErrMalformedEnvelope
+	}
+	length := int64(binary.BigEndian.Uint32(lenBuf[:]))
+
+	field := bytes.NewBuffer(make([]byte, 0, min(length, maxFieldPrealloc)))
+	if n, err := io.CopyN(field, r, length); err != nil || n != length {
+		return nil, ErrMalformedEnvelope
+	}
+	return field.Bytes(), nil
+}
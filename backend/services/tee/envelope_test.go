@@ -0,0 +1,211 @@
+package tee_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/hkdf"
+
+	service "github.com/trungnt1811/blockchain-engineer-interview/backend/services/tee"
+)
+
+func TestParseEnvelope(t *testing.T) {
+	teeService := service.NewTEEService()
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	publicKeyBytes := crypto.FromECDSAPub(&privateKey.PublicKey)
+
+	// Encrypt gene data bound to some associated data
+	aad := []byte("user:42")
+	encryptedData, err := teeService.EncryptGeneDataWithAAD(publicKeyBytes, "low risk", aad)
+	require.NoError(t, err)
+	require.True(t, service.IsEnvelope(encryptedData))
+
+	// Parse the envelope and check its parameters
+	env, err := service.ParseEnvelope(encryptedData)
+	require.NoError(t, err)
+	require.Equal(t, service.CurrentEnvelopeVersion, env.Version)
+	require.Equal(t, service.AlgorithmECIESSecp256k1AES256GCM, env.Algorithm)
+	require.Equal(t, service.KDFHKDFSHA256, env.KDFParams.KDF)
+	require.Len(t, env.KDFParams.Salt, 32)
+	require.Len(t, env.EphemeralPubkey, 65)
+	require.Len(t, env.Nonce, 12)
+	require.Equal(t, aad, env.AAD)
+	require.NotEmpty(t, env.Ciphertext)
+
+	// Re-serializing the parsed envelope yields the original bytes
+	marshaled, err := env.MarshalBinary()
+	require.NoError(t, err)
+	require.Equal(t, encryptedData, marshaled)
+
+	decrypted, err := teeService.DecryptGeneData(privateKey, encryptedData)
+	require.NoError(t, err)
+	require.Equal(t, "low risk", decrypted)
+}
+
+func TestParseEnvelope_Malformed(t *testing.T) {
+	// Data without the envelope magic, including legacy data
+	_, err := service.ParseEnvelope([]byte("not encrypted"))
+	require.ErrorIs(t, err, service.ErrNotEnvelope)
+	_, err = service.ParseEnvelope(encryptLegacy(t, crypto.FromECDSAPub(&testKey(t).PublicKey), "low risk"))
+	require.ErrorIs(t, err, service.ErrNotEnvelope)
+
+	// Legacy data too short for its format
+	_, err = service.ParseLegacyEnvelope(make([]byte, 11), service.LegacyFormatBaseline)
+	require.ErrorIs(t, err, service.ErrMalformedEnvelope)
+	_, err = service.ParseLegacyEnvelope(make([]byte, 76), service.LegacyFormatEphemeralECDH)
+	require.ErrorIs(t, err, service.ErrMalformedEnvelope)
+
+	// Unknown envelope version
+	_, err = service.ParseEnvelope([]byte{'G', 'E', 'N', 'E', 99, 1, 1})
+	require.ErrorIs(t, err, service.ErrUnsupportedVersion)
+
+	// Truncated header
+	_, err = service.ParseEnvelope([]byte{'G', 'E', 'N', 'E', 1, 1, 1, 0, 32, 1})
+	require.ErrorIs(t, err, service.ErrMalformedEnvelope)
+}
+
+func TestDecryptGeneData_TamperedHeader(t *testing.T) {
+	teeService := service.NewTEEService()
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	encryptedData, err := teeService.EncryptGeneDataWithAAD(crypto.FromECDSAPub(&privateKey.PublicKey), "high risk", []byte("user:1"))
+	require.NoError(t, err)
+
+	// Swapping the associated data must be detected
+	env, err := service.ParseEnvelope(encryptedData)
+	require.NoError(t, err)
+	env.AAD = []byte("user:2")
+	tampered, err := env.MarshalBinary()
+	require.NoError(t, err)
+
+	_, err = teeService.DecryptGeneData(privateKey, tampered)
+	require.Error(t, err)
+}
+
+func TestMigrateGeneData(t *testing.T) {
+	teeService := service.NewTEEService()
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	// Build a blob in the legacy ephemeralPubkey || nonce || ciphertext layout
+	legacyData := encryptLegacy(t, crypto.FromECDSAPub(&privateKey.PublicKey), "slightly high risk")
+
+	env, err := service.ParseLegacyEnvelope(legacyData, service.LegacyFormatEphemeralECDH)
+	require.NoError(t, err)
+	require.Equal(t, service.EnvelopeVersionLegacy, env.Version)
+
+	// Legacy data still decrypts
+	decrypted, err := teeService.DecryptLegacyGeneData(privateKey, legacyData, service.LegacyFormatEphemeralECDH)
+	require.NoError(t, err)
+	require.Equal(t, "slightly high risk", decrypted)
+
+	// Migrate to the current envelope version
+	migrated, err := teeService.MigrateGeneData(privateKey, legacyData, service.LegacyFormatEphemeralECDH)
+	require.NoError(t, err)
+	require.True(t, service.IsEnvelope(migrated))
+
+	decrypted, err = teeService.DecryptGeneData(privateKey, migrated)
+	require.NoError(t, err)
+	require.Equal(t, "slightly high risk", decrypted)
+
+	// Migrating current data is a no-op
+	again, err := teeService.MigrateGeneData(privateKey, migrated, service.LegacyFormatEphemeralECDH)
+	require.NoError(t, err)
+	require.Equal(t, migrated, again)
+}
+
+// baselineFixtures were encrypted to testKey by the original EncryptGeneData, which wrote
+// nonce || ciphertext under sha256(pubkey.X). The second nonce starts with 0x04.
+var baselineFixtures = []string{
+	"d4ce4c542e342b437569d7cb004730ccc82383bcf98397e602e720d21b645b618ec045d62fd843dac80279e623fa",
+	"04b9e032dd2460e1c3e5f7c9d7c896aa77499a584a069606b114482961808fe46b2c67b676310e7ff73fa184499a",
+}
+
+func TestMigrateGeneData_Baseline(t *testing.T) {
+	teeService := service.NewTEEService()
+	privateKey := testKey(t)
+
+	for _, fixture := range baselineFixtures {
+		baselineData, err := hex.DecodeString(fixture)
+		require.NoError(t, err)
+
+		// Baseline data decrypts when its format is stated
+		env, err := service.ParseLegacyEnvelope(baselineData, service.LegacyFormatBaseline)
+		require.NoError(t, err)
+		require.Equal(t, baselineData[:12], env.Nonce)
+		marshaled, err := env.MarshalBinary()
+		require.NoError(t, err)
+		require.Equal(t, baselineData, marshaled)
+		decrypted, err := teeService.DecryptLegacyGeneData(privateKey, baselineData, service.LegacyFormatBaseline)
+		require.NoError(t, err)
+		require.Equal(t, "slightly high risk", decrypted)
+
+		// ... and migrates to the current envelope version
+		migrated, err := teeService.MigrateGeneData(privateKey, baselineData, service.LegacyFormatBaseline)
+		require.NoError(t, err)
+		decrypted, err = teeService.DecryptGeneData(privateKey, migrated)
+		require.NoError(t, err)
+		require.Equal(t, "slightly high risk", decrypted)
+
+		// Other keys and formats cannot decrypt it
+		otherKey, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		_, err = teeService.DecryptLegacyGeneData(otherKey, baselineData, service.LegacyFormatBaseline)
+		require.Error(t, err)
+		_, err = teeService.MigrateGeneData(privateKey, baselineData, service.LegacyFormatEphemeralECDH)
+		require.Error(t, err)
+	}
+}
+
+// testKey returns the fixed key the baseline fixtures are encrypted to.
+func testKey(t *testing.T) *ecdsa.PrivateKey {
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	require.NoError(t, err)
+	return privateKey
+}
+
+// encryptLegacy produces gene data in the un-versioned layout written before envelopes were introduced.
+func encryptLegacy(t *testing.T, recipientPubkeyBytes []byte, plaintext string) []byte {
+	recipientPubkey, err := crypto.UnmarshalPubkey(recipientPubkeyBytes)
+	require.NoError(t, err)
+
+	ephemeralKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	ephemeralPubkeyBytes := crypto.FromECDSAPub(&ephemeralKey.PublicKey)
+
+	x, _ := crypto.S256().ScalarMult(recipientPubkey.X, recipientPubkey.Y, ephemeralKey.D.Bytes())
+	sharedSecret := make([]byte, 32)
+	x.FillBytes(sharedSecret)
+
+	info := []byte("genomic-system/gene-data/ecies-secp256k1-hkdf-sha256-aes256gcm")
+	info = append(info, ephemeralPubkeyBytes...)
+	info = append(info, recipientPubkeyBytes...)
+	key := make([]byte, 32)
+	_, err = io.ReadFull(hkdf.New(sha256.New, sharedSecret, nil, info), key)
+	require.NoError(t, err)
+
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	aesGCM, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+
+	nonce := make([]byte, aesGCM.NonceSize())
+	_, err = rand.Read(nonce)
+	require.NoError(t, err)
+
+	out := append(ephemeralPubkeyBytes, nonce...)
+	return append(out, aesGCM.Seal(nil, nonce, []byte(plaintext), ephemeralPubkeyBytes)...)
+}
@@ -0,0 +1,97 @@
+package tee
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/storage"
+)
+
+// Errors returned by ReencryptGeneData and ShareDataKey.
+var (
+	ErrConsentInactive    = errors.New("consent grant is expired or revoked")
+	ErrConsentMismatch    = errors.New("consent grant does not cover this gene data")
+	ErrGranteeKeyMismatch = errors.New("public key does not belong to the grantee")
+)
+
+// ReencryptGeneData re-encrypts gene data encrypted with EncryptGeneDataWithDataKey to a grantee's
+// public key, so it can be shared without handing out anyone's private key. The data key is
+// unwrapped with the enclave key, so the owner must have wrapped it to EnclavePublicKey, and the
+// decrypted gene data never leaves the TEE.
+//
+// The grant must be signed by the owner, active, issued for this encrypted data (its file ID is
+// the keccak256 hash of the data) and to the address of granteePublicKey; it is checked before the
+// data key is unwrapped. The result is a streaming envelope with the same associated data as the
+// input.
+func (s *TEEService) ReencryptGeneData(
+	encryptedData []byte,
+	wrappedKeys []WrappedKey,
+	grant storage.ConsentGrant,
+	ownerPublicKey []byte,
+	granteePublicKey []byte,
+) ([]byte, error) {
+	// Check the grant before touching the data
+	if err := checkGrant(ownerPublicKey, encryptedData, grant, granteePublicKey); err != nil {
+		return nil, err
+	}
+
+	env, err := readEnvelopeHeader(bytes.NewReader(encryptedData))
+	if err != nil {
+		return nil, err
+	}
+
+	var plaintext bytes.Buffer
+	// Do not leave the gene data in memory longer than needed
+	defer func() { clear(plaintext.Bytes()) }()
+	if err := s.DecryptGeneDataWithDataKey(&plaintext, bytes.NewReader(encryptedData), s.enclaveKey, wrappedKeys); err != nil {
+		return nil, err
+	}
+
+	var reencrypted bytes.Buffer
+	if err := s.EncryptGeneDataStream(&reencrypted, &plaintext, granteePublicKey, env.AAD); err != nil {
+		return nil, err
+	}
+	return reencrypted.Bytes(), nil
+}
+
+// ShareDataKey wraps the data key of gene data encrypted with EncryptGeneDataWithDataKey to a
+// grantee's public key. It checks the grant and unwraps the data key with the enclave key like
+// ReencryptGeneData, but the gene data itself is neither decrypted nor re-encrypted.
+func (s *TEEService) ShareDataKey(
+	encryptedData []byte,
+	wrappedKeys []WrappedKey,
+	grant storage.ConsentGrant,
+	ownerPublicKey []byte,
+	granteePublicKey []byte,
+) (WrappedKey, error) {
+	if err := checkGrant(ownerPublicKey, encryptedData, grant, granteePublicKey); err != nil {
+		return WrappedKey{}, err
+	}
+	return s.WrapDataKey(s.enclaveKey, encryptedData, wrappedKeys, granteePublicKey)
+}
+
+// checkGrant checks that the grant is signed by the owner, active, issued for the encrypted data
+// and to the address of the grantee's public key.
+func checkGrant(ownerPublicKey, encryptedData []byte, grant storage.ConsentGrant, granteePublicKey []byte) error {
+	if err := grant.Verify(ownerPublicKey); err != nil {
+		return err
+	}
+	if !grant.Active(time.Now()) {
+		return ErrConsentInactive
+	}
+	if grant.FileID != hex.EncodeToString(crypto.Keccak256(encryptedData)) {
+		return ErrConsentMismatch
+	}
+	granteePubkey, err := crypto.UnmarshalPubkey(granteePublicKey)
+	if err != nil {
+		return errors.New("invalid public key")
+	}
+	if crypto.PubkeyToAddress(*granteePubkey) != grant.Grantee {
+		return ErrGranteeKeyMismatch
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package tee_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trungnt1811/blockchain-engineer-interview/backend/services/storage"
+	service "github.com/trungnt1811/blockchain-engineer-interview/backend/services/tee"
+)
+
+// newGrant returns a grant for the encrypted data to the grantee, signed by the owner.
+func newGrant(t *testing.T, owner *ecdsa.PrivateKey, grantee *ecdsa.PrivateKey, encryptedData []byte) storage.ConsentGrant {
+	grant := storage.ConsentGrant{
+		FileID:    hex.EncodeToString(crypto.Keccak256(encryptedData)),
+		Grantee:   crypto.PubkeyToAddress(grantee.PublicKey),
+		Purpose:   "clinical care",
+		ExpiresAt: time.Now().Add(time.Hour),
+		Nonce:     "1",
+	}
+	return signGrant(t, owner, grant)
+}
+
+// signGrant signs the grant message with personal_sign.
+func signGrant(t *testing.T, owner *ecdsa.PrivateKey, grant storage.ConsentGrant) storage.ConsentGrant {
+	signature, err := crypto.Sign(accounts.TextHash([]byte(grant.Message())), owner)
+	require.NoError(t, err)
+	grant.Signature = signature
+	return grant
+}
+
+func TestReencryptGeneData(t *testing.T) {
+	teeService := service.NewTEEService()
+
+	owner, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	grantee, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	granteePublicKey := crypto.FromECDSAPub(&grantee.PublicKey)
+
+	data := bytes.Repeat([]byte("A"), 3*segmentSize+5)
+	encryptedData, wrappedKeys := encryptForTEE(t, teeService, data, []byte("file-1"), owner)
+	grant := newGrant(t, owner, grantee, encryptedData)
+
+	reencrypted, err := teeService.ReencryptGeneData(encryptedData, wrappedKeys, grant, crypto.FromECDSAPub(&owner.PublicKey), granteePublicKey)
+	require.NoError(t, err)
+
+	env, err := service.ParseEnvelope(reencrypted)
+	require.NoError(t, err)
+	require.Equal(t, service.AlgorithmECIESSecp256k1AES256GCMStream, env.Algorithm)
+	require.Equal(t, []byte("file-1"), env.AAD)
+
+	// Only the grantee can decrypt the result
+	var decrypted bytes.Buffer
+	require.NoError(t, teeService.DecryptGeneDataStream(&decrypted, bytes.NewReader(reencrypted), grantee))
+	require.Equal(t, data, decrypted.Bytes())
+
+	err = teeService.DecryptGeneDataStream(&bytes.Buffer{}, bytes.NewReader(reencrypted), owner)
+	require.Error(t, err)
+}
+
+func TestReencryptGeneData_InvalidGrant(t *testing.T) {
+	teeService := service.NewTEEService()
+
+	owner, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	grantee, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	ownerPublicKey := crypto.FromECDSAPub(&owner.PublicKey)
+	granteePublicKey := crypto.FromECDSAPub(&grantee.PublicKey)
+
+	encryptedData, wrappedKeys := encryptForTEE(t, teeService, []byte("low risk"), nil, owner)
+	valid := newGrant(t, owner, grantee, encryptedData)
+
+	// The TEE rejects every grant that does not let the grantee read this data, both for
+	// re-encryption and for sharing the data key
+	check := func(grant storage.ConsentGrant, publicKey []byte, target error) {
+		t.Helper()
+		_, err := teeService.ReencryptGeneData(encryptedData, wrappedKeys, grant, ownerPublicKey, publicKey)
+		require.ErrorIs(t, err, target)
+		_, err = teeService.ShareDataKey(encryptedData, wrappedKeys, grant, ownerPublicKey, publicKey)
+		require.ErrorIs(t, err, target)
+	}
+
+	// Signed by someone other than the owner
+	check(signGrant(t, other, valid), granteePublicKey, storage.ErrGrantSignerMismatch)
+
+	// Expired
+	expired := valid
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	check(signGrant(t, owner, expired), granteePublicKey, service.ErrConsentInactive)
+
+	// Revoked
+	revoked := valid
+	revokedAt := time.Now()
+	revoked.RevokedAt = &revokedAt
+	check(revoked, granteePublicKey, service.ErrConsentInactive)
+
+	// Issued for other gene data
+	otherData, _ := encryptForTEE(t, teeService, []byte("low risk"), nil, owner)
+	check(newGrant(t, owner, grantee, otherData), granteePublicKey, service.ErrConsentMismatch)
+
+	// Shared with someone other than the grantee
+	check(valid, crypto.FromECDSAPub(&other.PublicKey), service.ErrGranteeKeyMismatch)
+}
+
+func TestReencryptGeneData_NotWrappedToTEE(t *testing.T) {
+	teeService := service.NewTEEService()
+
+	owner, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	ownerPublicKey := crypto.FromECDSAPub(&owner.PublicKey)
+
+	// The data key is only wrapped to the owner
+	encryptedData, wrappedKeys := encryptWithDataKey(t, teeService, []byte("low risk"), nil, owner)
+	_, err = teeService.ReencryptGeneData(encryptedData, wrappedKeys, newGrant(t, owner, owner, encryptedData), ownerPublicKey, ownerPublicKey)
+	require.ErrorIs(t, err, service.ErrNoWrappedKey)
+
+	// Envelopes sealed directly to a public key have no data key
+	oneShot, err := teeService.EncryptGeneData(ownerPublicKey, "low risk")
+	require.NoError(t, err)
+	_, err = teeService.ReencryptGeneData(oneShot, nil, newGrant(t, owner, owner, oneShot), ownerPublicKey, ownerPublicKey)
+	require.ErrorIs(t, err, service.ErrNotDataKeyEnvelope)
+
+	// Another TEE cannot unwrap the data key
+	encryptedData, wrappedKeys = encryptForTEE(t, teeService, []byte("low risk"), nil, owner)
+	_, err = service.NewTEEService().ReencryptGeneData(encryptedData, wrappedKeys, newGrant(t, owner, owner, encryptedData), ownerPublicKey, ownerPublicKey)
+	require.ErrorIs(t, err, service.ErrNoWrappedKey)
+}
@@ -0,0 +1,72 @@
+package tee
+
+import (
+	"errors"
+	"strings"
+)
+
+// RiskLevel is the stroke risk category assigned to gene data. Its numeric value is the
+// risk score sent to the Controller contract and matches the keys of
+// PostCovidStrokePrevention.riskScoreToAward.
+type RiskLevel uint8
+
+const (
+	// RiskLevelExtremelyHigh is rewarded with 15000 PCSP.
+	RiskLevelExtremelyHigh RiskLevel = 1
+	// RiskLevelHigh is rewarded with 3000 PCSP.
+	RiskLevelHigh RiskLevel = 2
+	// RiskLevelSlightlyHigh is rewarded with 225 PCSP.
+	RiskLevelSlightlyHigh RiskLevel = 3
+	// RiskLevelLow is rewarded with 30 PCSP.
+	RiskLevelLow RiskLevel = 4
+)
+
+// ErrUnrecognizedGeneData is returned when gene data does not match any risk category.
+var ErrUnrecognizedGeneData = errors.New("unrecognized gene data")
+
+// riskCategories maps the gene file content of each category to its risk level.
+var riskCategories = map[string]RiskLevel{
+	"extremely high risk": RiskLevelExtremelyHigh,
+	"high risk":           RiskLevelHigh,
+	"slightly high risk":  RiskLevelSlightlyHigh,
+	"low risk":            RiskLevelLow,
+}
+
+// String returns the gene file content of the risk category.
+func (l RiskLevel) String() string {
+	switch l {
+	case RiskLevelExtremelyHigh:
+		return "extremely high risk"
+	case RiskLevelHigh:
+		return "high risk"
+	case RiskLevelSlightlyHigh:
+		return "slightly high risk"
+	case RiskLevelLow:
+		return "low risk"
+	default:
+		return "unknown"
+	}
+}
+
+// Valid reports whether l is one of the four risk categories.
+func (l RiskLevel) Valid() bool {
+	return l >= RiskLevelExtremelyHigh && l <= RiskLevelLow
+}
+
+// Score returns the risk score to submit on-chain for this level.
+func (l RiskLevel) Score() uint8 {
+	return uint8(l)
+}
+
+// ClassifyGeneData parses a gene text file and returns its risk level. The comparison ignores
+// case and surrounding or repeated whitespace (e.g. a trailing newline); any other content is
+// rejected with ErrUnrecognizedGeneData.
+func ClassifyGeneData(geneData string) (RiskLevel, error) {
+	normalized := strings.ToLower(strings.Join(strings.Fields(geneData), " "))
+
+	level, exists := riskCategories[normalized]
+	if !exists {
+		return 0, ErrUnrecognizedGeneData
+	}
+	return level, nil
+}
@@ -0,0 +1,200 @@
+package tee
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// GStrokeModelName is the name of the built-in G-Stroke risk model.
+	GStrokeModelName = "g-stroke"
+	// GStrokeModelVersion is the version of the built-in G-Stroke risk model.
+	GStrokeModelVersion = "1.0.0"
+	// gStrokeMaxInputSize bounds the gene file read by the G-Stroke model; files are around 40MB.
+	gStrokeMaxInputSize = 64 << 20
+)
+
+var (
+	// ErrRiskModelNotFound is returned when no model is registered under the requested name and version.
+	ErrRiskModelNotFound = errors.New("risk model not found")
+	// ErrInputTooLarge is returned when gene data exceeds the model's maximum input size.
+	ErrInputTooLarge = errors.New("gene data exceeds the model input size")
+)
+
+// InputSchema describes the gene data a risk model accepts.
+type InputSchema struct {
+	MediaType   string // e.g. "text/plain".
+	MaxSize     int64  // Maximum input size in bytes.
+	Description string
+}
+
+// Result is the outcome of scoring gene data, along with the model that produced it.
+type Result struct {
+	RiskLevel    RiskLevel
+	Model        string
+	ModelVersion string
+	InputHash    common.Hash // Keccak256 hash of the scored gene data, attested by Attest.
+}
+
+// RiskModel computes a stroke risk level from gene data inside the TEE. Models are identified
+// by name and version so that several versions can be registered side-by-side.
+type RiskModel interface {
+	Name() string
+	Version() string
+	InputSchema() InputSchema
+	Score(ctx context.Context, geneData io.Reader) (Result, error)
+}
+
+// riskModelKey identifies a registered model.
+type riskModelKey struct {
+	name    string
+	version string
+}
+
+// RegisterRiskModel adds a model to the registry. The first registered model becomes the default.
+func (s *TEEService) RegisterRiskModel(model RiskModel) error {
+	key := riskModelKey{name: model.Name(), version: model.Version()}
+	if key.name == "" || key.version == "" {
+		return errors.New("risk model name and version are required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.riskModels[key]; exists {
+		return fmt.Errorf("risk model %s@%s already registered", key.name, key.version)
+	}
+	s.riskModels[key] = model
+	if s.defaultRiskModel == (riskModelKey{}) {
+		s.defaultRiskModel = key
+	}
+	return nil
+}
+
+// SetDefaultRiskModel selects the registered model used when no model is requested explicitly.
+func (s *TEEService) SetDefaultRiskModel(name, version string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := riskModelKey{name: name, version: version}
+	if _, exists := s.riskModels[key]; !exists {
+		return ErrRiskModelNotFound
+	}
+	s.defaultRiskModel = key
+	return nil
+}
+
+// RiskModel returns the model registered under name and version. Empty name and version
+// select the default model.
+func (s *TEEService) RiskModel(name, version string) (RiskModel, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key := riskModelKey{name: name, version: version}
+	if key == (riskModelKey{}) {
+		key = s.defaultRiskModel
+	}
+
+	model, exists := s.riskModels[key]
+	if !exists {
+		return nil, ErrRiskModelNotFound
+	}
+	return model, nil
+}
+
+// RiskModels returns all registered models ordered by name and version.
+func (s *TEEService) RiskModels() []RiskModel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	models := make([]RiskModel, 0, len(s.riskModels))
+	for _, model := range s.riskModels {
+		models = append(models, model)
+	}
+	slices.SortFunc(models, func(a, b RiskModel) int {
+		if c := cmp.Compare(a.Name(), b.Name()); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Version(), b.Version())
+	})
+	return models
+}
+
+// ScoreGeneData scores the gene data with the requested model (or the default model if name and
+// version are empty). The result records the model name and version that produced the score and
+// the hash of the gene data, so it can be attested with Attest.
+func (s *TEEService) ScoreGeneData(ctx context.Context, name, version string, geneData io.Reader) (Result, error) {
+	model, err := s.RiskModel(name, version)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// Hash the input as the model reads it, then whatever it left unread
+	hasher := crypto.NewKeccakState()
+	result, err := model.Score(ctx, io.TeeReader(geneData, hasher))
+	if err != nil {
+		return Result{}, err
+	}
+	if _, err := io.Copy(hasher, geneData); err != nil {
+		return Result{}, err
+	}
+	if !result.RiskLevel.Valid() {
+		return Result{}, fmt.Errorf("risk model %s@%s returned invalid risk level %d", model.Name(), model.Version(), result.RiskLevel)
+	}
+
+	result.Model = model.Name()
+	result.ModelVersion = model.Version()
+	hasher.Read(result.InputHash[:])
+	return result, nil
+}
+
+// GStrokeModel is the built-in G-Stroke model. It classifies a gene text file into one of the
+// four risk categories using ClassifyGeneData.
+type GStrokeModel struct{}
+
+// Name implements RiskModel.
+func (GStrokeModel) Name() string {
+	return GStrokeModelName
+}
+
+// Version implements RiskModel.
+func (GStrokeModel) Version() string {
+	return GStrokeModelVersion
+}
+
+// InputSchema implements RiskModel.
+func (GStrokeModel) InputSchema() InputSchema {
+	return InputSchema{
+		MediaType:   "text/plain",
+		MaxSize:     gStrokeMaxInputSize,
+		Description: `Gene text file containing one of "extremely high risk", "high risk", "slightly high risk" or "low risk".`,
+	}
+}
+
+// Score implements RiskModel.
+func (GStrokeModel) Score(ctx context.Context, geneData io.Reader) (Result, error) {
+	// Read one byte past the limit to detect oversized input
+	data, err := io.ReadAll(io.LimitReader(geneData, gStrokeMaxInputSize+1))
+	if err != nil {
+		return Result{}, err
+	}
+	if len(data) > gStrokeMaxInputSize {
+		return Result{}, ErrInputTooLarge
+	}
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	level, err := ClassifyGeneData(string(data))
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{RiskLevel: level}, nil
+}
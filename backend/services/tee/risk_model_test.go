@@ -0,0 +1,109 @@
+package tee_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	service "github.com/trungnt1811/blockchain-engineer-interview/backend/services/tee"
+)
+
+// fixedModel is a test risk model that always returns the same risk level.
+type fixedModel struct {
+	version string
+	level   service.RiskLevel
+}
+
+func (m fixedModel) Name() string    { return service.GStrokeModelName }
+func (m fixedModel) Version() string { return m.version }
+func (m fixedModel) InputSchema() service.InputSchema {
+	return service.InputSchema{MediaType: "text/plain", MaxSize: 1 << 10}
+}
+func (m fixedModel) Score(ctx context.Context, geneData io.Reader) (service.Result, error) {
+	return service.Result{RiskLevel: m.level}, nil
+}
+
+func TestRiskModelRegistry(t *testing.T) {
+	teeService := service.NewTEEService()
+
+	// The G-Stroke model is registered as the default
+	model, err := teeService.RiskModel("", "")
+	require.NoError(t, err)
+	require.Equal(t, service.GStrokeModelName, model.Name())
+	require.Equal(t, service.GStrokeModelVersion, model.Version())
+
+	// A new version is registered side-by-side without replacing the default
+	require.NoError(t, teeService.RegisterRiskModel(fixedModel{version: "2.0.0", level: service.RiskLevelSlightlyHigh}))
+	models := teeService.RiskModels()
+	require.Len(t, models, 2)
+	require.Equal(t, service.GStrokeModelVersion, models[0].Version())
+	require.Equal(t, "2.0.0", models[1].Version())
+
+	inputHash := crypto.Keccak256Hash([]byte("high risk"))
+	result, err := teeService.ScoreGeneData(context.Background(), "", "", strings.NewReader("high risk"))
+	require.NoError(t, err)
+	require.Equal(t, service.Result{RiskLevel: service.RiskLevelHigh, Model: "g-stroke", ModelVersion: "1.0.0", InputHash: inputHash}, result)
+
+	result, err = teeService.ScoreGeneData(context.Background(), "g-stroke", "2.0.0", strings.NewReader("high risk"))
+	require.NoError(t, err)
+	// The input is hashed even though this model does not read it
+	require.Equal(t, service.Result{RiskLevel: service.RiskLevelSlightlyHigh, Model: "g-stroke", ModelVersion: "2.0.0", InputHash: inputHash}, result)
+
+	// Switching the default affects CalculateRiskScore
+	require.NoError(t, teeService.SetDefaultRiskModel("g-stroke", "2.0.0"))
+	level, err := teeService.CalculateRiskScore("high risk")
+	require.NoError(t, err)
+	require.Equal(t, service.RiskLevelSlightlyHigh, level)
+
+	// Duplicates and unknown models are rejected
+	require.EqualError(t, teeService.RegisterRiskModel(fixedModel{version: "2.0.0"}), "risk model g-stroke@2.0.0 already registered")
+	require.EqualError(t, teeService.RegisterRiskModel(fixedModel{}), "risk model name and version are required")
+	require.ErrorIs(t, teeService.SetDefaultRiskModel("g-stroke", "3.0.0"), service.ErrRiskModelNotFound)
+	_, err = teeService.ScoreGeneData(context.Background(), "g-stroke", "3.0.0", strings.NewReader("high risk"))
+	require.ErrorIs(t, err, service.ErrRiskModelNotFound)
+}
+
+func TestScoreGeneData_InvalidModelOutput(t *testing.T) {
+	teeService := service.NewTEEService()
+	require.NoError(t, teeService.RegisterRiskModel(fixedModel{version: "0.0.1", level: 7}))
+
+	_, err := teeService.ScoreGeneData(context.Background(), "g-stroke", "0.0.1", strings.NewReader("high risk"))
+	require.EqualError(t, err, "risk model g-stroke@0.0.1 returned invalid risk level 7")
+}
+
+func TestGStrokeModel_Score(t *testing.T) {
+	model := service.GStrokeModel{}
+	require.Equal(t, "text/plain", model.InputSchema().MediaType)
+
+	result, err := model.Score(context.Background(), strings.NewReader("extremely high risk\n"))
+	require.NoError(t, err)
+	require.Equal(t, service.RiskLevelExtremelyHigh, result.RiskLevel)
+
+	_, err = model.Score(context.Background(), strings.NewReader("not a category"))
+	require.ErrorIs(t, err, service.ErrUnrecognizedGeneData)
+
+	// Inputs larger than the schema allows are rejected
+	oversized := io.LimitReader(spaceReader{}, model.InputSchema().MaxSize+1)
+	_, err = model.Score(context.Background(), oversized)
+	require.ErrorIs(t, err, service.ErrInputTooLarge)
+
+	// Canceled contexts stop scoring
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = model.Score(ctx, strings.NewReader("low risk"))
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+// spaceReader is an endless stream of spaces.
+type spaceReader struct{}
+
+func (spaceReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = ' '
+	}
+	return len(p), nil
+}
@@ -0,0 +1,64 @@
+package tee_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	service "github.com/trungnt1811/blockchain-engineer-interview/backend/services/tee"
+)
+
+func TestCalculateRiskScore(t *testing.T) {
+	teeService := service.NewTEEService()
+
+	// Scores must match the keys of PostCovidStrokePrevention.riskScoreToAward
+	testCases := []struct {
+		geneData string
+		level    service.RiskLevel
+		score    uint8
+	}{
+		{"extremely high risk", service.RiskLevelExtremelyHigh, 1},
+		{"high risk", service.RiskLevelHigh, 2},
+		{"slightly high risk", service.RiskLevelSlightlyHigh, 3},
+		{"low risk", service.RiskLevelLow, 4},
+		{"  Extremely High   Risk\n", service.RiskLevelExtremelyHigh, 1},
+		{"LOW RISK\r\n", service.RiskLevelLow, 4},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.geneData, func(t *testing.T) {
+			level, err := teeService.CalculateRiskScore(tc.geneData)
+			require.NoError(t, err)
+			require.Equal(t, tc.level, level)
+			require.Equal(t, tc.score, level.Score())
+			require.True(t, level.Valid())
+		})
+	}
+}
+
+func TestCalculateRiskScore_Unrecognized(t *testing.T) {
+	teeService := service.NewTEEService()
+
+	for _, geneData := range []string{"", "risk", "very high risk", "high risk high risk", "high-risk", "ACGTACGT"} {
+		_, err := teeService.CalculateRiskScore(geneData)
+		require.ErrorIs(t, err, service.ErrUnrecognizedGeneData, geneData)
+	}
+}
+
+func TestRiskLevel_String(t *testing.T) {
+	for _, level := range []service.RiskLevel{
+		service.RiskLevelExtremelyHigh,
+		service.RiskLevelHigh,
+		service.RiskLevelSlightlyHigh,
+		service.RiskLevelLow,
+	} {
+		// The category text classifies back to the same level
+		parsed, err := service.ClassifyGeneData(level.String())
+		require.NoError(t, err)
+		require.Equal(t, level, parsed)
+	}
+
+	require.False(t, service.RiskLevel(0).Valid())
+	require.False(t, service.RiskLevel(5).Valid())
+	require.Equal(t, "unknown", service.RiskLevel(5).String())
+}
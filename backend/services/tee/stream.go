@@ -0,0 +1,179 @@
+package tee
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// Streaming envelopes follow the STREAM construction (Hoang, Reyhanitabar, Rogaway and Vizár):
+// the plaintext is split into segments of streamSegmentSize bytes and each segment is sealed
+// with AES-256-GCM under the nonce
+//
+//	nonce prefix (7 bytes) || segment counter (4 bytes, big-endian) || last segment flag (1 byte)
+//
+// and the envelope header as additional data. Only the final segment, which may be shorter or
+// empty, has the flag set, so reordering, dropping or truncating segments is detected.
+const (
+	// streamSegmentSize is the plaintext size of every segment except the last.
+	streamSegmentSize = 64 << 10
+	// streamNoncePrefixSize is the size of the random nonce prefix stored in the envelope.
+	streamNoncePrefixSize = 7
+)
+
+// ErrTruncatedStream is returned when a streaming envelope ends before its final segment.
+var ErrTruncatedStream = errors.New("gene data stream is truncated")
+
+// EncryptGeneDataStream reads gene data from src and writes it to dst as a streaming envelope
+// sealed to the user's public key. Unlike EncryptGeneData, memory use does not depend on the
+// size of the data, which makes it suitable for full gene files (around 40MB). The optional AAD
+// is stored in clear text and authenticated like in EncryptGeneDataWithAAD.
+//
+// The result is also accepted by DecryptGeneData.
+func (s *TEEService) EncryptGeneDataStream(dst io.Writer, src io.Reader, publicKeyBytes, aad []byte) error {
+	env, key, header, err := newEnvelope(publicKeyBytes, AlgorithmECIESSecp256k1AES256GCMStream, streamNoncePrefixSize, aad)
+	if err != nil {
+		return err
+	}
+
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+	return sealSegments(dst, src, key, env.Nonce, header)
+}
+
+// DecryptGeneDataStream reads a streaming envelope produced by EncryptGeneDataStream from src
+// and writes the decrypted gene data to dst. Segments are written as soon as they are
+// authenticated, so if an error is returned dst may already hold part of the data and the
+// caller must discard it.
+func (s *TEEService) DecryptGeneDataStream(dst io.Writer, src io.Reader, privateKey *ecdsa.PrivateKey) error {
+	env, err := readEnvelopeHeader(src)
+	if err != nil {
+		return err
+	}
+	if env.Algorithm != AlgorithmECIESSecp256k1AES256GCMStream {
+		return errors.New("envelope is not a streaming envelope")
+	}
+
+	key, header, err := envelopeKey(privateKey, env)
+	if err != nil {
+		return err
+	}
+	return openSegments(dst, src, key, env.Nonce, header)
+}
+
+// sealSegments encrypts src segment by segment and writes the sealed segments to dst.
+func sealSegments(dst io.Writer, src io.Reader, key, noncePrefix, header []byte) error {
+	aesGCM, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+
+	r := bufio.NewReaderSize(src, streamSegmentSize)
+	plaintext := make([]byte, streamSegmentSize)
+	ciphertext := make([]byte, 0, streamSegmentSize+aesGCM.Overhead())
+
+	for counter := uint64(0); ; counter++ {
+		n, err := io.ReadFull(r, plaintext)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+
+		last, err := isLastSegment(r, n < len(plaintext))
+		if err != nil {
+			return err
+		}
+
+		nonce, err := segmentNonce(noncePrefix, counter, last)
+		if err != nil {
+			return err
+		}
+		ciphertext = aesGCM.Seal(ciphertext[:0], nonce, plaintext[:n], header)
+		if _, err := dst.Write(ciphertext); err != nil {
+			return err
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+// openSegments decrypts the sealed segments read from src and writes the plaintext to dst.
+func openSegments(dst io.Writer, src io.Reader, key, noncePrefix, header []byte) error {
+	if len(noncePrefix) != streamNoncePrefixSize {
+		return errors.New("invalid nonce size")
+	}
+	aesGCM, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+
+	r := bufio.NewReaderSize(src, streamSegmentSize+aesGCM.Overhead())
+	ciphertext := make([]byte, streamSegmentSize+aesGCM.Overhead())
+	plaintext := make([]byte, 0, streamSegmentSize)
+
+	for counter := uint64(0); ; counter++ {
+		n, err := io.ReadFull(r, ciphertext)
+		if err == io.EOF {
+			// The previous segment was full-sized but not marked as the last one
+			return ErrTruncatedStream
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+
+		last, err := isLastSegment(r, n < len(ciphertext))
+		if err != nil {
+			return err
+		}
+
+		nonce, err := segmentNonce(noncePrefix, counter, last)
+		if err != nil {
+			return err
+		}
+		plaintext, err = aesGCM.Open(plaintext[:0], nonce, ciphertext[:n], header)
+		if err != nil {
+			return err
+		}
+		if _, err := dst.Write(plaintext); err != nil {
+			return err
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+// isLastSegment reports whether the segment just read is the last one: either it was short,
+// or the input ends right after it.
+func isLastSegment(r *bufio.Reader, short bool) (bool, error) {
+	if short {
+		return true, nil
+	}
+	if _, err := r.Peek(1); err == io.EOF {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+// segmentNonce builds the GCM nonce of the segment with the given counter.
+func segmentNonce(noncePrefix []byte, counter uint64, last bool) ([]byte, error) {
+	if counter > math.MaxUint32 {
+		return nil, errors.New("gene data stream has too many segments")
+	}
+
+	nonce := make([]byte, gcmNonceSize)
+	copy(nonce, noncePrefix)
+	binary.BigEndian.PutUint32(nonce[streamNoncePrefixSize:], uint32(counter))
+	if last {
+		nonce[gcmNonceSize-1] = 1
+	}
+	return nonce, nil
+}
@@ -0,0 +1,208 @@
+package tee_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	service "github.com/trungnt1811/blockchain-engineer-interview/backend/services/tee"
+)
+
+const (
+	// segmentSize mirrors the plaintext segment size of streaming envelopes.
+	segmentSize = 64 << 10
+	// sealedSegmentSize is a full segment plus its GCM tag.
+	sealedSegmentSize = segmentSize + 16
+	// geneFileSize is the typical size of a real gene file.
+	geneFileSize = 40 << 20
+)
+
+// encryptStream encrypts data with EncryptGeneDataStream and returns the envelope and its header size.
+func encryptStream(t testing.TB, teeService *service.TEEService, publicKeyBytes, data, aad []byte) ([]byte, int) {
+	var encrypted bytes.Buffer
+	require.NoError(t, teeService.EncryptGeneDataStream(&encrypted, bytes.NewReader(data), publicKeyBytes, aad))
+
+	env, err := service.ParseEnvelope(encrypted.Bytes())
+	require.NoError(t, err)
+	return encrypted.Bytes(), encrypted.Len() - len(env.Ciphertext)
+}
+
+func TestEncryptGeneDataStream_RoundTrip(t *testing.T) {
+	teeService := service.NewTEEService()
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	publicKeyBytes := crypto.FromECDSAPub(&privateKey.PublicKey)
+
+	for _, size := range []int{0, 1, segmentSize - 1, segmentSize, segmentSize + 1, 3*segmentSize + 100} {
+		t.Run(strconv.Itoa(size), func(t *testing.T) {
+			data := make([]byte, size)
+			_, err := rand.Read(data)
+			require.NoError(t, err)
+
+			encrypted, headerSize := encryptStream(t, teeService, publicKeyBytes, data, []byte("file-1"))
+
+			// Every segment but the last is full, and there is always at least one segment
+			segments := max(1, (size+segmentSize-1)/segmentSize)
+			require.Equal(t, headerSize+size+segments*16, len(encrypted))
+
+			env, err := service.ParseEnvelope(encrypted)
+			require.NoError(t, err)
+			require.Equal(t, service.AlgorithmECIESSecp256k1AES256GCMStream, env.Algorithm)
+			require.Equal(t, []byte("file-1"), env.AAD)
+
+			// Streaming decryption
+			var decrypted bytes.Buffer
+			require.NoError(t, teeService.DecryptGeneDataStream(&decrypted, bytes.NewReader(encrypted), privateKey))
+			require.Equal(t, string(data), decrypted.String())
+
+			// In-memory decryption accepts streaming envelopes as well
+			geneData, err := teeService.DecryptGeneData(privateKey, encrypted)
+			require.NoError(t, err)
+			require.Equal(t, string(data), geneData)
+		})
+	}
+}
+
+func TestDecryptGeneDataStream_Tampered(t *testing.T) {
+	teeService := service.NewTEEService()
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	publicKeyBytes := crypto.FromECDSAPub(&privateKey.PublicKey)
+
+	// Three full segments and a short final one
+	data := bytes.Repeat([]byte("x"), 3*segmentSize+100)
+	encrypted, headerSize := encryptStream(t, teeService, publicKeyBytes, data, nil)
+	header := encrypted[:headerSize]
+	segment := func(i int) []byte {
+		start := headerSize + i*sealedSegmentSize
+		return encrypted[start:min(start+sealedSegmentSize, len(encrypted))]
+	}
+
+	testCases := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{
+			name: "truncated at a segment boundary",
+			data: bytes.Join([][]byte{header, segment(0), segment(1)}, nil),
+		},
+		{
+			name: "missing final segment",
+			data: bytes.Join([][]byte{header, segment(0), segment(1), segment(2)}, nil),
+		},
+		{
+			name: "header only",
+			data: header,
+			err:  service.ErrTruncatedStream,
+		},
+		{
+			name: "reordered segments",
+			data: bytes.Join([][]byte{header, segment(1), segment(0), segment(2), segment(3)}, nil),
+		},
+		{
+			name: "trailing data",
+			data: bytes.Join([][]byte{encrypted, []byte{0}}, nil),
+		},
+		{
+			name: "flipped ciphertext bit",
+			data: func() []byte {
+				tampered := bytes.Clone(encrypted)
+				tampered[headerSize+segmentSize+5] ^= 0x01
+				return tampered
+			}(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := teeService.DecryptGeneDataStream(io.Discard, bytes.NewReader(tc.data), privateKey)
+			require.Error(t, err)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+			}
+
+			_, err = teeService.DecryptGeneData(privateKey, tc.data)
+			require.Error(t, err)
+		})
+	}
+
+	// Decrypting with another key fails
+	otherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	require.Error(t, teeService.DecryptGeneDataStream(io.Discard, bytes.NewReader(encrypted), otherKey))
+}
+
+func TestDecryptGeneDataStream_NotStreaming(t *testing.T) {
+	teeService := service.NewTEEService()
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	encrypted, err := teeService.EncryptGeneData(crypto.FromECDSAPub(&privateKey.PublicKey), "high risk")
+	require.NoError(t, err)
+
+	err = teeService.DecryptGeneDataStream(io.Discard, bytes.NewReader(encrypted), privateKey)
+	require.EqualError(t, err, "envelope is not a streaming envelope")
+
+	err = teeService.DecryptGeneDataStream(io.Discard, bytes.NewReader([]byte("not an envelope")), privateKey)
+	require.ErrorIs(t, err, service.ErrNotEnvelope)
+}
+
+// newGeneFile returns a random 40MB gene file and a key pair to encrypt it to.
+func newGeneFile(b *testing.B) ([]byte, *service.TEEService, []byte) {
+	data := make([]byte, geneFileSize)
+	_, err := rand.Read(data)
+	require.NoError(b, err)
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(b, err)
+	return data, service.NewTEEService(), crypto.FromECDSAPub(&privateKey.PublicKey)
+}
+
+func BenchmarkEncryptGeneData_40MB(b *testing.B) {
+	data, teeService, publicKeyBytes := newGeneFile(b)
+	geneData := string(data)
+
+	b.SetBytes(geneFileSize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := teeService.EncryptGeneData(publicKeyBytes, geneData)
+		require.NoError(b, err)
+	}
+}
+
+func BenchmarkEncryptGeneDataStream_40MB(b *testing.B) {
+	data, teeService, publicKeyBytes := newGeneFile(b)
+
+	b.SetBytes(geneFileSize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := teeService.EncryptGeneDataStream(io.Discard, bytes.NewReader(data), publicKeyBytes, nil)
+		require.NoError(b, err)
+	}
+}
+
+func BenchmarkDecryptGeneDataStream_40MB(b *testing.B) {
+	data := make([]byte, geneFileSize)
+	_, err := rand.Read(data)
+	require.NoError(b, err)
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(b, err)
+	teeService := service.NewTEEService()
+	encrypted, _ := encryptStream(b, teeService, crypto.FromECDSAPub(&privateKey.PublicKey), data, nil)
+
+	b.SetBytes(geneFileSize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := teeService.DecryptGeneDataStream(io.Discard, bytes.NewReader(encrypted), privateKey)
+		require.NoError(b, err)
+	}
+}
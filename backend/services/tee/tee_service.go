@@ -1,80 +1,360 @@
 package tee
 
 import (
+	"bytes"
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/ecdsa"
 	"crypto/rand"
 	"crypto/sha256"
+	"errors"
+	"fmt"
 	"io"
-	"math/big"
+	"strings"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/hkdf"
 )
 
+const (
+	// ephemeralPubkeySize is the size of an uncompressed secp256k1 public key.
+	ephemeralPubkeySize = 65
+	// aesKeySize is the size of the AES-256 key derived from the ECDH shared secret.
+	aesKeySize = 32
+	// gcmNonceSize is the standard AES-GCM nonce size.
+	gcmNonceSize = 12
+	// kdfSaltSize is the size of the random HKDF salt stored in each envelope.
+	kdfSaltSize = 32
+)
+
+// kdfInfo binds the derived AES key to its use for gene data encryption.
+var kdfInfo = []byte("genomic-system/gene-data/ecies-secp256k1-hkdf-sha256-aes256gcm")
+
 // TEEService simulates a Trusted Execution Environment (TEE) service.
-type TEEService struct{}
+type TEEService struct {
+	riskModels       map[riskModelKey]RiskModel
+	defaultRiskModel riskModelKey
+	attestationKey   *ecdsa.PrivateKey
+	enclaveKey       *ecdsa.PrivateKey // Unwraps data keys wrapped to the TEE, never leaves it.
+	mu               sync.RWMutex
+}
 
-// NewTEEService creates a new instance of TEEService.
+// NewTEEService creates a new instance of TEEService with the G-Stroke model registered as the
+// default risk model and freshly generated attestation and enclave keys.
 func NewTEEService() *TEEService {
-	return &TEEService{}
+	return NewTEEServiceWithAttestationKey(generateKey())
 }
 
-// CalculateRiskScore calculates a risk score based on the provided gene data.
-// The score is a simple mock and will return 1, 2, 3, or 4 based on the hash of the gene data.
-func (s *TEEService) CalculateRiskScore(geneData string) uint {
-	hash := sha256.Sum256([]byte(geneData))
+// NewTEEServiceWithAttestationKey creates a new instance of TEEService like NewTEEService, but
+// signing attestation quotes with the given key so that verifiers can be configured to trust it.
+func NewTEEServiceWithAttestationKey(attestationKey *ecdsa.PrivateKey) *TEEService {
+	return NewTEEServiceWithKeys(attestationKey, generateKey())
+}
+
+// NewTEEServiceWithKeys creates a new instance of TEEService like NewTEEServiceWithAttestationKey,
+// but also unwrapping data keys with the given enclave key, so gene data wrapped to the TEE stays
+// readable by it across restarts.
+func NewTEEServiceWithKeys(attestationKey, enclaveKey *ecdsa.PrivateKey) *TEEService {
+	s := &TEEService{
+		riskModels:     make(map[riskModelKey]RiskModel),
+		attestationKey: attestationKey,
+		enclaveKey:     enclaveKey,
+	}
+	_ = s.RegisterRiskModel(GStrokeModel{})
+	return s
+}
 
-	hashInt := new(big.Int).SetBytes(hash[:])
+// EnclavePublicKey returns the public key of the enclave key. Owners wrap the data key of their
+// gene data to it so that the TEE can score and share the data without their private key.
+func (s *TEEService) EnclavePublicKey() []byte {
+	return crypto.FromECDSAPub(&s.enclaveKey.PublicKey)
+}
 
-	riskScore := new(big.Int).Mod(hashInt, big.NewInt(4)).Uint64() + 1
+// generateKey generates a secp256k1 key.
+func generateKey() *ecdsa.PrivateKey {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		// crypto/rand does not fail on supported platforms
+		panic(err)
+	}
+	return key
+}
 
-	return uint(riskScore)
+// CalculateRiskScore scores the gene data with the default risk model.
+// It returns ErrUnrecognizedGeneData if the content does not match any category.
+func (s *TEEService) CalculateRiskScore(geneData string) (RiskLevel, error) {
+	result, err := s.ScoreGeneData(context.Background(), "", "", strings.NewReader(geneData))
+	if err != nil {
+		return 0, err
+	}
+	return result.RiskLevel, nil
 }
 
-// EncryptGeneData encrypts the gene data using the user's public key.
+// EncryptGeneData encrypts the gene data to the user's public key using ECIES:
+// an ephemeral secp256k1 key pair is generated, ECDH with the user's public key
+// yields a shared secret, HKDF-SHA256 derives an AES-256 key from it, and the
+// data is sealed with AES-256-GCM.
+//
+// The result is a versioned Envelope (see ParseEnvelope). Only the holder of the
+// user's private key can decrypt it.
 func (s *TEEService) EncryptGeneData(publicKeyBytes []byte, geneData string) ([]byte, error) {
-	publicKey, err := crypto.UnmarshalPubkey(publicKeyBytes)
+	return s.EncryptGeneDataWithAAD(publicKeyBytes, geneData, nil)
+}
+
+// EncryptGeneDataWithAAD is like EncryptGeneData but also binds the given associated data
+// (e.g. a user or file identifier) to the envelope. The AAD is stored in clear text and
+// authenticated on decryption.
+func (s *TEEService) EncryptGeneDataWithAAD(publicKeyBytes []byte, geneData string, aad []byte) ([]byte, error) {
+	env, err := sealEnvelope(publicKeyBytes, []byte(geneData), aad)
 	if err != nil {
 		return nil, err
 	}
+	return env.MarshalBinary()
+}
 
-	sharedSecret := generateSharedSecret(publicKey)
+// DecryptGeneData decrypts gene data produced by EncryptGeneData using the user's private key.
+// Un-versioned data written before the envelope format is decrypted with DecryptLegacyGeneData.
+func (s *TEEService) DecryptGeneData(privateKey *ecdsa.PrivateKey, encryptedData []byte) (string, error) {
+	env, err := ParseEnvelope(encryptedData)
+	if err != nil {
+		return "", err
+	}
 
-	// Encrypt the gene data using AES-256-GCM with the shared secret
-	encryptedData, err := encryptAES(sharedSecret, []byte(geneData))
+	plaintext, err := openEnvelope(privateKey, env)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	return encryptedData, nil
+	return string(plaintext), nil
 }
 
-// generateSharedSecret generates a shared secret using the user's public key.
-func generateSharedSecret(publicKey *ecdsa.PublicKey) []byte {
-	// Hash the public key to generate the shared secret
-	xBytes := publicKey.X.Bytes()
-	sharedSecret := sha256.Sum256(xBytes)
-	return sharedSecret[:]
+// DecryptLegacyGeneData decrypts un-versioned gene data stored in the given format using the
+// user's private key.
+func (s *TEEService) DecryptLegacyGeneData(privateKey *ecdsa.PrivateKey, encryptedData []byte, format LegacyFormat) (string, error) {
+	env, err := ParseLegacyEnvelope(encryptedData, format)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := openEnvelope(privateKey, env)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
 }
 
-// encryptAES encrypts data using AES-256-GCM.
-func encryptAES(key, plaintext []byte) ([]byte, error) {
-	block, err := aes.NewCipher(key)
+// MigrateGeneData re-encrypts un-versioned gene data stored in the given format into the current
+// envelope version, preserving its associated data. Data that already is a current envelope is
+// returned unchanged, so migrations can be re-run.
+func (s *TEEService) MigrateGeneData(privateKey *ecdsa.PrivateKey, encryptedData []byte, format LegacyFormat) ([]byte, error) {
+	if env, err := ParseEnvelope(encryptedData); err == nil && env.Version == CurrentEnvelopeVersion {
+		return encryptedData, nil
+	}
+	env, err := ParseLegacyEnvelope(encryptedData, format)
 	if err != nil {
 		return nil, err
 	}
 
-	aesGCM, err := cipher.NewGCM(block)
+	plaintext, err := openEnvelope(privateKey, env)
 	if err != nil {
 		return nil, err
 	}
 
-	nonce := make([]byte, aesGCM.NonceSize())
+	migrated, err := sealEnvelope(crypto.FromECDSAPub(&privateKey.PublicKey), plaintext, env.AAD)
+	if err != nil {
+		return nil, err
+	}
+	return migrated.MarshalBinary()
+}
+
+// sealEnvelope encrypts the plaintext to the recipient's public key and returns a current-version envelope.
+func sealEnvelope(recipientPubkeyBytes, plaintext, aad []byte) (*Envelope, error) {
+	env, key, header, err := newEnvelope(recipientPubkeyBytes, AlgorithmECIESSecp256k1AES256GCM, gcmNonceSize, aad)
+	if err != nil {
+		return nil, err
+	}
+
+	env.Ciphertext, err = sealAES(key, env.Nonce, plaintext, header)
+	if err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// newEnvelope creates a current-version envelope without ciphertext for the recipient, with a
+// fresh ephemeral key, salt and nonce of the given size. It returns the envelope together with
+// the derived AES key and the serialized header to authenticate.
+func newEnvelope(recipientPubkeyBytes []byte, algorithm Algorithm, nonceSize int, aad []byte) (*Envelope, []byte, []byte, error) {
+	recipientPubkey, err := crypto.UnmarshalPubkey(recipientPubkeyBytes)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Generate a fresh ephemeral key pair for this message
+	ephemeralKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	salt := make([]byte, kdfSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, nil, nil, err
+	}
+	nonce := make([]byte, nonceSize)
 	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, nil, nil, err
+	}
+
+	env := &Envelope{
+		Version:         CurrentEnvelopeVersion,
+		Algorithm:       algorithm,
+		KDFParams:       KDFParams{KDF: KDFHKDFSHA256, Salt: salt},
+		EphemeralPubkey: crypto.FromECDSAPub(&ephemeralKey.PublicKey),
+		Nonce:           nonce,
+		AAD:             aad,
+	}
+
+	key, err := deriveKey(ephemeralKey, recipientPubkey, env.KDFParams, env.EphemeralPubkey, recipientPubkeyBytes)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// The whole header is authenticated so that no parameter can be swapped
+	header, err := env.Header()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return env, key, header, nil
+}
+
+// openEnvelope decrypts an envelope with the recipient's private key.
+func openEnvelope(privateKey *ecdsa.PrivateKey, env *Envelope) ([]byte, error) {
+	key, additionalData, err := envelopeKey(privateKey, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if env.Algorithm == AlgorithmECIESSecp256k1AES256GCMStream {
+		var plaintext bytes.Buffer
+		if err := openSegments(&plaintext, bytes.NewReader(env.Ciphertext), key, env.Nonce, additionalData); err != nil {
+			return nil, err
+		}
+		return plaintext.Bytes(), nil
+	}
+	return openAES(key, env.Nonce, env.Ciphertext, additionalData)
+}
+
+// envelopeKey derives the AES key of an envelope with the recipient's private key and returns it
+// together with the additional data authenticated by the AEAD.
+func envelopeKey(privateKey *ecdsa.PrivateKey, env *Envelope) ([]byte, []byte, error) {
+	if env.Version == EnvelopeVersionLegacy && env.Algorithm == AlgorithmBaselineAES256GCM {
+		// The baseline key only depended on the recipient's public key and nothing was authenticated
+		key := sha256.Sum256(privateKey.PublicKey.X.Bytes())
+		return key[:], nil, nil
+	}
+	if env.Algorithm == AlgorithmAES256GCMStreamDataKey {
+		return nil, nil, errors.New("gene data is encrypted with a data key, use DecryptGeneDataWithDataKey")
+	}
+	if env.Algorithm != AlgorithmECIESSecp256k1AES256GCM && env.Algorithm != AlgorithmECIESSecp256k1AES256GCMStream {
+		return nil, nil, fmt.Errorf("unsupported algorithm: %d", env.Algorithm)
+	}
+	if env.KDFParams.KDF != KDFHKDFSHA256 {
+		return nil, nil, fmt.Errorf("unsupported KDF: %d", env.KDFParams.KDF)
+	}
+
+	ephemeralPubkey, err := crypto.UnmarshalPubkey(env.EphemeralPubkey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := deriveKey(privateKey, ephemeralPubkey, env.KDFParams, env.EphemeralPubkey, crypto.FromECDSAPub(&privateKey.PublicKey))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Legacy blobs only authenticated the ephemeral public key
+	additionalData := env.EphemeralPubkey
+	if env.Version != EnvelopeVersionLegacy {
+		if additionalData, err = env.Header(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return key, additionalData, nil
+}
+
+// deriveKey performs ECDH between the private and public key and derives an AES-256 key
+// from the shared secret with HKDF-SHA256. Both public keys are mixed into the KDF so the
+// key is bound to this particular sender/recipient pair.
+func deriveKey(privateKey *ecdsa.PrivateKey, publicKey *ecdsa.PublicKey, params KDFParams, ephemeralPubkeyBytes, recipientPubkeyBytes []byte) ([]byte, error) {
+	sharedSecret, err := ecdh(privateKey, publicKey)
+	if err != nil {
 		return nil, err
 	}
 
-	ciphertext := aesGCM.Seal(nil, nonce, plaintext, nil)
-	return append(nonce, ciphertext...), nil
+	info := make([]byte, 0, len(kdfInfo)+len(ephemeralPubkeyBytes)+len(recipientPubkeyBytes))
+	info = append(info, kdfInfo...)
+	info = append(info, ephemeralPubkeyBytes...)
+	info = append(info, recipientPubkeyBytes...)
+
+	key := make([]byte, aesKeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, sharedSecret, params.Salt, info), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ecdh computes the x-coordinate of privateKey * publicKey on secp256k1.
+func ecdh(privateKey *ecdsa.PrivateKey, publicKey *ecdsa.PublicKey) ([]byte, error) {
+	curve := crypto.S256()
+	if !curve.IsOnCurve(publicKey.X, publicKey.Y) {
+		return nil, errors.New("public key is not on the secp256k1 curve")
+	}
+
+	x, _ := curve.ScalarMult(publicKey.X, publicKey.Y, privateKey.D.Bytes())
+	if x == nil || x.Sign() == 0 {
+		return nil, errors.New("invalid shared secret")
+	}
+
+	// Left-pad the x-coordinate to a fixed 32 bytes
+	sharedSecret := make([]byte, 32)
+	x.FillBytes(sharedSecret)
+	return sharedSecret, nil
+}
+
+// sealAES encrypts data using AES-256-GCM with the given nonce.
+func sealAES(key, nonce, plaintext, additionalData []byte) ([]byte, error) {
+	aesGCM, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aesGCM.NonceSize() {
+		return nil, errors.New("invalid nonce size")
+	}
+
+	return aesGCM.Seal(nil, nonce, plaintext, additionalData), nil
+}
+
+// openAES decrypts data sealed by sealAES.
+func openAES(key, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	aesGCM, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aesGCM.NonceSize() {
+		return nil, errors.New("invalid nonce size")
+	}
+
+	return aesGCM.Open(nil, nonce, ciphertext, additionalData)
+}
+
+// newGCM creates an AES-GCM AEAD for the given key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
 }
@@ -43,3 +43,65 @@ func TestEncryptGeneData_InvalidPublicKey(t *testing.T) {
 	_, err := teeService.EncryptGeneData(invalidPublicKey, geneData)
 	require.Error(t, err)
 }
+
+func TestDecryptGeneData(t *testing.T) {
+	// Initialize the TEE service
+	teeService := service.NewTEEService()
+
+	// Generate a new ECDSA private key
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	publicKeyBytes := crypto.FromECDSAPub(&privateKey.PublicKey)
+
+	// Encrypt and then decrypt the gene data
+	originalGeneData := "This is a test gene data."
+	encryptedData, err := teeService.EncryptGeneData(publicKeyBytes, originalGeneData)
+	require.NoError(t, err)
+
+	decryptedGeneData, err := teeService.DecryptGeneData(privateKey, encryptedData)
+	require.NoError(t, err)
+	require.Equal(t, originalGeneData, decryptedGeneData)
+
+	// Encrypting the same data twice must not produce the same ciphertext
+	anotherEncryptedData, err := teeService.EncryptGeneData(publicKeyBytes, originalGeneData)
+	require.NoError(t, err)
+	require.NotEqual(t, encryptedData, anotherEncryptedData)
+}
+
+func TestDecryptGeneData_WrongPrivateKey(t *testing.T) {
+	// Initialize the TEE service
+	teeService := service.NewTEEService()
+
+	// Generate the owner's key and an unrelated key
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	anotherPrivateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	encryptedData, err := teeService.EncryptGeneData(crypto.FromECDSAPub(&privateKey.PublicKey), "This is a test gene data.")
+	require.NoError(t, err)
+
+	// Decryption with a key other than the owner's must fail
+	_, err = teeService.DecryptGeneData(anotherPrivateKey, encryptedData)
+	require.Error(t, err)
+}
+
+func TestDecryptGeneData_TamperedData(t *testing.T) {
+	// Initialize the TEE service
+	teeService := service.NewTEEService()
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	encryptedData, err := teeService.EncryptGeneData(crypto.FromECDSAPub(&privateKey.PublicKey), "This is a test gene data.")
+	require.NoError(t, err)
+
+	// Flip a bit in the ciphertext
+	encryptedData[len(encryptedData)-1] ^= 0x01
+	_, err = teeService.DecryptGeneData(privateKey, encryptedData)
+	require.Error(t, err)
+
+	// Truncated data must be rejected
+	_, err = teeService.DecryptGeneData(privateKey, encryptedData[:10])
+	require.Error(t, err)
+}
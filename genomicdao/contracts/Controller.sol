@@ -1,13 +1,21 @@
 // SPDX-License-Identifier: MIT
 pragma solidity ^0.8.9;
 
+import "@openzeppelin/contracts/access/Ownable.sol";
 import "@openzeppelin/contracts/utils/Counters.sol";
+import "@openzeppelin/contracts/utils/cryptography/ECDSA.sol";
 import "./NFT.sol";
 import "./Token.sol";
 
-contract Controller {
+contract Controller is Ownable {
     using Counters for Counters.Counter;
 
+    //
+    // CONSTANTS
+    //
+    // Version of the TEE attestation quote layout accepted as proof
+    uint8 public constant QUOTE_VERSION = 1;
+
     //
     // STATE VARIABLES
     //
@@ -32,16 +40,38 @@ contract Controller {
     mapping(string => bool) docSubmits;
     mapping(uint256 => string) nftDocs;
 
+    // Attestation keys of the TEEs trusted to compute risk scores
+    mapping(address => bool) public teeSigners;
+
     //
     // EVENTS
     //
     event UploadData(string docId, uint256 sessionId);
+    event TEESignerAdded(address signer);
+    event TEESignerRemoved(address signer);
 
     constructor(address nftAddress, address pcspAddress) {
         geneNFT = GeneNFT(nftAddress);
         pcspToken = PostCovidStrokePrevention(pcspAddress);
     }
 
+    function addTEESigner(address signer) public onlyOwner {
+        require(signer != address(0), "Invalid TEE signer");
+        require(!teeSigners[signer], "TEE signer already added");
+
+        teeSigners[signer] = true;
+
+        emit TEESignerAdded(signer);
+    }
+
+    function removeTEESigner(address signer) public onlyOwner {
+        require(teeSigners[signer], "TEE signer not found");
+
+        delete teeSigners[signer];
+
+        emit TEESignerRemoved(signer);
+    }
+
     function uploadData(string memory docId) public returns (uint256) {
         // To start an uploading gene data session. The doc id is used to identify a unique gene profile. Also should check if the doc id has been submited to the system before. This method return the session id
         require(!docSubmits[docId], "Doc already been submitted");
@@ -67,13 +97,11 @@ contract Controller {
     function confirm(
         string memory docId,
         string memory contentHash,
-        string memory proof,
+        bytes memory proof,
         uint256 sessionId,
         uint256 riskScore
     ) public {
-        // The proof here is used to verify that the result is returned from a valid computation on the gene data. For simplicity, we will skip the proof verification in this implementation. The gene data's owner will receive a NFT as a ownership certicate for his/her gene profile.
-
-        // TODO: Verify proof, we can skip this step
+        // The proof here is used to verify that the result is returned from a valid computation on the gene data. The gene data's owner will receive a NFT as a ownership certicate for his/her gene profile.
 
         require(
             bytes(getDoc(docId).id).length == 0,
@@ -90,6 +118,9 @@ contract Controller {
             "Session is ended"
         );
 
+        // The proof is the TEE's attestation quote for the risk score. It must be signed by a registered TEE signer and attest to this doc, content hash, session and risk score
+        _verifyProof(confirmNonce(docId, contentHash, sessionId), proof, riskScore);
+
         // Update doc content
         docs[docId] = DataDoc({
             id: docId,
@@ -108,6 +139,15 @@ contract Controller {
         sessions[sessionId].proof = "success";
     }
 
+    function confirmNonce(
+        string memory docId,
+        string memory contentHash,
+        uint256 sessionId
+    ) public view returns (bytes32) {
+        // The nonce the TEE attests to binds its quote to a single confirmation on this contract and chain
+        return keccak256(abi.encode(block.chainid, address(this), docId, contentHash, sessionId));
+    }
+
     function getSession(uint256 sessionId) public view returns(UploadSession memory) {
         return sessions[sessionId];
     }
@@ -115,4 +155,35 @@ contract Controller {
     function getDoc(string memory docId) public view returns(DataDoc memory) {
         return docs[docId];
     }
+
+    function _verifyProof(bytes32 nonce, bytes memory proof, uint256 riskScore) internal view {
+        // The proof is the ABI encoded quote (version, measurement, model, modelVersion, inputHash, outputHash, nonce, signature), signed over the keccak256 hash of the ABI encoded quote without its signature
+        (
+            uint8 version,
+            bytes32 measurement,
+            string memory model,
+            string memory modelVersion,
+            bytes32 inputHash,
+            bytes32 outputHash,
+            bytes32 quoteNonce,
+            bytes memory signature
+        ) = abi.decode(proof, (uint8, bytes32, string, string, bytes32, bytes32, bytes32, bytes));
+
+        require(version == QUOTE_VERSION, "Unsupported proof version");
+        require(quoteNonce == nonce, "Proof is not for this confirmation");
+        require(
+            riskScore <= type(uint8).max && outputHash == keccak256(abi.encodePacked(uint8(riskScore))),
+            "Proof does not attest the risk score"
+        );
+
+        bytes32 digest = keccak256(abi.encode(version, measurement, model, modelVersion, inputHash, outputHash, quoteNonce));
+
+        // The TEE signs with recovery ids 0 and 1, ecrecover expects 27 and 28
+        require(signature.length == 65, "Invalid proof signature");
+        if (uint8(signature[64]) < 27) {
+            signature[64] = bytes1(uint8(signature[64]) + 27);
+        }
+
+        require(teeSigners[ECDSA.recover(digest, signature)], "Proof is not signed by a TEE signer");
+    }
 }
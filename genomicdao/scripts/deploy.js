@@ -26,6 +26,14 @@ async function main() {
     await ntfToken.transferOwnership(controller.target);
     await pcspToken.transferOwnership(controller.target);
     console.log("Ownership of NTFToken and PCSPToken transferred to Controller.");
+
+    // Register the attestation key of the backend TEE, whose quotes are the confirm proofs
+    if (process.env.TEE_SIGNER) {
+        await controller.addTEESigner(process.env.TEE_SIGNER);
+        console.log("TEE signer registered:", process.env.TEE_SIGNER);
+    } else {
+        console.log("TEE_SIGNER not set, register the TEE attestation key with addTEESigner before confirming.");
+    }
 }
 
 main().catch((error) => {
@@ -5,6 +5,24 @@ const {
 
 const { expect } = require("chai");
 
+const QUOTE_TYPES = ["uint8", "bytes32", "string", "string", "bytes32", "bytes32", "bytes32"]
+
+// Signs the TEE attestation quote accepted as the confirm proof for the doc, content hash, session and risk score
+async function signProof(controller, tee, docId, contentHash, sessionId, riskScore) {
+  const coder = ethers.AbiCoder.defaultAbiCoder()
+  const quote = [
+    1,
+    ethers.id("genomic-system/tee/simulated/1"),
+    "g-stroke",
+    "1.0.0",
+    ethers.id("gene data"),
+    ethers.solidityPackedKeccak256(["uint8"], [riskScore]),
+    await controller.confirmNonce(docId, contentHash, sessionId),
+  ]
+  const signature = tee.signingKey.sign(ethers.keccak256(coder.encode(QUOTE_TYPES, quote))).serialized
+  return coder.encode([...QUOTE_TYPES, "bytes"], [...quote, signature])
+}
+
 describe("Controller", function () {
   async function deployControllerFixture() {
     const [owner, addr1, addr2] = await ethers.getSigners();
@@ -17,7 +35,10 @@ describe("Controller", function () {
     await nft.transferOwnership(controller.target)
     await pcspToken.transferOwnership(controller.target)
 
-    return { controller, nft, pcspToken, owner, addr1, addr2 }
+    const tee = ethers.Wallet.createRandom()
+    await controller.addTEESigner(tee.address)
+
+    return { controller, nft, pcspToken, owner, addr1, addr2, tee }
   }
 
   describe("Upload Data", function () {
@@ -32,15 +53,16 @@ describe("Controller", function () {
     })
 
     it("Should fail if the doc is submited", async function () {
-      const { controller, addr1, addr2 } = await loadFixture(deployControllerFixture);
+      const { controller, addr1, addr2, tee } = await loadFixture(deployControllerFixture);
 
       const docId = "doc1"
       const contentHash = "dochash"
-      const proof = "success"
       const riskScore = 1
       const sessionId = 0
 
       await controller.connect(addr1).uploadData(docId)
+
+      const proof = await signProof(controller, tee, docId, contentHash, sessionId, riskScore)
       await controller.connect(addr1).confirm(docId, contentHash, proof, sessionId, riskScore)
 
       await expect(
@@ -51,32 +73,34 @@ describe("Controller", function () {
 
   describe("Confirm data", function () {
     it("Should receive correct nft", async function () {
-      const { controller, nft, owner } = await loadFixture(deployControllerFixture);
+      const { controller, nft, owner, tee } = await loadFixture(deployControllerFixture);
 
       const docId = "doc1"
       const contentHash = "dochash"
-      const proof = "success"
       const riskScore = 1
       const sessionId = 0
 
       await controller.uploadData(docId)
+
+      const proof = await signProof(controller, tee, docId, contentHash, sessionId, riskScore)
       await controller.confirm(docId, contentHash, proof, sessionId, riskScore)
 
       expect(await nft.ownerOf(0)).to.equal(owner.address);
     })
 
     it("Should receive correct pcsp reward", async function () {
-      const { controller, pcspToken, addr1 } = await loadFixture(deployControllerFixture);
+      const { controller, pcspToken, addr1, tee } = await loadFixture(deployControllerFixture);
 
       const docId = "doc1"
       const contentHash = "dochash"
-      const proof = "success"
       const riskScore = 1
       const sessionId = 0
 
       const awardAmount = BigInt("15000") * BigInt("10") ** BigInt("18")
 
       await controller.connect(addr1).uploadData(docId)
+
+      const proof = await signProof(controller, tee, docId, contentHash, sessionId, riskScore)
       await controller.connect(addr1).confirm(docId, contentHash, proof, sessionId, riskScore)
 
       const ownerBalance = await pcspToken.balanceOf(addr1.address)
@@ -85,33 +109,35 @@ describe("Controller", function () {
     })
 
     it("Should close session", async function () {
-      const { controller, addr1 } = await loadFixture(deployControllerFixture);
+      const { controller, addr1, tee } = await loadFixture(deployControllerFixture);
 
       const docId = "doc1"
       const contentHash = "dochash"
-      const proof = "success"
       const riskScore = 1
       const sessionId = 0
 
       await controller.connect(addr1).uploadData(docId)
+
+      const proof = await signProof(controller, tee, docId, contentHash, sessionId, riskScore)
       await controller.connect(addr1).confirm(docId, contentHash, proof, sessionId, riskScore)
 
       const session = await controller.getSession(sessionId)
 
-      expect(session.proof).to.equal(proof)
+      expect(session.proof).to.equal("success")
       expect(session.confirmed).to.equal(true)
     })
 
     it("Should content hash uploaded", async function () {
-      const { controller, addr1 } = await loadFixture(deployControllerFixture);
+      const { controller, addr1, tee } = await loadFixture(deployControllerFixture);
 
       const docId = "doc1"
       const contentHash = "dochash"
-      const proof = "success"
       const riskScore = 1
       const sessionId = 0
 
       await controller.connect(addr1).uploadData(docId)
+
+      const proof = await signProof(controller, tee, docId, contentHash, sessionId, riskScore)
       await controller.connect(addr1).confirm(docId, contentHash, proof, sessionId, riskScore)
 
       const doc = await controller.getDoc(docId)
@@ -120,15 +146,16 @@ describe("Controller", function () {
     })
 
     it("Should fail if the doc is submitted", async function () {
-      const { controller, addr1 } = await loadFixture(deployControllerFixture);
+      const { controller, addr1, tee } = await loadFixture(deployControllerFixture);
 
       const docId = "doc1"
       const contentHash = "dochash"
-      const proof = "success"
       const riskScore = 1
       const sessionId = 0
 
       await controller.connect(addr1).uploadData(docId)
+
+      const proof = await signProof(controller, tee, docId, contentHash, sessionId, riskScore)
       await controller.connect(addr1).confirm(docId, contentHash, proof, sessionId, riskScore)
 
       await expect(
@@ -137,32 +164,33 @@ describe("Controller", function () {
     })
 
     it("Should fail if the session owner is invalid", async function () {
-      const { controller, addr1, addr2 } = await loadFixture(deployControllerFixture);
+      const { controller, addr1, addr2, tee } = await loadFixture(deployControllerFixture);
 
       const docId = "doc1"
       const contentHash = "dochash"
-      const proof = "success"
       const riskScore = 1
       const sessionId = 0
 
       await controller.connect(addr1).uploadData(docId)
 
+      const proof = await signProof(controller, tee, docId, contentHash, sessionId, riskScore)
       await expect(
         controller.connect(addr2).confirm(docId, contentHash, proof, sessionId, riskScore)
       ).to.be.revertedWith("Invalid session owner")
     })
 
     it("Should fail if the session is end", async function () {
-      const { controller, addr1 } = await loadFixture(deployControllerFixture);
+      const { controller, addr1, tee } = await loadFixture(deployControllerFixture);
 
       const docId = "doc1"
       const docId2 = "doc2"
       const contentHash = "dochash"
-      const proof = "success"
       const riskScore = 1
       const sessionId = 0
 
       await controller.connect(addr1).uploadData(docId)
+
+      const proof = await signProof(controller, tee, docId, contentHash, sessionId, riskScore)
       await controller.connect(addr1).confirm(docId, contentHash, proof, sessionId, riskScore)
 
       await expect(
@@ -170,4 +198,107 @@ describe("Controller", function () {
       ).to.be.revertedWith("Session is ended")
     })
   })
-})
\ No newline at end of file
+
+  describe("Verify proof", function () {
+    it("Should fail if the proof is not signed by a TEE signer", async function () {
+      const { controller, addr1 } = await loadFixture(deployControllerFixture);
+
+      const docId = "doc1"
+      const contentHash = "dochash"
+      const riskScore = 1
+      const sessionId = 0
+
+      await controller.connect(addr1).uploadData(docId)
+
+      const proof = await signProof(controller, ethers.Wallet.createRandom(), docId, contentHash, sessionId, riskScore)
+      await expect(
+        controller.connect(addr1).confirm(docId, contentHash, proof, sessionId, riskScore)
+      ).to.be.revertedWith("Proof is not signed by a TEE signer")
+    })
+
+    it("Should fail if the proof is for another confirmation", async function () {
+      const { controller, addr1, tee } = await loadFixture(deployControllerFixture);
+
+      const docId = "doc1"
+      const contentHash = "dochash"
+      const riskScore = 1
+      const sessionId = 0
+
+      await controller.connect(addr1).uploadData(docId)
+
+      const proof = await signProof(controller, tee, docId, "otherhash", sessionId, riskScore)
+      await expect(
+        controller.connect(addr1).confirm(docId, contentHash, proof, sessionId, riskScore)
+      ).to.be.revertedWith("Proof is not for this confirmation")
+    })
+
+    it("Should fail if the proof is for another risk score", async function () {
+      const { controller, addr1, tee } = await loadFixture(deployControllerFixture);
+
+      const docId = "doc1"
+      const contentHash = "dochash"
+      const sessionId = 0
+
+      await controller.connect(addr1).uploadData(docId)
+
+      const proof = await signProof(controller, tee, docId, contentHash, sessionId, 4)
+      await expect(
+        controller.connect(addr1).confirm(docId, contentHash, proof, sessionId, 1)
+      ).to.be.revertedWith("Proof does not attest the risk score")
+    })
+
+    it("Should accept recovery ids 0 and 1", async function () {
+      const { controller, nft, addr1, tee } = await loadFixture(deployControllerFixture);
+
+      const docId = "doc1"
+      const contentHash = "dochash"
+      const riskScore = 1
+      const sessionId = 0
+
+      await controller.connect(addr1).uploadData(docId)
+
+      // The backend TEE signs with go-ethereum, which uses recovery ids 0 and 1 instead of 27 and 28
+      const coder = ethers.AbiCoder.defaultAbiCoder()
+      const decoded = coder.decode([...QUOTE_TYPES, "bytes"], await signProof(controller, tee, docId, contentHash, sessionId, riskScore))
+      const signature = ethers.getBytes(decoded[7])
+      signature[64] -= 27
+      const proof = coder.encode([...QUOTE_TYPES, "bytes"], [...decoded.slice(0, 7), signature])
+
+      await controller.connect(addr1).confirm(docId, contentHash, proof, sessionId, riskScore)
+
+      expect(await nft.ownerOf(0)).to.equal(addr1.address);
+    })
+
+    it("Should fail if the TEE signer is removed", async function () {
+      const { controller, addr1, tee } = await loadFixture(deployControllerFixture);
+
+      const docId = "doc1"
+      const contentHash = "dochash"
+      const riskScore = 1
+      const sessionId = 0
+
+      await expect(controller.removeTEESigner(tee.address))
+        .to.emit(controller, "TEESignerRemoved")
+        .withArgs(tee.address)
+      await controller.connect(addr1).uploadData(docId)
+
+      const proof = await signProof(controller, tee, docId, contentHash, sessionId, riskScore)
+      await expect(
+        controller.connect(addr1).confirm(docId, contentHash, proof, sessionId, riskScore)
+      ).to.be.revertedWith("Proof is not signed by a TEE signer")
+    })
+
+    it("Should only let the owner manage TEE signers", async function () {
+      const { controller, addr1, tee } = await loadFixture(deployControllerFixture);
+
+      expect(await controller.teeSigners(tee.address)).to.equal(true)
+
+      await expect(
+        controller.connect(addr1).addTEESigner(addr1.address)
+      ).to.be.revertedWith("Ownable: caller is not the owner")
+      await expect(
+        controller.connect(addr1).removeTEESigner(tee.address)
+      ).to.be.revertedWith("Ownable: caller is not the owner")
+    })
+  })
+})